@@ -0,0 +1,68 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/baiirun/prog/internal/db"
+	"github.com/baiirun/prog/internal/model"
+)
+
+func TestCLI_BlockSplitsIDsFromReason(t *testing.T) {
+	path := setupTestCLI(t)
+	a := strings.TrimSpace(runCommand(t, "--db", path, "add", "A", "-p", "cli"))
+	b := strings.TrimSpace(runCommand(t, "--db", path, "add", "B", "-p", "cli"))
+	c := strings.TrimSpace(runCommand(t, "--db", path, "add", "C", "-p", "cli"))
+
+	tests := []struct {
+		args       []string
+		wantIDs    []string
+		wantReason string
+	}{
+		// Abbreviated ids are items, not reason text
+		{[]string{a[3:], b[3:], "Need", "spec"}, []string{a, b}, "Need spec"},
+		// A reason that looks like an id but names no item is text
+		{[]string{c, "ts-zzzzzz", "must", "ship"}, []string{c}, "ts-zzzzzz must ship"},
+		// Everything after -- is the reason, even a real id
+		{[]string{a, "--", b, "first"}, []string{a}, b + " first"},
+		// Short words aren't taken for ids
+		{[]string{b, "a", "flaky", "test"}, []string{b}, "a flaky test"},
+	}
+	for _, tt := range tests {
+		for _, id := range []string{a, b, c} {
+			runCommand(t, "--db", path, "reopen", id, "--force")
+		}
+		out := runCommand(t, append([]string{"--db", path, "block"}, tt.args...)...)
+		for _, id := range tt.wantIDs {
+			if !strings.Contains(out, "Blocked "+id+": "+tt.wantReason+"\n") {
+				t.Errorf("block %v output missing %s: %s:\n%s", tt.args, id, tt.wantReason, out)
+			}
+		}
+		if n := strings.Count(out, "Blocked "); n != len(tt.wantIDs) {
+			t.Errorf("block %v blocked %d items, want %d:\n%s", tt.args, n, len(tt.wantIDs), out)
+		}
+	}
+
+	if _, err := runCommandErr(t, "--db", path, "block", "ts-zzzzzz", "reason"); !errors.Is(err, db.ErrNotFound) {
+		t.Errorf("block of a missing first id = %v, want ErrNotFound", err)
+	}
+}
+
+func TestFormatDepCheck(t *testing.T) {
+	tests := []struct {
+		check db.DepCheck
+		want  string
+	}{
+		{db.DepCheck{DependsOnStatus: model.StatusOpen}, ""},
+		{db.DepCheck{DependsOnStatus: model.StatusBlocked}, "Warning: ts-aaa111 is blocked; ts-bbb222 can't start until it is unblocked and done"},
+		{db.DepCheck{DependsOnStatus: model.StatusDone}, "Note: ts-aaa111 is already done, so it doesn't hold back ts-bbb222"},
+		{db.DepCheck{DependsOnStatus: model.StatusDone, Ready: true}, "Note: ts-aaa111 is already done, so it doesn't hold back ts-bbb222 (ts-bbb222 is ready)"},
+	}
+
+	for _, tt := range tests {
+		if got := formatDepCheck("ts-bbb222", "ts-aaa111", &tt.check); got != tt.want {
+			t.Errorf("formatDepCheck(%+v) = %q, want %q", tt.check, got, tt.want)
+		}
+	}
+}
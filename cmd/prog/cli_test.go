@@ -0,0 +1,302 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/baiirun/prog/internal/db"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// setupTestCLI points the CLI at a fresh database and returns its path.
+func setupTestCLI(t *testing.T) string {
+	t.Helper()
+	t.Setenv("HOME", t.TempDir())
+	path := filepath.Join(t.TempDir(), "prog.db")
+	runCommand(t, "--db", path, "init")
+	return path
+}
+
+// runCommand executes the CLI with args and returns everything it wrote.
+// Flags are reset first, since cobra keeps their values between executions.
+func runCommand(t *testing.T, args ...string) string {
+	t.Helper()
+	out, err := runCommandErr(t, args...)
+	if err != nil {
+		t.Fatalf("prog %s: %v\n%s", strings.Join(args, " "), err, out)
+	}
+	return out
+}
+
+// runCommandErr is runCommand for commands expected to fail: it returns the
+// error instead of failing the test. Stdin is empty.
+func runCommandErr(t *testing.T, args ...string) (string, error) {
+	t.Helper()
+	resetFlags(rootCmd)
+
+	var buf bytes.Buffer
+	rootCmd.SetOut(&buf)
+	rootCmd.SetErr(&buf)
+	rootCmd.SetIn(strings.NewReader(""))
+	rootCmd.SetArgs(args)
+	defer func() {
+		rootCmd.SetOut(nil)
+		rootCmd.SetErr(nil)
+		rootCmd.SetIn(nil)
+		rootCmd.SetArgs(nil)
+	}()
+
+	err := rootCmd.Execute()
+	return buf.String(), err
+}
+
+func resetFlags(cmd *cobra.Command) {
+	reset := func(f *pflag.Flag) {
+		if v, ok := f.Value.(pflag.SliceValue); ok {
+			_ = v.Replace(nil)
+		} else {
+			_ = f.Value.Set(f.DefValue)
+		}
+		f.Changed = false
+	}
+	cmd.Flags().VisitAll(reset)
+	cmd.PersistentFlags().VisitAll(reset)
+	cmd.SilenceUsage = false
+	for _, sub := range cmd.Commands() {
+		resetFlags(sub)
+	}
+}
+
+func TestCLI_ListShowStatus(t *testing.T) {
+	path := setupTestCLI(t)
+
+	id := strings.TrimSpace(runCommand(t, "--db", path, "add", "Write the parser", "-p", "cli", "--priority", "high"))
+	runCommand(t, "--db", path, "add", "Document the parser", "-p", "cli")
+	runCommand(t, "--db", path, "start", id)
+
+	list := runCommand(t, "--db", path, "list", "-p", "cli")
+	if !strings.Contains(list, "ID") || !strings.Contains(list, "TITLE") {
+		t.Errorf("list missing header:\n%s", list)
+	}
+	for _, want := range []string{id, "in_progress", "Write the parser", "Document the parser"} {
+		if !strings.Contains(list, want) {
+			t.Errorf("list missing %q:\n%s", want, list)
+		}
+	}
+
+	paged := runCommand(t, "--db", path, "list", "-p", "cli", "--limit", "1")
+	if !strings.Contains(paged, "Showing 1-1 of 2") {
+		t.Errorf("paged list missing footer:\n%s", paged)
+	}
+
+	show := runCommand(t, "--db", path, "show", id)
+	if !strings.Contains(show, "Write the parser") || !strings.Contains(show, "in_progress") {
+		t.Errorf("show missing item details:\n%s", show)
+	}
+
+	status := runCommand(t, "--db", path, "status", "-p", "cli")
+	if !strings.Contains(status, "Write the parser") {
+		t.Errorf("status missing in-progress item:\n%s", status)
+	}
+}
+
+func TestCLI_DoneNote(t *testing.T) {
+	path := setupTestCLI(t)
+
+	id := strings.TrimSpace(runCommand(t, "--db", path, "add", "Fix login", "-p", "cli"))
+	runCommand(t, "--db", path, "done", id, "--note", "Fixed by caching the token")
+
+	show := runCommand(t, "--db", path, "show", id)
+	if !strings.Contains(show, "Done: Fixed by caching the token") {
+		t.Errorf("show missing completion note:\n%s", show)
+	}
+
+	// Without --note no log entry is added
+	other := strings.TrimSpace(runCommand(t, "--db", path, "add", "Tidy up", "-p", "cli"))
+	runCommand(t, "--db", path, "done", other)
+	if show := runCommand(t, "--db", path, "show", other); strings.Contains(show, "Done:") {
+		t.Errorf("unexpected completion note:\n%s", show)
+	}
+}
+
+func TestCLI_CancelReason(t *testing.T) {
+	path := setupTestCLI(t)
+
+	id := strings.TrimSpace(runCommand(t, "--db", path, "add", "Old plan", "-p", "cli"))
+	out := runCommand(t, "--db", path, "cancel", id, "Requirements", "changed")
+	if !strings.Contains(out, "Canceled "+id+": Requirements changed") {
+		t.Errorf("unexpected cancel output:\n%s", out)
+	}
+	if show := runCommand(t, "--db", path, "show", id); !strings.Contains(show, "Canceled: Requirements changed") {
+		t.Errorf("show missing cancel reason:\n%s", show)
+	}
+
+	// A refused cancel records no reason either
+	locked := strings.TrimSpace(runCommand(t, "--db", path, "add", "Frozen", "-p", "cli"))
+	runCommand(t, "--db", path, "lock", locked)
+	if _, err := runCommandErr(t, "--db", path, "cancel", locked, "Too late"); err == nil {
+		t.Fatal("expected canceling a locked task to fail")
+	}
+	if show := runCommand(t, "--db", path, "show", locked); strings.Contains(show, "Too late") {
+		t.Errorf("refused cancel still logged its reason:\n%s", show)
+	}
+}
+
+func TestCLI_CompleteItemIDs(t *testing.T) {
+	path := setupTestCLI(t)
+
+	id := strings.TrimSpace(runCommand(t, "--db", path, "add", "Write the parser", "-p", "cli"))
+	done := strings.TrimSpace(runCommand(t, "--db", path, "add", "Old work", "-p", "cli"))
+	runCommand(t, "--db", path, "done", done)
+
+	out := runCommand(t, "--db", path, cobra.ShellCompRequestCmd, "show", id[:5])
+	if !strings.Contains(out, id+"\tWrite the parser") {
+		t.Errorf("completion missing open item:\n%s", out)
+	}
+	if strings.Contains(out, done) {
+		t.Errorf("completion offered done item:\n%s", out)
+	}
+
+	// Only the first argument of show-like commands is an id
+	if out := runCommand(t, "--db", path, cobra.ShellCompRequestCmd, "log", id, ""); strings.Contains(out, id) {
+		t.Errorf("completion offered id for log message:\n%s", out)
+	}
+}
+
+func TestCLI_ListFormats(t *testing.T) {
+	path := setupTestCLI(t)
+
+	id := strings.TrimSpace(runCommand(t, "--db", path, "add", "Write the parser", "-p", "cli"))
+	runCommand(t, "--db", path, "add", "Document the parser", "-p", "cli", "--priority", "low")
+
+	compact := runCommand(t, "--db", path, "list", "-p", "cli", "--format", "compact")
+	if !strings.HasPrefix(compact, id+" open Write the parser\n") || strings.Contains(compact, "TITLE") {
+		t.Errorf("unexpected compact output:\n%s", compact)
+	}
+
+	rows := runCommand(t, "--db", path, "list", "-p", "cli", "--no-header")
+	if strings.Contains(rows, "TITLE") || len(strings.Split(strings.TrimSpace(rows), "\n")) != 2 {
+		t.Errorf("unexpected header-less output:\n%s", rows)
+	}
+}
+
+func TestCLI_ListColumns(t *testing.T) {
+	path := setupTestCLI(t)
+
+	id := strings.TrimSpace(runCommand(t, "--db", path, "add", "Write the parser", "-p", "cli"))
+	runCommand(t, "--db", path, "tag", id, "backend")
+
+	out := runCommand(t, "--db", path, "list", "-p", "cli", "--columns", "title, project,tags")
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	if len(lines) != 2 || strings.Fields(lines[0])[0] != "TITLE" || !strings.HasSuffix(lines[1], "cli     backend") {
+		t.Errorf("unexpected columns output:\n%s", out)
+	}
+
+	compact := runCommand(t, "--db", path, "list", "-p", "cli", "--format", "compact", "--columns", "id,pri")
+	if compact != id+" medium\n" {
+		t.Errorf("compact columns = %q", compact)
+	}
+}
+
+func TestCLI_ExitCodes(t *testing.T) {
+	path := setupTestCLI(t)
+
+	out, err := runCommandErr(t, "--db", path, "ready", "-p", "cli")
+	if exitStatus(err) != exitNothing || out != "No ready tasks\n" {
+		t.Errorf("empty ready = %q, %v; want status %d", out, err, exitNothing)
+	}
+
+	id := strings.TrimSpace(runCommand(t, "--db", path, "add", "Task", "-p", "cli"))
+	if _, err := runCommandErr(t, "--db", path, "ready", "-p", "cli"); err != nil {
+		t.Errorf("ready with a task = %v, want success", err)
+	}
+
+	if _, err := runCommandErr(t, "--db", path, "show", "ts-000000"); exitStatus(err) != exitNotFound {
+		t.Errorf("show missing = %v (status %d), want status %d", err, exitStatus(err), exitNotFound)
+	}
+	if _, err := runCommandErr(t, "--db", path, "bump", id, "extra"); exitStatus(err) != exitError {
+		t.Errorf("bad args status = %d, want %d", exitStatus(err), exitError)
+	}
+}
+
+func TestCLI_UsageOnlyForBadArgs(t *testing.T) {
+	path := setupTestCLI(t)
+
+	out, err := runCommandErr(t, "--db", path, "show", "ts-000000")
+	if err == nil || strings.Contains(out, "Usage:") || strings.Contains(out, "Error:") {
+		t.Errorf("runtime error output = %q, %v; want nothing printed (main prints the error)", out, err)
+	}
+	out, err = runCommandErr(t, "--db", path, "show")
+	if err == nil || !strings.Contains(out, "Usage:") {
+		t.Errorf("bad args output = %q, %v; want usage", out, err)
+	}
+}
+
+func TestCLI_ProjectResolvesPrefix(t *testing.T) {
+	path := setupTestCLI(t)
+
+	id := strings.TrimSpace(runCommand(t, "--db", path, "add", "Write the parser", "-p", "cli"))
+	out := runCommand(t, "--db", path, "project", id[3:], "parser")
+	if !strings.Contains(out, id+" is now in project parser") {
+		t.Errorf("project output = %q, want full id", out)
+	}
+	if list := runCommand(t, "--db", path, "list", "-p", "parser"); !strings.Contains(list, id) {
+		t.Errorf("item not moved to parser:\n%s", list)
+	}
+}
+
+func TestErrorHint(t *testing.T) {
+	path := setupTestCLI(t)
+	id := strings.TrimSpace(runCommand(t, "--db", path, "add", "Write the parser", "-p", "cli"))
+	runCommand(t, "--db", path, "done", id)
+
+	// The db error names no flags; the hint does, for commands that have them
+	_, err := runCommandErr(t, "--db", path, "block", id, "waiting")
+	if err == nil || strings.Contains(err.Error(), "--force") {
+		t.Fatalf("block of done task = %v, want an error without CLI advice", err)
+	}
+	if hint := errorHint(blockCmd, err); hint != "Use --force to override." {
+		t.Errorf("transition hint = %q", hint)
+	}
+
+	runCommand(t, "--db", path, "reopen", id)
+	runCommand(t, "--db", path, "lock", id)
+	_, err = runCommandErr(t, "--db", path, "next", "--start", "-p", "cli")
+	if err == nil || strings.Contains(err.Error(), "unlock") {
+		t.Fatalf("next --start on locked task = %v, want an error without CLI advice", err)
+	}
+	if hint := errorHint(nextCmd, err); strings.Contains(hint, "--force") || !strings.Contains(hint, "prog unlock") {
+		t.Errorf("locked hint for a command without --force = %q", hint)
+	}
+	if hint := errorHint(startCmd, err); !strings.Contains(hint, "--force") {
+		t.Errorf("locked hint for start = %q, want --force", hint)
+	}
+}
+
+func TestCLI_StartWarnsPastWIPLimit(t *testing.T) {
+	path := setupTestCLI(t)
+
+	a := strings.TrimSpace(runCommand(t, "--db", path, "add", "A", "-p", "cli"))
+	b := strings.TrimSpace(runCommand(t, "--db", path, "add", "B", "-p", "cli"))
+	c := strings.TrimSpace(runCommand(t, "--db", path, "add", "C", "-p", "cli"))
+	runCommand(t, "--db", path, "project", "wip", "1", "-p", "cli")
+	runCommand(t, "--db", path, "start", a)
+
+	// By default the start goes ahead with a warning
+	out := runCommand(t, "--db", path, "start", b)
+	if !strings.Contains(out, "Started "+b) || !strings.Contains(out, "Warning: WIP limit exceeded for cli: 2 in progress (limit 1)") {
+		t.Errorf("start past the limit output:\n%s", out)
+	}
+
+	// --strict refuses instead
+	if _, err := runCommandErr(t, "--db", path, "start", c, "--strict"); !errors.Is(err, db.ErrWIPLimit) {
+		t.Errorf("start --strict past the limit = %v, want ErrWIPLimit", err)
+	}
+	if show := runCommand(t, "--db", path, "show", c); strings.Contains(show, "in_progress") {
+		t.Errorf("strict start changed the task:\n%s", show)
+	}
+}
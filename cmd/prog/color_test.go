@@ -0,0 +1,62 @@
+package main
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/baiirun/prog/internal/model"
+)
+
+func TestResolveColor(t *testing.T) {
+	tests := []struct {
+		mode       string
+		noColorEnv bool
+		terminal   bool
+		want       bool
+	}{
+		{"auto", false, true, true},
+		{"auto", false, false, false},
+		{"auto", true, true, false},
+		{"always", true, false, true},
+		{"never", false, true, false},
+	}
+	for _, tt := range tests {
+		got, err := resolveColor(tt.mode, tt.noColorEnv, tt.terminal)
+		if err != nil {
+			t.Errorf("resolveColor(%q) error: %v", tt.mode, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("resolveColor(%q, noColor=%v, tty=%v) = %v, want %v", tt.mode, tt.noColorEnv, tt.terminal, got, tt.want)
+		}
+	}
+
+	if _, err := resolveColor("sometimes", false, true); err == nil {
+		t.Error("expected error for invalid mode")
+	}
+}
+
+func TestPrintItemsTable_ColorKeepsColumns(t *testing.T) {
+	items := []model.Item{
+		{ID: "ts-000001", Status: model.StatusInProgress, Priority: 1, Title: "Working"},
+		{ID: "ts-000002", Status: model.StatusOpen, Priority: 2, Title: "Waiting"},
+	}
+
+	colorOutput = true
+	defer func() { colorOutput = false }()
+	output := captureOutput(func(out io.Writer) { printItemsTable(out, items) })
+
+	if !strings.Contains(output, "\x1b[33m") {
+		t.Errorf("expected in_progress to be colored:\n%q", output)
+	}
+	// Stripped of escapes, every title starts in the same column
+	plain := strings.NewReplacer("\x1b[33m", "", "\x1b[0m", "").Replace(output)
+	lines := strings.Split(strings.TrimSpace(plain), "\n")
+	col := strings.Index(lines[0], "TITLE")
+	for _, line := range lines[1:] {
+		if !strings.HasPrefix(line[col:], "W") {
+			t.Errorf("title misaligned in %q", line)
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestProjectConfig_RoundTrip(t *testing.T) {
+	dir := t.TempDir()
+
+	cfg, err := loadProjectConfig(dir)
+	if err != nil {
+		t.Fatalf("missing config should not error: %v", err)
+	}
+	if cfg.Project != "" {
+		t.Errorf("missing config project = %q, want empty", cfg.Project)
+	}
+
+	if err := writeProjectConfig(dir, projectConfig{Project: "myproject"}); err != nil {
+		t.Fatalf("writeProjectConfig failed: %v", err)
+	}
+	cfg, err = loadProjectConfig(dir)
+	if err != nil {
+		t.Fatalf("loadProjectConfig failed: %v", err)
+	}
+	if cfg.Project != "myproject" {
+		t.Errorf("project = %q, want myproject", cfg.Project)
+	}
+}
+
+func TestProjectConfig_Invalid(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, projectConfigFile), []byte("project = x"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loadProjectConfig(dir); err == nil {
+		t.Error("expected error for malformed config")
+	}
+}
+
+func TestProjectConfig_MalformedOnlyWarns(t *testing.T) {
+	path := setupTestCLI(t)
+	dir := t.TempDir()
+	t.Chdir(dir)
+	if err := os.WriteFile(filepath.Join(dir, projectConfigFile), []byte("project = x"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	out := runCommand(t, "--db", path, "list")
+	if !strings.Contains(out, "Warning: invalid") {
+		t.Errorf("list output missing config warning:\n%s", out)
+	}
+
+	// project use repairs the file
+	runCommand(t, "project", "use", "fixed")
+	cfg, err := loadProjectConfig(dir)
+	if err != nil || cfg.Project != "fixed" {
+		t.Errorf("config after project use = %+v, %v; want fixed", cfg, err)
+	}
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestReadConfirmation(t *testing.T) {
+	tests := []struct {
+		answer string
+		want   bool
+	}{
+		{"y\n", true},
+		{"YES\n", true},
+		{" yes ", true},
+		{"n\n", false},
+		{"\n", false},
+		{"", false},
+		{"yep\n", false},
+	}
+	for _, tt := range tests {
+		var out bytes.Buffer
+		if got := readConfirmation(strings.NewReader(tt.answer), &out, "Delete it?"); got != tt.want {
+			t.Errorf("readConfirmation(%q) = %v, want %v", tt.answer, got, tt.want)
+		}
+		if out.String() != "Delete it? [y/N] " {
+			t.Errorf("prompt = %q", out.String())
+		}
+	}
+}
+
+func TestCLI_DeleteRequiresConfirmation(t *testing.T) {
+	path := setupTestCLI(t)
+	id := strings.TrimSpace(runCommand(t, "--db", path, "add", "Keep me", "-p", "cli"))
+
+	// Unconfirmed, the delete is refused and the item survives
+	if _, err := runCommandErr(t, "--db", path, "delete", id); err != errAborted {
+		t.Fatalf("unconfirmed delete error = %v, want errAborted", err)
+	}
+	if out := runCommand(t, "--db", path, "show", id); !strings.Contains(out, "Keep me") {
+		t.Fatalf("item gone after refused delete:\n%s", out)
+	}
+
+	if out := runCommand(t, "--db", path, "delete", id, "-y"); out != "Deleted "+id+"\n" {
+		t.Errorf("delete -y = %q", out)
+	}
+}
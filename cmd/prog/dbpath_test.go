@@ -0,0 +1,181 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/baiirun/prog/internal/db"
+)
+
+func TestDBPath_Precedence(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("PROG_DB", "")
+	t.Chdir(t.TempDir()) // no .prog above the working directory
+	old := flagDB
+	t.Cleanup(func() { flagDB = old })
+
+	flagDB = ""
+	got, err := dbPath()
+	if err != nil {
+		t.Fatalf("dbPath failed: %v", err)
+	}
+	if want := filepath.Join(home, ".prog", "prog.db"); got != want {
+		t.Errorf("default = %q, want %q", got, want)
+	}
+
+	t.Setenv("PROG_DB", "/tmp/from-env.db")
+	if got, _ := dbPath(); got != "/tmp/from-env.db" {
+		t.Errorf("env = %q, want /tmp/from-env.db", got)
+	}
+
+	flagDB = "/tmp/from-flag.db"
+	if got, _ := dbPath(); got != "/tmp/from-flag.db" {
+		t.Errorf("flag = %q, want /tmp/from-flag.db", got)
+	}
+}
+
+func TestDBPath_Local(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("PROG_DB", "")
+	oldDB, oldLocal := flagDB, flagLocal
+	t.Cleanup(func() { flagDB, flagLocal = oldDB, oldLocal })
+	flagDB, flagLocal = "", false
+
+	repo := t.TempDir()
+	sub := filepath.Join(repo, "src", "pkg")
+	if err := os.MkdirAll(sub, 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Chdir(sub)
+
+	// --local points at the working directory even before .prog exists
+	flagLocal = true
+	if got, _ := dbPath(); got != filepath.Join(sub, ".prog", "prog.db") {
+		t.Errorf("--local = %q", got)
+	}
+	flagLocal = false
+
+	if err := os.Mkdir(filepath.Join(repo, ".prog"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	want := filepath.Join(repo, ".prog", "prog.db")
+	if got, _ := dbPath(); got != want {
+		t.Errorf("detected = %q, want %q", got, want)
+	}
+
+	t.Setenv("PROG_DB", "/tmp/from-env.db")
+	if got, _ := dbPath(); got != "/tmp/from-env.db" {
+		t.Errorf("env = %q, want it to beat detection", got)
+	}
+
+	flagDB, flagLocal = "/tmp/from-flag.db", true
+	if _, err := dbPath(); err == nil {
+		t.Error("expected error combining --db and --local")
+	}
+}
+
+func TestOpenDB_FlagCreatesParentDirs(t *testing.T) {
+	old := flagDB
+	t.Cleanup(func() { flagDB = old })
+
+	flagDB = filepath.Join(t.TempDir(), "nested", "dir", "scratch.db")
+	if _, err := runCommandErr(t, "init", "--db", flagDB); err != nil {
+		t.Fatalf("init failed: %v", err)
+	}
+	database, err := openDB()
+	if err != nil {
+		t.Fatalf("openDB failed: %v", err)
+	}
+	_ = database.Close()
+}
+
+func TestOpenDB_Uninitialized(t *testing.T) {
+	old := flagDB
+	t.Cleanup(func() { flagDB = old })
+
+	flagDB = filepath.Join(t.TempDir(), "fresh.db")
+	_, err := openDB()
+	if !errors.Is(err, db.ErrNotInitialized) || !strings.Contains(err.Error(), "run 'prog init'") {
+		t.Errorf("openDB on a fresh file = %v, want not initialized with a hint to run init", err)
+	}
+
+	flagDB = filepath.Join(t.TempDir(), "garbage.db")
+	if err := os.WriteFile(flagDB, []byte(strings.Repeat("not a database ", 100)), 0644); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+	_, err = openDB()
+	if !errors.Is(err, db.ErrCorrupt) {
+		t.Errorf("openDB on a garbage file = %v, want ErrCorrupt", err)
+	}
+}
+
+func TestOpenDB_LocalWithoutInitCreatesNothing(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("PROG_DB", "")
+	dir := t.TempDir()
+	t.Chdir(dir)
+
+	_, err := runCommandErr(t, "--local", "list")
+	if !errors.Is(err, db.ErrNotInitialized) {
+		t.Errorf("list --local = %v, want not initialized", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, db.LocalDir)); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("expected no %s directory, stat = %v", db.LocalDir, err)
+	}
+
+	runCommand(t, "--local", "init")
+	if _, err := runCommandErr(t, "--local", "list"); err != nil {
+		t.Errorf("list --local after init = %v", err)
+	}
+}
+
+func TestBackups_LocalDatabaseKeepsItsOwn(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("PROG_DB", "")
+	dir := t.TempDir()
+	t.Chdir(dir)
+
+	runCommand(t, "init")
+	runCommand(t, "add", "Global task")
+	runCommand(t, "--local", "init")
+	runCommand(t, "--local", "add", "Local task")
+
+	globalDir := filepath.Join(home, ".prog", db.BackupDir)
+	localDir := filepath.Join(dir, db.LocalDir, db.BackupDir)
+	global, _ := db.ListBackups(filepath.Join(home, ".prog", "prog.db"))
+	local, _ := db.ListBackups(filepath.Join(dir, db.LocalDir, "prog.db"))
+	if len(global) != 1 || filepath.Dir(global[0].Path) != globalDir {
+		t.Errorf("global backups = %+v, want one in %s", global, globalDir)
+	}
+	if len(local) != 1 || filepath.Dir(local[0].Path) != localDir {
+		t.Errorf("local backups = %+v, want one in %s", local, localDir)
+	}
+
+	out := runCommand(t, "--local", "backups")
+	if !strings.Contains(out, localDir) || !strings.Contains(out, local[0].Name) {
+		t.Errorf("local backups listing:\n%s", out)
+	}
+}
+
+func TestBackups_ScratchDatabaseLeavesHomeAlone(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	path := filepath.Join(t.TempDir(), "scratch.db")
+	t.Setenv("PROG_DB", path)
+
+	runCommand(t, "init")
+	runCommand(t, "add", "Scratch task")
+	runCommand(t, "backup", "--quiet")
+
+	if backups, _ := db.ListBackups(path); len(backups) == 0 {
+		t.Error("expected backups beside the scratch database")
+	}
+	if _, err := os.Stat(filepath.Join(home, ".prog")); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("scratch database touched ~/.prog: %v", err)
+	}
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseDate(t *testing.T) {
+	now := time.Date(2024, 5, 10, 15, 30, 0, 0, time.Local)
+
+	tests := []struct {
+		value string
+		want  time.Time
+	}{
+		{"2024-06-01", time.Date(2024, 6, 1, 0, 0, 0, 0, time.Local)},
+		{"+3d", now.AddDate(0, 0, 3)},
+		{"+2w", now.AddDate(0, 0, 14)},
+		{"+12h", now.Add(12 * time.Hour)},
+		{" +0d ", now},
+	}
+	for _, tt := range tests {
+		got, err := parseDate(tt.value, now)
+		if err != nil {
+			t.Errorf("parseDate(%q) error: %v", tt.value, err)
+			continue
+		}
+		if !got.Equal(tt.want) {
+			t.Errorf("parseDate(%q) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestParseDate_Invalid(t *testing.T) {
+	for _, value := range []string{"", "tomorrow", "+3", "+d", "+3m", "+-1d", "2024-13-01"} {
+		if _, err := parseDate(value, time.Now()); err == nil {
+			t.Errorf("parseDate(%q) expected error", value)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/baiirun/prog/internal/model"
+)
+
+func TestWriteItemsCSV(t *testing.T) {
+	database := setupTestDB(t)
+
+	created := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+	for _, item := range []*model.Item{
+		{ID: "ts-aaa111", Project: "test", Type: model.ItemTypeTask, Title: "Plain", Status: model.StatusDone, Priority: 1},
+		{ID: "ts-bbb222", Project: "test", Type: model.ItemTypeTask, Title: `Has "quotes", commas`, Status: model.StatusOpen, Priority: 2},
+		{ID: "ts-ccc333", Project: "other", Type: model.ItemTypeTask, Title: "Elsewhere", Status: model.StatusDone, Priority: 3},
+	} {
+		item.CreatedAt = created
+		item.UpdatedAt = created
+		if err := database.CreateItem(item); err != nil {
+			t.Fatalf("failed to create item: %v", err)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := writeItemsCSV(&buf, database.AllItems("test"), nil); err != nil {
+		t.Fatalf("writeItemsCSV failed: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("expected header + 2 rows, got %d lines:\n%s", len(lines), buf.String())
+	}
+	if lines[0] != "id,type,title,status,priority,created_at,updated_at" {
+		t.Errorf("unexpected header: %s", lines[0])
+	}
+	if !strings.Contains(buf.String(), `"Has ""quotes"", commas"`) {
+		t.Errorf("expected quoted title in output:\n%s", buf.String())
+	}
+	if !strings.Contains(lines[1], "2024-03-01T09:00:00Z") {
+		t.Errorf("expected RFC3339 timestamps, got: %s", lines[1])
+	}
+
+	// Status filter
+	done := model.StatusDone
+	buf.Reset()
+	if err := writeItemsCSV(&buf, database.AllItems(""), &done); err != nil {
+		t.Fatalf("writeItemsCSV failed: %v", err)
+	}
+	out := buf.String()
+	if strings.Contains(out, "ts-bbb222") {
+		t.Error("open item should be filtered out")
+	}
+	if !strings.Contains(out, "ts-aaa111") || !strings.Contains(out, "ts-ccc333") {
+		t.Errorf("expected done items from all projects:\n%s", out)
+	}
+}
+
+func TestWriteItemsMarkdown(t *testing.T) {
+	database := setupTestDB(t)
+
+	epicID := "ep-epic01"
+	for _, item := range []*model.Item{
+		{ID: epicID, Project: "test", Type: model.ItemTypeEpic, Title: "Auth", Status: model.StatusOpen},
+		{ID: "ts-child1", Project: "test", Type: model.ItemTypeTask, Title: "Login form", Status: model.StatusDone, ParentID: &epicID},
+		{ID: "ts-child2", Project: "test", Type: model.ItemTypeTask, Title: "Session store", Status: model.StatusOpen, ParentID: &epicID},
+		{ID: "ts-orphan", Project: "test", Type: model.ItemTypeTask, Title: "Fix typo", Status: model.StatusOpen},
+		{ID: "ts-elsewh", Project: "other", Type: model.ItemTypeTask, Title: "Other project", Status: model.StatusOpen},
+	} {
+		if err := database.CreateItem(item); err != nil {
+			t.Fatalf("failed to create item: %v", err)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := writeItemsMarkdown(&buf, database, "test"); err != nil {
+		t.Fatalf("writeItemsMarkdown failed: %v", err)
+	}
+	out := buf.String()
+
+	for _, want := range []string{
+		"# test\n",
+		"## Auth (1/2 done)\n",
+		"- [x] Login form\n",
+		"- [ ] Session store\n",
+		"## Orphan tasks\n",
+		"- [ ] Fix typo\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in output:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "Other project") {
+		t.Error("output should be scoped to the project")
+	}
+	if strings.Index(out, "Session store") > strings.Index(out, "Orphan tasks") {
+		t.Error("epic children should appear before orphan tasks")
+	}
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/baiirun/prog/internal/db"
+)
+
+func TestWriteDepGraphDOT(t *testing.T) {
+	edges := []db.DepEdge{
+		{ItemID: "ts-aaa111", ItemTitle: `Say "hi"`, ItemStatus: "blocked", DependsOnID: "ts-bbb222", DependsOnTitle: "Setup", DependsOnStatus: "done"},
+		{ItemID: "ts-ccc333", ItemTitle: "Other", ItemStatus: "open", DependsOnID: "ts-bbb222", DependsOnTitle: "Setup", DependsOnStatus: "done"},
+	}
+
+	var buf bytes.Buffer
+	writeDepGraphDOT(&buf, edges)
+	out := buf.String()
+
+	for _, want := range []string{
+		"digraph deps {",
+		`"ts-aaa111" [label="ts-aaa111\nSay \"hi\"", color=red];`,
+		`"ts-bbb222" [label="ts-bbb222\nSetup", color=green];`,
+		`"ts-ccc333" [label="ts-ccc333\nOther"];`,
+		`"ts-bbb222" -> "ts-aaa111";`,
+		`"ts-bbb222" -> "ts-ccc333";`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in output:\n%s", want, out)
+		}
+	}
+	if strings.Count(out, `"ts-bbb222" [`) != 1 {
+		t.Errorf("shared node should be declared once:\n%s", out)
+	}
+	if !strings.HasSuffix(out, "}\n") {
+		t.Errorf("expected closing brace:\n%s", out)
+	}
+}
@@ -0,0 +1,21 @@
+package main
+
+import "testing"
+
+func TestFormatListFooter(t *testing.T) {
+	tests := []struct {
+		offset, shown, total int
+		want                 string
+	}{
+		{0, 3, 3, ""},
+		{0, 100, 312, "Showing 1-100 of 312"},
+		{300, 12, 312, "Showing 301-312 of 312"},
+		{400, 0, 312, "No items past offset 400 (312 total)"},
+		{0, 0, 0, ""},
+	}
+	for _, tt := range tests {
+		if got := formatListFooter(tt.offset, tt.shown, tt.total); got != tt.want {
+			t.Errorf("formatListFooter(%d, %d, %d) = %q, want %q", tt.offset, tt.shown, tt.total, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/baiirun/prog/internal/db"
+	"github.com/baiirun/prog/internal/model"
+)
+
+func TestFormatLogEntry(t *testing.T) {
+	at := time.Date(2024, 1, 2, 15, 4, 0, 0, time.UTC)
+
+	got := formatLogEntry(model.Log{Message: "Did a thing", Source: "claude", CreatedAt: at})
+	if want := "[2024-01-02 15:04] (claude) Did a thing"; got != want {
+		t.Errorf("formatLogEntry = %q, want %q", got, want)
+	}
+
+	got = formatLogEntry(model.Log{Message: "Old entry", CreatedAt: at})
+	if want := "[2024-01-02 15:04] Old entry"; got != want {
+		t.Errorf("formatLogEntry = %q, want %q", got, want)
+	}
+}
+
+func TestDefaultLogSource(t *testing.T) {
+	t.Setenv("USER", "alice")
+	if got := defaultLogSource(); got != "alice" {
+		t.Errorf("defaultLogSource = %q, want alice", got)
+	}
+
+	t.Setenv("USER", "")
+	if got := defaultLogSource(); got != "agent" {
+		t.Errorf("defaultLogSource = %q, want agent", got)
+	}
+}
+
+func TestReadLogMessage(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "notes.md")
+	if err := os.WriteFile(path, []byte("First line\n  indented second\n\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	got, err := readLogMessage(path)
+	if err != nil {
+		t.Fatalf("readLogMessage failed: %v", err)
+	}
+	if want := "First line\n  indented second"; got != want {
+		t.Errorf("message = %q, want %q", got, want)
+	}
+
+	if err := os.WriteFile(path, []byte(" \n\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := readLogMessage(path); err == nil {
+		t.Error("expected error for blank message")
+	}
+}
+
+func TestResolveEditor(t *testing.T) {
+	t.Setenv("PROG_EDITOR", "")
+	t.Setenv("EDITOR", "code --wait")
+	if got := resolveEditor(); got != "code --wait" {
+		t.Errorf("resolveEditor = %q, want $EDITOR", got)
+	}
+
+	t.Setenv("PROG_EDITOR", "hx")
+	if got := resolveEditor(); got != "hx" {
+		t.Errorf("resolveEditor = %q, want $PROG_EDITOR to win", got)
+	}
+
+	t.Setenv("PROG_EDITOR", "code --wait")
+	cmd, err := editorCommand("/tmp/note.md")
+	if err != nil {
+		t.Fatalf("editorCommand failed: %v", err)
+	}
+	if want := []string{"code", "--wait", "/tmp/note.md"}; !slices.Equal(cmd.Args, want) {
+		t.Errorf("editor args = %v, want %v", cmd.Args, want)
+	}
+
+	t.Setenv("PROG_EDITOR", "  ")
+	if _, err := editorCommand("/tmp/note.md"); err == nil {
+		t.Error("expected error for a blank editor setting")
+	}
+}
+
+func TestCLI_EditRespectsLock(t *testing.T) {
+	path := setupTestCLI(t)
+	id := strings.TrimSpace(runCommand(t, "--db", path, "add", "Write the parser", "-p", "cli"))
+
+	// The "editor" locks the task while it is open, then saves
+	t.Setenv("PROG_EDITOR", "editor")
+	orig := execCommand
+	t.Cleanup(func() { execCommand = orig })
+	execCommand = func(name string, arg ...string) *exec.Cmd {
+		database, err := db.Open(path)
+		if err != nil {
+			t.Fatalf("failed to open db: %v", err)
+		}
+		defer func() { _ = database.Close() }()
+		if err := database.SetLocked(id, true); err != nil {
+			t.Fatalf("failed to lock: %v", err)
+		}
+		return exec.Command("sh", "-c", `printf 'edited' > "$0"`, arg[0])
+	}
+
+	if _, err := runCommandErr(t, "--db", path, "edit", id); !errors.Is(err, db.ErrLocked) {
+		t.Errorf("edit locked meanwhile = %v, want ErrLocked", err)
+	}
+	if show := runCommand(t, "--db", path, "show", id); strings.Contains(show, "edited") {
+		t.Errorf("description changed despite the lock:\n%s", show)
+	}
+
+	// Already locked: refused before the editor opens, unless --force
+	execCommand = func(name string, arg ...string) *exec.Cmd {
+		return exec.Command("sh", "-c", `printf 'edited' > "$0"`, arg[0])
+	}
+	if _, err := runCommandErr(t, "--db", path, "edit", id); !errors.Is(err, db.ErrLocked) {
+		t.Errorf("edit of locked task = %v, want ErrLocked", err)
+	}
+	runCommand(t, "--db", path, "edit", id, "--force")
+	if show := runCommand(t, "--db", path, "show", id); !strings.Contains(show, "edited") {
+		t.Errorf("forced edit not saved:\n%s", show)
+	}
+}
@@ -1,16 +1,32 @@
 package main
 
 import (
+	"bufio"
+	"cmp"
+	"context"
+	"encoding/csv"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"io/fs"
+	"iter"
+	"net"
+	"net/http"
 	"os"
 	"os/exec"
+	"os/signal"
 	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
+	"unicode"
 
+	"github.com/baiirun/prog/internal/api"
 	"github.com/baiirun/prog/internal/db"
+	"github.com/baiirun/prog/internal/mcp"
 	"github.com/baiirun/prog/internal/model"
 	"github.com/baiirun/prog/internal/tui"
 	"github.com/spf13/cobra"
@@ -38,7 +54,7 @@ var (
 	flagProject          string
 	flagStatus           string
 	flagEpic             bool
-	flagPriority         int
+	flagPriority         string
 	flagForce            bool
 	flagParent           string
 	flagBlocks           string
@@ -70,22 +86,162 @@ var (
 	flagLabelsColor      string
 	flagAddLabels        []string
 	flagFilterLabels     []string
+	flagUndepOn          string
+	flagFilterTag        string
+	flagDue              string
+	flagListSort         string
+	flagListReverse      bool
+	flagExportFormat     string
+	flagExportStatus     string
+	flagNextStart        bool
+	flagIncludeArchived  bool
+	flagGraphFormat      string
+	flagMvWithChildren   bool
+	flagReadyWatch       bool
+	flagReadyInterval    time.Duration
+	flagLogBy            string
+	flagEstimate         int
+	flagDB               string
+	flagLocal            bool
+	flagStatusForce      bool
+	flagAbsolute         bool
+	flagStatsSince       string
+	flagRecentLimit      int
+	flagCloneTitle       string
+	flagColor            string
+	flagStatusJSON       bool
+	flagProjectMerge     bool
+	flagStartStrict      bool
+	flagParentTo         string
+	flagParentDetach     bool
+	flagBlockOn          string
+	flagShowExport       bool
+	flagLogFile          string
+	flagLogEditor        bool
+	flagDoctorFix        bool
+	flagListLimit        int
+	flagListOffset       int
+	flagListAll          bool
+	flagDoneNote         string
+	flagListPriority     string
+	flagListMinPriority  string
+	flagRecur            string
+	flagListEmpty        bool
+	flagDependsOn        []string
+	flagServeHTTP        string
+	flagListSince        string
+	flagListUntil        string
+	flagListBy           string
+	flagListPlan         bool
+	flagNoteRm           string
+	flagLoadForce        bool
+	flagListNoDone       bool
+	flagListActive       bool
+	flagReadyRanked      bool
+	flagListFormat       string
+	flagListNoHeader     bool
+	flagDescForce        bool
+	flagPruneKeep        int
+	flagPruneOlderThan   string
+	flagInheritPriority  bool
+	flagListColumns      string
+	flagReadyParallel    bool
+	flagYes              bool
+	flagIncludeSnoozed   bool
+	flagReadyTop         int
+	flagStaleOlderThan   string
+	flagCheckMove        int
+	flagLinkKind         string
+
+	// colorOutput is resolved from --color before any command runs.
+	colorOutput bool
 )
 
+// dbPath resolves the database location: --db flag, then --local, then
+// PROG_DB, then a .prog directory in the working directory or an ancestor,
+// then the default ~/.prog/prog.db.
+func dbPath() (string, error) {
+	if flagDB != "" {
+		if flagLocal {
+			return "", fmt.Errorf("--db and --local cannot be combined")
+		}
+		return flagDB, nil
+	}
+	if flagLocal || os.Getenv("PROG_DB") == "" {
+		cwd, err := os.Getwd()
+		if err != nil {
+			return "", fmt.Errorf("failed to get working directory: %w", err)
+		}
+		if flagLocal {
+			return filepath.Join(cwd, db.LocalDir, "prog.db"), nil
+		}
+		if path, ok := db.LocalPath(cwd); ok {
+			return path, nil
+		}
+	}
+	return db.DefaultPath()
+}
+
+// openDB opens the database at dbPath and brings its schema up to date. A
+// missing database is reported, not created: only init creates one, so a
+// mistyped --db or a stray --local doesn't leave an empty database behind.
 func openDB() (*db.DB, error) {
-	path, err := db.DefaultPath()
+	path, err := dbPath()
 	if err != nil {
 		return nil, err
 	}
+	if _, err := os.Stat(path); errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("%w: no database at %s (run 'prog init' to create it)", db.ErrNotInitialized, path)
+	}
 	database, err := db.Open(path)
 	if err != nil {
+		if db.IsCorrupt(err) {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
 		return nil, fmt.Errorf("%w (try running 'prog init' first)", err)
 	}
 	// Run any pending migrations
 	if err := database.Migrate(); err != nil {
 		_ = database.Close()
+		if db.IsCorrupt(err) {
+			return nil, fmt.Errorf("%s: %w: %w", path, db.ErrCorrupt, err)
+		}
 		return nil, fmt.Errorf("migration failed: %w", err)
 	}
+	if err := database.CheckInitialized(); err != nil {
+		_ = database.Close()
+		switch {
+		case errors.Is(err, db.ErrNotInitialized):
+			return nil, fmt.Errorf("%w at %s (run 'prog init' to create it)", err, path)
+		case db.IsCorrupt(err):
+			return nil, fmt.Errorf("%s: %w: %w", path, db.ErrCorrupt, err)
+		}
+		return nil, err
+	}
+	if v := os.Getenv("PROG_MAX_DESCRIPTION"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit <= 0 {
+			_ = database.Close()
+			return nil, fmt.Errorf("invalid PROG_MAX_DESCRIPTION: %s (want a positive byte count)", v)
+		}
+		database.MaxDescription = limit
+	}
+	if v := os.Getenv("PROG_ID_BYTES"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 2 || n > 16 {
+			_ = database.Close()
+			return nil, fmt.Errorf("invalid PROG_ID_BYTES: %s (want 2-16)", v)
+		}
+		database.IDFormat.Bytes = n
+	}
+	switch v := os.Getenv("PROG_ID_ALPHABET"); v {
+	case "", "hex":
+	case "base32":
+		database.IDFormat.Base32 = true
+	default:
+		_ = database.Close()
+		return nil, fmt.Errorf("invalid PROG_ID_ALPHABET: %s (want hex or base32)", v)
+	}
 	return database, nil
 }
 
@@ -104,14 +260,155 @@ Quick start:
   prog ready -p myproject
   prog start <id>
   prog done <id>`,
+	// main prints errors itself, followed by any hint
+	SilenceErrors: true,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		// Arguments are valid by now, so a usage dump wouldn't help
+		cmd.SilenceUsage = true
+
+		var err error
+		colorOutput, err = resolveColor(flagColor, os.Getenv("NO_COLOR") != "", isTerminal(os.Stdout))
+		if err != nil {
+			return err
+		}
+		// An explicit -p (even -p "") wins over the directory's default
+		if cmd.Flags().Changed("project") {
+			return nil
+		}
+		cwd, err := os.Getwd()
+		if err != nil {
+			return fmt.Errorf("failed to get working directory: %w", err)
+		}
+		// A broken config shouldn't lock out every command, including the
+		// 'prog project use' that would rewrite it
+		cfg, err := loadProjectConfig(cwd)
+		if err != nil {
+			fmt.Fprintf(cmd.ErrOrStderr(), "Warning: %v (ignoring it)\n", err)
+			return nil
+		}
+		flagProject = cfg.Project
+		return nil
+	},
+}
+
+// projectConfigFile, in the working directory, sets the default project.
+const projectConfigFile = ".prog.json"
+
+// projectConfig is the contents of projectConfigFile.
+type projectConfig struct {
+	Project string `json:"project"`
+}
+
+// loadProjectConfig reads projectConfigFile from dir. A missing file yields
+// an empty config.
+func loadProjectConfig(dir string) (projectConfig, error) {
+	var cfg projectConfig
+	path := filepath.Join(dir, projectConfigFile)
+	content, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return cfg, nil
+	}
+	if err != nil {
+		return cfg, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	if err := json.Unmarshal(content, &cfg); err != nil {
+		return cfg, fmt.Errorf("invalid %s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// writeProjectConfig writes cfg to projectConfigFile in dir.
+func writeProjectConfig(dir string, cfg projectConfig) error {
+	output, err := json.MarshalIndent(cfg, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal config: %w", err)
+	}
+	path := filepath.Join(dir, projectConfigFile)
+	if err := os.WriteFile(path, append(output, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
+
+// resolveColor decides whether to emit ANSI colors for a --color mode.
+// auto colors only when stdout is a terminal and NO_COLOR is unset.
+func resolveColor(mode string, noColorEnv, terminal bool) (bool, error) {
+	switch mode {
+	case "always":
+		return true, nil
+	case "never":
+		return false, nil
+	case "auto", "":
+		return terminal && !noColorEnv, nil
+	}
+	return false, fmt.Errorf("invalid --color: %s (valid: auto, always, never)", mode)
+}
+
+// isTerminal reports whether f is attached to a terminal.
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// errAborted is returned when a destructive command isn't confirmed.
+var errAborted = errors.New("aborted (pass -y to skip the confirmation prompt)")
+
+// confirm asks the user to approve a destructive action, returning true
+// straight away under --yes. Without a terminal to ask on it refuses, so
+// scripts and agents must opt in with -y.
+func confirm(cmd *cobra.Command, prompt string) bool {
+	if flagYes {
+		return true
+	}
+	if !isTerminal(os.Stdin) {
+		return false
+	}
+	return readConfirmation(cmd.InOrStdin(), cmd.ErrOrStderr(), prompt)
+}
+
+// readConfirmation writes prompt to out and reports whether the answer read
+// from in is yes. Anything else, including no answer, is a no.
+func readConfirmation(in io.Reader, out io.Writer, prompt string) bool {
+	fmt.Fprintf(out, "%s [y/N] ", prompt)
+	answer, _ := bufio.NewReader(in).ReadString('\n')
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return true
+	}
+	return false
+}
+
+// statusColors maps statuses to ANSI color codes; open stays uncolored.
+var statusColors = map[model.Status]string{
+	model.StatusDone:       "32", // green
+	model.StatusInProgress: "33", // yellow
+	model.StatusBlocked:    "31", // red
+}
+
+// colorize wraps text in the color for status when color output is enabled.
+// Pad text before calling so escape codes don't affect column widths.
+func colorize(status model.Status, text string) string {
+	code, ok := statusColors[status]
+	if !colorOutput || !ok {
+		return text
+	}
+	return "\x1b[" + code + "m" + text + "\x1b[0m"
 }
 
 var initCmd = &cobra.Command{
 	Use:   "init",
 	Short: "Initialize the prog database",
-	Long:  "Creates the database at ~/.prog/prog.db if it doesn't exist.",
+	Long: `Creates the database at ~/.prog/prog.db if it doesn't exist.
+
+Use --db or the PROG_DB environment variable to initialize a database
+elsewhere (the flag takes precedence).
+
+With --local the database goes in ./.prog/prog.db instead. Every prog
+command run in this directory or below then uses it, found by walking up
+from the working directory the way git finds .git.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		path, err := db.DefaultPath()
+		out := cmd.OutOrStdout()
+		path, err := dbPath()
 		if err != nil {
 			return err
 		}
@@ -124,8 +421,8 @@ var initCmd = &cobra.Command{
 		if err := database.Init(); err != nil {
 			return err
 		}
-		fmt.Printf("Initialized prog database at %s\n", path)
-		fmt.Println("\nNext: run 'prog onboard' to set up Claude Code integration")
+		fmt.Fprintf(out, "Initialized prog database at %s\n", path)
+		fmt.Fprintln(out, "\nNext: run 'prog onboard' to set up Claude Code integration")
 		return nil
 	},
 }
@@ -141,51 +438,79 @@ Examples:
   prog add "Fix login bug" -p myproject
   prog add "Auth system" -p myproject -e
   prog add "Critical fix" --priority 1
+  prog add "Cleanup" --priority low
   prog add "Subtask" --parent ep-abc123
   prog add "Dependency" --blocks ts-xyz789
-  prog add "Bug fix" -p myproject -l bug -l urgent`,
+  prog add "Deploy" --depends-on ts-abc123 --depends-on ts-def456
+  prog add "Bug fix" -p myproject -l bug -l urgent
+  prog add "Ship release" --due 2024-06-01
+  prog add "Follow up" --due +3d
+  prog add "Write docs" --estimate 90
+  prog add "Update dependencies" --due +7d --recur weekly`,
 	Args: cobra.MinimumNArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
+		out := cmd.OutOrStdout()
 		database, err := openDB()
 		if err != nil {
 			return err
 		}
 		defer func() { _ = database.Close() }()
 
+		for _, id := range []*string{&flagParent, &flagBlocks} {
+			if err := resolveIDFlag(database, id); err != nil {
+				return err
+			}
+		}
+		if err := resolveIDArgs(database, flagDependsOn); err != nil {
+			return err
+		}
+
 		itemType := model.ItemTypeTask
 		if flagEpic {
 			itemType = model.ItemTypeEpic
 		}
 
+		priority, err := model.ParsePriority(flagPriority)
+		if err != nil {
+			return err
+		}
+
 		item := &model.Item{
-			ID:        model.GenerateID(itemType),
-			Project:   flagProject,
-			Type:      itemType,
-			Title:     strings.Join(args, " "),
-			Status:    model.StatusOpen,
-			Priority:  flagPriority,
-			CreatedAt: time.Now(),
-			UpdatedAt: time.Now(),
+			Project:     flagProject,
+			Type:        itemType,
+			Title:       strings.Join(args, " "),
+			Status:      model.StatusOpen,
+			Priority:    priority,
+			PrioritySet: cmd.Flags().Changed("priority"),
+			CreatedAt:   database.Now(),
+			UpdatedAt:   database.Now(),
 		}
 
-		if err := database.CreateItem(item); err != nil {
-			return err
+		if flagEstimate < 0 {
+			return fmt.Errorf("estimate cannot be negative: %d", flagEstimate)
 		}
+		item.EstimateMinutes = flagEstimate
 
-		// Set parent if specified
-		if flagParent != "" {
-			if err := database.SetParent(item.ID, flagParent); err != nil {
+		if flagDue != "" {
+			dueAt, err := parseDate(flagDue, database.Now())
+			if err != nil {
 				return err
 			}
+			item.DueAt = &dueAt
+		}
+		item.Recurrence = flagRecur
+		if flagParent != "" {
+			parent := flagParent
+			item.ParentID = &parent
 		}
 
-		// Add blocking relationship if specified
+		// This new item blocks --blocks (the blocked item depends on it)
+		var blocks []string
 		if flagBlocks != "" {
-			// This new item blocks the specified item
-			// (the blocked item depends on this new one)
-			if err := database.AddDep(flagBlocks, item.ID); err != nil {
-				return err
-			}
+			blocks = []string{flagBlocks}
+		}
+		if err := database.CreateItemWithDeps(item, flagDependsOn, blocks); err != nil {
+			return err
 		}
 
 		// Add labels if specified
@@ -195,7 +520,7 @@ Examples:
 			}
 		}
 
-		fmt.Println(item.ID)
+		fmt.Fprintln(out, item.ID)
 
 		// Backup after successful mutation
 		database.BackupQuiet()
@@ -213,43 +538,156 @@ Examples:
   prog list
   prog list -p myproject
   prog list --status open
+  prog list --no-done
+  prog list --active
   prog list -p myproject --status blocked
   prog list --parent ep-abc123
+  prog list --parent ep-abc123 --status open
   prog list --type epic
   prog list --blocking ts-xyz789
   prog list --blocked-by ts-abc123
   prog list --has-blockers
   prog list --no-blockers
-  prog list -l bug -l urgent`,
+  prog list --empty
+  prog list -l bug -l urgent
+  prog list --tag backend
+  prog list --status open --priority high
+  prog list --min-priority medium
+  prog list --sort updated
+  prog list --sort created --reverse
+  prog list --include-archived
+  prog list --include-snoozed
+  prog list --limit 50 --offset 50
+  prog list --all
+  prog list --since 7d
+  prog list --since 2024-05-01 --until 2024-05-31 --by updated
+  prog list -p myproject --status open --plan
+  prog list --format compact | fzf
+  prog list --no-header | wc -l
+  prog list --columns id,status,project,created,title
+
+Output is capped at 100 items by default; a footer shows which range is
+displayed. --format compact prints one "id status title" line per item with
+no header or padding, for piping into fzf or grep; it and --no-header send
+the footer to stderr so only items reach the pipe.
+
+--columns picks and orders the table's columns from: id, status, pri, type,
+project, parent, created, updated, due, labels, tags, title. The default is
+id,status,pri,title. With --format compact it replaces "id status title".
+
+--plan orders the items as an execution plan: each item comes after the
+listed items it depends on, higher priority first among the rest. A
+dependency cycle is reported as an error.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
+		out := cmd.OutOrStdout()
 		database, err := openDB()
 		if err != nil {
 			return err
 		}
 		defer func() { _ = database.Close() }()
 
-		var status *model.Status
-		if flagStatus != "" {
-			s := model.Status(flagStatus)
-			if !s.IsValid() {
-				return fmt.Errorf("invalid status: %s (valid: open, in_progress, blocked, done, canceled)", flagStatus)
+		for _, id := range []*string{&flagListParent, &flagBlocking, &flagBlockedBy} {
+			if err := resolveIDFlag(database, id); err != nil {
+				return err
+			}
+		}
+
+		status, err := parseStatusFlag(flagStatus)
+		if err != nil {
+			return err
+		}
+		var exclude []model.Status
+		switch {
+		case (flagListNoDone || flagListActive) && status != nil:
+			return fmt.Errorf("--no-done and --active cannot be combined with --status")
+		case flagListActive:
+			exclude = []model.Status{model.StatusDone, model.StatusCanceled}
+		case flagListNoDone:
+			exclude = []model.Status{model.StatusDone}
+		}
+
+		var priority, minPriority int
+		if flagListPriority != "" {
+			if priority, err = model.ParsePriority(flagListPriority); err != nil {
+				return err
+			}
+		}
+		if flagListMinPriority != "" {
+			if minPriority, err = model.ParsePriority(flagListMinPriority); err != nil {
+				return err
+			}
+		}
+
+		if flagListBy != "created" && flagListBy != "updated" {
+			return fmt.Errorf("invalid --by: %s (valid: created, updated)", flagListBy)
+		}
+		var since, until time.Time
+		if flagListSince != "" {
+			if since, err = parseSince(flagListSince, database.Now()); err != nil {
+				return err
+			}
+		}
+		if flagListUntil != "" {
+			if until, err = parseUntil(flagListUntil, database.Now()); err != nil {
+				return err
 			}
-			status = &s
 		}
 
 		filter := db.ListFilter{
-			Project:     flagProject,
-			Status:      status,
-			Parent:      flagListParent,
-			Type:        flagListType,
-			Blocking:    flagBlocking,
-			BlockedBy:   flagBlockedBy,
-			HasBlockers: flagHasBlockers,
-			NoBlockers:  flagNoBlockers,
-			Labels:      flagFilterLabels,
+			Project:         flagProject,
+			Status:          status,
+			ExcludeStatuses: exclude,
+			Parent:          flagListParent,
+			Type:            flagListType,
+			Blocking:        flagBlocking,
+			BlockedBy:       flagBlockedBy,
+			HasBlockers:     flagHasBlockers,
+			NoBlockers:      flagNoBlockers,
+			EmptyEpics:      flagListEmpty,
+			Labels:          flagFilterLabels,
+			Tag:             flagFilterTag,
+			Priority:        priority,
+			MinPriority:     minPriority,
+			Sort:            flagListSort,
+			Reverse:         flagListReverse,
+			IncludeArchived: flagIncludeArchived,
+			IncludeSnoozed:  flagIncludeSnoozed,
+			Offset:          flagListOffset,
+			Since:           since,
+			Until:           until,
+			DateField:       flagListBy,
+		}
+		if !flagListAll {
+			if flagListLimit <= 0 {
+				return fmt.Errorf("--limit must be positive (use --all for no limit)")
+			}
+			filter.Limit = flagListLimit
+		}
+		if flagListPlan && cmd.Flags().Changed("sort") {
+			return fmt.Errorf("--plan and --sort cannot be combined")
+		}
+		if flagListFormat != "table" && flagListFormat != "compact" {
+			return fmt.Errorf("invalid --format: %s (valid: table, compact)", flagListFormat)
+		}
+		spec := flagListColumns
+		if flagListFormat == "compact" && !cmd.Flags().Changed("columns") {
+			spec = "id,status,title"
+		}
+		columns, err := parseListColumns(spec)
+		if err != nil {
+			return err
 		}
 
-		items, err := database.ListItemsFiltered(filter)
+		var items []model.Item
+		var total int
+		if flagListPlan {
+			items, total, err = listPlan(database, filter)
+		} else {
+			items, err = database.ListItemsFiltered(filter)
+			if err == nil {
+				total, err = database.CountItemsFiltered(filter)
+			}
+		}
 		if err != nil {
 			return err
 		}
@@ -258,758 +696,1024 @@ Examples:
 		if err := database.PopulateItemLabels(items); err != nil {
 			return err
 		}
+		if slices.Contains(columns, "tags") {
+			if err := database.PopulateItemTags(items); err != nil {
+				return err
+			}
+		}
 
-		printItemsTable(items)
+		footerOut := out
+		switch {
+		case flagListFormat == "compact":
+			printItemsCompact(out, items, columns)
+			footerOut = cmd.ErrOrStderr()
+		case flagListNoHeader:
+			printItemColumns(out, items, columns, false)
+			footerOut = cmd.ErrOrStderr()
+		case len(items) == 0:
+			fmt.Fprintln(out, "No items")
+		default:
+			printItemColumns(out, items, columns, true)
+		}
+		if footer := formatListFooter(flagListOffset, len(items), total); footer != "" {
+			fmt.Fprintln(footerOut, footer)
+		}
 		return nil
 	},
 }
 
-var readyCmd = &cobra.Command{
-	Use:   "ready",
-	Short: "Show tasks ready for work (unblocked)",
-	Long: `Show tasks that are ready to work on.
+// listPlan returns the page of filter's items in plan order, with the total
+// number of matches. The whole match set is ordered before paging so pages
+// stay consistent.
+func listPlan(database *db.DB, filter db.ListFilter) ([]model.Item, int, error) {
+	limit, offset := filter.Limit, filter.Offset
+	filter.Limit, filter.Offset = 0, 0
+	items, err := database.ListItemsFiltered(filter)
+	if err != nil {
+		return nil, 0, err
+	}
+	plan, err := database.PlanOrder(items)
+	if err != nil {
+		return nil, 0, err
+	}
+	total := len(plan)
+	if offset >= total {
+		return nil, total, nil
+	}
+	plan = plan[offset:]
+	if limit > 0 && limit < len(plan) {
+		plan = plan[:limit]
+	}
+	return plan, total, nil
+}
 
-A task is "ready" when:
-  - Status is "open" (not in_progress, blocked, or done)
-  - All dependencies are "done"
+var searchCmd = &cobra.Command{
+	Use:   "search <query>",
+	Short: "Search tasks by title and description",
+	Long: `Search tasks whose title or description contains the query.
 
-Results are sorted by priority (1=high first).
+Matching is case-insensitive. Title matches are listed before
+description-only matches.
 
 Examples:
-  prog ready
-  prog ready -p myproject
-  prog ready -l bug`,
+  prog search "auth refactor"
+  prog search token -p myproject`,
+	Args: cobra.MinimumNArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
+		out := cmd.OutOrStdout()
 		database, err := openDB()
 		if err != nil {
 			return err
 		}
 		defer func() { _ = database.Close() }()
 
-		items, err := database.ReadyItemsFiltered(flagProject, flagFilterLabels)
+		items, err := database.SearchItems(flagProject, strings.Join(args, " "))
 		if err != nil {
 			return err
 		}
 
-		if len(items) == 0 {
-			fmt.Println("No ready tasks")
-			return nil
-		}
-
 		// Populate labels for display
 		if err := database.PopulateItemLabels(items); err != nil {
 			return err
 		}
 
-		printReadyTable(items)
+		printItemsTable(out, items)
 		return nil
 	},
 }
 
-var showCmd = &cobra.Command{
-	Use:   "show <id>",
-	Short: "Show task details",
-	Long: `Show full details for a task including description, logs, dependencies,
-and suggested concepts for context retrieval.
+var readyCmd = &cobra.Command{
+	Use:   "ready",
+	Short: "Show tasks ready for work (unblocked)",
+	Long: `Show tasks that are ready to work on.
 
-Example:
-  prog show ts-a1b2c3`,
-	Args: cobra.ExactArgs(1),
+A task is "ready" when:
+  - Status is "open" (not in_progress, blocked, or done)
+  - All dependencies are "done", transitively (a dependency's own
+    unfinished dependencies also hold the task back)
+
+Results are sorted by priority (1=high first). With --ranked they are
+sorted by a score instead, shown in its own column, that also weighs how long
+a task has waited and how many tasks depend on it. --top N keeps only the
+first N tasks in that order.
+
+With --parallel, ready tasks are split into groups that share no
+unfinished dependencies, directly or transitively, in either direction
+(done tasks don't tie groups together). Tasks in
+different groups can be handed to separate agents and worked at the same
+time without stepping on each other.
+
+With --watch, the list is polled every --interval and redrawn whenever it
+changes. Press Ctrl-C to exit.
+
+Exits with status 2 when there are no ready tasks, so scripts can check $?
+instead of parsing the output.
+
+Examples:
+  prog ready
+  prog ready -p myproject
+  prog ready -l bug
+  prog ready --top 5 -p myproject
+  prog ready --ranked
+  prog ready --parallel
+  prog ready --watch
+  prog ready --watch --interval 5s`,
 	RunE: func(cmd *cobra.Command, args []string) error {
+		out := cmd.OutOrStdout()
 		database, err := openDB()
 		if err != nil {
 			return err
 		}
 		defer func() { _ = database.Close() }()
 
-		item, err := database.GetItem(args[0])
-		if err != nil {
-			return err
+		if flagReadyTop < 0 {
+			return fmt.Errorf("invalid --top: %d (must not be negative)", flagReadyTop)
 		}
 
-		// Get labels for display
-		labels, err := database.GetItemLabels(args[0])
-		if err != nil {
-			return err
+		if flagReadyParallel {
+			if flagReadyRanked || flagReadyWatch || flagReadyTop > 0 {
+				return fmt.Errorf("--parallel cannot be combined with --ranked, --watch or --top")
+			}
+			groups, err := database.IndependentReadyFiltered(flagProject, flagFilterLabels)
+			if err != nil {
+				return err
+			}
+			for _, group := range groups {
+				if err := database.PopulateItemLabels(group); err != nil {
+					return err
+				}
+			}
+			printReadyParallel(out, groups)
+			if len(groups) == 0 {
+				return quietExit(cmd, exitNothing)
+			}
+			return nil
 		}
-		for _, l := range labels {
-			item.Labels = append(item.Labels, l.Name)
+
+		if flagReadyRanked {
+			if flagReadyWatch {
+				return fmt.Errorf("--ranked cannot be combined with --watch")
+			}
+			items, err := database.ReadyScoredFiltered(flagProject, flagFilterLabels)
+			if err != nil {
+				return err
+			}
+			if flagReadyTop > 0 && len(items) > flagReadyTop {
+				items = items[:flagReadyTop]
+			}
+			printReadyRanked(out, items)
+			if len(items) == 0 {
+				return quietExit(cmd, exitNothing)
+			}
+			return nil
 		}
 
-		logs, err := database.GetLogs(args[0])
-		if err != nil {
-			return err
+		if flagReadyWatch {
+			if flagReadyInterval <= 0 {
+				return fmt.Errorf("invalid interval: %s (must be positive)", flagReadyInterval)
+			}
+			return watchReady(out, database, flagReadyInterval)
 		}
 
-		deps, err := database.GetDeps(args[0])
+		items, err := loadReadyItems(database)
 		if err != nil {
 			return err
 		}
+		printReady(out, items)
+		if len(items) == 0 {
+			return quietExit(cmd, exitNothing)
+		}
+		return nil
+	},
+}
 
-		// Get related concepts for context suggestions
-		concepts, err := database.GetRelatedConcepts(args[0])
+// loadReadyItems fetches ready items for the current filters, with labels.
+func loadReadyItems(database *db.DB) ([]model.Item, error) {
+	items, err := database.ReadyItemsTop(flagProject, flagFilterLabels, flagReadyTop)
+	if err != nil {
+		return nil, err
+	}
+
+	// Populate labels for display
+	if err := database.PopulateItemLabels(items); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+func printReady(out io.Writer, items []model.Item) {
+	if len(items) == 0 {
+		fmt.Fprintln(out, "No ready tasks")
+		return
+	}
+	printReadyTable(out, items)
+}
+
+// printReadyRanked prints ready items with their scores, highest first.
+func printReadyRanked(out io.Writer, items []db.ScoredItem) {
+	if len(items) == 0 {
+		fmt.Fprintln(out, "No ready tasks")
+		return
+	}
+	fmt.Fprintf(out, "%-12s %-6s %-4s %-5s %s\n", "ID", "SCORE", "PRI", "DEPS", "TITLE")
+	for _, item := range items {
+		fmt.Fprintf(out, "%-12s %-6.1f %-4d %-5d %s\n", item.ID, item.Score, item.Priority, item.Dependents, item.Title)
+	}
+}
+
+// printReadyParallel prints each group of independent ready items as its
+// own table.
+func printReadyParallel(out io.Writer, groups [][]model.Item) {
+	if len(groups) == 0 {
+		fmt.Fprintln(out, "No ready tasks")
+		return
+	}
+	for i, group := range groups {
+		if i > 0 {
+			fmt.Fprintln(out)
+		}
+		fmt.Fprintf(out, "Group %d (%s):\n", i+1, pluralize(len(group), "task"))
+		printReadyTable(out, group)
+	}
+}
+
+// watchReady redraws the ready list whenever it changes until interrupted.
+func watchReady(out io.Writer, database *db.DB, interval time.Duration) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	last := ""
+	first := true
+	for {
+		items, err := loadReadyItems(database)
 		if err != nil {
 			return err
 		}
+		if sig := readySignature(items); first || sig != last {
+			first = false
+			last = sig
+			fmt.Fprint(out, "\033[H\033[2J") // clear screen, cursor home
+			fmt.Fprintf(out, "Every %s: prog ready (Ctrl-C to exit)\n\n", interval)
+			printReady(out, items)
+		}
 
-		printItemDetail(item, logs, deps, concepts)
-		return nil
-	},
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
 }
 
-var startCmd = &cobra.Command{
-	Use:   "start <id>",
-	Short: "Start working on a task",
-	Args:  cobra.ExactArgs(1),
+// readySignature summarizes the visible fields of items so watch mode can
+// skip redraws when nothing changed.
+func readySignature(items []model.Item) string {
+	var b strings.Builder
+	for _, item := range items {
+		fmt.Fprintf(&b, "%s|%d|%s|%s\n", item.ID, item.Priority, item.Title, strings.Join(item.Labels, ","))
+	}
+	return b.String()
+}
+
+var blockedCmd = &cobra.Command{
+	Use:   "blocked",
+	Short: "Show blocked tasks and what holds them up",
+	Long: `Show every blocked task with its block reason and the unfinished
+dependencies it is waiting on, blocked longest first.
+
+This is the counterpart to 'prog ready': use it to triage what's stuck.
+
+Examples:
+  prog blocked
+  prog blocked -p myproject`,
+	Args: cobra.NoArgs,
 	RunE: func(cmd *cobra.Command, args []string) error {
+		out := cmd.OutOrStdout()
 		database, err := openDB()
 		if err != nil {
 			return err
 		}
 		defer func() { _ = database.Close() }()
 
-		if err := database.UpdateStatus(args[0], model.StatusInProgress); err != nil {
+		items, err := database.BlockedItems(flagProject)
+		if err != nil {
 			return err
 		}
-		fmt.Printf("Started %s\n", args[0])
+		printBlocked(out, items, database.Now())
 		return nil
 	},
 }
 
-var doneCmd = &cobra.Command{
-	Use:   "done <id>",
-	Short: "Mark a task as done",
-	Args:  cobra.ExactArgs(1),
-	RunE: func(cmd *cobra.Command, args []string) error {
-		database, err := openDB()
-		if err != nil {
-			return err
+// printBlocked lists blocked items with how long they've been blocked, their
+// reason and the dependencies they wait on.
+func printBlocked(out io.Writer, items []db.BlockedItem, now time.Time) {
+	if len(items) == 0 {
+		fmt.Fprintln(out, "No blocked tasks")
+		return
+	}
+	fmt.Fprintf(out, "%-12s %-5s %s\n", "ID", "FOR", "TITLE")
+	for _, item := range items {
+		fmt.Fprintf(out, "%-12s %-5s %s\n", item.ID, formatDurationShort(now.Sub(item.BlockedSince)), item.Title)
+		if item.BlockReason != "" {
+			fmt.Fprintf(out, "%-12s Reason: %s\n", "", item.BlockReason)
 		}
-		defer func() { _ = database.Close() }()
-
-		if err := database.UpdateStatus(args[0], model.StatusDone); err != nil {
-			return err
+		if len(item.WaitingOn) > 0 {
+			fmt.Fprintf(out, "%-12s Waiting on: %s\n", "", strings.Join(item.WaitingOn, ", "))
 		}
-		fmt.Printf("Completed %s\n", args[0])
-
-		// Backup after successful mutation
-		database.BackupQuiet()
-
-		return nil
-	},
+	}
 }
 
-var cancelCmd = &cobra.Command{
-	Use:   "cancel <id> [reason]",
-	Short: "Cancel a task without completing it",
-	Long: `Cancel a task that is no longer relevant.
+var showCmd = &cobra.Command{
+	Use:   "show <id>",
+	Short: "Show task details",
+	Long: `Show full details for a task including description, logs, dependencies,
+dependents (items waiting on this one), and suggested concepts for context
+retrieval.
 
-Use this instead of delete when you want to preserve the task history
-but close it without marking it as successfully completed.
+Timestamps are shown relative to now ("2 hours ago", "in 3 days"); use
+--absolute for RFC3339 timestamps.
 
-Example:
-  prog cancel ts-a1b2c3
-  prog cancel ts-a1b2c3 "Requirements changed, no longer needed"`,
-	Args: cobra.MinimumNArgs(1),
+--export prints the item, its logs and dependencies, and for an epic all of
+its children, as JSON in the 'prog import' format. Use it to hand work to
+another database: prog show <id> --export | prog import - -p <project>.
+
+Examples:
+  prog show ts-a1b2c3
+  prog show ts-a1b2c3 --absolute
+  prog show ep-a1b2c3 --export > auth.json`,
+	Args: cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
+		out := cmd.OutOrStdout()
 		database, err := openDB()
 		if err != nil {
 			return err
 		}
 		defer func() { _ = database.Close() }()
 
-		id := args[0]
-
-		if err := database.UpdateStatus(id, model.StatusCanceled); err != nil {
+		if err := resolveIDArgs(database, args[:1]); err != nil {
 			return err
 		}
 
-		if len(args) > 1 {
-			reason := strings.Join(args[1:], " ")
-			if err := database.AddLog(id, "Canceled: "+reason); err != nil {
+		if flagShowExport {
+			specs, err := database.ExportItem(args[0])
+			if err != nil {
 				return err
 			}
-			fmt.Printf("Canceled %s: %s\n", id, reason)
-		} else {
-			fmt.Printf("Canceled %s\n", id)
+			b, err := json.MarshalIndent(specs, "", "  ")
+			if err != nil {
+				return fmt.Errorf("failed to marshal JSON: %w", err)
+			}
+			fmt.Fprintln(out, string(b))
+			return nil
 		}
 
-		// Backup after successful mutation
-		database.BackupQuiet()
-
-		return nil
-	},
-}
-
-var blockCmd = &cobra.Command{
-	Use:   "block <id> <reason>",
-	Short: "Mark a task as blocked",
-	Long: `Mark a task as blocked and log the reason.
+		item, err := database.GetItem(args[0])
+		if err != nil {
+			return err
+		}
 
-Use this when you can't proceed and need to hand off to another agent.
+		// Get labels for display
+		labels, err := database.GetItemLabels(args[0])
+		if err != nil {
+			return err
+		}
+		for _, l := range labels {
+			item.Labels = append(item.Labels, l.Name)
+		}
 
-Example:
-  prog block ts-a1b2c3 "Need API spec from product team"`,
-	Args: cobra.MinimumNArgs(2),
-	RunE: func(cmd *cobra.Command, args []string) error {
-		database, err := openDB()
+		item.Tags, err = database.GetItemTags(args[0])
 		if err != nil {
 			return err
 		}
-		defer func() { _ = database.Close() }()
 
-		id := args[0]
-		reason := strings.Join(args[1:], " ")
+		logs, err := database.GetLogs(args[0])
+		if err != nil {
+			return err
+		}
 
-		if err := database.UpdateStatus(id, model.StatusBlocked); err != nil {
+		notes, err := database.GetNotes(args[0])
+		if err != nil {
 			return err
 		}
-		if err := database.AddLog(id, "Blocked: "+reason); err != nil {
+
+		checklist, err := database.GetChecklist(args[0])
+		if err != nil {
 			return err
 		}
-		fmt.Printf("Blocked %s: %s\n", id, reason)
-		return nil
-	},
-}
 
-var deleteCmd = &cobra.Command{
-	Use:   "delete <id>",
-	Short: "Delete a task or epic",
-	Long: `Permanently delete a task or epic and all associated data.
+		deps, err := database.GetDeps(args[0])
+		if err != nil {
+			return err
+		}
 
-This removes the item, its logs, and any dependencies.
-This action cannot be undone.
+		dependents, err := database.GetDependents(args[0])
+		if err != nil {
+			return err
+		}
 
-Example:
-  prog delete ts-a1b2c3`,
-	Args: cobra.ExactArgs(1),
-	RunE: func(cmd *cobra.Command, args []string) error {
-		database, err := openDB()
+		links, err := database.GetLinks(args[0])
 		if err != nil {
 			return err
 		}
-		defer func() { _ = database.Close() }()
 
-		if err := database.DeleteItem(args[0]); err != nil {
+		// Get related concepts for context suggestions
+		concepts, err := database.GetRelatedConcepts(args[0])
+		if err != nil {
 			return err
 		}
-		fmt.Printf("Deleted %s\n", args[0])
+
+		detail := itemDetail{
+			item:       item,
+			logs:       logs,
+			notes:      notes,
+			checklist:  checklist,
+			deps:       deps,
+			dependents: dependents,
+			links:      links,
+			concepts:   concepts,
+			absolute:   flagAbsolute,
+		}
+
+		if item.Type == model.ItemTypeEpic {
+			detail.childrenDone, detail.childrenTotal, err = database.EpicProgress(item.ID)
+			if err != nil {
+				return err
+			}
+		}
+
+		printItemDetail(out, detail)
 		return nil
 	},
 }
 
-var logCmd = &cobra.Command{
-	Use:   "log <id> <message>",
-	Short: "Add a log entry to a task",
-	Long: `Add a timestamped log entry to a task's audit trail.
+var startCmd = &cobra.Command{
+	Use:   "start [id...]",
+	Short: "Start working on tasks",
+	Long: `Set one or more tasks to in_progress.
 
-Use this to track progress while working.
+Multiple ids are updated together: if any id is invalid, none are changed.
 
-Example:
-  prog log ts-a1b2c3 "Implemented token refresh logic"`,
-	Args: cobra.MinimumNArgs(2),
+With no ids, pick one of the ready tasks (scoped by -p) from an interactive
+list; type / to filter it. This needs a terminal on stdin.
+
+Illegal transitions, such as starting a canceled task, are rejected; use
+--force to override.
+
+If starting the tasks would put a project over its WIP limit (see
+'prog project wip'), a warning is printed; with --strict nothing is started.
+
+Examples:
+  prog start ts-a1b2c3
+  prog start ts-a1b2c3 ts-d4e5f6
+  prog start -p myproject`,
 	RunE: func(cmd *cobra.Command, args []string) error {
+		out := cmd.OutOrStdout()
 		database, err := openDB()
 		if err != nil {
 			return err
 		}
 		defer func() { _ = database.Close() }()
 
-		id := args[0]
-		message := strings.Join(args[1:], " ")
+		if len(args) == 0 {
+			id, err := pickReadyTask(cmd, database)
+			if err != nil || id == "" {
+				return err
+			}
+			args = []string{id}
+		}
+
+		if err := resolveIDArgs(database, args); err != nil {
+			return err
+		}
+
+		excess, err := database.CheckWIP(args)
+		if err != nil {
+			return err
+		}
+		if flagStartStrict && len(excess) > 0 {
+			e := excess[0]
+			return fmt.Errorf("%w for %s: %d in progress (limit %d; no changes made)", db.ErrWIPLimit, e.Project, e.InProgress, e.Limit)
+		}
 
-		if err := database.AddLog(id, message); err != nil {
+		if err := statusUpdater(database)(args, model.StatusInProgress, ""); err != nil {
 			return err
 		}
-		fmt.Printf("Logged to %s\n", id)
+		for _, id := range args {
+			fmt.Fprintf(out, "Started %s\n", id)
+		}
+		for _, e := range excess {
+			fmt.Fprintf(cmd.ErrOrStderr(), "Warning: %s\n", formatWIPExcess(e))
+		}
 		return nil
 	},
 }
 
-var graphCmd = &cobra.Command{
-	Use:   "graph",
-	Short: "Show dependency graph",
-	Long: `Show all task dependencies as a graph.
+// pickReadyTask lets the user choose one of the project's ready tasks, drawing
+// the list on stderr so stdout stays clean. It returns "" if they quit.
+func pickReadyTask(cmd *cobra.Command, database *db.DB) (string, error) {
+	if !isTerminal(os.Stdin) {
+		return "", fmt.Errorf("no task id given (pass an id, or run in a terminal to pick from ready tasks)")
+	}
+	items, err := database.ReadyItems(flagProject)
+	if err != nil {
+		return "", err
+	}
+	if len(items) == 0 {
+		return "", fmt.Errorf("no ready tasks to start")
+	}
+	chosen, err := tui.Pick("Start which task?", items, cmd.InOrStdin(), cmd.ErrOrStderr())
+	if err != nil {
+		return "", err
+	}
+	if chosen == nil {
+		fmt.Fprintln(cmd.ErrOrStderr(), "No task started")
+		return "", nil
+	}
+	return chosen.ID, nil
+}
 
-Displays which tasks are blocked by other tasks.
+func formatWIPExcess(e db.WIPExcess) string {
+	return fmt.Sprintf("WIP limit exceeded for %s: %d in progress (limit %d)", e.Project, e.InProgress, e.Limit)
+}
+
+var doneCmd = &cobra.Command{
+	Use:   "done <id> [id...]",
+	Short: "Mark tasks as done",
+	Long: `Mark one or more tasks as done.
+
+Multiple ids are updated together: if any id is invalid, none are changed.
+
+An epic can't be marked done while any of its children is unfinished
+(canceled children are fine), and blocked or canceled tasks can't be marked
+done directly. Use --force to override.
+
+Completing a recurring task (see add --recur) creates its next occurrence:
+an open copy due one period after the current due date.
+
+--note records how the work was finished as a "Done: <note>" log entry,
+written together with the status change.
 
 Examples:
-  prog graph
-  prog graph -p myproject`,
+  prog done ts-a1b2c3
+  prog done ts-a1b2c3 --note "Fixed by caching the token"
+  prog done ts-a1b2c3 ts-d4e5f6 ts-789abc
+  prog done ep-a1b2c3 --force`,
+	Args: cobra.MinimumNArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
+		out := cmd.OutOrStdout()
 		database, err := openDB()
 		if err != nil {
 			return err
 		}
 		defer func() { _ = database.Close() }()
 
-		edges, err := database.GetAllDeps(flagProject)
-		if err != nil {
+		if err := resolveIDArgs(database, args); err != nil {
 			return err
 		}
 
-		if len(edges) == 0 {
-			fmt.Println("No dependencies")
-			return nil
+		logMessage := ""
+		if note := strings.TrimSpace(flagDoneNote); note != "" {
+			logMessage = "Done: " + note
+		}
+		next, err := database.CompleteItems(args, logMessage, flagStatusForce)
+		if err != nil {
+			return err
 		}
+		for _, id := range args {
+			fmt.Fprintf(out, "Completed %s\n", id)
+			if next := next[id]; next != nil {
+				fmt.Fprintf(out, "Next occurrence: %s (due %s)\n", next.ID, next.DueAt.Format("2006-01-02"))
+			}
+		}
+
+		// Backup after successful mutation
+		database.BackupQuiet()
 
-		printDepGraph(edges)
 		return nil
 	},
 }
 
-var projectsCmd = &cobra.Command{
-	Use:   "projects",
-	Short: "List all projects",
-	Long:  `List all projects that have tasks.`,
+var reopenCmd = &cobra.Command{
+	Use:   "reopen <id>",
+	Short: "Reopen a done or canceled task",
+	Long: `Set a task back to open.
+
+Reopening a done task records a "Reopened from done" log entry so the
+history shows it was completed before.
+
+Example:
+  prog reopen ts-a1b2c3`,
+	Args: cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
+		out := cmd.OutOrStdout()
 		database, err := openDB()
 		if err != nil {
 			return err
 		}
 		defer func() { _ = database.Close() }()
 
-		projects, err := database.ListProjects()
-		if err != nil {
+		if err := resolveIDArgs(database, args[:1]); err != nil {
 			return err
 		}
 
-		if len(projects) == 0 {
-			fmt.Println("No projects")
-			return nil
+		if err := statusUpdater(database)(args[:1], model.StatusOpen, ""); err != nil {
+			return err
 		}
+		fmt.Fprintf(out, "Reopened %s\n", args[0])
+
+		// Backup after successful mutation
+		database.BackupQuiet()
 
-		for _, p := range projects {
-			fmt.Println(p)
-		}
 		return nil
 	},
 }
 
-var statusCmd = &cobra.Command{
-	Use:   "status",
-	Short: "Show project status overview",
-	Long: `Show a summary of project status for agent spin-up.
-
-Includes:
-  - Count by status (open, in_progress, blocked, done)
-  - Recently completed tasks
-  - Currently in-progress tasks
-  - Blocked tasks with reasons
-  - Ready tasks by priority (limited to 10 by default)
+var undoCmd = &cobra.Command{
+	Use:   "undo <id>",
+	Short: "Revert a task's last status change",
+	Long: `Revert a task to the status it had before its most recent change.
 
-Use --all to show all ready tasks.
+Every status change is recorded, so running undo again steps further back.
+Fails if the task has no status changes left to undo.
 
-Examples:
-  prog status
-  prog status -p myproject
-  prog status --all
-  prog status -l bug`,
+Example:
+  prog done ts-a1b2c3   # oops
+  prog undo ts-a1b2c3   # back to in_progress`,
+	Args: cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
+		out := cmd.OutOrStdout()
 		database, err := openDB()
 		if err != nil {
 			return err
 		}
 		defer func() { _ = database.Close() }()
 
-		report, err := database.ProjectStatusFiltered(flagProject, flagFilterLabels)
+		if err := resolveIDArgs(database, args[:1]); err != nil {
+			return err
+		}
+
+		status, err := database.UndoStatus(args[0])
 		if err != nil {
 			return err
 		}
+		fmt.Fprintf(out, "Reverted %s to %s\n", args[0], status)
 
-		// Populate labels for all item slices in the report
-		_ = database.PopulateItemLabels(report.RecentDone)
-		_ = database.PopulateItemLabels(report.InProgItems)
-		_ = database.PopulateItemLabels(report.BlockedItems)
-		_ = database.PopulateItemLabels(report.ReadyItems)
+		// Backup after successful mutation
+		database.BackupQuiet()
 
-		printStatusReport(report, flagStatusAll)
 		return nil
 	},
 }
 
-var appendCmd = &cobra.Command{
-	Use:   "append <id> <text>",
-	Short: "Append text to a task's description",
-	Long: `Append text to a task's description.
-
-Use this to add context, decisions, or handoff notes.
+var historyCmd = &cobra.Command{
+	Use:   "history <id>",
+	Short: "Show everything that happened to a task",
+	Long: `Show a task's full audit trail, oldest first: its creation, status
+changes, log entries, description edits and added dependencies, each
+tagged with its kind. Status changes reverted by undo are marked (undone).
 
 Example:
-  prog append ts-a1b2c3 "Decided to use JWT instead of sessions"`,
-	Args: cobra.MinimumNArgs(2),
+  prog history ts-a1b2c3`,
+	Args: cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
+		out := cmd.OutOrStdout()
 		database, err := openDB()
 		if err != nil {
 			return err
 		}
 		defer func() { _ = database.Close() }()
 
-		id := args[0]
-		text := strings.Join(args[1:], " ")
+		if err := resolveIDArgs(database, args[:1]); err != nil {
+			return err
+		}
 
-		if err := database.AppendDescription(id, text); err != nil {
+		events, err := database.ItemHistory(args[0])
+		if err != nil {
 			return err
 		}
-		fmt.Printf("Appended to %s\n", id)
+		for _, e := range events {
+			fmt.Fprintln(out, formatEvent(e))
+		}
 		return nil
 	},
 }
 
-var editCmd = &cobra.Command{
-	Use:   "edit <id>",
-	Short: "Edit a task's title or description",
-	Long: `Edit a task's title or description.
+// formatEvent renders a history entry as "[time] [kind] message", with the
+// author of log entries in parentheses.
+func formatEvent(e db.Event) string {
+	ts := e.At.Local().Format("2006-01-02 15:04")
+	msg := e.Message
+	if e.Source != "" {
+		msg = fmt.Sprintf("(%s) %s", e.Source, msg)
+	}
+	return fmt.Sprintf("[%s] %-13s %s", ts, "["+e.Kind+"]", msg)
+}
 
-With --title, updates the title directly without opening an editor.
-Without flags, opens the description in your configured editor.
+var bumpCmd = &cobra.Command{
+	Use:   "bump <id>",
+	Short: "Raise a task's priority one level",
+	Long: `Raise a task's priority one level (low -> medium -> high).
 
-Uses $PROG_EDITOR if set, otherwise defaults to nvim, then nano, then vi.
+Already-high tasks are left alone. The change is logged on the task.
 
-Examples:
-  prog edit ts-a1b2c3                     # Edit description in editor
-  prog edit ts-a1b2c3 --title "New title" # Update title directly
-  PROG_EDITOR=code prog edit ts-a1b2c3    # Use VS Code as editor`,
+Example:
+  prog bump ts-a1b2c3`,
 	Args: cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
-		database, err := openDB()
-		if err != nil {
-			return err
-		}
-		defer func() { _ = database.Close() }()
+		return runAdjustPriority(cmd.OutOrStdout(), args[0], -1)
+	},
+}
 
-		id := args[0]
+var dropCmd = &cobra.Command{
+	Use:   "drop <id>",
+	Short: "Lower a task's priority one level",
+	Long: `Lower a task's priority one level (high -> medium -> low).
 
-		// If --title flag is set, update title directly
-		if flagEditTitle != "" {
-			if err := database.SetTitle(id, flagEditTitle); err != nil {
-				return err
-			}
-			fmt.Printf("Updated title for %s\n", id)
-			return nil
-		}
+Already-low tasks are left alone. The change is logged on the task.
 
-		// Get current description
-		item, err := database.GetItem(id)
-		if err != nil {
-			return err
-		}
-
-		// Get editor (prefer $PROG_EDITOR, then nvim, then nano)
-		editor := os.Getenv("PROG_EDITOR")
-		if editor == "" {
-			if _, err := exec.LookPath("nvim"); err == nil {
-				editor = "nvim"
-			} else if _, err := exec.LookPath("nano"); err == nil {
-				editor = "nano"
-			} else {
-				editor = "vi"
-			}
-		}
-
-		// Create temp file
-		tmpfile, err := os.CreateTemp("", "prog-edit-*.md")
-		if err != nil {
-			return fmt.Errorf("failed to create temp file: %w", err)
-		}
-		tmpPath := tmpfile.Name()
-		defer func() { _ = os.Remove(tmpPath) }()
-
-		// Write current description
-		if _, err := tmpfile.WriteString(item.Description); err != nil {
-			_ = tmpfile.Close()
-			return fmt.Errorf("failed to write temp file: %w", err)
-		}
-		if err := tmpfile.Close(); err != nil {
-			return fmt.Errorf("failed to close temp file: %w", err)
-		}
-
-		// Get original stat for comparison
-		origStat, err := os.Stat(tmpPath)
-		if err != nil {
-			return fmt.Errorf("failed to stat temp file: %w", err)
-		}
-
-		// Open editor
-		editorCmd := execCommand(editor, tmpPath)
-		editorCmd.Stdin = os.Stdin
-		editorCmd.Stdout = os.Stdout
-		editorCmd.Stderr = os.Stderr
-		if err := editorCmd.Run(); err != nil {
-			return fmt.Errorf("editor failed: %w", err)
-		}
-
-		// Check if file was modified
-		newStat, err := os.Stat(tmpPath)
-		if err != nil {
-			return fmt.Errorf("failed to stat temp file: %w", err)
-		}
+Example:
+  prog drop ts-a1b2c3`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runAdjustPriority(cmd.OutOrStdout(), args[0], 1)
+	},
+}
 
-		if newStat.ModTime().Equal(origStat.ModTime()) {
-			fmt.Println("No changes made")
-			return nil
-		}
+// runAdjustPriority implements bump and drop.
+func runAdjustPriority(out io.Writer, id string, delta int) error {
+	database, err := openDB()
+	if err != nil {
+		return err
+	}
+	defer func() { _ = database.Close() }()
 
-		// Read new content
-		newContent, err := os.ReadFile(tmpPath)
-		if err != nil {
-			return fmt.Errorf("failed to read temp file: %w", err)
-		}
+	id, err = database.ResolveID(id)
+	if err != nil {
+		return err
+	}
 
-		// Update description
-		if err := database.SetDescription(id, string(newContent)); err != nil {
-			return err
-		}
-		fmt.Printf("Updated description for %s\n", id)
+	oldPriority, newPriority, err := database.AdjustPriority(id, delta)
+	if err != nil {
+		return err
+	}
+	if oldPriority == newPriority {
+		fmt.Fprintf(out, "%s is already %s priority\n", id, model.PriorityName(newPriority))
 		return nil
-	},
-}
+	}
+	fmt.Fprintf(out, "%s priority: %s -> %s\n", id, model.PriorityName(oldPriority), model.PriorityName(newPriority))
 
-// execCommand wraps exec.Command for testing
-var execCommand = func(name string, arg ...string) *exec.Cmd {
-	return exec.Command(name, arg...)
+	// Backup after successful mutation
+	database.BackupQuiet()
+
+	return nil
 }
 
-var descCmd = &cobra.Command{
-	Use:   "desc <id> <text>",
-	Short: "Replace a task's description",
-	Long: `Replace a task's entire description with new text.
+var archiveCmd = &cobra.Command{
+	Use:   "archive <id>",
+	Short: "Hide a task from list and ready",
+	Long: `Archive a task to get it out of the way without deleting it.
 
-Use this when you need to rewrite or fix the description content.
-For adding to existing content, use 'prog append' instead.
+Archived tasks are hidden from list and ready but keep their logs and
+history. Use 'prog list --include-archived' to see them.
 
 Example:
-  prog desc ts-a1b2c3 "New description text here"`,
-	Args: cobra.MinimumNArgs(2),
+  prog archive ts-a1b2c3`,
+	Args: cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
+		out := cmd.OutOrStdout()
 		database, err := openDB()
 		if err != nil {
 			return err
 		}
 		defer func() { _ = database.Close() }()
 
-		id := args[0]
-		text := strings.Join(args[1:], " ")
+		if err := resolveIDArgs(database, args[:1]); err != nil {
+			return err
+		}
 
-		if err := database.SetDescription(id, text); err != nil {
+		if err := database.SetArchived(args[0], true); err != nil {
 			return err
 		}
-		fmt.Printf("Updated description for %s\n", id)
+		fmt.Fprintf(out, "Archived %s\n", args[0])
+
+		// Backup after successful mutation
+		database.BackupQuiet()
+
 		return nil
 	},
 }
 
-var parentCmd = &cobra.Command{
-	Use:   "parent <id> <epic-id>",
-	Short: "Set a task's parent epic",
-	Long: `Set the parent epic for a task.
-
-This establishes a hierarchical relationship where tasks belong to epics.
-The parent must be an epic (created with -e flag).
-
-Example:
-  prog parent ts-a1b2c3 ep-d4e5f6
-  # ts-a1b2c3 is now a child of ep-d4e5f6`,
-	Args: cobra.ExactArgs(2),
+var unarchiveCmd = &cobra.Command{
+	Use:   "unarchive <id>",
+	Short: "Restore an archived task",
+	Args:  cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
+		out := cmd.OutOrStdout()
 		database, err := openDB()
 		if err != nil {
 			return err
 		}
 		defer func() { _ = database.Close() }()
 
-		if err := database.SetParent(args[0], args[1]); err != nil {
+		if err := resolveIDArgs(database, args[:1]); err != nil {
 			return err
 		}
-		fmt.Printf("%s is now under %s\n", args[0], args[1])
+
+		if err := database.SetArchived(args[0], false); err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "Unarchived %s\n", args[0])
+
+		// Backup after successful mutation
+		database.BackupQuiet()
+
 		return nil
 	},
 }
 
-var projectCmd = &cobra.Command{
-	Use:   "project <id> <project>",
-	Short: "Set a task's project",
-	Long: `Set or change the project for a task.
+var lockCmd = &cobra.Command{
+	Use:   "lock <id>",
+	Short: "Protect a task from status and description changes",
+	Long: `Lock a task so its status and description can't change by accident.
 
-The project will be created if it doesn't exist.
+Status commands (start, done, block, cancel, reopen) and description edits
+(desc, append, edit) refuse to touch a locked task unless given --force.
+Use this for tasks that represent external commitments.
 
 Example:
-  prog project ts-a1b2c3 myproject
-  # ts-a1b2c3 is now in myproject`,
-	Args: cobra.ExactArgs(2),
+  prog lock ts-a1b2c3`,
+	Args: cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
+		out := cmd.OutOrStdout()
 		database, err := openDB()
 		if err != nil {
 			return err
 		}
 		defer func() { _ = database.Close() }()
 
-		if err := database.SetProject(args[0], args[1]); err != nil {
+		if err := resolveIDArgs(database, args[:1]); err != nil {
+			return err
+		}
+
+		if err := database.SetLocked(args[0], true); err != nil {
 			return err
 		}
-		fmt.Printf("%s is now in project %s\n", args[0], args[1])
+		fmt.Fprintf(out, "Locked %s\n", args[0])
+
+		// Backup after successful mutation
+		database.BackupQuiet()
+
 		return nil
 	},
 }
 
-var blocksCmd = &cobra.Command{
-	Use:   "blocks <id> <other-id>",
-	Short: "Mark a task as blocking another",
-	Long: `Mark a task as blocking another task.
-
-The second task cannot be started until the first is done.
-
-Example:
-  prog blocks ts-a1b2c3 ts-d4e5f6
-  # ts-d4e5f6 cannot start until ts-a1b2c3 is done`,
-	Args: cobra.ExactArgs(2),
+var unlockCmd = &cobra.Command{
+	Use:   "unlock <id>",
+	Short: "Allow changes to a locked task again",
+	Args:  cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
+		out := cmd.OutOrStdout()
 		database, err := openDB()
 		if err != nil {
 			return err
 		}
 		defer func() { _ = database.Close() }()
 
-		// blocks A B means B depends on A (A blocks B)
-		if err := database.AddDep(args[1], args[0]); err != nil {
+		if err := resolveIDArgs(database, args[:1]); err != nil {
+			return err
+		}
+
+		if err := database.SetLocked(args[0], false); err != nil {
 			return err
 		}
-		fmt.Printf("%s now blocks %s\n", args[0], args[1])
+		fmt.Fprintf(out, "Unlocked %s\n", args[0])
+
+		// Backup after successful mutation
+		database.BackupQuiet()
+
 		return nil
 	},
 }
 
-var labelCmd = &cobra.Command{
-	Use:   "label <item-id> <label-name>",
-	Short: "Add a label to a task",
-	Long: `Add a label to a task or epic.
+var snoozeCmd = &cobra.Command{
+	Use:   "snooze <id> <when>",
+	Short: "Hide a task until a later date",
+	Long: `Hide a task from ready and list until <when>, a date (YYYY-MM-DD,
+midnight local time) or an offset from now such as +3d, +2w or +12h.
 
-Creates the label if it doesn't exist (like concepts).
+When the time passes the task reappears on its own. Use
+'prog list --include-snoozed' to see snoozed tasks, and 'prog unsnooze'
+to bring one back early.
 
-Example:
-  prog label ts-a1b2c3 bug
-  prog label ts-a1b2c3 urgent`,
+Examples:
+  prog snooze ts-a1b2c3 +1w
+  prog snooze ts-a1b2c3 2026-01-05`,
 	Args: cobra.ExactArgs(2),
 	RunE: func(cmd *cobra.Command, args []string) error {
+		out := cmd.OutOrStdout()
 		database, err := openDB()
 		if err != nil {
 			return err
 		}
 		defer func() { _ = database.Close() }()
 
-		// Get item to find its project
-		item, err := database.GetItem(args[0])
+		if err := resolveIDArgs(database, args[:1]); err != nil {
+			return err
+		}
+
+		now := database.Now()
+		until, err := parseDate(args[1], now)
 		if err != nil {
 			return err
 		}
+		if !until.After(now) {
+			return fmt.Errorf("snooze time must be in the future: %s", args[1])
+		}
 
-		if err := database.AddLabelToItem(args[0], item.Project, args[1]); err != nil {
+		if err := database.SetSnoozedUntil(args[0], &until); err != nil {
 			return err
 		}
-		fmt.Printf("Added label %q to %s\n", args[1], args[0])
+		fmt.Fprintf(out, "Snoozed %s until %s\n", args[0], until.Local().Format("2006-01-02 15:04"))
+
+		// Backup after successful mutation
+		database.BackupQuiet()
+
 		return nil
 	},
 }
 
-var unlabelCmd = &cobra.Command{
-	Use:   "unlabel <item-id> <label-name>",
-	Short: "Remove a label from a task",
-	Long: `Remove a label from a task or epic.
-
-Example:
-  prog unlabel ts-a1b2c3 bug`,
-	Args: cobra.ExactArgs(2),
+var unsnoozeCmd = &cobra.Command{
+	Use:   "unsnooze <id>",
+	Short: "Bring a snoozed task back now",
+	Args:  cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
+		out := cmd.OutOrStdout()
 		database, err := openDB()
 		if err != nil {
 			return err
 		}
 		defer func() { _ = database.Close() }()
 
-		// Get item to find its project
-		item, err := database.GetItem(args[0])
-		if err != nil {
+		if err := resolveIDArgs(database, args[:1]); err != nil {
 			return err
 		}
 
-		if err := database.RemoveLabelFromItem(args[0], item.Project, args[1]); err != nil {
+		if err := database.SetSnoozedUntil(args[0], nil); err != nil {
 			return err
 		}
-		fmt.Printf("Removed label %q from %s\n", args[1], args[0])
+		fmt.Fprintf(out, "Unsnoozed %s\n", args[0])
+
+		// Backup after successful mutation
+		database.BackupQuiet()
+
 		return nil
 	},
 }
 
-var learnCmd = &cobra.Command{
-	Use:   "learn <summary>",
-	Short: "Log a learning for future context retrieval",
-	Long: `Log a learning discovered during work.
-
-Learnings are tagged with concepts for organized retrieval.
-Concepts are created automatically if they don't exist.
+var cancelCmd = &cobra.Command{
+	Use:   "cancel <id> [reason]",
+	Short: "Cancel a task without completing it",
+	Long: `Cancel a task that is no longer relevant.
 
-If a task is in progress for the project, the learning is linked to it.
+Use this instead of delete when you want to preserve the task history
+but close it without marking it as successfully completed.
 
-Examples:
-  prog learn "Token refresh has race condition" -p myproject -c auth -c concurrency
-  prog learn "Config loaded from env first" -p myproject -c config -f config.go
-  prog learn "Token refresh issue" -c auth -p myproject --detail "The mutex only protects..."
-  echo "multi-line detail" | prog learn "summary" -c auth -p myproject --detail -`,
+Example:
+  prog cancel ts-a1b2c3
+  prog cancel ts-a1b2c3 "Requirements changed, no longer needed"`,
 	Args: cobra.MinimumNArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
-		// Validate required flags
-		if flagProject == "" {
-			return fmt.Errorf("project is required (-p)")
-		}
-		if len(flagLearnConcept) == 0 {
-			return fmt.Errorf("at least one concept is required (-c)")
-		}
-
+		out := cmd.OutOrStdout()
 		database, err := openDB()
 		if err != nil {
 			return err
 		}
 		defer func() { _ = database.Close() }()
 
-		project := flagProject
-
-		// Get current in-progress task for this project
-		taskID, _ := database.GetCurrentTaskID(project)
-
-		// Handle detail from stdin
-		detail := flagLearnDetail
-		if detail == "-" {
-			data, err := io.ReadAll(os.Stdin)
-			if err != nil {
-				return fmt.Errorf("failed to read from stdin: %w", err)
-			}
-			detail = strings.TrimSpace(string(data))
+		if err := resolveIDArgs(database, args[:1]); err != nil {
+			return err
 		}
 
-		now := time.Now()
-		learning := &model.Learning{
-			ID:        model.GenerateLearningID(),
-			Project:   project,
-			CreatedAt: now,
-			UpdatedAt: now,
-			TaskID:    taskID,
-			Summary:   strings.Join(args, " "),
-			Detail:    detail,
-			Status:    model.LearningStatusActive,
-			Concepts:  flagLearnConcept,
-			Files:     flagLearnFile,
-		}
+		id := args[0]
+		reason := strings.Join(args[1:], " ")
 
-		if err := database.CreateLearning(learning); err != nil {
+		// The reason is logged in the same transaction as the status change
+		logMessage := ""
+		if reason != "" {
+			logMessage = "Canceled: " + reason
+		}
+		if err := statusUpdater(database)(args[:1], model.StatusCanceled, logMessage); err != nil {
 			return err
 		}
 
-		// Build output
-		output := learning.ID
-		if taskID != nil {
-			output += fmt.Sprintf(" (linked to %s)", *taskID)
+		if reason != "" {
+			fmt.Fprintf(out, "Canceled %s: %s\n", id, reason)
+		} else {
+			fmt.Fprintf(out, "Canceled %s\n", id)
 		}
-		fmt.Println(output)
 
 		// Backup after successful mutation
 		database.BackupQuiet()
@@ -1018,220 +1722,327 @@ Examples:
 	},
 }
 
-var learnEditCmd = &cobra.Command{
-	Use:   "edit <learning-id>",
-	Short: "Edit a learning's summary or detail",
-	Long: `Edit an existing learning's summary or detail.
+var blockCmd = &cobra.Command{
+	Use:   "block <id> [id...] <reason>",
+	Short: "Mark tasks as blocked",
+	Long: `Mark one or more tasks as blocked and record the reason.
 
-Examples:
-  prog learn edit lrn-abc123 --summary "Updated summary"
-  prog learn edit lrn-abc123 --detail "Full context explanation"
-  echo "multi-line" | prog learn edit lrn-abc123 --detail -`,
-	Args: cobra.ExactArgs(1),
-	RunE: func(cmd *cobra.Command, args []string) error {
-		if flagLearnEditSummary == "" && flagLearnEditDetail == "" {
-			return fmt.Errorf("--summary or --detail is required")
-		}
+The reason is shown by show, status, and prime until the task leaves
+blocked, and is also written to the log for history.
+
+Use this when you can't proceed and need to hand off to another agent.
+
+With --on <id>, the tasks also depend on that item, and are reopened
+automatically once it is done. The reason then defaults to "Waiting on <id>".
 
+Leading arguments that name items, in full or abbreviated, are the tasks
+to block; the remaining arguments form the reason. To be explicit, put the
+reason after --. Multiple ids are updated together: if any id is invalid,
+none are changed. Done and canceled tasks can't be blocked; use --force to
+override.
+
+Examples:
+  prog block ts-a1b2c3 "Need API spec from product team"
+  prog block a1b2 d4e5 "Waiting on staging environment"
+  prog block ts-a1b2c3 -- ts-d4e5f6 must ship first
+  prog block ts-a1b2c3 --on ts-d4e5f6`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		out := cmd.OutOrStdout()
 		database, err := openDB()
 		if err != nil {
 			return err
 		}
 		defer func() { _ = database.Close() }()
 
-		if flagLearnEditSummary != "" {
-			if err := database.UpdateLearningSummary(args[0], flagLearnEditSummary); err != nil {
-				return err
+		var ids, reasonArgs []string
+		if dash := cmd.ArgsLenAtDash(); dash >= 0 {
+			if dash == 0 {
+				return fmt.Errorf("no task to block: prog block <id> [id...] -- <reason>")
 			}
+			ids, reasonArgs = args[:dash], args[dash:]
+			err = resolveIDArgs(database, ids)
+		} else {
+			ids, reasonArgs, err = splitIDArgs(database, args)
+		}
+		if err != nil {
+			return err
+		}
+		if len(reasonArgs) == 0 && flagBlockOn == "" {
+			return fmt.Errorf("a reason is required: prog block <id> [id...] <reason>")
 		}
 
-		if flagLearnEditDetail != "" {
-			detail := flagLearnEditDetail
-			if detail == "-" {
-				data, err := io.ReadAll(os.Stdin)
-				if err != nil {
-					return fmt.Errorf("failed to read from stdin: %w", err)
-				}
-				detail = strings.TrimSpace(string(data))
-			}
-			if err := database.UpdateLearningDetail(args[0], detail); err != nil {
+		if flagBlockOn != "" {
+			if err := resolveIDFlag(database, &flagBlockOn); err != nil {
 				return err
 			}
+			if len(reasonArgs) == 0 {
+				reasonArgs = []string{"Waiting on", flagBlockOn}
+			}
 		}
+		reason := strings.Join(reasonArgs, " ")
 
-		fmt.Printf("Updated %s\n", args[0])
+		if flagBlockOn != "" {
+			err = database.BlockItemsOn(ids, flagBlockOn, reason, flagStatusForce)
+		} else {
+			err = database.BlockItems(ids, reason, flagStatusForce)
+		}
+		if err != nil {
+			return err
+		}
+		for _, id := range ids {
+			fmt.Fprintf(out, "Blocked %s: %s\n", id, reason)
+		}
 		return nil
 	},
 }
 
-var learnStaleCmd = &cobra.Command{
-	Use:   "stale <learning-id> [learning-id...]",
-	Short: "Mark learnings as stale (outdated)",
-	Long: `Mark one or more learnings as stale when they're outdated but still useful for reference.
+// statusUpdater returns the bulk status update to use, honoring --force.
+func statusUpdater(database *db.DB) func(ids []string, status model.Status, logMessage string) error {
+	if flagStatusForce {
+		return database.ForceUpdateStatuses
+	}
+	return database.UpdateStatuses
+}
+
+// resolveIDArgs replaces each id in ids with the full id it abbreviates.
+// It writes through to the backing array, so callers can pass a subslice of
+// their args.
+func resolveIDArgs(database *db.DB, ids []string) error {
+	for i, id := range ids {
+		full, err := database.ResolveID(id)
+		if err != nil {
+			return err
+		}
+		ids[i] = full
+	}
+	return nil
+}
+
+// resolveIDFlag expands an id given via a flag, leaving empty values alone.
+func resolveIDFlag(database *db.DB, id *string) error {
+	if *id == "" {
+		return nil
+	}
+	full, err := database.ResolveID(*id)
+	if err != nil {
+		return err
+	}
+	*id = full
+	return nil
+}
+
+// completionLimit bounds how many ids shell completion offers, keeping it
+// fast on large databases.
+const completionLimit = 50
+
+// completeItemIDs offers unfinished item ids matching what's been typed,
+// described by their titles. It backs every argument of multi-id commands.
+func completeItemIDs(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	database, err := openDB()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	defer func() { _ = database.Close() }()
+
+	items, err := database.IDCompletions(toComplete, flagProject, completionLimit)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	completions := make([]string, 0, len(items))
+	for _, item := range items {
+		completions = append(completions, item.ID+"\t"+item.Title)
+	}
+	return completions, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeFirstItemID completes only the leading id of commands whose later
+// arguments are free text or values.
+func completeFirstItemID(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	return completeItemIDs(cmd, args, toComplete)
+}
+
+// minSplitIDLen is the shortest argument splitIDArgs will take as an id
+// after the first, so words like "a" or "be" stay part of the text.
+const minSplitIDLen = 4
+
+// splitIDArgs splits args into the leading ones that name items, expanded
+// to full ids, and the free text after them. The first argument must name
+// an item; past it, the first argument that is short or doesn't name
+// exactly one item starts the text.
+func splitIDArgs(database *db.DB, args []string) (ids, rest []string, err error) {
+	for i, arg := range args {
+		if i > 0 && len(arg) < minSplitIDLen {
+			return ids, args[i:], nil
+		}
+		id, err := database.ResolveID(arg)
+		switch {
+		case err == nil:
+			ids = append(ids, id)
+		case i > 0 && (errors.Is(err, db.ErrNotFound) || errors.Is(err, db.ErrInvalid)):
+			return ids, args[i:], nil
+		default:
+			return nil, nil, err
+		}
+	}
+	return ids, nil, nil
+}
+
+var cloneCmd = &cobra.Command{
+	Use:   "clone <id>",
+	Short: "Copy a task as a new open task",
+	Long: `Create a new open item with the same title, description, priority,
+type, project, parent epic, and tags as an existing one. Logs, dependencies,
+and status history are not copied. Prints the new item's ID.
 
 Examples:
-  prog learn stale lrn-abc123 --reason "Refactored in v2"
-  prog learn stale lrn-a lrn-b lrn-c --reason "Compacted into lrn-xyz"`,
-	Args: cobra.MinimumNArgs(1),
+  prog clone ts-a1b2c3
+  prog clone ts-a1b2c3 --title "Deploy billing service"`,
+	Args: cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
+		out := cmd.OutOrStdout()
 		database, err := openDB()
 		if err != nil {
 			return err
 		}
 		defer func() { _ = database.Close() }()
 
-		for _, id := range args {
-			if err := database.UpdateLearningStatus(id, model.LearningStatusStale); err != nil {
-				return err
-			}
+		if err := resolveIDArgs(database, args[:1]); err != nil {
+			return err
 		}
 
-		// Output
-		if len(args) == 1 {
-			if flagLearnStaleReason != "" {
-				fmt.Printf("Marked %s as stale: %s\n", args[0], flagLearnStaleReason)
-			} else {
-				fmt.Printf("Marked %s as stale\n", args[0])
-			}
-		} else {
-			if flagLearnStaleReason != "" {
-				fmt.Printf("Marked %d learnings as stale: %s\n", len(args), flagLearnStaleReason)
-			} else {
-				fmt.Printf("Marked %d learnings as stale\n", len(args))
-			}
+		clone, err := database.CloneItem(args[0], flagCloneTitle)
+		if err != nil {
+			return err
 		}
+
+		fmt.Fprintln(out, clone.ID)
+
+		// Backup after successful mutation
+		database.BackupQuiet()
+
 		return nil
 	},
 }
 
-var learnRmCmd = &cobra.Command{
-	Use:   "rm <learning-id>",
-	Short: "Delete a learning",
-	Long: `Permanently delete a learning and its concept associations.
+var deleteCmd = &cobra.Command{
+	Use:   "delete <id>",
+	Short: "Delete a task or epic",
+	Long: `Permanently delete a task or epic and all associated data.
 
-Example:
-  prog learn rm lrn-abc123`,
+This removes the item, its logs, and any dependencies.
+This action cannot be undone.
+
+Asks for confirmation first; pass -y to skip the prompt. Without a
+terminal the command refuses unless -y is given.
+
+Examples:
+  prog delete ts-a1b2c3
+  prog delete ts-a1b2c3 -y`,
 	Args: cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
+		out := cmd.OutOrStdout()
 		database, err := openDB()
 		if err != nil {
 			return err
 		}
 		defer func() { _ = database.Close() }()
 
-		if err := database.DeleteLearning(args[0]); err != nil {
+		if err := resolveIDArgs(database, args[:1]); err != nil {
+			return err
+		}
+
+		if !confirm(cmd, fmt.Sprintf("Permanently delete %s with its logs and dependencies?", args[0])) {
+			return errAborted
+		}
+		if err := database.DeleteItem(args[0]); err != nil {
 			return err
 		}
-		fmt.Printf("Deleted %s\n", args[0])
+		fmt.Fprintf(out, "Deleted %s\n", args[0])
 		return nil
 	},
 }
 
-var conceptsCmd = &cobra.Command{
-	Use:   "concepts [name]",
-	Short: "List or edit concepts for a project",
-	Long: `List all concepts for a project, or edit a concept.
+var mergeCmd = &cobra.Command{
+	Use:   "merge <from> <into>",
+	Short: "Merge a duplicate task into another",
+	Long: `Fold one item into another and delete it, in a single transaction.
 
-Concepts are knowledge categories that group related learnings.
-Default sort is by learning count (most used first).
+The logs, tags, labels, learnings, and children of <from> move to <into>,
+its description is appended to <into>'s, and dependencies on or by <from>
+are rewritten to point at <into>. Edges that would make <into> depend on
+itself are dropped. <into> gets a "Merged" log entry recording the title
+of the deleted item.
 
-Examples:
-  prog concepts -p myproject                        # list concepts
-  prog concepts -p myproject --recent               # sort by last updated
-  prog concepts -p myproject --stats                # show count and oldest age
-  prog concepts --related ts-abc123                 # suggest concepts for a task
-  prog concepts fts -p myproject --summary "..."    # set concept summary
-  prog concepts fts -p myproject --rename "search"  # rename concept`,
+Asks for confirmation first; pass -y to skip the prompt (required without
+a terminal).
+
+Example:
+  prog merge ts-a1b2c3 ts-d4e5f6 -y`,
+	Args: cobra.ExactArgs(2),
 	RunE: func(cmd *cobra.Command, args []string) error {
+		out := cmd.OutOrStdout()
 		database, err := openDB()
 		if err != nil {
 			return err
 		}
 		defer func() { _ = database.Close() }()
 
-		// Edit mode: concept name provided with --summary or --rename
-		if len(args) > 0 && (flagConceptsSummary != "" || flagConceptsRename != "") {
-			if flagProject == "" {
-				return fmt.Errorf("project is required (-p)")
-			}
-			if flagConceptsSummary != "" {
-				if err := database.SetConceptSummary(args[0], flagProject, flagConceptsSummary); err != nil {
-					return err
-				}
-				fmt.Printf("Updated %s\n", args[0])
-			}
-			if flagConceptsRename != "" {
-				if err := database.RenameConcept(args[0], flagConceptsRename, flagProject); err != nil {
-					return err
-				}
-				fmt.Printf("Renamed %s -> %s\n", args[0], flagConceptsRename)
-			}
-			return nil
+		if err := resolveIDArgs(database, args); err != nil {
+			return err
 		}
 
-		// Stats mode
-		if flagConceptsStats {
-			if flagProject == "" {
-				return fmt.Errorf("project is required (-p)")
-			}
-			stats, err := database.ListConceptsWithStats(flagProject)
-			if err != nil {
-				return err
-			}
-			if len(stats) == 0 {
-				fmt.Println("No concepts")
-				return nil
-			}
-			printConceptsStats(stats)
-			return nil
+		if !confirm(cmd, fmt.Sprintf("Merge %s into %s and delete %s?", args[0], args[1], args[0])) {
+			return errAborted
 		}
-
-		// List mode
-		var concepts []model.Concept
-
-		if flagConceptsRelated != "" {
-			// Get concepts related to a task
-			concepts, err = database.GetRelatedConcepts(flagConceptsRelated)
-			if err != nil {
-				return err
-			}
-		} else {
-			// List all concepts for project
-			if flagProject == "" {
-				return fmt.Errorf("project is required (-p) or use --related <task-id>")
-			}
-			concepts, err = database.ListConcepts(flagProject, flagConceptsRecent)
-			if err != nil {
-				return err
-			}
+		result, err := database.MergeItems(args[0], args[1])
+		if err != nil {
+			return err
 		}
+		fmt.Fprintf(out, "Merged %s into %s\n", args[0], args[1])
+		fmt.Fprintf(out, "  Logs moved:           %d\n", result.Logs)
+		fmt.Fprintf(out, "  Dependencies rewired: %d\n", result.Deps)
+		fmt.Fprintf(out, "  Children re-parented: %d\n", result.Children)
 
-		if len(concepts) == 0 {
-			fmt.Println("No concepts")
-			return nil
-		}
+		// Backup after successful mutation
+		database.BackupQuiet()
 
-		printConceptsTable(concepts)
 		return nil
 	},
 }
 
-var labelsCmd = &cobra.Command{
-	Use:   "labels",
-	Short: "List or manage labels for a project",
-	Long: `List all labels for a project.
+var logCmd = &cobra.Command{
+	Use:   "log <id> [message]",
+	Short: "Add a log entry to a task",
+	Long: `Add a timestamped log entry to a task's audit trail.
 
-Labels are tags for categorizing tasks (bug, feature, refactor, etc).
-Labels are project-scoped and identified by name.
+Use this to track progress while working. Entries record who wrote them
+via --by, defaulting to $USER (or "agent" when unset).
+
+For multi-line notes, read the message from a file with --file (- for
+stdin), or compose it in an editor with --editor. The editor is
+$PROG_EDITOR, then $EDITOR, then nvim, nano or vi.
 
 Examples:
-  prog labels -p myproject           # list all labels
-  prog labels add bug -p myproject   # create a label
-  prog labels rm bug -p myproject    # delete a label
-  prog labels rename bug critical -p myproject`,
+  prog log ts-a1b2c3 "Implemented token refresh logic"
+  prog log ts-a1b2c3 "Reviewed the approach" --by claude
+  prog log ts-a1b2c3 --file notes.md
+  git log -3 | prog log ts-a1b2c3 --file -
+  prog log ts-a1b2c3 --editor`,
+	Args: cobra.MinimumNArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
-		if flagProject == "" {
-			return fmt.Errorf("project is required (-p)")
+		out := cmd.OutOrStdout()
+		sources := 0
+		for _, set := range []bool{len(args) > 1, flagLogFile != "", flagLogEditor} {
+			if set {
+				sources++
+			}
+		}
+		if sources != 1 {
+			return fmt.Errorf("give the message as arguments, --file, or --editor (exactly one)")
 		}
 
 		database, err := openDB()
@@ -1240,36 +2051,105 @@ Examples:
 		}
 		defer func() { _ = database.Close() }()
 
-		labels, err := database.ListLabels(flagProject)
+		if err := resolveIDArgs(database, args[:1]); err != nil {
+			return err
+		}
+
+		id := args[0]
+		var message string
+		switch {
+		case flagLogFile != "":
+			message, err = readLogMessage(flagLogFile)
+		case flagLogEditor:
+			message, err = composeInEditor("prog-log-*.md")
+		default:
+			message = strings.Join(args[1:], " ")
+		}
 		if err != nil {
 			return err
 		}
 
-		if len(labels) == 0 {
-			fmt.Println("No labels")
-			return nil
+		source := flagLogBy
+		if source == "" {
+			source = defaultLogSource()
 		}
 
-		printLabelsTable(labels)
+		if err := database.AddLog(id, message, source); err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "Logged to %s\n", id)
 		return nil
 	},
 }
 
-var labelsAddCmd = &cobra.Command{
-	Use:   "add <name>",
-	Short: "Create a new label",
-	Long: `Create a new label in a project.
+// readLogMessage reads a log message from path, or from stdin when path is
+// "-". Trailing whitespace is dropped; an empty message is an error.
+func readLogMessage(path string) (string, error) {
+	var content []byte
+	var err error
+	if path == "-" {
+		content, err = io.ReadAll(os.Stdin)
+	} else {
+		content, err = os.ReadFile(path)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read log message: %w", err)
+	}
+	message := strings.TrimRightFunc(string(content), unicode.IsSpace)
+	if strings.TrimSpace(message) == "" {
+		return "", fmt.Errorf("log message is empty")
+	}
+	return message, nil
+}
 
-Labels are created on first use when attached to items, but you can
-also create them explicitly with this command.
+// composeInEditor opens an empty temp file named by pattern in the user's
+// editor and returns what was written. Saving an empty file aborts.
+func composeInEditor(pattern string) (string, error) {
+	tmpfile, err := os.CreateTemp("", pattern)
+	if err != nil {
+		return "", fmt.Errorf("failed to create temp file: %w", err)
+	}
+	tmpPath := tmpfile.Name()
+	defer func() { _ = os.Remove(tmpPath) }()
+	if err := tmpfile.Close(); err != nil {
+		return "", fmt.Errorf("failed to close temp file: %w", err)
+	}
 
-Examples:
-  prog labels add bug -p myproject
-  prog labels add urgent -p myproject --color "#ff0000"`,
+	editorCmd, err := editorCommand(tmpPath)
+	if err != nil {
+		return "", err
+	}
+	editorCmd.Stdin = os.Stdin
+	editorCmd.Stdout = os.Stdout
+	editorCmd.Stderr = os.Stderr
+	if err := editorCmd.Run(); err != nil {
+		return "", fmt.Errorf("editor failed: %w", err)
+	}
+
+	message, err := readLogMessage(tmpPath)
+	if err != nil {
+		return "", fmt.Errorf("%w (aborted)", err)
+	}
+	return message, nil
+}
+
+var rmLogCmd = &cobra.Command{
+	Use:   "rm-log <log-id>",
+	Short: "Delete a log entry",
+	Long: `Delete a single log entry, e.g. one logged by mistake or containing a
+secret. Log ids are shown as #N in 'prog show' output.
+
+Asks for confirmation first; pass -y to skip the prompt (required without
+a terminal).
+
+Example:
+  prog rm-log 42 -y`,
 	Args: cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
-		if flagProject == "" {
-			return fmt.Errorf("project is required (-p)")
+		out := cmd.OutOrStdout()
+		logID, err := strconv.ParseInt(strings.TrimPrefix(args[0], "#"), 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid log id: %s", args[0])
 		}
 
 		database, err := openDB()
@@ -1278,35 +2158,47 @@ Examples:
 		}
 		defer func() { _ = database.Close() }()
 
-		now := time.Now()
-		label := &model.Label{
-			ID:        model.GenerateLabelID(),
-			Name:      args[0],
-			Project:   flagProject,
-			Color:     flagLabelsColor,
-			CreatedAt: now,
-			UpdatedAt: now,
+		if !confirm(cmd, fmt.Sprintf("Delete log #%d?", logID)) {
+			return errAborted
 		}
-
-		if err := database.CreateLabel(label); err != nil {
+		if err := database.DeleteLog(logID); err != nil {
 			return err
 		}
-		fmt.Printf("Created label: %s\n", args[0])
+		fmt.Fprintf(out, "Deleted log #%d\n", logID)
+
+		// Backup after successful mutation
+		database.BackupQuiet()
+
 		return nil
 	},
 }
 
-var labelsRmCmd = &cobra.Command{
-	Use:   "rm <name>",
-	Short: "Delete a label",
-	Long: `Delete a label and remove it from all items.
+var pruneLogsCmd = &cobra.Command{
+	Use:   "prune-logs",
+	Short: "Delete old log entries to keep the database lean",
+	Long: `Delete log entries across a project (or every project without -p).
 
-Example:
-  prog labels rm bug -p myproject`,
-	Args: cobra.ExactArgs(1),
+--keep N keeps only each task's N most recent entries. --older-than deletes
+entries written before a date (YYYY-MM-DD) or a lookback such as 90d or
+12w. Give either or both; with both, an entry is deleted if either rule
+selects it.
+
+Asks for confirmation first; pass -y to skip the prompt (required without
+a terminal).
+
+Examples:
+  prog prune-logs -p myproject --keep 100
+  prog prune-logs --older-than 90d -y
+  prog prune-logs -p myproject --keep 100 --older-than 2024-01-01`,
+	Args: cobra.NoArgs,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		if flagProject == "" {
-			return fmt.Errorf("project is required (-p)")
+		out := cmd.OutOrStdout()
+		byCount := cmd.Flags().Changed("keep")
+		if !byCount && flagPruneOlderThan == "" {
+			return fmt.Errorf("nothing to prune: use --keep, --older-than, or both")
+		}
+		if flagPruneKeep < 0 {
+			return fmt.Errorf("invalid --keep: %d (must not be negative)", flagPruneKeep)
 		}
 
 		database, err := openDB()
@@ -1315,25 +2207,76 @@ Example:
 		}
 		defer func() { _ = database.Close() }()
 
-		if err := database.DeleteLabel(flagProject, args[0]); err != nil {
+		var cutoff time.Time
+		if flagPruneOlderThan != "" {
+			if cutoff, _, err = parseDateBound("--older-than", flagPruneOlderThan, database.Now()); err != nil {
+				return err
+			}
+		}
+
+		scope := "every project"
+		if flagProject != "" {
+			scope = "project " + flagProject
+		}
+		if !confirm(cmd, fmt.Sprintf("Delete log entries across %s?", scope)) {
+			return errAborted
+		}
+
+		items, err := database.ListItemsFiltered(db.ListFilter{Project: flagProject, IncludeArchived: true, IncludeSnoozed: true})
+		if err != nil {
 			return err
 		}
-		fmt.Printf("Deleted label: %s\n", args[0])
+		pruned, touched := 0, 0
+		for _, item := range items {
+			n := 0
+			if byCount {
+				deleted, err := database.PruneLogs(item.ID, flagPruneKeep)
+				if err != nil {
+					return err
+				}
+				n += deleted
+			}
+			if !cutoff.IsZero() {
+				deleted, err := database.PruneLogsBefore(item.ID, cutoff)
+				if err != nil {
+					return err
+				}
+				n += deleted
+			}
+			if n > 0 {
+				pruned += n
+				touched++
+			}
+		}
+		if pruned == 0 {
+			fmt.Fprintln(out, "No logs to prune")
+			return nil
+		}
+
+		// Backup after successful mutation
+		database.BackupQuiet()
+
+		fmt.Fprintf(out, "Pruned %s from %s\n", pluralize(pruned, "log"), pluralize(touched, "task"))
 		return nil
 	},
 }
 
-var labelsRenameCmd = &cobra.Command{
-	Use:   "rename <old-name> <new-name>",
-	Short: "Rename a label",
-	Long: `Rename a label. All items with this label will be updated.
+var timeCmd = &cobra.Command{
+	Use:   "time <id> <minutes>",
+	Short: "Record time spent on a task",
+	Long: `Add minutes of actual effort to a task.
+
+Time accumulates across calls, so log each work session separately.
+Compare against the --estimate given at creation with 'prog show'.
 
 Example:
-  prog labels rename bug critical -p myproject`,
+  prog time ts-a1b2c3 45`,
 	Args: cobra.ExactArgs(2),
 	RunE: func(cmd *cobra.Command, args []string) error {
-		if flagProject == "" {
-			return fmt.Errorf("project is required (-p)")
+		out := cmd.OutOrStdout()
+		minutes, err := strconv.Atoi(args[1])
+		if err != nil {
+			return fmt.Errorf("invalid minutes: %s", args[1])
 		}
 
 		database, err := openDB()
@@ -1342,668 +2285,3389 @@ Example:
 		}
 		defer func() { _ = database.Close() }()
 
-		if err := database.RenameLabel(flagProject, args[0], args[1]); err != nil {
+		if err := resolveIDArgs(database, args[:1]); err != nil {
+			return err
+		}
+
+		if err := database.AddTime(args[0], minutes); err != nil {
 			return err
 		}
-		fmt.Printf("Renamed label: %s -> %s\n", args[0], args[1])
+		fmt.Fprintf(out, "Added %dm to %s\n", minutes, args[0])
 		return nil
 	},
 }
 
-var contextCmd = &cobra.Command{
-	Use:   "context",
-	Short: "Retrieve learnings for context",
-	Long: `Retrieve learnings by concept, full-text search, or specific ID.
+// defaultLogSource names the author of log entries when --by is not given.
+func defaultLogSource() string {
+	if user := os.Getenv("USER"); user != "" {
+		return user
+	}
+	return "agent"
+}
 
-Use this to load relevant context before starting work on a task.
+var graphCmd = &cobra.Command{
+	Use:   "graph",
+	Short: "Show dependency graph",
+	Long: `Show all task dependencies as a graph.
+
+Displays which tasks are blocked by other tasks.
+
+With --format dot, emits Graphviz DOT instead. Edges point from a
+dependency to the task it blocks; done tasks are green, blocked are red.
 
 Examples:
-  prog context -p myproject --summary                # all learnings, grouped by concept
-  prog context -c auth -c concurrency -p myproject   # by concepts
-  prog context -q "rate limit" -p myproject          # full-text search
-  prog context -c auth --summary -p myproject        # one-liner per learning
-  prog context --id lrn-abc123                       # specific learning by ID
-  prog context -c auth --include-stale -p myproject  # include stale learnings
-  prog context -c auth --json -p myproject           # JSON output for agents`,
+  prog graph
+  prog graph -p myproject
+  prog graph -p myproject --format dot | dot -Tpng -o deps.png`,
 	RunE: func(cmd *cobra.Command, args []string) error {
+		out := cmd.OutOrStdout()
 		database, err := openDB()
 		if err != nil {
 			return err
 		}
 		defer func() { _ = database.Close() }()
 
-		// Mode 1: Specific learning by ID
-		if flagContextID != "" {
-			learning, err := database.GetLearning(flagContextID)
-			if err != nil {
-				return err
-			}
-			if flagContextJSON {
-				return printLearningsJSON([]model.Learning{*learning})
-			}
-			printLearnings([]model.Learning{*learning})
-			return nil
+		if flagGraphFormat != "text" && flagGraphFormat != "dot" {
+			return fmt.Errorf("unsupported format: %s (valid: text, dot)", flagGraphFormat)
 		}
 
-		// All other modes require project
-		if flagProject == "" {
-			return fmt.Errorf("project is required (-p) or use --id")
+		edges, err := database.GetAllDeps(flagProject)
+		if err != nil {
+			return err
 		}
 
-		// Mode 2: All learnings with --summary (no concepts/query required)
-		if flagContextSummary && len(flagContextConcept) == 0 && flagContextQuery == "" {
-			learnings, err := database.GetAllLearnings(flagProject, flagContextStale)
-			if err != nil {
-				return err
-			}
-
-			if len(learnings) == 0 {
-				if flagContextJSON {
-					fmt.Println("[]")
-					return nil
-				}
-				fmt.Println("No learnings found")
-				return nil
-			}
-
-			if flagContextJSON {
-				return printLearningsJSON(learnings)
-			}
-
-			// Get concept summaries for grouped output
-			concepts, _ := database.ListConcepts(flagProject, false)
-			conceptMap := make(map[string]string)
-			for _, c := range concepts {
-				conceptMap[c.Name] = c.Summary
-			}
-			printAllLearningSummaries(learnings, conceptMap)
+		if flagGraphFormat == "dot" {
+			writeDepGraphDOT(out, edges)
 			return nil
 		}
 
-		// Modes 3 & 4 require concepts or query
-		if len(flagContextConcept) == 0 && flagContextQuery == "" {
-			return fmt.Errorf("specify concepts (-c), query (-q), or use --summary for all")
+		if len(edges) == 0 {
+			fmt.Fprintln(out, "No dependencies")
+			return nil
 		}
 
-		var learnings []model.Learning
+		printDepGraph(out, edges)
+		return nil
+	},
+}
 
-		if len(flagContextConcept) > 0 {
-			learnings, err = database.GetLearningsByConcepts(flagProject, flagContextConcept, flagContextStale)
-		} else {
-			learnings, err = database.SearchLearnings(flagProject, flagContextQuery, flagContextStale)
-		}
+var projectsCmd = &cobra.Command{
+	Use:   "projects",
+	Short: "List all projects",
+	Long: `List all projects with their open, in-progress, blocked and done
+task counts, busiest (most open tasks) first.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		out := cmd.OutOrStdout()
+		database, err := openDB()
 		if err != nil {
 			return err
 		}
+		defer func() { _ = database.Close() }()
 
-		if len(learnings) == 0 {
-			if flagContextJSON {
-				fmt.Println("[]")
-				return nil
-			}
-			fmt.Println("No learnings found")
-			return nil
-		}
-
-		// JSON mode
-		if flagContextJSON {
-			return printLearningsJSON(learnings)
+		summaries, err := database.ProjectSummaries()
+		if err != nil {
+			return err
 		}
 
-		// Mode 3: Summary mode (one-liners) for specific concepts
-		if flagContextSummary {
-			// Get concept summaries for header
-			concepts, _ := database.ListConcepts(flagProject, false)
-			conceptMap := make(map[string]string)
-			for _, c := range concepts {
-				conceptMap[c.Name] = c.Summary
-			}
-			printLearningSummaries(learnings, flagContextConcept, conceptMap)
+		if len(summaries) == 0 {
+			fmt.Fprintln(out, "No projects")
 			return nil
 		}
 
-		// Mode 4: Full output
-		printLearnings(learnings)
+		printProjectsTable(out, summaries)
 		return nil
 	},
 }
 
-var onboardCmd = &cobra.Command{
-	Use:   "onboard",
-	Short: "Set up prog integration for AI agents",
-	Long: `Set up prog integration for AI agents.
-
-Designed for Claude Code but provides guidance for other agents.
+// printProjectsTable writes one row of status counts per project.
+func printProjectsTable(out io.Writer, summaries []db.ProjectSummary) {
+	width := len("PROJECT")
+	for _, s := range summaries {
+		width = max(width, len(s.Name))
+	}
+	fmt.Fprintf(out, "%-*s  %6s  %11s  %7s  %6s\n", width, "PROJECT", "OPEN", "IN_PROGRESS", "BLOCKED", "DONE")
+	for _, s := range summaries {
+		fmt.Fprintf(out, "%-*s  %6d  %11d  %7d  %6d\n", width, s.Name, s.Open, s.InProgress, s.Blocked, s.Done)
+	}
+}
 
-For Claude Code, this command:
-1. Writes a prog workflow snippet to CLAUDE.md in the current directory
-2. Installs a SessionStart hook in ~/.claude/settings.json to auto-run 'prog prime'
+var statusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show project status overview",
+	Long: `Show a summary of project status for agent spin-up.
 
-For other agents (Cursor, Opencode, Droid, Codex, Gemini, etc.):
-- Copy the Task Tracking snippet to your agent's instruction file
-- If hooks are available, add 'prog prime' to session start
-- Otherwise, run 'prog prime' and paste output into agent context
+Includes:
+  - Count by status (open, in_progress, blocked, done)
+  - Recently completed tasks
+  - Currently in-progress tasks
+  - Epic progress (children done / total)
+  - Blocked tasks with reasons
+  - Ready tasks by priority (limited to 10 by default)
 
-Creates files if they don't exist. Skips if already configured (use --force to update).
+Use --all to show all ready tasks. --json prints the full report,
+including every ready task and per-epic progress, for dashboards.
 
-Example:
-  cd ~/code/myproject
-  prog onboard
-  prog onboard --force  # Update existing configuration`,
+Examples:
+  prog status
+  prog status -p myproject
+  prog status --all
+  prog status -l bug
+  prog status --json`,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		return runOnboard(flagForce)
-	},
-}
+		out := cmd.OutOrStdout()
+		database, err := openDB()
+		if err != nil {
+			return err
+		}
+		defer func() { _ = database.Close() }()
 
-func findClaudeMD() string {
-	// Check for existing file with exact case match
-	// (os.Stat is case-insensitive on macOS, so we use ReadDir)
-	entries, err := os.ReadDir(".")
-	if err == nil {
-		for _, e := range entries {
-			name := e.Name()
-			if strings.EqualFold(name, "claude.md") {
-				return name // Return actual casing
-			}
+		report, err := database.ProjectStatusFiltered(flagProject, flagFilterLabels)
+		if err != nil {
+			return err
 		}
-	}
-	// Default to uppercase if none exists
-	return "CLAUDE.md"
-}
 
-func runOnboard(force bool) error {
-	return runOnboardWithSettings(force, "")
+		// Populate labels for all item slices in the report
+		_ = database.PopulateItemLabels(report.RecentDone)
+		_ = database.PopulateItemLabels(report.InProgItems)
+		_ = database.PopulateItemLabels(report.BlockedItems)
+		_ = database.PopulateItemLabels(report.ReadyItems)
+
+		if flagStatusJSON {
+			return writeStatusJSON(out, report)
+		}
+		printStatusReport(out, report, flagStatusAll)
+		return nil
+	},
 }
 
-func runOnboardWithSettings(force bool, settingsPath string) error {
-	claudePath := findClaudeMD()
-	snippet := `## Task Tracking
+var appendCmd = &cobra.Command{
+	Use:   "append <id> <text>",
+	Short: "Append text to a task's description",
+	Long: `Append text to a task's description.
 
-This project uses **prog** for cross-session task management.
-Run ` + "`prog prime`" + ` for workflow context, or configure hooks for auto-injection.
+Use this to add context, decisions, or handoff notes.
 
-**Quick reference:**
-` + "```" + `
-prog ready              # Find unblocked work
-prog add "Title" -p X   # Create task
-prog start <id>         # Claim work
-prog log <id> "msg"     # Log progress
-prog done <id>          # Complete work
-` + "```" + `
+Example:
+  prog append ts-a1b2c3 "Decided to use JWT instead of sessions"`,
+	Args: cobra.MinimumNArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		out := cmd.OutOrStdout()
+		database, err := openDB()
+		if err != nil {
+			return err
+		}
+		defer func() { _ = database.Close() }()
 
-For full workflow: ` + "`prog prime`" + `
+		if err := resolveIDArgs(database, args[:1]); err != nil {
+			return err
+		}
+
+		id := args[0]
+		text := strings.Join(args[1:], " ")
+
+		appendDescription := database.AppendDescription
+		if flagDescForce {
+			appendDescription = database.ForceAppendDescription
+		}
+		if err := appendDescription(id, text); err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "Appended to %s\n", id)
+		return nil
+	},
+}
+
+var editCmd = &cobra.Command{
+	Use:   "edit <id>",
+	Short: "Edit a task's title or description",
+	Long: `Edit a task's title or description.
+
+With --title, updates the title directly without opening an editor.
+Without flags, opens the description in your configured editor.
+
+Uses $PROG_EDITOR if set, then $EDITOR, otherwise defaults to nvim, then
+nano, then vi.
+
+Examples:
+  prog edit ts-a1b2c3                     # Edit description in editor
+  prog edit ts-a1b2c3 --title "New title" # Update title directly
+  PROG_EDITOR=code prog edit ts-a1b2c3    # Use VS Code as editor`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		out := cmd.OutOrStdout()
+		database, err := openDB()
+		if err != nil {
+			return err
+		}
+		defer func() { _ = database.Close() }()
+
+		if err := resolveIDArgs(database, args[:1]); err != nil {
+			return err
+		}
+
+		id := args[0]
+
+		// If --title flag is set, update title directly
+		if flagEditTitle != "" {
+			if err := database.SetTitle(id, flagEditTitle); err != nil {
+				return err
+			}
+			fmt.Fprintf(out, "Updated title for %s\n", id)
+			return nil
+		}
+
+		// Get current description
+		item, err := database.GetItem(id)
+		if err != nil {
+			return err
+		}
+		// Refuse before the editor opens rather than discarding the edit
+		if item.Locked && !flagDescForce {
+			return db.LockedError(id)
+		}
+
+		// Create temp file
+		tmpfile, err := os.CreateTemp("", "prog-edit-*.md")
+		if err != nil {
+			return fmt.Errorf("failed to create temp file: %w", err)
+		}
+		tmpPath := tmpfile.Name()
+		defer func() { _ = os.Remove(tmpPath) }()
+
+		// Write current description
+		if _, err := tmpfile.WriteString(item.Description); err != nil {
+			_ = tmpfile.Close()
+			return fmt.Errorf("failed to write temp file: %w", err)
+		}
+		if err := tmpfile.Close(); err != nil {
+			return fmt.Errorf("failed to close temp file: %w", err)
+		}
+
+		// Get original stat for comparison
+		origStat, err := os.Stat(tmpPath)
+		if err != nil {
+			return fmt.Errorf("failed to stat temp file: %w", err)
+		}
+
+		// Open editor
+		editorCmd, err := editorCommand(tmpPath)
+		if err != nil {
+			return err
+		}
+		editorCmd.Stdin = os.Stdin
+		editorCmd.Stdout = os.Stdout
+		editorCmd.Stderr = os.Stderr
+		if err := editorCmd.Run(); err != nil {
+			return fmt.Errorf("editor failed: %w", err)
+		}
+
+		// Check if file was modified
+		newStat, err := os.Stat(tmpPath)
+		if err != nil {
+			return fmt.Errorf("failed to stat temp file: %w", err)
+		}
+
+		if newStat.ModTime().Equal(origStat.ModTime()) {
+			fmt.Fprintln(out, "No changes made")
+			return nil
+		}
+
+		// Read new content
+		newContent, err := os.ReadFile(tmpPath)
+		if err != nil {
+			return fmt.Errorf("failed to read temp file: %w", err)
+		}
+
+		// Update description, refusing if the task was locked meanwhile
+		setDescription := database.SetDescription
+		if flagDescForce {
+			setDescription = database.ForceSetDescription
+		}
+		if err := setDescription(id, string(newContent)); err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "Updated description for %s\n", id)
+		return nil
+	},
+}
+
+// resolveEditor picks the editor to launch: $PROG_EDITOR, then $EDITOR,
+// then the first of nvim or nano on PATH, falling back to vi.
+func resolveEditor() string {
+	if editor := os.Getenv("PROG_EDITOR"); editor != "" {
+		return editor
+	}
+	if editor := os.Getenv("EDITOR"); editor != "" {
+		return editor
+	}
+	for _, editor := range []string{"nvim", "nano"} {
+		if _, err := exec.LookPath(editor); err == nil {
+			return editor
+		}
+	}
+	return "vi"
+}
+
+// editorCommand returns the command that opens path in resolveEditor's
+// editor. The editor setting may carry arguments, e.g. "code --wait". It
+// errors if the setting is only whitespace.
+func editorCommand(path string) (*exec.Cmd, error) {
+	fields := strings.Fields(resolveEditor())
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("no editor configured: $PROG_EDITOR or $EDITOR is blank")
+	}
+	return execCommand(fields[0], append(fields[1:], path)...), nil
+}
+
+// execCommand wraps exec.Command for testing
+var execCommand = func(name string, arg ...string) *exec.Cmd {
+	return exec.Command(name, arg...)
+}
+
+var descCmd = &cobra.Command{
+	Use:   "desc <id> <text>",
+	Short: "Replace a task's description",
+	Long: `Replace a task's entire description with new text.
+
+Use this when you need to rewrite or fix the description content.
+For adding to existing content, use 'prog append' instead.
+
+Example:
+  prog desc ts-a1b2c3 "New description text here"`,
+	Args: cobra.MinimumNArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		out := cmd.OutOrStdout()
+		database, err := openDB()
+		if err != nil {
+			return err
+		}
+		defer func() { _ = database.Close() }()
+
+		if err := resolveIDArgs(database, args[:1]); err != nil {
+			return err
+		}
+
+		id := args[0]
+		text := strings.Join(args[1:], " ")
+
+		setDescription := database.SetDescription
+		if flagDescForce {
+			setDescription = database.ForceSetDescription
+		}
+		if err := setDescription(id, text); err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "Updated description for %s\n", id)
+		return nil
+	},
+}
+
+var parentCmd = &cobra.Command{
+	Use:   "parent <id> [<epic-id> | --to <epic-id> | --detach]",
+	Short: "Set or clear a task's parent epic",
+	Long: `Set the parent epic for a task, or detach it from its epic.
+
+This establishes a hierarchical relationship where tasks belong to epics.
+The parent must be an epic (created with -e flag). The epic can be given
+as a second argument or with --to. --detach removes the parent and errors
+if the task has none.
+
+With --inherit-priority, the task takes the epic's priority unless its own
+was set explicitly (with add --priority, bump, or drop), in which case it
+keeps it.
+
+Examples:
+  prog parent ts-a1b2c3 ep-d4e5f6
+  # ts-a1b2c3 is now a child of ep-d4e5f6
+  prog parent ts-a1b2c3 --to ep-g7h8i9
+  prog parent ts-a1b2c3 ep-d4e5f6 --inherit-priority
+  prog parent ts-a1b2c3 --detach`,
+	Args: cobra.RangeArgs(1, 2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		out := cmd.OutOrStdout()
+		epicID := flagParentTo
+		if len(args) == 2 {
+			if epicID != "" {
+				return fmt.Errorf("give the epic as an argument or with --to, not both")
+			}
+			epicID = args[1]
+		}
+		if flagParentDetach == (epicID != "") {
+			return fmt.Errorf("specify an epic to set (argument or --to) or --detach")
+		}
+		if flagParentDetach && flagInheritPriority {
+			return fmt.Errorf("--inherit-priority cannot be combined with --detach")
+		}
+
+		database, err := openDB()
+		if err != nil {
+			return err
+		}
+		defer func() { _ = database.Close() }()
+
+		if err := resolveIDArgs(database, args[:1]); err != nil {
+			return err
+		}
+
+		if flagParentDetach {
+			if err := database.ClearParent(args[0]); err != nil {
+				return err
+			}
+			fmt.Fprintf(out, "%s no longer has a parent\n", args[0])
+			return nil
+		}
+
+		if err := resolveIDFlag(database, &epicID); err != nil {
+			return err
+		}
+		if err := database.SetParent(args[0], epicID); err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "%s is now under %s\n", args[0], epicID)
+
+		if flagInheritPriority {
+			changed, err := database.InheritParentPriority(args[0])
+			if err != nil {
+				return err
+			}
+			if changed {
+				item, err := database.GetItem(args[0])
+				if err != nil {
+					return err
+				}
+				fmt.Fprintf(out, "%s inherited %s priority from %s\n", args[0], model.PriorityName(item.Priority), epicID)
+			}
+		}
+		return nil
+	},
+}
+
+var epicCmd = &cobra.Command{
+	Use:   "epic",
+	Short: "Manage epics",
+}
+
+var epicReprioritizeCmd = &cobra.Command{
+	Use:   "reprioritize <epic-id> <priority>",
+	Short: "Set an epic's priority and cascade it to its children",
+	Long: `Set an epic's priority and pass it down to its direct children.
+
+Children whose priority was never set explicitly (with add --priority,
+bump, or drop) follow the epic; the others keep their own. Every change is
+logged on the item.
+
+Examples:
+  prog epic reprioritize ep-a1b2c3 high
+  prog epic reprioritize ep-a1b2c3 3`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		out := cmd.OutOrStdout()
+		priority, err := model.ParsePriority(args[1])
+		if err != nil {
+			return err
+		}
+
+		database, err := openDB()
+		if err != nil {
+			return err
+		}
+		defer func() { _ = database.Close() }()
+
+		if err := resolveIDArgs(database, args[:1]); err != nil {
+			return err
+		}
+
+		result, err := database.ReprioritizeEpic(args[0], priority)
+		if err != nil {
+			return err
+		}
+
+		// Backup after successful mutation
+		database.BackupQuiet()
+
+		fmt.Fprintf(out, "%s priority: %s -> %s\n", args[0], model.PriorityName(result.OldPriority), model.PriorityName(priority))
+		if len(result.Updated) > 0 {
+			fmt.Fprintf(out, "Cascaded to: %s\n", strings.Join(result.Updated, ", "))
+		}
+		if len(result.Skipped) > 0 {
+			fmt.Fprintf(out, "Kept their own priority: %s\n", strings.Join(result.Skipped, ", "))
+		}
+		return nil
+	},
+}
+
+var projectCmd = &cobra.Command{
+	Use:   "project <id> <project>",
+	Short: "Set a task's project",
+	Long: `Set or change the project for a task.
+
+The project will be created if it doesn't exist.
+
+Example:
+  prog project ts-a1b2c3 myproject
+  # ts-a1b2c3 is now in myproject`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		out := cmd.OutOrStdout()
+		database, err := openDB()
+		if err != nil {
+			return err
+		}
+		defer func() { _ = database.Close() }()
+
+		if err := resolveIDArgs(database, args[:1]); err != nil {
+			return err
+		}
+		if err := database.SetProject(args[0], args[1]); err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "%s is now in project %s\n", args[0], args[1])
+		return nil
+	},
+}
+
+var projectRenameCmd = &cobra.Command{
+	Use:   "rename <old> <new>",
+	Short: "Rename a project across all items",
+	Long: `Rename a project, moving its items, labels, concepts and learnings.
+
+Renaming onto a project that already has items is refused so two projects
+aren't combined by accident. Pass --merge to combine them on purpose.
+
+Examples:
+  prog project rename gaia gaia-v2
+  prog project rename scratch gaia --merge`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		out := cmd.OutOrStdout()
+		database, err := openDB()
+		if err != nil {
+			return err
+		}
+		defer func() { _ = database.Close() }()
+
+		rename := database.RenameProject
+		if flagProjectMerge {
+			rename = database.MergeProject
+		}
+		if err := rename(args[0], args[1]); err != nil {
+			return err
+		}
+
+		// Backup after successful mutation
+		database.BackupQuiet()
+
+		if flagProjectMerge {
+			fmt.Fprintf(out, "Merged project %s into %s\n", args[0], args[1])
+		} else {
+			fmt.Fprintf(out, "Renamed project %s to %s\n", args[0], args[1])
+		}
+		return nil
+	},
+}
+
+var projectUseCmd = &cobra.Command{
+	Use:   "use <name>",
+	Short: "Set the default project for the current directory",
+	Long: `Write .prog.json in the current directory so commands run here default
+to the given project. An explicit -p/--project always wins; pass -p "" to
+see all projects.
+
+Example:
+  prog project use myproject
+  prog ready   # same as prog ready -p myproject`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		out := cmd.OutOrStdout()
+		cwd, err := os.Getwd()
+		if err != nil {
+			return fmt.Errorf("failed to get working directory: %w", err)
+		}
+		if err := writeProjectConfig(cwd, projectConfig{Project: args[0]}); err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "Default project for %s is now %s\n", cwd, args[0])
+		return nil
+	},
+}
+
+var projectWIPCmd = &cobra.Command{
+	Use:   "wip <n> -p <project>",
+	Short: "Set a project's work-in-progress limit",
+	Long: `Cap how many of a project's tasks should be in progress at once.
+
+'prog start' warns when it would exceed the limit (or refuses, with
+--strict), and 'prog status' shows the current count against it.
+A limit of 0 removes the cap.
+
+Examples:
+  prog project wip 3 -p myproject
+  prog project wip 0 -p myproject   # unlimited`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		out := cmd.OutOrStdout()
+		if flagProject == "" {
+			return fmt.Errorf("project is required (-p)")
+		}
+		limit, err := strconv.Atoi(args[0])
+		if err != nil {
+			return fmt.Errorf("invalid WIP limit: %s (want a whole number, 0 for unlimited)", args[0])
+		}
+
+		database, err := openDB()
+		if err != nil {
+			return err
+		}
+		defer func() { _ = database.Close() }()
+
+		if err := database.SetWIPLimit(flagProject, limit); err != nil {
+			return err
+		}
+
+		// Backup after successful mutation
+		database.BackupQuiet()
+
+		if limit == 0 {
+			fmt.Fprintf(out, "Removed WIP limit for %s\n", flagProject)
+		} else {
+			fmt.Fprintf(out, "WIP limit for %s set to %d\n", flagProject, limit)
+		}
+		return nil
+	},
+}
+
+var projectAutoCloseCmd = &cobra.Command{
+	Use:   "auto-close <on|off> -p <project>",
+	Short: "Complete a project's epics when their last child is done",
+	Long: `Turn automatic epic completion on or off for a project.
+
+When on, marking a task done also marks its parent epic done once every
+other child is done or canceled, logging "Auto-completed: all children
+done" on the epic. It is off by default, since some epics are ongoing
+buckets that should stay open.
+
+Examples:
+  prog project auto-close on -p myproject
+  prog project auto-close off -p myproject`,
+	Args:      cobra.ExactArgs(1),
+	ValidArgs: []string{"on", "off"},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		out := cmd.OutOrStdout()
+		if flagProject == "" {
+			return fmt.Errorf("project is required (-p)")
+		}
+		var on bool
+		switch args[0] {
+		case "on":
+			on = true
+		case "off":
+		default:
+			return fmt.Errorf("invalid auto-close setting: %s (want on or off)", args[0])
+		}
+
+		database, err := openDB()
+		if err != nil {
+			return err
+		}
+		defer func() { _ = database.Close() }()
+
+		if err := database.SetAutoCloseEpics(flagProject, on); err != nil {
+			return err
+		}
+
+		// Backup after successful mutation
+		database.BackupQuiet()
+
+		fmt.Fprintf(out, "Epic auto-close for %s is %s\n", flagProject, args[0])
+		return nil
+	},
+}
+
+var childrenCmd = &cobra.Command{
+	Use:   "children <epic-id>",
+	Short: "List an epic's direct child tasks",
+	Long: `List the tasks whose parent is the given epic.
+
+Example:
+  prog children ep-a1b2c3`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		out := cmd.OutOrStdout()
+		database, err := openDB()
+		if err != nil {
+			return err
+		}
+		defer func() { _ = database.Close() }()
+
+		if err := resolveIDArgs(database, args[:1]); err != nil {
+			return err
+		}
+
+		items, err := database.ListChildren(args[0])
+		if err != nil {
+			return err
+		}
+
+		if err := database.PopulateItemLabels(items); err != nil {
+			return err
+		}
+
+		printItemsTable(out, items)
+		return nil
+	},
+}
+
+var treeCmd = &cobra.Command{
+	Use:   "tree",
+	Short: "Show epics and tasks as a hierarchy",
+	Long: `Show the project hierarchy: epics with their child tasks indented
+beneath them, followed by tasks that have no parent epic.
+
+Each line is "[status] id title".
+
+Examples:
+  prog tree
+  prog tree -p myproject`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		out := cmd.OutOrStdout()
+		database, err := openDB()
+		if err != nil {
+			return err
+		}
+		defer func() { _ = database.Close() }()
+
+		roots, err := database.ItemTree(flagProject)
+		if err != nil {
+			return err
+		}
+
+		if len(roots) == 0 {
+			fmt.Fprintln(out, "No items")
+			return nil
+		}
+
+		printTree(out, roots, 0)
+		return nil
+	},
+}
+
+// printTree writes nodes as "[status] id title", indenting two spaces per level.
+func printTree(out io.Writer, nodes []*db.TreeNode, depth int) {
+	indent := strings.Repeat("  ", depth)
+	for _, n := range nodes {
+		fmt.Fprintf(out, "%s[%s] %s %s\n", indent, n.Item.Status, n.Item.ID, n.Item.Title)
+		printTree(out, n.Children, depth+1)
+	}
+}
+
+var mvCmd = &cobra.Command{
+	Use:   "mv <id> -p <project>",
+	Short: "Move a task or epic to another project",
+	Long: `Move an item to another project.
+
+The destination is given with -p/--project and is created if it doesn't
+exist. For epics, --with-children moves all child tasks in the same step.
+
+Examples:
+  prog mv ts-a1b2c3 -p otherproject
+  prog mv ep-a1b2c3 -p otherproject --with-children`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		out := cmd.OutOrStdout()
+		if flagProject == "" {
+			return fmt.Errorf("destination project is required: prog mv <id> -p <project>")
+		}
+
+		database, err := openDB()
+		if err != nil {
+			return err
+		}
+		defer func() { _ = database.Close() }()
+
+		if err := resolveIDArgs(database, args[:1]); err != nil {
+			return err
+		}
+
+		moved, err := database.MoveItem(args[0], flagProject, flagMvWithChildren)
+		if err != nil {
+			return err
+		}
+		if moved > 1 {
+			fmt.Fprintf(out, "Moved %s and %d children to %s\n", args[0], moved-1, flagProject)
+		} else {
+			fmt.Fprintf(out, "Moved %s to %s\n", args[0], flagProject)
+		}
+
+		// Backup after successful mutation
+		database.BackupQuiet()
+
+		return nil
+	},
+}
+
+var blocksCmd = &cobra.Command{
+	Use:   "blocks <id> <other-id>",
+	Short: "Mark a task as blocking another",
+	Long: `Mark a task as blocking another task.
+
+The second task cannot be started until the first is done.
+
+Example:
+  prog blocks ts-a1b2c3 ts-d4e5f6
+  # ts-d4e5f6 cannot start until ts-a1b2c3 is done`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		out := cmd.OutOrStdout()
+		database, err := openDB()
+		if err != nil {
+			return err
+		}
+		defer func() { _ = database.Close() }()
+
+		if err := resolveIDArgs(database, args[:2]); err != nil {
+			return err
+		}
+
+		// blocks A B means B depends on A (A blocks B)
+		if err := database.AddDep(args[1], args[0]); err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "%s now blocks %s\n", args[0], args[1])
+		return reportDepCheck(cmd.ErrOrStderr(), database, args[1], args[0])
+	},
+}
+
+// reportDepCheck prints a note on stderr when a new dependency is already
+// satisfied or is itself blocked. The edge is kept either way.
+func reportDepCheck(errOut io.Writer, database *db.DB, itemID, dependsOnID string) error {
+	check, err := database.CheckDep(itemID, dependsOnID)
+	if err != nil {
+		return err
+	}
+	if msg := formatDepCheck(itemID, dependsOnID, check); msg != "" {
+		fmt.Fprintln(errOut, msg)
+	}
+	return nil
+}
+
+// formatDepCheck describes the effect of the edge itemID -> dependsOnID, or
+// returns "" when there is nothing worth pointing out.
+func formatDepCheck(itemID, dependsOnID string, check *db.DepCheck) string {
+	switch check.DependsOnStatus {
+	case model.StatusBlocked:
+		return fmt.Sprintf("Warning: %s is blocked; %s can't start until it is unblocked and done", dependsOnID, itemID)
+	case model.StatusDone:
+		msg := fmt.Sprintf("Note: %s is already done, so it doesn't hold back %s", dependsOnID, itemID)
+		if check.Ready {
+			msg += fmt.Sprintf(" (%s is ready)", itemID)
+		}
+		return msg
+	}
+	return ""
+}
+
+var undepCmd = &cobra.Command{
+	Use:   "undep <id> --on <other-id>",
+	Short: "Remove a dependency between tasks",
+	Long: `Remove a dependency so a task no longer waits on another.
+
+This is the inverse of 'prog blocks'.
+
+Example:
+  prog undep ts-d4e5f6 --on ts-a1b2c3
+  # ts-d4e5f6 no longer waits for ts-a1b2c3`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		out := cmd.OutOrStdout()
+		database, err := openDB()
+		if err != nil {
+			return err
+		}
+		defer func() { _ = database.Close() }()
+
+		if err := resolveIDArgs(database, args[:1]); err != nil {
+			return err
+		}
+		if err := resolveIDFlag(database, &flagUndepOn); err != nil {
+			return err
+		}
+
+		if err := database.RemoveDep(args[0], flagUndepOn); err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "%s no longer depends on %s\n", args[0], flagUndepOn)
+		return nil
+	},
+}
+
+var linkCmd = &cobra.Command{
+	Use:   "link <id> <other-id>",
+	Short: "Link two related tasks without a dependency",
+	Long: `Record a loose link from one task to another, shown under "Related" in
+'prog show' on both items.
+
+Kinds:
+  relates     the tasks touch the same area (the default; no direction)
+  duplicates  the first task repeats the second
+  blocks      the first task gets in the way of the second
+
+Unlike 'prog blocks' and --depends-on, links never hold a task back from
+'prog ready'. Linking the same pair twice is a no-op.
+
+Examples:
+  prog link ts-a1b2c3 ts-d4e5f6
+  prog link ts-a1b2c3 ts-d4e5f6 --kind duplicates`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		out := cmd.OutOrStdout()
+		database, err := openDB()
+		if err != nil {
+			return err
+		}
+		defer func() { _ = database.Close() }()
+
+		if err := resolveIDArgs(database, args); err != nil {
+			return err
+		}
+		if err := database.AddLink(args[0], args[1], flagLinkKind); err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "%s %s\n", args[0], describeLink(model.Link{FromID: args[0], ToID: args[1], Kind: flagLinkKind}, args[0]))
+
+		// Backup after successful mutation
+		database.BackupQuiet()
+		return nil
+	},
+}
+
+var unlinkCmd = &cobra.Command{
+	Use:   "unlink <id> <other-id>",
+	Short: "Remove a link between tasks",
+	Long: `Remove a link added with 'prog link'. Pass the same --kind it was added
+with; a "relates" link is removed whichever way round it was added.
+
+Examples:
+  prog unlink ts-a1b2c3 ts-d4e5f6
+  prog unlink ts-a1b2c3 ts-d4e5f6 --kind duplicates`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		out := cmd.OutOrStdout()
+		database, err := openDB()
+		if err != nil {
+			return err
+		}
+		defer func() { _ = database.Close() }()
+
+		if err := resolveIDArgs(database, args); err != nil {
+			return err
+		}
+		if err := database.RemoveLink(args[0], args[1], flagLinkKind); err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "Removed %s link: %s -> %s\n", flagLinkKind, args[0], args[1])
+
+		// Backup after successful mutation
+		database.BackupQuiet()
+		return nil
+	},
+}
+
+var labelCmd = &cobra.Command{
+	Use:   "label <item-id> <label-name>",
+	Short: "Add a label to a task",
+	Long: `Add a label to a task or epic.
+
+Creates the label if it doesn't exist (like concepts).
+
+Example:
+  prog label ts-a1b2c3 bug
+  prog label ts-a1b2c3 urgent`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		out := cmd.OutOrStdout()
+		database, err := openDB()
+		if err != nil {
+			return err
+		}
+		defer func() { _ = database.Close() }()
+
+		if err := resolveIDArgs(database, args[:1]); err != nil {
+			return err
+		}
+
+		// Get item to find its project
+		item, err := database.GetItem(args[0])
+		if err != nil {
+			return err
+		}
+
+		if err := database.AddLabelToItem(args[0], item.Project, args[1]); err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "Added label %q to %s\n", args[1], args[0])
+		return nil
+	},
+}
+
+var unlabelCmd = &cobra.Command{
+	Use:   "unlabel <item-id> <label-name>",
+	Short: "Remove a label from a task",
+	Long: `Remove a label from a task or epic.
+
+Example:
+  prog unlabel ts-a1b2c3 bug`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		out := cmd.OutOrStdout()
+		database, err := openDB()
+		if err != nil {
+			return err
+		}
+		defer func() { _ = database.Close() }()
+
+		if err := resolveIDArgs(database, args[:1]); err != nil {
+			return err
+		}
+
+		// Get item to find its project
+		item, err := database.GetItem(args[0])
+		if err != nil {
+			return err
+		}
+
+		if err := database.RemoveLabelFromItem(args[0], item.Project, args[1]); err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "Removed label %q from %s\n", args[1], args[0])
+		return nil
+	},
+}
+
+var tagCmd = &cobra.Command{
+	Use:   "tag <item-id> [item-id...] <tag>",
+	Short: "Add a tag to tasks",
+	Long: `Add a free-form tag to one or more tasks or epics.
+
+Unlike labels, tags are not scoped to a project, so they can group
+work across projects. Tags are stored lowercase.
+
+Several items are tagged in one transaction: if any id is unknown, none
+of them are tagged.
+
+Examples:
+  prog tag ts-a1b2c3 backend
+  prog tag ts-a1b2c3 ts-d4e5f6 ts-789abc bug
+  prog list --tag backend`,
+	Args: cobra.MinimumNArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		out := cmd.OutOrStdout()
+		ids, tag := args[:len(args)-1], args[len(args)-1]
+		database, err := openDB()
+		if err != nil {
+			return err
+		}
+		defer func() { _ = database.Close() }()
+
+		if err := resolveIDArgs(database, ids); err != nil {
+			return err
+		}
+
+		if err := database.AddTagBulk(ids, tag); err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "Added tag %q to %s\n", db.NormalizeTag(tag), strings.Join(ids, ", "))
+		return nil
+	},
+}
+
+var untagCmd = &cobra.Command{
+	Use:   "untag <item-id> [item-id...] <tag>",
+	Short: "Remove a tag from tasks",
+	Long: `Remove a tag from one or more tasks or epics.
+
+Items that don't have the tag are skipped; it is an error only if none
+of them do.
+
+Examples:
+  prog untag ts-a1b2c3 backend
+  prog untag ts-a1b2c3 ts-d4e5f6 bug`,
+	Args: cobra.MinimumNArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		out := cmd.OutOrStdout()
+		ids, tag := args[:len(args)-1], args[len(args)-1]
+		database, err := openDB()
+		if err != nil {
+			return err
+		}
+		defer func() { _ = database.Close() }()
+
+		if err := resolveIDArgs(database, ids); err != nil {
+			return err
+		}
+
+		removed, err := database.RemoveTagBulk(ids, tag)
+		if err != nil {
+			return err
+		}
+		if len(removed) == 0 {
+			return fmt.Errorf("item does not have tag: %s", db.NormalizeTag(tag))
+		}
+		fmt.Fprintf(out, "Removed tag %q from %s\n", db.NormalizeTag(tag), strings.Join(removed, ", "))
+		return nil
+	},
+}
+
+var noteCmd = &cobra.Command{
+	Use:   "note <item-id> [<key> <value>]",
+	Short: "Pin a key/value note on a task",
+	Long: `Pin a note on a task or epic, such as the PR number or owner.
+
+Notes are keyed: setting a key again replaces its value, so they hold
+current metadata rather than history (use 'prog log' for that). They are
+shown in a "Notes" section of 'prog show'. With only an id, lists the
+task's notes.
+
+Examples:
+  prog note ts-a1b2c3 pr 123
+  prog note ts-a1b2c3 owner "alice (backend)"
+  prog note ts-a1b2c3
+  prog note ts-a1b2c3 --rm owner`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		out := cmd.OutOrStdout()
+		database, err := openDB()
+		if err != nil {
+			return err
+		}
+		defer func() { _ = database.Close() }()
+
+		if err := resolveIDArgs(database, args[:1]); err != nil {
+			return err
+		}
+		id := args[0]
+
+		switch {
+		case flagNoteRm != "":
+			if len(args) > 1 {
+				return fmt.Errorf("--rm takes no key or value arguments")
+			}
+			if err := database.RemoveNote(id, flagNoteRm); err != nil {
+				return err
+			}
+			fmt.Fprintf(out, "Removed note %q from %s\n", flagNoteRm, id)
+		case len(args) == 1:
+			notes, err := database.GetNotes(id)
+			if err != nil {
+				return err
+			}
+			if len(notes) == 0 {
+				fmt.Fprintf(out, "No notes on %s\n", id)
+			}
+			for _, n := range notes {
+				fmt.Fprintf(out, "%s: %s\n", n.Key, n.Value)
+			}
+			return nil
+		case len(args) == 2:
+			return fmt.Errorf("missing value for note %q", args[1])
+		default:
+			value := strings.Join(args[2:], " ")
+			if err := database.SetNote(id, args[1], value); err != nil {
+				return err
+			}
+			fmt.Fprintf(out, "Set note %s: %s on %s\n", strings.TrimSpace(args[1]), value, id)
+		}
+
+		// Backup after successful mutation
+		database.BackupQuiet()
+		return nil
+	},
+}
+
+var checkCmd = &cobra.Command{
+	Use:   "check <item-id> [n]",
+	Short: "Manage a task's checklist of small steps",
+	Long: `Keep a checklist of small steps on a task, for work that doesn't
+warrant separate tasks. Steps are numbered from 1 and shown as [x]/[ ]
+lines in 'prog show'.
+
+With only an id, lists the checklist. With a step number and --move,
+moves that step to a new position.
+
+Examples:
+  prog check add ts-a1b2c3 "write migration"
+  prog check toggle ts-a1b2c3 1
+  prog check rm ts-a1b2c3 2
+  prog check ts-a1b2c3
+  prog check ts-a1b2c3 3 --move 1`,
+	Args: cobra.RangeArgs(1, 2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		out := cmd.OutOrStdout()
+		database, err := openDB()
+		if err != nil {
+			return err
+		}
+		defer func() { _ = database.Close() }()
+
+		if err := resolveIDArgs(database, args[:1]); err != nil {
+			return err
+		}
+		id := args[0]
+
+		if len(args) == 1 {
+			if cmd.Flags().Changed("move") {
+				return fmt.Errorf("--move needs the number of the step to move")
+			}
+			if _, err := database.GetItem(id); err != nil {
+				return err
+			}
+			steps, err := database.GetChecklist(id)
+			if err != nil {
+				return err
+			}
+			if len(steps) == 0 {
+				fmt.Fprintf(out, "No checklist on %s\n", id)
+			}
+			printChecklist(out, steps, "")
+			return nil
+		}
+
+		if !cmd.Flags().Changed("move") {
+			return fmt.Errorf("nothing to do with step %s (use --move, or 'prog check toggle')", args[1])
+		}
+		n, err := parseStepNumber(args[1])
+		if err != nil {
+			return err
+		}
+		if err := database.MoveChecklistItem(id, n, flagCheckMove); err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "Moved step %d to %d on %s\n", n, flagCheckMove, id)
+
+		// Backup after successful mutation
+		database.BackupQuiet()
+		return nil
+	},
+}
+
+var checkAddCmd = &cobra.Command{
+	Use:   "add <item-id> <text>",
+	Short: "Add a step to a task's checklist",
+	Args:  cobra.MinimumNArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		out := cmd.OutOrStdout()
+		database, err := openDB()
+		if err != nil {
+			return err
+		}
+		defer func() { _ = database.Close() }()
+
+		if err := resolveIDArgs(database, args[:1]); err != nil {
+			return err
+		}
+		n, err := database.AddChecklistItem(args[0], strings.Join(args[1:], " "))
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "Added step %d to %s\n", n, args[0])
+
+		// Backup after successful mutation
+		database.BackupQuiet()
+		return nil
+	},
+}
+
+var checkToggleCmd = &cobra.Command{
+	Use:   "toggle <item-id> <n>",
+	Short: "Mark a checklist step done, or not done again",
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		out := cmd.OutOrStdout()
+		database, err := openDB()
+		if err != nil {
+			return err
+		}
+		defer func() { _ = database.Close() }()
+
+		if err := resolveIDArgs(database, args[:1]); err != nil {
+			return err
+		}
+		n, err := parseStepNumber(args[1])
+		if err != nil {
+			return err
+		}
+		step, err := database.ToggleChecklistItem(args[0], n)
+		if err != nil {
+			return err
+		}
+		printChecklist(out, []model.ChecklistItem{*step}, "")
+
+		// Backup after successful mutation
+		database.BackupQuiet()
+		return nil
+	},
+}
+
+var checkRmCmd = &cobra.Command{
+	Use:   "rm <item-id> <n>",
+	Short: "Remove a step from a task's checklist",
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		out := cmd.OutOrStdout()
+		database, err := openDB()
+		if err != nil {
+			return err
+		}
+		defer func() { _ = database.Close() }()
+
+		if err := resolveIDArgs(database, args[:1]); err != nil {
+			return err
+		}
+		n, err := parseStepNumber(args[1])
+		if err != nil {
+			return err
+		}
+		if err := database.RemoveChecklistItem(args[0], n); err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "Removed step %d from %s\n", n, args[0])
+
+		// Backup after successful mutation
+		database.BackupQuiet()
+		return nil
+	},
+}
+
+// parseStepNumber parses a 1-based checklist step number.
+func parseStepNumber(value string) (int, error) {
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 1 {
+		return 0, fmt.Errorf("invalid step number: %s (must be 1 or more)", value)
+	}
+	return n, nil
+}
+
+// printChecklist prints steps as numbered [x]/[ ] lines, each prefixed by
+// indent.
+func printChecklist(out io.Writer, steps []model.ChecklistItem, indent string) {
+	for _, step := range steps {
+		mark := " "
+		if step.Done {
+			mark = "x"
+		}
+		fmt.Fprintf(out, "%s%d. [%s] %s\n", indent, step.Position, mark, step.Text)
+	}
+}
+
+var learnCmd = &cobra.Command{
+	Use:   "learn <summary>",
+	Short: "Log a learning for future context retrieval",
+	Long: `Log a learning discovered during work.
+
+Learnings are tagged with concepts for organized retrieval.
+Concepts are created automatically if they don't exist.
+
+If a task is in progress for the project, the learning is linked to it.
+
+Examples:
+  prog learn "Token refresh has race condition" -p myproject -c auth -c concurrency
+  prog learn "Config loaded from env first" -p myproject -c config -f config.go
+  prog learn "Token refresh issue" -c auth -p myproject --detail "The mutex only protects..."
+  echo "multi-line detail" | prog learn "summary" -c auth -p myproject --detail -`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		out := cmd.OutOrStdout()
+		// Validate required flags
+		if flagProject == "" {
+			return fmt.Errorf("project is required (-p)")
+		}
+		if len(flagLearnConcept) == 0 {
+			return fmt.Errorf("at least one concept is required (-c)")
+		}
+
+		database, err := openDB()
+		if err != nil {
+			return err
+		}
+		defer func() { _ = database.Close() }()
+
+		project := flagProject
+
+		// Get current in-progress task for this project
+		taskID, _ := database.GetCurrentTaskID(project)
+
+		// Handle detail from stdin
+		detail := flagLearnDetail
+		if detail == "-" {
+			data, err := io.ReadAll(os.Stdin)
+			if err != nil {
+				return fmt.Errorf("failed to read from stdin: %w", err)
+			}
+			detail = strings.TrimSpace(string(data))
+		}
+
+		now := database.Now()
+		learning := &model.Learning{
+			ID:        model.GenerateLearningID(),
+			Project:   project,
+			CreatedAt: now,
+			UpdatedAt: now,
+			TaskID:    taskID,
+			Summary:   strings.Join(args, " "),
+			Detail:    detail,
+			Status:    model.LearningStatusActive,
+			Concepts:  flagLearnConcept,
+			Files:     flagLearnFile,
+		}
+
+		if err := database.CreateLearning(learning); err != nil {
+			return err
+		}
+
+		// Build output
+		output := learning.ID
+		if taskID != nil {
+			output += fmt.Sprintf(" (linked to %s)", *taskID)
+		}
+		fmt.Fprintln(out, output)
+
+		// Backup after successful mutation
+		database.BackupQuiet()
+
+		return nil
+	},
+}
+
+var learnEditCmd = &cobra.Command{
+	Use:   "edit <learning-id>",
+	Short: "Edit a learning's summary or detail",
+	Long: `Edit an existing learning's summary or detail.
+
+Examples:
+  prog learn edit lrn-abc123 --summary "Updated summary"
+  prog learn edit lrn-abc123 --detail "Full context explanation"
+  echo "multi-line" | prog learn edit lrn-abc123 --detail -`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		out := cmd.OutOrStdout()
+		if flagLearnEditSummary == "" && flagLearnEditDetail == "" {
+			return fmt.Errorf("--summary or --detail is required")
+		}
+
+		database, err := openDB()
+		if err != nil {
+			return err
+		}
+		defer func() { _ = database.Close() }()
+
+		if flagLearnEditSummary != "" {
+			if err := database.UpdateLearningSummary(args[0], flagLearnEditSummary); err != nil {
+				return err
+			}
+		}
+
+		if flagLearnEditDetail != "" {
+			detail := flagLearnEditDetail
+			if detail == "-" {
+				data, err := io.ReadAll(os.Stdin)
+				if err != nil {
+					return fmt.Errorf("failed to read from stdin: %w", err)
+				}
+				detail = strings.TrimSpace(string(data))
+			}
+			if err := database.UpdateLearningDetail(args[0], detail); err != nil {
+				return err
+			}
+		}
+
+		fmt.Fprintf(out, "Updated %s\n", args[0])
+		return nil
+	},
+}
+
+var learnStaleCmd = &cobra.Command{
+	Use:   "stale <learning-id> [learning-id...]",
+	Short: "Mark learnings as stale (outdated)",
+	Long: `Mark one or more learnings as stale when they're outdated but still useful for reference.
+
+Examples:
+  prog learn stale lrn-abc123 --reason "Refactored in v2"
+  prog learn stale lrn-a lrn-b lrn-c --reason "Compacted into lrn-xyz"`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		out := cmd.OutOrStdout()
+		database, err := openDB()
+		if err != nil {
+			return err
+		}
+		defer func() { _ = database.Close() }()
+
+		for _, id := range args {
+			if err := database.UpdateLearningStatus(id, model.LearningStatusStale); err != nil {
+				return err
+			}
+		}
+
+		// Output
+		if len(args) == 1 {
+			if flagLearnStaleReason != "" {
+				fmt.Fprintf(out, "Marked %s as stale: %s\n", args[0], flagLearnStaleReason)
+			} else {
+				fmt.Fprintf(out, "Marked %s as stale\n", args[0])
+			}
+		} else {
+			if flagLearnStaleReason != "" {
+				fmt.Fprintf(out, "Marked %d learnings as stale: %s\n", len(args), flagLearnStaleReason)
+			} else {
+				fmt.Fprintf(out, "Marked %d learnings as stale\n", len(args))
+			}
+		}
+		return nil
+	},
+}
+
+var learnRmCmd = &cobra.Command{
+	Use:   "rm <learning-id>",
+	Short: "Delete a learning",
+	Long: `Permanently delete a learning and its concept associations.
+
+Asks for confirmation first; pass -y to skip the prompt (required without
+a terminal).
+
+Example:
+  prog learn rm lrn-abc123 -y`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		out := cmd.OutOrStdout()
+		database, err := openDB()
+		if err != nil {
+			return err
+		}
+		defer func() { _ = database.Close() }()
+
+		if !confirm(cmd, fmt.Sprintf("Permanently delete learning %s?", args[0])) {
+			return errAborted
+		}
+		if err := database.DeleteLearning(args[0]); err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "Deleted %s\n", args[0])
+		return nil
+	},
+}
+
+var conceptsCmd = &cobra.Command{
+	Use:   "concepts [name]",
+	Short: "List or edit concepts for a project",
+	Long: `List all concepts for a project, or edit a concept.
+
+Concepts are knowledge categories that group related learnings.
+Default sort is by learning count (most used first).
+
+Examples:
+  prog concepts -p myproject                        # list concepts
+  prog concepts -p myproject --recent               # sort by last updated
+  prog concepts -p myproject --stats                # show count and oldest age
+  prog concepts --related ts-abc123                 # suggest concepts for a task
+  prog concepts fts -p myproject --summary "..."    # set concept summary
+  prog concepts fts -p myproject --rename "search"  # rename concept`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		out := cmd.OutOrStdout()
+		database, err := openDB()
+		if err != nil {
+			return err
+		}
+		defer func() { _ = database.Close() }()
+
+		// Edit mode: concept name provided with --summary or --rename
+		if len(args) > 0 && (flagConceptsSummary != "" || flagConceptsRename != "") {
+			if flagProject == "" {
+				return fmt.Errorf("project is required (-p)")
+			}
+			if flagConceptsSummary != "" {
+				if err := database.SetConceptSummary(args[0], flagProject, flagConceptsSummary); err != nil {
+					return err
+				}
+				fmt.Fprintf(out, "Updated %s\n", args[0])
+			}
+			if flagConceptsRename != "" {
+				if err := database.RenameConcept(args[0], flagConceptsRename, flagProject); err != nil {
+					return err
+				}
+				fmt.Fprintf(out, "Renamed %s -> %s\n", args[0], flagConceptsRename)
+			}
+			return nil
+		}
+
+		// Stats mode
+		if flagConceptsStats {
+			if flagProject == "" {
+				return fmt.Errorf("project is required (-p)")
+			}
+			stats, err := database.ListConceptsWithStats(flagProject)
+			if err != nil {
+				return err
+			}
+			if len(stats) == 0 {
+				fmt.Fprintln(out, "No concepts")
+				return nil
+			}
+			printConceptsStats(out, stats)
+			return nil
+		}
+
+		// List mode
+		var concepts []model.Concept
+
+		if flagConceptsRelated != "" {
+			// Get concepts related to a task
+			concepts, err = database.GetRelatedConcepts(flagConceptsRelated)
+			if err != nil {
+				return err
+			}
+		} else {
+			// List all concepts for project
+			if flagProject == "" {
+				return fmt.Errorf("project is required (-p) or use --related <task-id>")
+			}
+			concepts, err = database.ListConcepts(flagProject, flagConceptsRecent)
+			if err != nil {
+				return err
+			}
+		}
+
+		if len(concepts) == 0 {
+			fmt.Fprintln(out, "No concepts")
+			return nil
+		}
+
+		printConceptsTable(out, concepts)
+		return nil
+	},
+}
+
+var labelsCmd = &cobra.Command{
+	Use:   "labels",
+	Short: "List or manage labels for a project",
+	Long: `List all labels for a project.
+
+Labels are tags for categorizing tasks (bug, feature, refactor, etc).
+Labels are project-scoped and identified by name.
+
+Examples:
+  prog labels -p myproject           # list all labels
+  prog labels add bug -p myproject   # create a label
+  prog labels rm bug -p myproject    # delete a label
+  prog labels rename bug critical -p myproject`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		out := cmd.OutOrStdout()
+		if flagProject == "" {
+			return fmt.Errorf("project is required (-p)")
+		}
+
+		database, err := openDB()
+		if err != nil {
+			return err
+		}
+		defer func() { _ = database.Close() }()
+
+		labels, err := database.ListLabels(flagProject)
+		if err != nil {
+			return err
+		}
+
+		if len(labels) == 0 {
+			fmt.Fprintln(out, "No labels")
+			return nil
+		}
+
+		printLabelsTable(out, labels)
+		return nil
+	},
+}
+
+var labelsAddCmd = &cobra.Command{
+	Use:   "add <name>",
+	Short: "Create a new label",
+	Long: `Create a new label in a project.
+
+Labels are created on first use when attached to items, but you can
+also create them explicitly with this command.
+
+Examples:
+  prog labels add bug -p myproject
+  prog labels add urgent -p myproject --color "#ff0000"`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		out := cmd.OutOrStdout()
+		if flagProject == "" {
+			return fmt.Errorf("project is required (-p)")
+		}
+
+		database, err := openDB()
+		if err != nil {
+			return err
+		}
+		defer func() { _ = database.Close() }()
+
+		now := database.Now()
+		label := &model.Label{
+			ID:        model.GenerateLabelID(),
+			Name:      args[0],
+			Project:   flagProject,
+			Color:     flagLabelsColor,
+			CreatedAt: now,
+			UpdatedAt: now,
+		}
+
+		if err := database.CreateLabel(label); err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "Created label: %s\n", args[0])
+		return nil
+	},
+}
+
+var labelsRmCmd = &cobra.Command{
+	Use:   "rm <name>",
+	Short: "Delete a label",
+	Long: `Delete a label and remove it from all items.
+
+Asks for confirmation first; pass -y to skip the prompt (required without
+a terminal).
+
+Example:
+  prog labels rm bug -p myproject -y`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		out := cmd.OutOrStdout()
+		if flagProject == "" {
+			return fmt.Errorf("project is required (-p)")
+		}
+
+		database, err := openDB()
+		if err != nil {
+			return err
+		}
+		defer func() { _ = database.Close() }()
+
+		if !confirm(cmd, fmt.Sprintf("Delete label %s and remove it from every item in %s?", args[0], flagProject)) {
+			return errAborted
+		}
+		if err := database.DeleteLabel(flagProject, args[0]); err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "Deleted label: %s\n", args[0])
+		return nil
+	},
+}
+
+var labelsRenameCmd = &cobra.Command{
+	Use:   "rename <old-name> <new-name>",
+	Short: "Rename a label",
+	Long: `Rename a label. All items with this label will be updated.
+
+Example:
+  prog labels rename bug critical -p myproject`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		out := cmd.OutOrStdout()
+		if flagProject == "" {
+			return fmt.Errorf("project is required (-p)")
+		}
+
+		database, err := openDB()
+		if err != nil {
+			return err
+		}
+		defer func() { _ = database.Close() }()
+
+		if err := database.RenameLabel(flagProject, args[0], args[1]); err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "Renamed label: %s -> %s\n", args[0], args[1])
+		return nil
+	},
+}
+
+var contextCmd = &cobra.Command{
+	Use:   "context",
+	Short: "Retrieve learnings for context",
+	Long: `Retrieve learnings by concept, full-text search, or specific ID.
+
+Use this to load relevant context before starting work on a task.
+
+Examples:
+  prog context -p myproject --summary                # all learnings, grouped by concept
+  prog context -c auth -c concurrency -p myproject   # by concepts
+  prog context -q "rate limit" -p myproject          # full-text search
+  prog context -c auth --summary -p myproject        # one-liner per learning
+  prog context --id lrn-abc123                       # specific learning by ID
+  prog context -c auth --include-stale -p myproject  # include stale learnings
+  prog context -c auth --json -p myproject           # JSON output for agents`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		out := cmd.OutOrStdout()
+		database, err := openDB()
+		if err != nil {
+			return err
+		}
+		defer func() { _ = database.Close() }()
+
+		// Mode 1: Specific learning by ID
+		if flagContextID != "" {
+			learning, err := database.GetLearning(flagContextID)
+			if err != nil {
+				return err
+			}
+			if flagContextJSON {
+				return printLearningsJSON(out, []model.Learning{*learning})
+			}
+			printLearnings(out, []model.Learning{*learning})
+			return nil
+		}
+
+		// All other modes require project
+		if flagProject == "" {
+			return fmt.Errorf("project is required (-p) or use --id")
+		}
+
+		// Mode 2: All learnings with --summary (no concepts/query required)
+		if flagContextSummary && len(flagContextConcept) == 0 && flagContextQuery == "" {
+			learnings, err := database.GetAllLearnings(flagProject, flagContextStale)
+			if err != nil {
+				return err
+			}
+
+			if len(learnings) == 0 {
+				if flagContextJSON {
+					fmt.Fprintln(out, "[]")
+					return nil
+				}
+				fmt.Fprintln(out, "No learnings found")
+				return nil
+			}
+
+			if flagContextJSON {
+				return printLearningsJSON(out, learnings)
+			}
+
+			// Get concept summaries for grouped output
+			concepts, _ := database.ListConcepts(flagProject, false)
+			conceptMap := make(map[string]string)
+			for _, c := range concepts {
+				conceptMap[c.Name] = c.Summary
+			}
+			printAllLearningSummaries(out, learnings, conceptMap)
+			return nil
+		}
+
+		// Modes 3 & 4 require concepts or query
+		if len(flagContextConcept) == 0 && flagContextQuery == "" {
+			return fmt.Errorf("specify concepts (-c), query (-q), or use --summary for all")
+		}
+
+		var learnings []model.Learning
+
+		if len(flagContextConcept) > 0 {
+			learnings, err = database.GetLearningsByConcepts(flagProject, flagContextConcept, flagContextStale)
+		} else {
+			learnings, err = database.SearchLearnings(flagProject, flagContextQuery, flagContextStale)
+		}
+		if err != nil {
+			return err
+		}
+
+		if len(learnings) == 0 {
+			if flagContextJSON {
+				fmt.Fprintln(out, "[]")
+				return nil
+			}
+			fmt.Fprintln(out, "No learnings found")
+			return nil
+		}
+
+		// JSON mode
+		if flagContextJSON {
+			return printLearningsJSON(out, learnings)
+		}
+
+		// Mode 3: Summary mode (one-liners) for specific concepts
+		if flagContextSummary {
+			// Get concept summaries for header
+			concepts, _ := database.ListConcepts(flagProject, false)
+			conceptMap := make(map[string]string)
+			for _, c := range concepts {
+				conceptMap[c.Name] = c.Summary
+			}
+			printLearningSummaries(out, learnings, flagContextConcept, conceptMap)
+			return nil
+		}
+
+		// Mode 4: Full output
+		printLearnings(out, learnings)
+		return nil
+	},
+}
+
+var onboardCmd = &cobra.Command{
+	Use:   "onboard",
+	Short: "Set up prog integration for AI agents",
+	Long: `Set up prog integration for AI agents.
+
+Designed for Claude Code but provides guidance for other agents.
+
+For Claude Code, this command:
+1. Writes a prog workflow snippet to CLAUDE.md in the current directory
+2. Installs a SessionStart hook in ~/.claude/settings.json to auto-run 'prog prime'
+
+For other agents (Cursor, Opencode, Droid, Codex, Gemini, etc.):
+- Copy the Task Tracking snippet to your agent's instruction file
+- If hooks are available, add 'prog prime' to session start
+- Otherwise, run 'prog prime' and paste output into agent context
+
+Creates files if they don't exist. Skips if already configured (use --force to update).
+
+Example:
+  cd ~/code/myproject
+  prog onboard
+  prog onboard --force  # Update existing configuration`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runOnboard(cmd.OutOrStdout(), flagForce)
+	},
+}
+
+func findClaudeMD() string {
+	// Check for existing file with exact case match
+	// (os.Stat is case-insensitive on macOS, so we use ReadDir)
+	entries, err := os.ReadDir(".")
+	if err == nil {
+		for _, e := range entries {
+			name := e.Name()
+			if strings.EqualFold(name, "claude.md") {
+				return name // Return actual casing
+			}
+		}
+	}
+	// Default to uppercase if none exists
+	return "CLAUDE.md"
+}
+
+func runOnboard(out io.Writer, force bool) error {
+	return runOnboardWithSettings(out, force, "")
+}
+
+func runOnboardWithSettings(out io.Writer, force bool, settingsPath string) error {
+	claudePath := findClaudeMD()
+	snippet := `## Task Tracking
+
+This project uses **prog** for cross-session task management.
+Run ` + "`prog prime`" + ` for workflow context, or configure hooks for auto-injection.
+
+**Quick reference:**
+` + "```" + `
+prog ready              # Find unblocked work
+prog add "Title" -p X   # Create task
+prog start <id>         # Claim work
+prog log <id> "msg"     # Log progress
+prog done <id>          # Complete work
+` + "```" + `
+
+For full workflow: ` + "`prog prime`" + `
 `
 
-	var claudeMDUpdated bool
+	var claudeMDUpdated bool
+
+	// Check if file exists
+	content, err := os.ReadFile(claudePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			// Create new file
+			if err := os.WriteFile(claudePath, []byte(snippet), 0644); err != nil {
+				return fmt.Errorf("failed to create CLAUDE.md: %w", err)
+			}
+			fmt.Fprintln(out, "Created CLAUDE.md with prog integration")
+			claudeMDUpdated = true
+		} else {
+			return fmt.Errorf("failed to read CLAUDE.md: %w", err)
+		}
+	} else {
+		// Check if already onboarded
+		if strings.Contains(string(content), "## Task Tracking") {
+			if !force {
+				fmt.Fprintln(out, "CLAUDE.md already has Task Tracking section")
+			} else {
+				// Replace existing section
+				newContent := replaceTaskTrackingSection(string(content), snippet)
+				if err := os.WriteFile(claudePath, []byte(newContent), 0644); err != nil {
+					return fmt.Errorf("failed to update %s: %w", claudePath, err)
+				}
+				fmt.Fprintf(out, "Updated Task Tracking section in %s\n", claudePath)
+				claudeMDUpdated = true
+			}
+		} else {
+			// Append to existing file
+			newContent := string(content)
+			if !strings.HasSuffix(newContent, "\n") {
+				newContent += "\n"
+			}
+			newContent += "\n" + snippet
+
+			if err := os.WriteFile(claudePath, []byte(newContent), 0644); err != nil {
+				return fmt.Errorf("failed to update %s: %w", claudePath, err)
+			}
+			fmt.Fprintf(out, "Added prog integration to %s\n", claudePath)
+			claudeMDUpdated = true
+		}
+	}
+
+	// Install SessionStart hook
+	hookAdded, err := installSessionStartHook(settingsPath)
+	if err != nil {
+		return fmt.Errorf("failed to install hook: %w", err)
+	}
+
+	if hookAdded {
+		fmt.Fprintln(out, "Installed SessionStart hook in ~/.claude/settings.json")
+	} else {
+		fmt.Fprintln(out, "SessionStart hook already installed")
+	}
+
+	if !claudeMDUpdated && !hookAdded && !force {
+		fmt.Fprintln(out, "Use --force to update existing configuration")
+	}
+
+	// Print summary and guidance for other agents
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, "Note: This assumes Claude Code. For other agents:")
+	fmt.Fprintln(out, "  1. Update your agent's instruction file (AGENTS.md, .cursorrules, etc.)")
+	fmt.Fprintln(out, "     with the Task Tracking section above")
+	fmt.Fprintln(out, "  2. If your tool supports hooks, add 'prog prime' to session start")
+	fmt.Fprintln(out, "  3. If no hooks, run 'prog prime' and paste output into agent context")
+
+	return nil
+}
+
+// replaceTaskTrackingSection finds the "## Task Tracking" section and replaces it
+// with the new snippet. The section ends at the next heading (# or ##) or EOF.
+func replaceTaskTrackingSection(content, snippet string) string {
+	// Find where "## Task Tracking" starts
+	startIdx := strings.Index(content, "## Task Tracking")
+	if startIdx == -1 {
+		return content
+	}
+
+	// Find where the section ends (next heading or EOF)
+	rest := content[startIdx+len("## Task Tracking"):]
+	endIdx := -1
+
+	// Look for next heading (line starting with #)
+	lines := strings.Split(rest, "\n")
+	charCount := 0
+	for i, line := range lines {
+		if i > 0 && len(line) > 0 && line[0] == '#' {
+			endIdx = startIdx + len("## Task Tracking") + charCount
+			break
+		}
+		charCount += len(line) + 1 // +1 for newline
+	}
+
+	// Build new content
+	before := content[:startIdx]
+	var after string
+	if endIdx == -1 {
+		// Section goes to EOF
+		after = ""
+	} else {
+		after = content[endIdx:]
+	}
+
+	// Ensure proper spacing
+	result := strings.TrimRight(before, "\n")
+	if result != "" {
+		result += "\n\n"
+	}
+	result += strings.TrimRight(snippet, "\n")
+	if after != "" {
+		result += "\n\n" + strings.TrimLeft(after, "\n")
+	} else {
+		result += "\n"
+	}
+
+	return result
+}
+
+// installSessionStartHook installs "prog prime" in ~/.claude/settings.json
+// Returns true if hook was added, false if already present
+func installSessionStartHook(settingsPath string) (bool, error) {
+	// Default to ~/.claude/settings.json if not specified
+	if settingsPath == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return false, fmt.Errorf("failed to get home directory: %w", err)
+		}
+		settingsPath = filepath.Join(home, ".claude", "settings.json")
+	}
+
+	// Read existing settings or create new
+	var settings ClaudeSettings
+	content, err := os.ReadFile(settingsPath)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			return false, fmt.Errorf("failed to read settings: %w", err)
+		}
+		// File doesn't exist, start fresh
+		settings = ClaudeSettings{
+			Hooks: make(map[string][]HookMatcher),
+		}
+	} else {
+		if err := json.Unmarshal(content, &settings); err != nil {
+			return false, fmt.Errorf("failed to parse settings: %w", err)
+		}
+		if settings.Hooks == nil {
+			settings.Hooks = make(map[string][]HookMatcher)
+		}
+	}
+
+	// Check if hook already exists
+	const hookCommand = "prog prime"
+	for _, matcher := range settings.Hooks["SessionStart"] {
+		for _, hook := range matcher.Hooks {
+			if hook.Command == hookCommand {
+				return false, nil // Already installed
+			}
+		}
+	}
+
+	// Add the hook
+	newHook := Hook{
+		Type:    "command",
+		Command: hookCommand,
+	}
+
+	// Find or create a matcher with empty string (matches all)
+	found := false
+	for i, matcher := range settings.Hooks["SessionStart"] {
+		if matcher.Matcher == "" {
+			settings.Hooks["SessionStart"][i].Hooks = append(matcher.Hooks, newHook)
+			found = true
+			break
+		}
+	}
+
+	if !found {
+		// Create new matcher
+		settings.Hooks["SessionStart"] = append(settings.Hooks["SessionStart"], HookMatcher{
+			Matcher: "",
+			Hooks:   []Hook{newHook},
+		})
+	}
+
+	// Ensure directory exists
+	if err := os.MkdirAll(filepath.Dir(settingsPath), 0755); err != nil {
+		return false, fmt.Errorf("failed to create settings directory: %w", err)
+	}
+
+	// Write settings back
+	output, err := json.MarshalIndent(settings, "", "  ")
+	if err != nil {
+		return false, fmt.Errorf("failed to marshal settings: %w", err)
+	}
+
+	if err := os.WriteFile(settingsPath, append(output, '\n'), 0644); err != nil {
+		return false, fmt.Errorf("failed to write settings: %w", err)
+	}
 
-	// Check if file exists
-	content, err := os.ReadFile(claudePath)
-	if err != nil {
-		if os.IsNotExist(err) {
-			// Create new file
-			if err := os.WriteFile(claudePath, []byte(snippet), 0644); err != nil {
-				return fmt.Errorf("failed to create CLAUDE.md: %w", err)
+	return true, nil
+}
+
+var primeCmd = &cobra.Command{
+	Use:   "prime",
+	Short: "Output context for Claude Code hooks",
+	Long: `Output essential workflow context for AI agents.
+
+Designed to run on SessionStart and PreCompact hooks to ensure
+agents maintain context about the prog workflow.
+
+The "Current State" section lists in-progress, blocked, ready, and
+recently completed tasks (scoped with -p if given).
+
+Example hook configuration in Claude Code settings:
+  "hooks": {
+    "SessionStart": [{"command": "prog prime"}],
+    "PreCompact": [{"command": "prog prime"}]
+  }`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		out := cmd.OutOrStdout()
+		database, err := openDB()
+		if err != nil {
+			// Still output prime content even if DB fails
+			printPrimeContent(out, nil, nil)
+			return nil
+		}
+		defer func() { _ = database.Close() }()
+
+		report, _ := database.ProjectStatus(flagProject)
+
+		// Get all projects to check compaction candidates
+		projects, _ := database.ListProjects()
+		var allStats []db.ConceptStats
+		for _, p := range projects {
+			stats, _ := database.ListConceptsWithStats(p)
+			allStats = append(allStats, stats...)
+		}
+
+		printPrimeContent(out, report, allStats)
+		return nil
+	},
+}
+
+var compactCmd = &cobra.Command{
+	Use:   "compact",
+	Short: "Output compaction workflow guidance for agents",
+	Long: `Output guidance for grooming learnings and concepts.
+
+Use this when learnings have accumulated and need review.
+Covers identifying redundancy, staleness, and quality issues.
+
+The workflow uses two phases:
+1. Discovery: Scan summaries to identify grooming candidates
+2. Selection: Load detail for candidates, groom, repeat
+
+Example:
+  prog compact              # Output compaction guidance
+  prog compact -p myproject # Include project-specific stats`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		out := cmd.OutOrStdout()
+		database, err := openDB()
+		if err != nil {
+			printCompactContent(out, nil)
+			return nil
+		}
+		defer func() { _ = database.Close() }()
+
+		var stats []db.ConceptStats
+		if flagProject != "" {
+			stats, _ = database.ListConceptsWithStats(flagProject)
+		} else {
+			// Get stats across all projects
+			projects, _ := database.ListProjects()
+			for _, p := range projects {
+				pStats, _ := database.ListConceptsWithStats(p)
+				stats = append(stats, pStats...)
+			}
+		}
+
+		printCompactContent(out, stats)
+		return nil
+	},
+}
+
+var flagBackupQuiet bool
+
+var backupCmd = &cobra.Command{
+	Use:   "backup [path]",
+	Short: "Create a backup of the database",
+	Long: `Create a backup of the prog database.
+
+Backups are stored in a backups directory beside the database (for the
+default database, ~/.prog/backups/) with timestamped names. The last 10
+backups of each database are kept; older ones are automatically pruned.
+
+Optionally specify a custom path for the backup file.
+
+Examples:
+  prog backup                    # Create backup beside the database
+  prog backup ~/my-backup.db     # Create backup at custom path
+  prog backup --quiet            # Silent backup (for hooks)`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		out := cmd.OutOrStdout()
+		database, err := openDB()
+		if err != nil {
+			return err
+		}
+		defer func() { _ = database.Close() }()
+
+		var backupPath string
+		if len(args) > 0 {
+			// Custom path - use VACUUM INTO directly
+			backupPath = args[0]
+			_, err = database.Exec(fmt.Sprintf("VACUUM INTO '%s'", backupPath))
+			if err != nil {
+				return fmt.Errorf("failed to create backup: %w", err)
 			}
-			fmt.Println("Created CLAUDE.md with prog integration")
-			claudeMDUpdated = true
 		} else {
-			return fmt.Errorf("failed to read CLAUDE.md: %w", err)
+			backupPath, err = database.Backup()
+			if err != nil {
+				return err
+			}
+		}
+
+		if !flagBackupQuiet {
+			fmt.Fprintf(out, "Backup created: %s\n", backupPath)
+		}
+		return nil
+	},
+}
+
+var backupsCmd = &cobra.Command{
+	Use:   "backups",
+	Short: "List available backups",
+	Long: `List all available database backups.
+
+Shows the current database's backups, newest first. Each database keeps
+its own beside it, so --local and --db list their own backups.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		out := cmd.OutOrStdout()
+		// Not openDB: this must work when the database is too damaged to open
+		path, err := dbPath()
+		if err != nil {
+			return err
+		}
+		backups, err := db.ListBackups(path)
+		if err != nil {
+			return err
+		}
+
+		if len(backups) == 0 {
+			fmt.Fprintln(out, "No backups found")
+			return nil
+		}
+
+		fmt.Fprintf(out, "Backups in %s\n\n", db.BackupPath(path))
+		fmt.Fprintf(out, "%-30s  %10s  %s\n", "BACKUP", "SIZE", "CREATED")
+		for _, b := range backups {
+			size := formatSize(b.Size)
+			age := humanizeTime(b.ModTime)
+			fmt.Fprintf(out, "%-30s  %10s  %s\n", b.Name, size, age)
+		}
+		return nil
+	},
+}
+
+var restoreCmd = &cobra.Command{
+	Use:   "restore <path>",
+	Short: "Restore database from a backup",
+	Long: `Restore the prog database from a backup file.
+
+This replaces the current database with the backup.
+A backup of the current database is created first. Pick a backup of the
+same database from 'prog backups'.
+
+Examples:
+  prog restore ~/.prog/backups/prog-2024-01-09T12-00-00.db
+  prog restore ~/my-backup.db`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		out := cmd.OutOrStdout()
+		backupPath := args[0]
+
+		// First, create a backup of current state
+		database, err := openDB()
+		if err != nil {
+			// If we can't open the DB, that's fine - just restore
+			fmt.Fprintln(out, "Note: Could not backup current database (may not exist)")
+		} else {
+			preRestorePath, err := database.Backup()
+			_ = database.Close()
+			if err != nil {
+				fmt.Fprintf(out, "Warning: Could not backup current database: %v\n", err)
+			} else {
+				fmt.Fprintf(out, "Current database backed up to: %s\n", preRestorePath)
+			}
+		}
+
+		// Restore from backup
+		path, err := dbPath()
+		if err != nil {
+			return err
+		}
+		if err := db.Restore(backupPath, path); err != nil {
+			return err
+		}
+
+		fmt.Fprintf(out, "Restored from: %s\n", backupPath)
+		return nil
+	},
+}
+
+var dumpCmd = &cobra.Command{
+	Use:   "dump",
+	Short: "Write the whole database as JSON",
+	Long: `Write every item, log, dependency, project, tag, label, note, status
+change, and learning to stdout as one JSON document.
+
+Unlike 'prog export' and 'show --export', nothing is filtered and ids are
+kept exactly, so 'prog load' on another machine recreates the same
+database. Unlike 'prog backup', the output is plain text that can be
+diffed or edited.
+
+Examples:
+  prog dump > prog.json
+  prog dump | ssh laptop prog load -`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		out := cmd.OutOrStdout()
+		database, err := openDB()
+		if err != nil {
+			return err
+		}
+		defer func() { _ = database.Close() }()
+
+		dump, err := database.Dump()
+		if err != nil {
+			return err
+		}
+		b, err := json.MarshalIndent(dump, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal JSON: %w", err)
+		}
+		fmt.Fprintln(out, string(b))
+		return nil
+	},
+}
+
+var loadCmd = &cobra.Command{
+	Use:   "load <file>",
+	Short: "Load a database dump into an empty database",
+	Long: `Load a JSON document written by 'prog dump', preserving ids.
+
+The database must be empty (a new --db or PROG_DB path is initialized);
+with --force its current contents are backed up and then replaced. The load
+happens in one transaction, so a bad dump changes nothing. Use - to read
+from stdin.
+
+Examples:
+  prog load prog.json --db ~/new/prog.db
+  prog load prog.json --force`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		out := cmd.OutOrStdout()
+		var r io.Reader = cmd.InOrStdin()
+		if args[0] != "-" {
+			f, err := os.Open(args[0])
+			if err != nil {
+				return fmt.Errorf("failed to open dump: %w", err)
+			}
+			defer func() { _ = f.Close() }()
+			r = f
+		}
+		dump, err := db.ReadDump(r)
+		if err != nil {
+			return err
+		}
+
+		database, err := openDB()
+		if err != nil {
+			return err
+		}
+		defer func() { _ = database.Close() }()
+
+		// The target is often a brand-new path, so create the schema if needed
+		if err := database.Init(); err != nil {
+			return err
+		}
+
+		if flagLoadForce {
+			backupPath, err := database.Backup()
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(out, "Current database backed up to: %s\n", backupPath)
+		}
+
+		counts, err := database.Load(dump, flagLoadForce)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "Loaded %s, %s, %s\n",
+			pluralize(counts["items"], "item"), pluralize(counts["logs"], "log"), pluralize(counts["deps"], "dep"))
+
+		// Backup after successful mutation
+		database.BackupQuiet()
+		return nil
+	},
+}
+
+var doctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "Check the database for inconsistencies",
+	Long: `Check the database after a crash or manual edits.
+
+Runs SQLite's integrity check and looks for dependencies that point at
+missing items, logs whose item is gone, and tasks whose parent is missing or
+isn't an epic. Each problem is listed; the command fails if any are found.
+
+With --fix, dangling dependencies and orphaned logs are deleted and bad
+parent links are cleared, all in one transaction. Integrity check failures
+can't be fixed here; restore from a backup instead (see 'prog backups').
+
+Examples:
+  prog doctor
+  prog doctor --fix`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		out := cmd.OutOrStdout()
+		database, err := openDB()
+		if err != nil {
+			return err
 		}
-	} else {
-		// Check if already onboarded
-		if strings.Contains(string(content), "## Task Tracking") {
-			if !force {
-				fmt.Println("CLAUDE.md already has Task Tracking section")
-			} else {
-				// Replace existing section
-				newContent := replaceTaskTrackingSection(string(content), snippet)
-				if err := os.WriteFile(claudePath, []byte(newContent), 0644); err != nil {
-					return fmt.Errorf("failed to update %s: %w", claudePath, err)
-				}
-				fmt.Printf("Updated Task Tracking section in %s\n", claudePath)
-				claudeMDUpdated = true
-			}
-		} else {
-			// Append to existing file
-			newContent := string(content)
-			if !strings.HasSuffix(newContent, "\n") {
-				newContent += "\n"
-			}
-			newContent += "\n" + snippet
+		defer func() { _ = database.Close() }()
 
-			if err := os.WriteFile(claudePath, []byte(newContent), 0644); err != nil {
-				return fmt.Errorf("failed to update %s: %w", claudePath, err)
+		problems, err := database.Diagnose()
+		if err != nil {
+			return err
+		}
+		if len(problems) == 0 {
+			fmt.Fprintln(out, "No problems found")
+			return nil
+		}
+
+		fixable := 0
+		for _, p := range problems {
+			fmt.Fprintf(out, "[%s] %s\n", p.Kind, p.Detail)
+			if p.Fixable {
+				fixable++
 			}
-			fmt.Printf("Added prog integration to %s\n", claudePath)
-			claudeMDUpdated = true
 		}
-	}
 
-	// Install SessionStart hook
-	hookAdded, err := installSessionStartHook(settingsPath)
-	if err != nil {
-		return fmt.Errorf("failed to install hook: %w", err)
-	}
+		if !flagDoctorFix {
+			return fmt.Errorf("found %s (%d fixable with --fix)", pluralize(len(problems), "problem"), fixable)
+		}
+		fixed, err := database.Repair()
+		if err != nil {
+			return err
+		}
 
-	if hookAdded {
-		fmt.Println("Installed SessionStart hook in ~/.claude/settings.json")
-	} else {
-		fmt.Println("SessionStart hook already installed")
-	}
+		// Backup after successful mutation
+		database.BackupQuiet()
 
-	if !claudeMDUpdated && !hookAdded && !force {
-		fmt.Println("Use --force to update existing configuration")
+		fmt.Fprintf(out, "Fixed %s\n", pluralize(fixed, "row"))
+		if unfixed := len(problems) - fixable; unfixed > 0 {
+			return fmt.Errorf("%s can't be fixed automatically; restore from a backup", pluralize(unfixed, "problem"))
+		}
+		return nil
+	},
+}
+
+func formatSize(bytes int64) string {
+	const unit = 1024
+	if bytes < unit {
+		return fmt.Sprintf("%d B", bytes)
+	}
+	div, exp := int64(unit), 0
+	for n := bytes / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
 	}
+	return fmt.Sprintf("%.1f %cB", float64(bytes)/float64(div), "KMGTPE"[exp])
+}
 
-	// Print summary and guidance for other agents
-	fmt.Println()
-	fmt.Println("Note: This assumes Claude Code. For other agents:")
-	fmt.Println("  1. Update your agent's instruction file (AGENTS.md, .cursorrules, etc.)")
-	fmt.Println("     with the Task Tracking section above")
-	fmt.Println("  2. If your tool supports hooks, add 'prog prime' to session start")
-	fmt.Println("  3. If no hooks, run 'prog prime' and paste output into agent context")
+var exportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export tasks for reporting",
+	Long: `Export tasks to stdout for use in spreadsheets or other tools.
 
-	return nil
+Formats:
+  csv  Items streamed in creation order with the columns
+       id, type, title, status, priority, created_at, updated_at
+  md   Markdown board: a heading per epic with its child tasks as
+       checkboxes, followed by tasks that have no parent epic
+
+Examples:
+  prog export --format csv > tasks.csv
+  prog export --format csv -p myproject --status done
+  prog export --format md -p myproject > board.md`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		out := cmd.OutOrStdout()
+		if flagExportFormat != "csv" && flagExportFormat != "md" {
+			return fmt.Errorf("unsupported format: %s (valid: csv, md)", flagExportFormat)
+		}
+		status, err := parseStatusFlag(flagExportStatus)
+		if err != nil {
+			return err
+		}
+
+		database, err := openDB()
+		if err != nil {
+			return err
+		}
+		defer func() { _ = database.Close() }()
+
+		if flagExportFormat == "md" {
+			return writeItemsMarkdown(out, database, flagProject)
+		}
+		return writeItemsCSV(out, database.AllItems(flagProject), status)
+	},
 }
 
-// replaceTaskTrackingSection finds the "## Task Tracking" section and replaces it
-// with the new snippet. The section ends at the next heading (# or ##) or EOF.
-func replaceTaskTrackingSection(content, snippet string) string {
-	// Find where "## Task Tracking" starts
-	startIdx := strings.Index(content, "## Task Tracking")
-	if startIdx == -1 {
-		return content
-	}
+var importCmd = &cobra.Command{
+	Use:   "import <file>",
+	Short: "Create tasks in bulk from a JSON file",
+	Long: `Create tasks and epics from a JSON array in one transaction.
+
+Each entry needs a key and title, and may set type (task or epic),
+priority (1-3), priority_set, description, status (default open), tags,
+due_at, estimate_minutes, recurrence, logs, parent, and deps. A priority
+counts as explicitly set, so it isn't replaced by an epic's, unless
+priority_set is false.
+parent and deps refer to other entries by key; the generated id for each key
+is printed once the import succeeds. If any entry is invalid or a key can't
+be resolved, nothing is created. Use - to read from stdin.
+
+'prog show <id> --export' writes an item and its subtree in this format.
+
+Example file:
+  [
+    {"key": "auth", "title": "Auth system", "type": "epic", "priority": 1},
+    {"key": "schema", "title": "Design user schema", "parent": "auth"},
+    {"key": "login", "title": "Login endpoint", "parent": "auth", "deps": ["schema"]}
+  ]
 
-	// Find where the section ends (next heading or EOF)
-	rest := content[startIdx+len("## Task Tracking"):]
-	endIdx := -1
+Examples:
+  prog import seed.json -p myproject
+  cat seed.json | prog import - -p myproject`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		out := cmd.OutOrStdout()
+		specs, err := readImportFile(args[0])
+		if err != nil {
+			return err
+		}
 
-	// Look for next heading (line starting with #)
-	lines := strings.Split(rest, "\n")
-	charCount := 0
-	for i, line := range lines {
-		if i > 0 && len(line) > 0 && line[0] == '#' {
-			endIdx = startIdx + len("## Task Tracking") + charCount
-			break
+		database, err := openDB()
+		if err != nil {
+			return err
 		}
-		charCount += len(line) + 1 // +1 for newline
-	}
+		defer func() { _ = database.Close() }()
 
-	// Build new content
-	before := content[:startIdx]
-	var after string
-	if endIdx == -1 {
-		// Section goes to EOF
-		after = ""
-	} else {
-		after = content[endIdx:]
-	}
+		ids, err := database.ImportItems(flagProject, specs)
+		if err != nil {
+			return err
+		}
 
-	// Ensure proper spacing
-	result := strings.TrimRight(before, "\n")
-	if result != "" {
-		result += "\n\n"
+		fmt.Fprintf(out, "Imported %d items:\n", len(specs))
+		for _, spec := range specs {
+			fmt.Fprintf(out, "  %s -> %s\n", spec.Key, ids[spec.Key])
+		}
+
+		// Backup after successful mutation
+		database.BackupQuiet()
+		return nil
+	},
+}
+
+// readImportFile decodes a JSON array of import items from path, or from
+// stdin when path is "-".
+func readImportFile(path string) ([]db.ImportItem, error) {
+	var r io.Reader = os.Stdin
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open import file: %w", err)
+		}
+		defer func() { _ = f.Close() }()
+		r = f
 	}
-	result += strings.TrimRight(snippet, "\n")
-	if after != "" {
-		result += "\n\n" + strings.TrimLeft(after, "\n")
-	} else {
-		result += "\n"
+
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+	var specs []db.ImportItem
+	if err := dec.Decode(&specs); err != nil {
+		return nil, fmt.Errorf("failed to parse import file: %w", err)
 	}
+	return specs, nil
+}
 
-	return result
+var templateCmd = &cobra.Command{
+	Use:   "template",
+	Short: "Save and apply reusable task structures",
+	Long: `Save a task, or an epic with its children, as a named template and
+create fresh copies of it later.
+
+Templates keep titles, types, priorities, descriptions, the parent/child
+skeleton and the dependencies between the captured items, stored in the
+same JSON format as 'prog show --export'. Statuses and logs are not kept:
+every applied item starts open.
+
+Examples:
+  prog template save release ep-a1b2c3
+  prog template apply release -p myproject
+  prog template list
+  prog template rm release`,
 }
 
-// installSessionStartHook installs "prog prime" in ~/.claude/settings.json
-// Returns true if hook was added, false if already present
-func installSessionStartHook(settingsPath string) (bool, error) {
-	// Default to ~/.claude/settings.json if not specified
-	if settingsPath == "" {
-		home, err := os.UserHomeDir()
+var templateSaveCmd = &cobra.Command{
+	Use:   "save <name> <id>",
+	Short: "Capture an item (and an epic's children) as a template",
+	Long: `Capture an item as a named template, replacing any template with the
+same name. For an epic, its children (recursively) and the dependencies
+among them are captured too.
+
+Example:
+  prog template save release ep-a1b2c3`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		out := cmd.OutOrStdout()
+		database, err := openDB()
 		if err != nil {
-			return false, fmt.Errorf("failed to get home directory: %w", err)
+			return err
 		}
-		settingsPath = filepath.Join(home, ".claude", "settings.json")
-	}
+		defer func() { _ = database.Close() }()
 
-	// Read existing settings or create new
-	var settings ClaudeSettings
-	content, err := os.ReadFile(settingsPath)
-	if err != nil {
-		if !os.IsNotExist(err) {
-			return false, fmt.Errorf("failed to read settings: %w", err)
+		id, err := database.ResolveID(args[1])
+		if err != nil {
+			return err
 		}
-		// File doesn't exist, start fresh
-		settings = ClaudeSettings{
-			Hooks: make(map[string][]HookMatcher),
+		t, err := database.SaveTemplate(args[0], id)
+		if err != nil {
+			return err
 		}
-	} else {
-		if err := json.Unmarshal(content, &settings); err != nil {
-			return false, fmt.Errorf("failed to parse settings: %w", err)
+		fmt.Fprintf(out, "Saved template %s (%s)\n", t.Name, pluralize(len(t.Items), "item"))
+
+		// Backup after successful mutation
+		database.BackupQuiet()
+		return nil
+	},
+}
+
+var templateApplyCmd = &cobra.Command{
+	Use:   "apply <name>",
+	Short: "Create fresh items from a template",
+	Long: `Create new open items from a template in one transaction, recreating
+its epic/child structure and dependencies. Items are created in the
+project given with -p.
+
+Example:
+  prog template apply release -p myproject`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		out := cmd.OutOrStdout()
+		database, err := openDB()
+		if err != nil {
+			return err
+		}
+		defer func() { _ = database.Close() }()
+
+		t, ids, err := database.ApplyTemplate(args[0], flagProject)
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintf(out, "Created %s from template %s:\n", pluralize(len(t.Items), "item"), t.Name)
+		for _, spec := range t.Items {
+			fmt.Fprintf(out, "  %s  %s\n", ids[spec.Key], spec.Title)
+		}
+
+		// Backup after successful mutation
+		database.BackupQuiet()
+		return nil
+	},
+}
+
+var templateListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List saved templates",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		out := cmd.OutOrStdout()
+		database, err := openDB()
+		if err != nil {
+			return err
+		}
+		defer func() { _ = database.Close() }()
+
+		templates, err := database.ListTemplates()
+		if err != nil {
+			return err
+		}
+		if len(templates) == 0 {
+			fmt.Fprintln(out, "No templates")
+			return nil
+		}
+
+		fmt.Fprintf(out, "%-20s  %-6s  %-12s  %s\n", "NAME", "ITEMS", "UPDATED", "ROOT")
+		for _, t := range templates {
+			root := ""
+			if len(t.Items) > 0 {
+				root = t.Items[0].Title
+			}
+			fmt.Fprintf(out, "%-20s  %-6d  %-12s  %s\n", t.Name, len(t.Items), humanizeTime(t.UpdatedAt), root)
+		}
+		return nil
+	},
+}
+
+var templateRmCmd = &cobra.Command{
+	Use:   "rm <name>",
+	Short: "Delete a template",
+	Long: `Delete a template. Items already created from it are unaffected.
+
+Example:
+  prog template rm release`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		out := cmd.OutOrStdout()
+		database, err := openDB()
+		if err != nil {
+			return err
+		}
+		defer func() { _ = database.Close() }()
+
+		if err := database.DeleteTemplate(args[0]); err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "Deleted template: %s\n", args[0])
+		return nil
+	},
+}
+
+// writeItemsMarkdown renders epics with their child tasks as checkbox lists,
+// followed by an "Orphan tasks" section for tasks without a parent.
+func writeItemsMarkdown(out io.Writer, database *db.DB, project string) error {
+	epics, err := database.ListItemsFiltered(db.ListFilter{Project: project, Type: string(model.ItemTypeEpic)})
+	if err != nil {
+		return err
+	}
+	tasks, err := database.ListItemsFiltered(db.ListFilter{Project: project, Type: string(model.ItemTypeTask)})
+	if err != nil {
+		return err
+	}
+
+	title := project
+	if title == "" {
+		title = "All projects"
+	}
+	fmt.Fprintf(out, "# %s\n", title)
+
+	for _, epic := range epics {
+		done, total, err := database.EpicProgress(epic.ID)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "\n## %s (%d/%d done)\n\n", epic.Title, done, total)
+		children, err := database.ListItemsFiltered(db.ListFilter{Parent: epic.ID})
+		if err != nil {
+			return err
 		}
-		if settings.Hooks == nil {
-			settings.Hooks = make(map[string][]HookMatcher)
+		if len(children) == 0 {
+			fmt.Fprintln(out, "_No tasks_")
 		}
-	}
-
-	// Check if hook already exists
-	const hookCommand = "prog prime"
-	for _, matcher := range settings.Hooks["SessionStart"] {
-		for _, hook := range matcher.Hooks {
-			if hook.Command == hookCommand {
-				return false, nil // Already installed
-			}
+		for _, child := range children {
+			writeMarkdownTask(out, child)
 		}
 	}
 
-	// Add the hook
-	newHook := Hook{
-		Type:    "command",
-		Command: hookCommand,
+	var orphans []model.Item
+	for _, task := range tasks {
+		if task.ParentID == nil {
+			orphans = append(orphans, task)
+		}
 	}
-
-	// Find or create a matcher with empty string (matches all)
-	found := false
-	for i, matcher := range settings.Hooks["SessionStart"] {
-		if matcher.Matcher == "" {
-			settings.Hooks["SessionStart"][i].Hooks = append(matcher.Hooks, newHook)
-			found = true
-			break
+	if len(orphans) > 0 {
+		fmt.Fprintf(out, "\n## Orphan tasks\n\n")
+		for _, task := range orphans {
+			writeMarkdownTask(out, task)
 		}
 	}
+	return nil
+}
 
-	if !found {
-		// Create new matcher
-		settings.Hooks["SessionStart"] = append(settings.Hooks["SessionStart"], HookMatcher{
-			Matcher: "",
-			Hooks:   []Hook{newHook},
-		})
+func writeMarkdownTask(out io.Writer, item model.Item) {
+	check := " "
+	if item.Status == model.StatusDone {
+		check = "x"
 	}
-
-	// Ensure directory exists
-	if err := os.MkdirAll(filepath.Dir(settingsPath), 0755); err != nil {
-		return false, fmt.Errorf("failed to create settings directory: %w", err)
+	title := item.Title
+	if item.Status == model.StatusCanceled {
+		title = "~~" + title + "~~"
 	}
+	fmt.Fprintf(out, "- [%s] %s\n", check, title)
+}
 
-	// Write settings back
-	output, err := json.MarshalIndent(settings, "", "  ")
-	if err != nil {
-		return false, fmt.Errorf("failed to marshal settings: %w", err)
+// writeItemsCSV writes items as CSV with a header row, skipping items that
+// don't match status (when non-nil).
+func writeItemsCSV(out io.Writer, items iter.Seq2[model.Item, error], status *model.Status) error {
+	w := csv.NewWriter(out)
+	if err := w.Write([]string{"id", "type", "title", "status", "priority", "created_at", "updated_at"}); err != nil {
+		return fmt.Errorf("failed to write csv: %w", err)
 	}
-
-	if err := os.WriteFile(settingsPath, append(output, '\n'), 0644); err != nil {
-		return false, fmt.Errorf("failed to write settings: %w", err)
+	for item, err := range items {
+		if err != nil {
+			return err
+		}
+		if status != nil && item.Status != *status {
+			continue
+		}
+		record := []string{
+			item.ID,
+			string(item.Type),
+			item.Title,
+			string(item.Status),
+			strconv.Itoa(item.Priority),
+			item.CreatedAt.Format(time.RFC3339),
+			item.UpdatedAt.Format(time.RFC3339),
+		}
+		if err := w.Write(record); err != nil {
+			return fmt.Errorf("failed to write csv: %w", err)
+		}
 	}
-
-	return true, nil
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return fmt.Errorf("failed to write csv: %w", err)
+	}
+	return nil
 }
 
-var primeCmd = &cobra.Command{
-	Use:   "prime",
-	Short: "Output context for Claude Code hooks",
-	Long: `Output essential workflow context for AI agents.
+var tuiCmd = &cobra.Command{
+	Use:     "tui",
+	Aliases: []string{"ui"},
+	Short:   "Launch interactive terminal UI",
+	Long: `Launch an interactive terminal UI for managing tasks.
 
-Designed to run on SessionStart and PreCompact hooks to ensure
-agents maintain context about the prog workflow.
+Navigation:
+  j/k or arrows    Move cursor up/down
+  g/G or home/end  Jump to first/last item
+  enter or l       View task details
+  esc or h         Go back to list
 
-Example hook configuration in Claude Code settings:
-  "hooks": {
-    "SessionStart": [{"command": "prog prime"}],
-    "PreCompact": [{"command": "prog prime"}]
-  }`,
+Actions:
+  s   Start task (open/blocked -> in_progress)
+  d   Mark done (in_progress -> done)
+  b   Block task (prompts for reason)
+  L   Log progress (prompts for message)
+  c   Cancel task (prompts for optional reason)
+  n   Create new task (inherits project from selected item)
+  D   Delete task
+  a   Add dependency (prompts for blocker ID)
+  r   Refresh task list
+
+Filtering:
+  /       Search by title/ID/description
+  p       Filter by project
+  1-5     Toggle status: 1=open 2=in_progress 3=blocked 4=done 5=canceled
+  0       Show all statuses
+  esc     Clear filters, or quit if none set
+
+Press q to quit.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		database, err := openDB()
 		if err != nil {
-			// Still output prime content even if DB fails
-			printPrimeContent(nil, nil)
-			return nil
+			return err
 		}
 		defer func() { _ = database.Close() }()
 
-		report, _ := database.ProjectStatus("")
+		return tui.Run(database)
+	},
+}
 
-		// Get all projects to check compaction candidates
-		projects, _ := database.ListProjects()
-		var allStats []db.ConceptStats
-		for _, p := range projects {
-			stats, _ := database.ListConceptsWithStats(p)
-			allStats = append(allStats, stats...)
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Run an MCP server on stdin/stdout, or an HTTP API with --http",
+	Long: `Serve prog to AI agents over the Model Context Protocol.
+
+Reads JSON-RPC requests from stdin and writes responses to stdout, one per
+line, until stdin closes. Register it with an MCP client as the command
+"prog serve". Tools:
+
+  list_ready      Open tasks with all dependencies done
+  create_task     Create a task (title, project, priority, description, parent)
+  start_task      Set a task to in_progress
+  complete_task   Mark a task done, with an optional note
+  add_log         Add a progress entry to a task's log
+
+Each tool returns JSON. Errors are reported to the agent as tool errors.
+
+With --http, serves a JSON REST API on the given address instead. An
+address without a host, like :8080, listens on 127.0.0.1 only; there is no
+authentication, so pass 0.0.0.0:8080 explicitly to listen on every interface.
+
+  GET  /items              List items (?project=, ?status=, ?type=, ?parent=)
+  POST /items              Create an item
+  GET  /items/{id}         Show an item
+  POST /items/{id}/status  Change an item's status
+  GET  /status             Project status report (?project=)
+
+Examples:
+  prog serve
+  prog serve --http 127.0.0.1:8080`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		database, err := openDB()
+		if err != nil {
+			return err
 		}
+		defer func() { _ = database.Close() }()
 
-		printPrimeContent(report, allStats)
-		return nil
+		if flagServeHTTP != "" {
+			addr, err := serveAddr(flagServeHTTP)
+			if err != nil {
+				return err
+			}
+			server := &http.Server{
+				Addr:              addr,
+				Handler:           api.NewHandler(database),
+				ReadHeaderTimeout: 10 * time.Second,
+			}
+			_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "Serving HTTP API on %s\n", addr)
+			return server.ListenAndServe()
+		}
+		return mcp.NewServer(database).Serve(cmd.InOrStdin(), cmd.OutOrStdout())
 	},
 }
 
-var compactCmd = &cobra.Command{
-	Use:   "compact",
-	Short: "Output compaction workflow guidance for agents",
-	Long: `Output guidance for grooming learnings and concepts.
+// serveAddr returns the address serve --http listens on, binding addresses
+// without a host to 127.0.0.1 rather than every interface.
+func serveAddr(addr string) (string, error) {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return "", fmt.Errorf("invalid --http address %q: %w (e.g. 127.0.0.1:8080)", addr, err)
+	}
+	if host == "" {
+		host = "127.0.0.1"
+	}
+	return net.JoinHostPort(host, port), nil
+}
 
-Use this when learnings have accumulated and need review.
-Covers identifying redundancy, staleness, and quality issues.
+var nextCmd = &cobra.Command{
+	Use:   "next",
+	Short: "Show the single highest-priority ready task",
+	Long: `Show the one task to work on now.
 
-The workflow uses two phases:
-1. Discovery: Scan summaries to identify grooming candidates
-2. Selection: Load detail for candidates, groom, repeat
+Picks the top ready task (highest priority, then oldest). Use --start to
+also set it to in_progress in one step.
 
-Example:
-  prog compact              # Output compaction guidance
-  prog compact -p myproject # Include project-specific stats`,
+Examples:
+  prog next
+  prog next -p myproject
+  prog next --start`,
 	RunE: func(cmd *cobra.Command, args []string) error {
+		out := cmd.OutOrStdout()
 		database, err := openDB()
 		if err != nil {
-			printCompactContent(nil)
-			return nil
+			return err
 		}
 		defer func() { _ = database.Close() }()
 
-		var stats []db.ConceptStats
-		if flagProject != "" {
-			stats, _ = database.ListConceptsWithStats(flagProject)
-		} else {
-			// Get stats across all projects
-			projects, _ := database.ListProjects()
-			for _, p := range projects {
-				pStats, _ := database.ListConceptsWithStats(p)
-				stats = append(stats, pStats...)
-			}
+		items, err := database.ReadyItems(flagProject)
+		if err != nil {
+			return err
+		}
+		item, ok := pickNext(items)
+		if !ok {
+			fmt.Fprintln(out, "Nothing ready")
+			return nil
 		}
 
-		printCompactContent(stats)
+		if flagNextStart {
+			if err := database.UpdateStatus(item.ID, model.StatusInProgress); err != nil {
+				return err
+			}
+			fmt.Fprintf(out, "Started %s  %s\n", item.ID, item.Title)
+			return nil
+		}
+		fmt.Fprintf(out, "%s  %s\n", item.ID, item.Title)
 		return nil
 	},
 }
 
-var flagBackupQuiet bool
-
-var backupCmd = &cobra.Command{
-	Use:   "backup [path]",
-	Short: "Create a backup of the database",
-	Long: `Create a backup of the prog database.
+// pickNext returns the highest-priority item, oldest first among equals.
+func pickNext(items []model.Item) (model.Item, bool) {
+	if len(items) == 0 {
+		return model.Item{}, false
+	}
+	sorted := slices.Clone(items)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].Priority != sorted[j].Priority {
+			return sorted[i].Priority < sorted[j].Priority
+		}
+		return sorted[i].CreatedAt.Before(sorted[j].CreatedAt)
+	})
+	return sorted[0], true
+}
 
-Backups are stored in ~/.prog/backups/ by default with timestamped names.
-The last 10 backups are kept; older ones are automatically pruned.
+var overdueCmd = &cobra.Command{
+	Use:   "overdue",
+	Short: "Show tasks past their due date",
+	Long: `Show open, in-progress, and blocked items whose due date has passed.
 
-Optionally specify a custom path for the backup file.
+Results are sorted with the most overdue first.
 
 Examples:
-  prog backup                    # Create backup in ~/.prog/backups/
-  prog backup ~/my-backup.db     # Create backup at custom path
-  prog backup --quiet            # Silent backup (for hooks)`,
-	Args: cobra.MaximumNArgs(1),
+  prog overdue
+  prog overdue -p myproject`,
 	RunE: func(cmd *cobra.Command, args []string) error {
+		out := cmd.OutOrStdout()
 		database, err := openDB()
 		if err != nil {
 			return err
 		}
 		defer func() { _ = database.Close() }()
 
-		var backupPath string
-		if len(args) > 0 {
-			// Custom path - use VACUUM INTO directly
-			backupPath = args[0]
-			_, err = database.Exec(fmt.Sprintf("VACUUM INTO '%s'", backupPath))
-			if err != nil {
-				return fmt.Errorf("failed to create backup: %w", err)
-			}
-		} else {
-			backupPath, err = database.Backup()
-			if err != nil {
-				return err
-			}
+		items, err := database.OverdueItems(flagProject, database.Now())
+		if err != nil {
+			return err
 		}
 
-		if !flagBackupQuiet {
-			fmt.Printf("Backup created: %s\n", backupPath)
+		if len(items) == 0 {
+			fmt.Fprintln(out, "No overdue tasks")
+			return nil
+		}
+
+		if err := database.PopulateItemLabels(items); err != nil {
+			return err
 		}
+
+		printItemsTable(out, items)
 		return nil
 	},
 }
 
-var backupsCmd = &cobra.Command{
-	Use:   "backups",
-	Short: "List available backups",
-	Long: `List all available database backups.
+var staleCmd = &cobra.Command{
+	Use:   "stale",
+	Short: "Show in-progress tasks that haven't been updated in a while",
+	Long: `Show in-progress items whose last update is older than --older-than,
+least recently updated first. These are usually tasks someone started and
+then forgot; finish them, or move them back with 'prog reopen'.
 
-Shows backups in ~/.prog/backups/, newest first.`,
+'prog status' counts in-progress items idle for 3 days or more.
+
+Examples:
+  prog stale
+  prog stale --older-than 1w -p myproject
+  prog stale --older-than 12h`,
+	Args: cobra.NoArgs,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		backups, err := db.ListBackups()
+		out := cmd.OutOrStdout()
+		olderThan, err := parseAge("--older-than", flagStaleOlderThan)
 		if err != nil {
 			return err
 		}
 
-		if len(backups) == 0 {
-			fmt.Println("No backups found")
+		database, err := openDB()
+		if err != nil {
+			return err
+		}
+		defer func() { _ = database.Close() }()
+
+		items, err := database.StaleInProgress(flagProject, olderThan)
+		if err != nil {
+			return err
+		}
+		if len(items) == 0 {
+			fmt.Fprintln(out, "No stale tasks")
 			return nil
 		}
 
-		fmt.Printf("%-30s  %10s  %s\n", "BACKUP", "SIZE", "CREATED")
-		for _, b := range backups {
-			size := formatSize(b.Size)
-			age := formatTimeAgo(b.ModTime)
-			fmt.Printf("%-30s  %10s  %s\n", b.Name, size, age)
+		fmt.Fprintf(out, "%-12s  %-12s  %s\n", "ID", "UPDATED", "TITLE")
+		for _, item := range items {
+			fmt.Fprintf(out, "%-12s  %-12s  %s\n", item.ID, humanizeTime(item.UpdatedAt), item.Title)
 		}
 		return nil
 	},
 }
 
-var restoreCmd = &cobra.Command{
-	Use:   "restore <path>",
-	Short: "Restore database from a backup",
-	Long: `Restore the prog database from a backup file.
+var recentCmd = &cobra.Command{
+	Use:   "recent",
+	Short: "Show recent activity",
+	Long: `Show the latest log entries and status changes, newest first.
 
-This replaces the current database with the backup.
-A backup of the current database is created first.
+Useful when resuming a session to see what happened last.
 
 Examples:
-  prog restore ~/.prog/backups/prog-2024-01-09T12-00-00.db
-  prog restore ~/my-backup.db`,
-	Args: cobra.ExactArgs(1),
+  prog recent
+  prog recent -p myproject --limit 50`,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		backupPath := args[0]
+		out := cmd.OutOrStdout()
+		if flagRecentLimit <= 0 {
+			return fmt.Errorf("--limit must be positive: %d", flagRecentLimit)
+		}
 
-		// First, create a backup of current state
 		database, err := openDB()
 		if err != nil {
-			// If we can't open the DB, that's fine - just restore
-			fmt.Println("Note: Could not backup current database (may not exist)")
-		} else {
-			preRestorePath, err := database.Backup()
-			_ = database.Close()
-			if err != nil {
-				fmt.Printf("Warning: Could not backup current database: %v\n", err)
-			} else {
-				fmt.Printf("Current database backed up to: %s\n", preRestorePath)
-			}
+			return err
 		}
+		defer func() { _ = database.Close() }()
 
-		// Restore from backup
-		if err := db.Restore(backupPath); err != nil {
+		activity, err := database.RecentActivity(flagProject, flagRecentLimit)
+		if err != nil {
 			return err
 		}
 
-		fmt.Printf("Restored from: %s\n", backupPath)
+		if len(activity) == 0 {
+			fmt.Fprintln(out, "No recent activity")
+			return nil
+		}
+		for _, a := range activity {
+			fmt.Fprintln(out, formatActivity(a))
+		}
 		return nil
-	},
-}
-
-func formatSize(bytes int64) string {
-	const unit = 1024
-	if bytes < unit {
-		return fmt.Sprintf("%d B", bytes)
-	}
-	div, exp := int64(unit), 0
-	for n := bytes / unit; n >= unit; n /= unit {
-		div *= unit
-		exp++
-	}
-	return fmt.Sprintf("%.1f %cB", float64(bytes)/float64(div), "KMGTPE"[exp])
+	},
 }
 
-var tuiCmd = &cobra.Command{
-	Use:     "tui",
-	Aliases: []string{"ui"},
-	Short:   "Launch interactive terminal UI",
-	Long: `Launch an interactive terminal UI for managing tasks.
-
-Navigation:
-  j/k or arrows    Move cursor up/down
-  g/G or home/end  Jump to first/last item
-  enter or l       View task details
-  esc or h         Go back to list
+// formatActivity renders a feed entry as "[time] id title: message", with
+// status changes prefixed by "status".
+func formatActivity(a db.Activity) string {
+	ts := a.At.Local().Format("2006-01-02 15:04")
+	msg := a.Message
+	if a.Status {
+		msg = "status " + msg
+	}
+	return fmt.Sprintf("[%s] %s %s: %s", ts, a.ItemID, a.Title, msg)
+}
 
-Actions:
-  s   Start task (open/blocked -> in_progress)
-  d   Mark done (in_progress -> done)
-  b   Block task (prompts for reason)
-  L   Log progress (prompts for message)
-  c   Cancel task (prompts for optional reason)
-  n   Create new task (inherits project from selected item)
-  D   Delete task
-  a   Add dependency (prompts for blocker ID)
-  r   Refresh task list
+var statsCmd = &cobra.Command{
+	Use:   "stats",
+	Short: "Show tasks completed per week",
+	Long: `Show how many tasks were completed each week, to track velocity.
 
-Filtering:
-  /       Search by title/ID/description
-  p       Filter by project
-  1-5     Toggle status: 1=open 2=in_progress 3=blocked 4=done 5=canceled
-  0       Show all statuses
-  esc     Clear filters, or quit if none set
+Weeks start on Monday. Items count toward the week they were marked done.
+--since accepts a date (YYYY-MM-DD) or a lookback such as 8w or 30d.
 
-Press q to quit.`,
+Examples:
+  prog stats
+  prog stats -p myproject --since 12w
+  prog stats --since 2024-01-01`,
 	RunE: func(cmd *cobra.Command, args []string) error {
+		out := cmd.OutOrStdout()
 		database, err := openDB()
 		if err != nil {
 			return err
 		}
 		defer func() { _ = database.Close() }()
 
-		return tui.Run(database)
+		since, err := parseSince(flagStatsSince, database.Now())
+		if err != nil {
+			return err
+		}
+
+		weeks, err := database.CompletionStats(flagProject, since)
+		if err != nil {
+			return err
+		}
+
+		printCompletionStats(out, weeks)
+		return nil
 	},
 }
 
 func init() {
 	// Global flags
 	rootCmd.PersistentFlags().StringVarP(&flagProject, "project", "p", "", "Project scope")
+	rootCmd.PersistentFlags().StringVar(&flagDB, "db", "", "Database path (overrides PROG_DB and ~/.prog/prog.db)")
+	rootCmd.PersistentFlags().BoolVar(&flagLocal, "local", false, "Use the database in ./.prog (create it with 'prog init --local')")
+	rootCmd.PersistentFlags().StringVar(&flagColor, "color", "auto", "Colorize output by status: auto, always, never")
+
+	// log flags
+	logCmd.Flags().StringVar(&flagLogBy, "by", "", "Author of the entry (default $USER or \"agent\")")
+	logCmd.Flags().StringVar(&flagLogFile, "file", "", "Read the message from a file (- for stdin)")
+	logCmd.Flags().BoolVar(&flagLogEditor, "editor", false, "Compose the message in $PROG_EDITOR or $EDITOR")
+
+	// doctor flags
+	doctorCmd.Flags().BoolVar(&flagDoctorFix, "fix", false, "Clean up dangling rows in one transaction")
+
+	// block flags
+	blockCmd.Flags().StringVar(&flagBlockOn, "on", "", "Item the tasks are waiting on; adds a dependency that reopens them when it's done")
+
+	// parent flags
+	parentCmd.Flags().StringVar(&flagParentTo, "to", "", "Epic to set as the parent")
+	parentCmd.Flags().BoolVar(&flagParentDetach, "detach", false, "Remove the task's parent")
+	parentCmd.Flags().BoolVar(&flagInheritPriority, "inherit-priority", false, "Take the epic's priority if the task is still at the default")
+
+	// project rename flags
+	projectRenameCmd.Flags().BoolVar(&flagProjectMerge, "merge", false, "Combine with an existing project of the new name")
+
+	// mv flags
+	mvCmd.Flags().BoolVar(&flagMvWithChildren, "with-children", false, "Also move an epic's child tasks")
+
+	// graph flags
+	graphCmd.Flags().StringVar(&flagGraphFormat, "format", "text", "Output format (text, dot)")
+
+	// clone flags
+	cloneCmd.Flags().StringVar(&flagCloneTitle, "title", "", "Title for the new item (default: copy the original)")
+
+	// recent flags
+	recentCmd.Flags().IntVar(&flagRecentLimit, "limit", 20, "Maximum number of entries to show")
+
+	// stale flags
+	staleCmd.Flags().StringVar(&flagStaleOlderThan, "older-than", "3d", "Minimum time since the last update (e.g. 3d, 1w, 12h)")
+
+	// stats flags
+	statsCmd.Flags().StringVar(&flagStatsSince, "since", "8w", "Start of the report: YYYY-MM-DD or a lookback like 8w, 30d")
+
+	// show flags
+	showCmd.Flags().BoolVar(&flagAbsolute, "absolute", false, "Show RFC3339 timestamps instead of relative times")
+	showCmd.Flags().BoolVar(&flagShowExport, "export", false, "Print the item and its subtree as importable JSON")
+
+	// status change flags
+	startCmd.Flags().BoolVar(&flagStatusForce, "force", false, "Allow otherwise illegal status transitions and locked tasks")
+	startCmd.Flags().BoolVar(&flagStartStrict, "strict", false, "Refuse to exceed a project's WIP limit")
+	doneCmd.Flags().BoolVar(&flagStatusForce, "force", false, "Complete epics with unfinished children; allow illegal transitions and locked tasks")
+	doneCmd.Flags().StringVar(&flagDoneNote, "note", "", "Log how the task was completed")
+	blockCmd.Flags().BoolVar(&flagStatusForce, "force", false, "Allow otherwise illegal status transitions and locked tasks")
+	cancelCmd.Flags().BoolVar(&flagStatusForce, "force", false, "Cancel even if the task is locked")
+	reopenCmd.Flags().BoolVar(&flagStatusForce, "force", false, "Reopen even if the task is locked")
+
+	// description flags
+	appendCmd.Flags().BoolVar(&flagDescForce, "force", false, "Append even if the task is locked")
+	descCmd.Flags().BoolVar(&flagDescForce, "force", false, "Replace the description even if the task is locked")
+	editCmd.Flags().BoolVar(&flagDescForce, "force", false, "Edit the description even if the task is locked")
+
+	// prune-logs flags
+	pruneLogsCmd.Flags().IntVar(&flagPruneKeep, "keep", 0, "Keep only each task's N most recent log entries")
+	pruneLogsCmd.Flags().StringVar(&flagPruneOlderThan, "older-than", "", "Delete log entries before a date (YYYY-MM-DD) or lookback (90d, 12w)")
+
+	// load flags
+	loadCmd.Flags().BoolVar(&flagLoadForce, "force", false, "Back up and replace a non-empty database")
+
+	// note flags
+	noteCmd.Flags().StringVar(&flagNoteRm, "rm", "", "Delete the note with this key")
+
+	// serve flags
+	serveCmd.Flags().StringVar(&flagServeHTTP, "http", "", "Serve a JSON REST API on this address (e.g. 127.0.0.1:8080) instead of MCP on stdio")
+
+	// next flags
+	nextCmd.Flags().BoolVar(&flagNextStart, "start", false, "Also set the chosen task to in_progress")
 
 	// add flags
 	addCmd.Flags().BoolVarP(&flagEpic, "epic", "e", false, "Create an epic instead of a task")
-	addCmd.Flags().IntVar(&flagPriority, "priority", 2, "Priority (1=high, 2=medium, 3=low)")
+	addCmd.Flags().StringVar(&flagPriority, "priority", "medium", "Priority: high, medium, low (or 1-3)")
 	addCmd.Flags().StringVar(&flagParent, "parent", "", "Parent epic ID")
 	addCmd.Flags().StringVar(&flagBlocks, "blocks", "", "ID of task this will block")
 	addCmd.Flags().StringArrayVarP(&flagAddLabels, "label", "l", nil, "Label to attach (can be repeated)")
+	addCmd.Flags().IntVar(&flagEstimate, "estimate", 0, "Estimated effort in minutes")
+	addCmd.Flags().StringVar(&flagDue, "due", "", "Due date (YYYY-MM-DD or relative like +3d, +2w)")
+	addCmd.Flags().StringArrayVar(&flagDependsOn, "depends-on", nil, "ID of a task this one waits on (can be repeated)")
+	addCmd.Flags().StringVar(&flagRecur, "recur", "", "Repeat when done: daily, weekly, or monthly")
 
 	// list flags
 	listCmd.Flags().StringVar(&flagStatus, "status", "", "Filter by status (open, in_progress, blocked, done, canceled)")
-	listCmd.Flags().StringVar(&flagListParent, "parent", "", "Filter by parent epic ID")
+	listCmd.Flags().BoolVar(&flagListNoDone, "no-done", false, "Hide done items")
+	listCmd.Flags().BoolVar(&flagListActive, "active", false, "Show only open, in_progress, and blocked items")
+	listCmd.Flags().StringVar(&flagListFormat, "format", "table", "Output format (table, compact)")
+	listCmd.Flags().BoolVar(&flagListNoHeader, "no-header", false, "Omit the table header")
+	listCmd.Flags().StringVar(&flagListColumns, "columns", "id,status,pri,title", "Comma-separated columns to show (id, status, pri, type, project, parent, created, updated, due, labels, tags, title)")
+	listCmd.Flags().StringVar(&flagListParent, "parent", "", "Filter by parent epic ID (errors if not an epic)")
 	listCmd.Flags().StringVar(&flagListType, "type", "", "Filter by item type (task, epic)")
 	listCmd.Flags().StringVar(&flagBlocking, "blocking", "", "Show items that block the given ID")
 	listCmd.Flags().StringVar(&flagBlockedBy, "blocked-by", "", "Show items blocked by the given ID")
 	listCmd.Flags().BoolVar(&flagHasBlockers, "has-blockers", false, "Show only items with unresolved blockers")
 	listCmd.Flags().BoolVar(&flagNoBlockers, "no-blockers", false, "Show only items with no blockers")
+	listCmd.Flags().BoolVar(&flagListEmpty, "empty", false, "Show only epics with no children")
 	listCmd.Flags().StringArrayVarP(&flagFilterLabels, "label", "l", nil, "Filter by label (can be repeated, AND logic)")
+	listCmd.Flags().StringVar(&flagFilterTag, "tag", "", "Filter by tag (across projects unless -p is set)")
+	listCmd.Flags().StringVar(&flagListPriority, "priority", "", "Filter by priority: high, medium, low (or 1-3)")
+	listCmd.Flags().StringVar(&flagListMinPriority, "min-priority", "", "Show items at this priority or more urgent (e.g. medium: high and medium)")
+	listCmd.Flags().StringVar(&flagListSort, "sort", "priority", "Sort by priority, created, updated, or status")
+	listCmd.Flags().BoolVar(&flagListReverse, "reverse", false, "Reverse the sort order")
+	listCmd.Flags().BoolVar(&flagIncludeArchived, "include-archived", false, "Include archived items")
+	listCmd.Flags().BoolVar(&flagIncludeSnoozed, "include-snoozed", false, "Include items snoozed into the future")
+	listCmd.Flags().IntVar(&flagListLimit, "limit", 100, "Maximum number of items to show")
+	listCmd.Flags().IntVar(&flagListOffset, "offset", 0, "Number of items to skip")
+	listCmd.Flags().BoolVar(&flagListAll, "all", false, "Show every matching item (ignores --limit)")
+	listCmd.Flags().StringVar(&flagListSince, "since", "", "Only items created on or after: YYYY-MM-DD or a lookback like 7d, 2w")
+	listCmd.Flags().StringVar(&flagListUntil, "until", "", "Only items created on or before: YYYY-MM-DD (inclusive) or a lookback like 1d")
+	listCmd.Flags().BoolVar(&flagListPlan, "plan", false, "Order as an execution plan: dependencies first, then by priority")
+	listCmd.Flags().StringVar(&flagListBy, "by", "created", "Timestamp --since and --until apply to: created or updated")
 
 	// onboard flags
 	onboardCmd.Flags().BoolVar(&flagForce, "force", false, "Replace existing Task Tracking section")
@@ -2013,9 +5677,15 @@ func init() {
 
 	// ready flags
 	readyCmd.Flags().StringArrayVarP(&flagFilterLabels, "label", "l", nil, "Filter by label (can be repeated, AND logic)")
+	readyCmd.Flags().BoolVar(&flagReadyWatch, "watch", false, "Redraw the list whenever it changes")
+	readyCmd.Flags().DurationVar(&flagReadyInterval, "interval", 2*time.Second, "Polling interval for --watch")
+	readyCmd.Flags().BoolVar(&flagReadyRanked, "ranked", false, "Rank by a score of priority, age and dependents, shown as a column")
+	readyCmd.Flags().BoolVar(&flagReadyParallel, "parallel", false, "Group ready tasks into sets that share no unfinished dependencies")
+	readyCmd.Flags().IntVar(&flagReadyTop, "top", 0, "Show only the N highest-priority ready tasks (0 for all)")
 
 	// status flags
 	statusCmd.Flags().BoolVar(&flagStatusAll, "all", false, "Show all ready tasks (default: limit to 10)")
+	statusCmd.Flags().BoolVar(&flagStatusJSON, "json", false, "Output as JSON for machine processing")
 	statusCmd.Flags().StringArrayVarP(&flagFilterLabels, "label", "l", nil, "Filter by label (can be repeated, AND logic)")
 
 	// learn flags
@@ -2042,6 +5712,10 @@ func init() {
 	conceptsCmd.Flags().StringVar(&flagConceptsRename, "rename", "", "Rename concept (requires concept name as argument)")
 	conceptsCmd.Flags().BoolVar(&flagConceptsStats, "stats", false, "Show statistics (count and oldest learning age)")
 
+	// undep flags
+	undepCmd.Flags().StringVar(&flagUndepOn, "on", "", "ID of the task to stop depending on")
+	_ = undepCmd.MarkFlagRequired("on")
+
 	// labels flags
 	labelsAddCmd.Flags().StringVar(&flagLabelsColor, "color", "", "Label color (hex, e.g. #ff0000)")
 
@@ -2050,6 +5724,25 @@ func init() {
 	labelsCmd.AddCommand(labelsRmCmd)
 	labelsCmd.AddCommand(labelsRenameCmd)
 
+	// link flags
+	for _, c := range []*cobra.Command{linkCmd, unlinkCmd} {
+		c.Flags().StringVar(&flagLinkKind, "kind", "relates", "Link kind: "+strings.Join(model.LinkKinds, ", "))
+	}
+
+	// check flags
+	checkCmd.Flags().IntVar(&flagCheckMove, "move", 0, "Move the step to this position")
+
+	// check subcommands
+	checkCmd.AddCommand(checkAddCmd)
+	checkCmd.AddCommand(checkToggleCmd)
+	checkCmd.AddCommand(checkRmCmd)
+
+	// template subcommands
+	templateCmd.AddCommand(templateSaveCmd)
+	templateCmd.AddCommand(templateApplyCmd)
+	templateCmd.AddCommand(templateListCmd)
+	templateCmd.AddCommand(templateRmCmd)
+
 	// context flags
 	contextCmd.Flags().StringArrayVarP(&flagContextConcept, "concept", "c", nil, "Concept to retrieve learnings for (can be repeated)")
 	contextCmd.Flags().StringVarP(&flagContextQuery, "query", "q", "", "Full-text search query")
@@ -2058,6 +5751,15 @@ func init() {
 	contextCmd.Flags().StringVar(&flagContextID, "id", "", "Load specific learning by ID")
 	contextCmd.Flags().BoolVar(&flagContextJSON, "json", false, "Output as JSON for machine processing")
 
+	// export flags
+	exportCmd.Flags().StringVar(&flagExportFormat, "format", "csv", "Output format (csv, md)")
+	exportCmd.Flags().StringVar(&flagExportStatus, "status", "", "Only export items with this status (csv)")
+
+	// confirmation flags
+	for _, c := range []*cobra.Command{deleteCmd, mergeCmd, rmLogCmd, pruneLogsCmd, learnRmCmd, labelsRmCmd} {
+		c.Flags().BoolVarP(&flagYes, "yes", "y", false, "Skip the confirmation prompt")
+	}
+
 	// backup flags
 	backupCmd.Flags().BoolVarP(&flagBackupQuiet, "quiet", "q", false, "Silent backup (no output)")
 
@@ -2065,13 +5767,38 @@ func init() {
 	rootCmd.AddCommand(addCmd)
 	rootCmd.AddCommand(listCmd)
 	rootCmd.AddCommand(readyCmd)
+	rootCmd.AddCommand(blockedCmd)
+	rootCmd.AddCommand(nextCmd)
+	rootCmd.AddCommand(overdueCmd)
+	rootCmd.AddCommand(staleCmd)
+	rootCmd.AddCommand(importCmd)
+	rootCmd.AddCommand(templateCmd)
+	rootCmd.AddCommand(statsCmd)
+	rootCmd.AddCommand(recentCmd)
+	rootCmd.AddCommand(searchCmd)
 	rootCmd.AddCommand(showCmd)
 	rootCmd.AddCommand(startCmd)
 	rootCmd.AddCommand(doneCmd)
 	rootCmd.AddCommand(cancelCmd)
+	rootCmd.AddCommand(undoCmd)
+	rootCmd.AddCommand(historyCmd)
+	rootCmd.AddCommand(bumpCmd)
+	rootCmd.AddCommand(dropCmd)
+	rootCmd.AddCommand(archiveCmd)
+	rootCmd.AddCommand(unarchiveCmd)
+	rootCmd.AddCommand(lockCmd)
+	rootCmd.AddCommand(unlockCmd)
+	rootCmd.AddCommand(snoozeCmd)
+	rootCmd.AddCommand(unsnoozeCmd)
+	rootCmd.AddCommand(reopenCmd)
 	rootCmd.AddCommand(blockCmd)
+	rootCmd.AddCommand(cloneCmd)
 	rootCmd.AddCommand(deleteCmd)
+	rootCmd.AddCommand(mergeCmd)
 	rootCmd.AddCommand(logCmd)
+	rootCmd.AddCommand(rmLogCmd)
+	rootCmd.AddCommand(pruneLogsCmd)
+	rootCmd.AddCommand(timeCmd)
 	rootCmd.AddCommand(statusCmd)
 	rootCmd.AddCommand(projectsCmd)
 	rootCmd.AddCommand(graphCmd)
@@ -2079,10 +5806,26 @@ func init() {
 	rootCmd.AddCommand(descCmd)
 	rootCmd.AddCommand(editCmd)
 	rootCmd.AddCommand(parentCmd)
+	rootCmd.AddCommand(epicCmd)
 	rootCmd.AddCommand(projectCmd)
+	projectCmd.AddCommand(projectRenameCmd)
+	epicCmd.AddCommand(epicReprioritizeCmd)
+	projectCmd.AddCommand(projectWIPCmd)
+	projectCmd.AddCommand(projectAutoCloseCmd)
+	projectCmd.AddCommand(projectUseCmd)
+	rootCmd.AddCommand(mvCmd)
+	rootCmd.AddCommand(childrenCmd)
+	rootCmd.AddCommand(treeCmd)
 	rootCmd.AddCommand(blocksCmd)
+	rootCmd.AddCommand(undepCmd)
+	rootCmd.AddCommand(linkCmd)
+	rootCmd.AddCommand(unlinkCmd)
 	rootCmd.AddCommand(labelCmd)
 	rootCmd.AddCommand(unlabelCmd)
+	rootCmd.AddCommand(tagCmd)
+	rootCmd.AddCommand(untagCmd)
+	rootCmd.AddCommand(noteCmd)
+	rootCmd.AddCommand(checkCmd)
 	rootCmd.AddCommand(learnCmd)
 	rootCmd.AddCommand(conceptsCmd)
 	rootCmd.AddCommand(labelsCmd)
@@ -2091,49 +5834,288 @@ func init() {
 	rootCmd.AddCommand(compactCmd)
 	rootCmd.AddCommand(onboardCmd)
 	rootCmd.AddCommand(tuiCmd)
+	rootCmd.AddCommand(serveCmd)
 	rootCmd.AddCommand(backupCmd)
 	rootCmd.AddCommand(backupsCmd)
 	rootCmd.AddCommand(restoreCmd)
+	rootCmd.AddCommand(dumpCmd)
+	rootCmd.AddCommand(loadCmd)
+	rootCmd.AddCommand(doctorCmd)
+	rootCmd.AddCommand(exportCmd)
+
+	// Complete item ids from the database
+	for _, c := range []*cobra.Command{startCmd, doneCmd, blockCmd, blocksCmd, tagCmd, untagCmd, linkCmd, unlinkCmd} {
+		c.ValidArgsFunction = completeItemIDs
+	}
+	for _, c := range []*cobra.Command{
+		showCmd, bumpCmd, dropCmd, archiveCmd, lockCmd, unlockCmd, snoozeCmd, unsnoozeCmd, cancelCmd,
+		cloneCmd, deleteCmd, logCmd, timeCmd, appendCmd, editCmd, descCmd,
+		parentCmd, childrenCmd, mvCmd, undepCmd, labelCmd, unlabelCmd, noteCmd,
+		historyCmd, checkCmd, checkAddCmd, checkToggleCmd, checkRmCmd,
+	} {
+		c.ValidArgsFunction = completeFirstItemID
+	}
+	for _, f := range []struct {
+		cmd  *cobra.Command
+		name string
+	}{{addCmd, "parent"}, {addCmd, "depends-on"}, {blockCmd, "on"}, {parentCmd, "to"}, {undepCmd, "on"}} {
+		_ = f.cmd.RegisterFlagCompletionFunc(f.name, completeItemIDs)
+	}
+}
+
+// Exit statuses, so scripts can branch on $? without parsing output.
+const (
+	exitError    = 1 // any other failure
+	exitNothing  = 2 // the command worked but found nothing (e.g. no ready tasks)
+	exitNotFound = 3 // a named item doesn't exist
+)
+
+// exitCode is returned by a command whose result, rather than a failure,
+// sets the exit status. main exits with it without printing anything.
+type exitCode int
+
+func (c exitCode) Error() string {
+	return fmt.Sprintf("exit status %d", int(c))
+}
+
+// quietExit makes cmd end with code once its output is written, without
+// cobra reporting an error or printing usage.
+func quietExit(cmd *cobra.Command, code int) error {
+	cmd.SilenceErrors = true
+	cmd.SilenceUsage = true
+	return exitCode(code)
+}
+
+// exitStatus maps the error a command returned to the process exit status.
+func exitStatus(err error) int {
+	var code exitCode
+	switch {
+	case errors.As(err, &code):
+		return int(code)
+	case errors.Is(err, db.ErrNotFound):
+		return exitNotFound
+	}
+	return exitError
 }
 
 func main() {
-	if err := rootCmd.Execute(); err != nil {
-		fmt.Fprintln(os.Stderr, err)
-		os.Exit(1)
+	if cmd, err := rootCmd.ExecuteC(); err != nil {
+		var code exitCode
+		if !errors.As(err, &code) {
+			fmt.Fprintln(os.Stderr, err)
+		}
+		if hint := errorHint(cmd, err); hint != "" {
+			fmt.Fprintln(os.Stderr, hint)
+		}
+		os.Exit(exitStatus(err))
+	}
+}
+
+// describeLink phrases a link as seen from the item with id, e.g.
+// "duplicates ts-a1b2c3" or "duplicated by ts-a1b2c3".
+func describeLink(l model.Link, id string) string {
+	if l.FromID == id {
+		if l.Kind == "relates" {
+			return "relates to " + l.ToID
+		}
+		return l.Kind + " " + l.ToID
+	}
+	switch l.Kind {
+	case "duplicates":
+		return "duplicated by " + l.FromID
+	case "blocks":
+		return "blocked by " + l.FromID
+	default:
+		return "relates to " + l.FromID
+	}
+}
+
+// errorHint returns what to print after err from cmd to help the user past
+// it, or "" if there's nothing to add. The db package leaves out advice that
+// names CLI flags or commands, so it is added here.
+func errorHint(cmd *cobra.Command, err error) string {
+	canForce := cmd != nil && cmd.Flags().Lookup("force") != nil
+	switch {
+	case errors.Is(err, db.ErrCorrupt):
+		return unopenableHint
+	case db.IsCorrupt(err):
+		return corruptHint
+	case errors.Is(err, db.ErrLocked) && canForce:
+		return "Use --force to override, or 'prog unlock <id>' to allow changes again."
+	case errors.Is(err, db.ErrLocked):
+		return "Use 'prog unlock <id>' to allow changes again."
+	case errors.Is(err, db.ErrTransition) && canForce:
+		return "Use --force to override."
 	}
+	return ""
 }
 
+// corruptHint follows errors caused by damage found in a database file that
+// still opens, which 'prog doctor' can inspect.
+const corruptHint = `The database file looks damaged. Run 'prog doctor' to check it, or
+'prog backups' and 'prog restore <path>' to go back to a backup.`
+
+// unopenableHint follows errors from a database file too damaged to open, or
+// not a database at all. Every command, doctor included, fails the same way,
+// so only a backup helps.
+const unopenableHint = `The database file can't be opened. Run 'prog backups' and
+'prog restore <path>' to go back to a backup.`
+
 // Output formatting
 
-func printItemsTable(items []model.Item) {
+func printItemsTable(out io.Writer, items []model.Item) {
 	if len(items) == 0 {
-		fmt.Println("No items")
+		fmt.Fprintln(out, "No items")
 		return
 	}
 
-	fmt.Printf("%-12s %-12s %-4s %s\n", "ID", "STATUS", "PRI", "TITLE")
+	printItemColumns(out, items, defaultListColumns, true)
+}
+
+// listColumn is a column of the item table that --columns can select.
+type listColumn struct {
+	header string
+	width  int // minimum width when padded
+	value  func(item model.Item) string
+}
+
+// listColumns are the selectable columns by name. The title column also
+// shows labels unless the labels column is selected.
+var listColumns = map[string]listColumn{
+	"id":     {"ID", 12, func(item model.Item) string { return item.ID }},
+	"status": {"STATUS", 12, func(item model.Item) string { return string(item.Status) }},
+	"pri":    {"PRI", 6, func(item model.Item) string { return model.PriorityName(item.Priority) }},
+	"type":   {"TYPE", 4, func(item model.Item) string { return string(item.Type) }},
+	"project": {"PROJECT", 0, func(item model.Item) string {
+		return cmp.Or(item.Project, "-")
+	}},
+	"parent": {"PARENT", 12, func(item model.Item) string {
+		if item.ParentID == nil {
+			return "-"
+		}
+		return *item.ParentID
+	}},
+	"created": {"CREATED", 10, func(item model.Item) string { return item.CreatedAt.Local().Format("2006-01-02") }},
+	"updated": {"UPDATED", 10, func(item model.Item) string { return item.UpdatedAt.Local().Format("2006-01-02") }},
+	"due": {"DUE", 10, func(item model.Item) string {
+		if item.DueAt == nil {
+			return "-"
+		}
+		return item.DueAt.Local().Format("2006-01-02")
+	}},
+	"labels": {"LABELS", 0, func(item model.Item) string { return cmp.Or(strings.Join(item.Labels, ","), "-") }},
+	"tags":   {"TAGS", 0, func(item model.Item) string { return cmp.Or(strings.Join(item.Tags, ","), "-") }},
+	"title":  {"TITLE", 0, func(item model.Item) string { return item.Title }},
+}
+
+// listColumnOrder lists the column names in the order help text shows them.
+var listColumnOrder = []string{"id", "status", "pri", "type", "project", "parent", "created", "updated", "due", "labels", "tags", "title"}
+
+// defaultListColumns are the columns the item table has always shown.
+var defaultListColumns = []string{"id", "status", "pri", "title"}
+
+// parseListColumns parses a comma-separated --columns value.
+func parseListColumns(spec string) ([]string, error) {
+	var columns []string
+	for name := range strings.SplitSeq(spec, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if _, ok := listColumns[name]; !ok {
+			return nil, fmt.Errorf("unknown column: %q (valid: %s)", name, strings.Join(listColumnOrder, ", "))
+		}
+		columns = append(columns, name)
+	}
+	return columns, nil
+}
+
+// columnValue renders one cell, prefixing titles with labels when the labels
+// column isn't shown.
+func columnValue(item model.Item, name string, columns []string) string {
+	value := listColumns[name].value(item)
+	if name == "title" && len(item.Labels) > 0 && !slices.Contains(columns, "labels") {
+		value = formatLabels(item.Labels) + " " + value
+	}
+	return value
+}
+
+// printItemColumns prints items as a table of the named columns. Every column
+// but the last is padded to its widest value, and status cells are colorized.
+func printItemColumns(out io.Writer, items []model.Item, columns []string, header bool) {
+	cells := make([][]string, len(items))
+	widths := make([]int, len(columns))
+	for j, name := range columns {
+		widths[j] = max(listColumns[name].width, len(listColumns[name].header))
+	}
+	for i, item := range items {
+		cells[i] = make([]string, len(columns))
+		for j, name := range columns {
+			cells[i][j] = columnValue(item, name, columns)
+			widths[j] = max(widths[j], len(cells[i][j]))
+		}
+	}
+
+	last := len(columns) - 1
+	if header {
+		parts := make([]string, len(columns))
+		for j, name := range columns {
+			parts[j] = listColumns[name].header
+			if j < last {
+				parts[j] = fmt.Sprintf("%-*s", widths[j], parts[j])
+			}
+		}
+		fmt.Fprintln(out, strings.Join(parts, " "))
+	}
+	for i, item := range items {
+		parts := make([]string, len(columns))
+		for j, name := range columns {
+			parts[j] = cells[i][j]
+			if j < last {
+				parts[j] = fmt.Sprintf("%-*s", widths[j], parts[j])
+			}
+			if name == "status" {
+				parts[j] = colorize(item.Status, parts[j])
+			}
+		}
+		fmt.Fprintln(out, strings.Join(parts, " "))
+	}
+}
+
+// printItemsCompact prints the named columns of each item on one unpadded,
+// space-separated line, for piping into line-oriented tools.
+func printItemsCompact(out io.Writer, items []model.Item, columns []string) {
 	for _, item := range items {
-		title := item.Title
-		if len(item.Labels) > 0 {
-			title = formatLabels(item.Labels) + " " + title
+		parts := make([]string, len(columns))
+		for j, name := range columns {
+			parts[j] = listColumns[name].value(item)
 		}
-		fmt.Printf("%-12s %-12s %-4d %s\n", item.ID, item.Status, item.Priority, title)
+		fmt.Fprintln(out, strings.Join(parts, " "))
+	}
+}
+
+// formatListFooter describes which slice of total matching items a paged
+// list shows, or returns "" when every match is shown.
+func formatListFooter(offset, shown, total int) string {
+	if shown == total {
+		return ""
+	}
+	if shown == 0 {
+		return fmt.Sprintf("No items past offset %d (%d total)", offset, total)
 	}
+	return fmt.Sprintf("Showing %d-%d of %d", offset+1, offset+shown, total)
 }
 
-func printReadyTable(items []model.Item) {
+func printReadyTable(out io.Writer, items []model.Item) {
 	if len(items) == 0 {
-		fmt.Println("No items")
+		fmt.Fprintln(out, "No items")
 		return
 	}
 
-	fmt.Printf("%-12s %-4s %s\n", "ID", "PRI", "TITLE")
+	fmt.Fprintf(out, "%-12s %-4s %s\n", "ID", "PRI", "TITLE")
 	for _, item := range items {
 		title := item.Title
 		if len(item.Labels) > 0 {
 			title = formatLabels(item.Labels) + " " + title
 		}
-		fmt.Printf("%-12s %-4d %s\n", item.ID, item.Priority, title)
+		fmt.Fprintf(out, "%-12s %-4d %s\n", item.ID, item.Priority, title)
 	}
 }
 
@@ -2149,92 +6131,360 @@ func formatLabels(labels []string) string {
 	return strings.Join(parts, " ")
 }
 
-func printItemDetail(item *model.Item, logs []model.Log, deps []string, concepts []model.Concept) {
-	fmt.Printf("ID:          %s\n", item.ID)
-	fmt.Printf("Type:        %s\n", item.Type)
-	fmt.Printf("Project:     %s\n", item.Project)
-	fmt.Printf("Title:       %s\n", item.Title)
-	fmt.Printf("Status:      %s\n", item.Status)
-	fmt.Printf("Priority:    %d\n", item.Priority)
+// parseStatusFlag validates a --status value. An empty value means no filter.
+func parseStatusFlag(value string) (*model.Status, error) {
+	if value == "" {
+		return nil, nil
+	}
+	s := model.Status(value)
+	if !s.IsValid() {
+		return nil, fmt.Errorf("invalid status: %s (valid: open, in_progress, blocked, done, canceled)", value)
+	}
+	return &s, nil
+}
+
+// parseDate parses an absolute date (YYYY-MM-DD, local time) or a relative
+// offset from now such as +3d, +2w, or +12h.
+func parseDate(value string, now time.Time) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if strings.HasPrefix(value, "+") && len(value) > 2 {
+		n, err := strconv.Atoi(value[1 : len(value)-1])
+		if err == nil && n >= 0 {
+			switch value[len(value)-1] {
+			case 'h':
+				return now.Add(time.Duration(n) * time.Hour), nil
+			case 'd':
+				return now.AddDate(0, 0, n), nil
+			case 'w':
+				return now.AddDate(0, 0, 7*n), nil
+			}
+		}
+	}
+	if t, err := time.ParseInLocation("2006-01-02", value, time.Local); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("invalid date: %s (use YYYY-MM-DD or +Nd, +Nw, +Nh)", value)
+}
+
+// parseSince parses an absolute date (YYYY-MM-DD, local time) or a lookback
+// from now such as 8w or 30d.
+func parseSince(value string, now time.Time) (time.Time, error) {
+	t, _, err := parseDateBound("--since", value, now)
+	return t, err
+}
+
+// parseUntil parses an end bound like parseSince. A YYYY-MM-DD date includes
+// that whole day, so it yields the following midnight.
+func parseUntil(value string, now time.Time) (time.Time, error) {
+	t, isDate, err := parseDateBound("--until", value, now)
+	if isDate {
+		t = t.AddDate(0, 0, 1)
+	}
+	return t, err
+}
+
+// parseDateBound parses an absolute date or lookback for flag, reporting
+// whether value was an absolute date.
+func parseDateBound(flag, value string, now time.Time) (time.Time, bool, error) {
+	value = strings.TrimSpace(value)
+	if len(value) > 1 && value[0] >= '0' && value[0] <= '9' {
+		n, err := strconv.Atoi(value[:len(value)-1])
+		if err == nil && n >= 0 {
+			switch value[len(value)-1] {
+			case 'd':
+				return now.AddDate(0, 0, -n), false, nil
+			case 'w':
+				return now.AddDate(0, 0, -7*n), false, nil
+			}
+		}
+	}
+	if t, err := time.ParseInLocation("2006-01-02", value, time.Local); err == nil {
+		return t, true, nil
+	}
+	return time.Time{}, false, fmt.Errorf("invalid %s: %s (use YYYY-MM-DD or Nw, Nd)", flag, value)
+}
+
+// parseAge parses a length of time for flag: Nd or Nw, or a Go duration
+// such as 12h or 90m.
+func parseAge(flag, value string) (time.Duration, error) {
+	value = strings.TrimSpace(value)
+	if len(value) > 1 {
+		if n, err := strconv.Atoi(value[:len(value)-1]); err == nil && n >= 0 {
+			switch value[len(value)-1] {
+			case 'd':
+				return time.Duration(n) * 24 * time.Hour, nil
+			case 'w':
+				return time.Duration(n) * 7 * 24 * time.Hour, nil
+			}
+		}
+	}
+	if d, err := time.ParseDuration(value); err == nil && d >= 0 {
+		return d, nil
+	}
+	return 0, fmt.Errorf("invalid %s: %s (use Nd, Nw, or a duration like 12h)", flag, value)
+}
+
+// printCompletionStats prints one bar per week followed by the total and
+// weekly average.
+func printCompletionStats(out io.Writer, weeks []db.WeekCount) {
+	total := 0
+	for _, w := range weeks {
+		total += w.Count
+	}
+	if total == 0 {
+		fmt.Fprintln(out, "No tasks completed in this period")
+		return
+	}
+
+	fmt.Fprintln(out, "Completed per week:")
+	for _, w := range weeks {
+		line := fmt.Sprintf("  %s  %3d %s", w.Start.Format("2006-01-02"), w.Count, strings.Repeat("#", w.Count))
+		fmt.Fprintln(out, strings.TrimRight(line, " "))
+	}
+	fmt.Fprintf(out, "\nTotal: %d over %d weeks (avg %.1f/week)\n",
+		total, len(weeks), float64(total)/float64(len(weeks)))
+}
+
+// itemDetail holds everything 'prog show' renders for a single item.
+type itemDetail struct {
+	item          *model.Item
+	logs          []model.Log
+	notes         []model.Note
+	checklist     []model.ChecklistItem
+	deps          []string
+	dependents    []string // items that depend on this one
+	links         []model.Link
+	concepts      []model.Concept
+	childrenDone  int // epics only
+	childrenTotal int // epics only
+	absolute      bool
+}
+
+func printItemDetail(out io.Writer, d itemDetail) {
+	item := d.item
+	fmt.Fprintf(out, "ID:          %s\n", item.ID)
+	fmt.Fprintf(out, "Type:        %s\n", item.Type)
+	fmt.Fprintf(out, "Project:     %s\n", item.Project)
+	fmt.Fprintf(out, "Title:       %s\n", item.Title)
+	var marks []string
+	if item.Archived {
+		marks = append(marks, "archived")
+	}
+	if item.Locked {
+		marks = append(marks, "locked")
+	}
+	if len(marks) > 0 {
+		fmt.Fprintf(out, "Status:      %s (%s)\n", item.Status, strings.Join(marks, ", "))
+	} else {
+		fmt.Fprintf(out, "Status:      %s\n", item.Status)
+	}
+	if item.BlockReason != "" {
+		fmt.Fprintf(out, "Blocked by:  %s\n", item.BlockReason)
+	}
+	fmt.Fprintf(out, "Priority:    %d\n", item.Priority)
 	if item.ParentID != nil {
-		fmt.Printf("Parent:      %s\n", *item.ParentID)
+		fmt.Fprintf(out, "Parent:      %s\n", *item.ParentID)
 	}
 	if len(item.Labels) > 0 {
-		fmt.Printf("Labels:      %s\n", strings.Join(item.Labels, ", "))
+		fmt.Fprintf(out, "Labels:      %s\n", strings.Join(item.Labels, ", "))
+	}
+	if len(item.Tags) > 0 {
+		fmt.Fprintf(out, "Tags:        %s\n", strings.Join(item.Tags, ", "))
+	}
+	if item.DueAt != nil {
+		if d.absolute {
+			fmt.Fprintf(out, "Due:         %s\n", item.DueAt.Format("2006-01-02"))
+		} else {
+			fmt.Fprintf(out, "Due:         %s (%s)\n", item.DueAt.Format("2006-01-02"), humanizeTime(*item.DueAt))
+		}
+	}
+	if item.SnoozedUntil != nil && time.Until(*item.SnoozedUntil) > 0 {
+		if d.absolute {
+			fmt.Fprintf(out, "Snoozed:     until %s\n", item.SnoozedUntil.Local().Format(time.RFC3339))
+		} else {
+			fmt.Fprintf(out, "Snoozed:     until %s (%s)\n", item.SnoozedUntil.Local().Format("2006-01-02 15:04"), humanizeTime(*item.SnoozedUntil))
+		}
+	}
+	if item.Recurrence != "" {
+		fmt.Fprintf(out, "Repeats:     %s\n", item.Recurrence)
+	}
+	if item.EstimateMinutes > 0 || item.ActualMinutes > 0 {
+		fmt.Fprintf(out, "Effort:      %s\n", formatEffort(item.EstimateMinutes, item.ActualMinutes))
+	}
+	if item.Type == model.ItemTypeEpic {
+		fmt.Fprintf(out, "Children:    %d/%d done\n", d.childrenDone, d.childrenTotal)
+	}
+	if item.Status == model.StatusDone && item.StartedAt != nil && item.DoneAt != nil {
+		fmt.Fprintf(out, "Cycle time:  %s\n", formatCycleTime(item.DoneAt.Sub(*item.StartedAt)))
+	}
+	if d.absolute {
+		fmt.Fprintf(out, "Created:     %s\n", item.CreatedAt.Format(time.RFC3339))
+		fmt.Fprintf(out, "Updated:     %s\n", item.UpdatedAt.Format(time.RFC3339))
+	} else {
+		fmt.Fprintf(out, "Created:     %s\n", humanizeTime(item.CreatedAt))
+		fmt.Fprintf(out, "Updated:     %s\n", humanizeTime(item.UpdatedAt))
 	}
 
 	if item.Description != "" {
-		fmt.Printf("\nDescription:\n%s\n", item.Description)
+		fmt.Fprintf(out, "\nDescription:\n%s\n", item.Description)
+	}
+
+	if len(d.notes) > 0 {
+		fmt.Fprintf(out, "\nNotes:\n")
+		for _, n := range d.notes {
+			fmt.Fprintf(out, "  %s: %s\n", n.Key, n.Value)
+		}
+	}
+
+	if len(d.checklist) > 0 {
+		done := 0
+		for _, step := range d.checklist {
+			if step.Done {
+				done++
+			}
+		}
+		fmt.Fprintf(out, "\nChecklist (%d/%d done):\n", done, len(d.checklist))
+		printChecklist(out, d.checklist, "  ")
+	}
+
+	if len(d.deps) > 0 {
+		fmt.Fprintf(out, "\nDependencies:\n")
+		for _, dep := range d.deps {
+			fmt.Fprintf(out, "  - %s\n", dep)
+		}
+	}
+
+	if len(d.dependents) > 0 {
+		fmt.Fprintf(out, "\nDependents (blocked by this):\n")
+		for _, id := range d.dependents {
+			fmt.Fprintf(out, "  - %s\n", id)
+		}
 	}
 
-	if len(deps) > 0 {
-		fmt.Printf("\nDependencies:\n")
-		for _, dep := range deps {
-			fmt.Printf("  - %s\n", dep)
+	if len(d.links) > 0 {
+		fmt.Fprintf(out, "\nRelated:\n")
+		for _, l := range d.links {
+			fmt.Fprintf(out, "  - %s\n", describeLink(l, item.ID))
 		}
 	}
 
-	if len(logs) > 0 {
-		fmt.Printf("\nLogs:\n")
-		for _, log := range logs {
-			fmt.Printf("  [%s] %s\n", log.CreatedAt.Format("2006-01-02 15:04"), log.Message)
+	if len(d.logs) > 0 {
+		fmt.Fprintf(out, "\nLogs:\n")
+		for _, log := range d.logs {
+			fmt.Fprintf(out, "  #%d %s\n", log.ID, formatLogEntry(log))
 		}
 	}
 
-	if len(concepts) > 0 {
-		fmt.Printf("\nSuggested context:\n")
+	if len(d.concepts) > 0 {
+		fmt.Fprintf(out, "\nSuggested context:\n")
 		var conceptFlags []string
-		for _, c := range concepts {
+		for _, c := range d.concepts {
 			summary := c.Summary
 			if summary == "" {
 				summary = "(no summary)"
 			}
-			fmt.Printf("  %s (%d) - %s\n", c.Name, c.LearningCount, summary)
+			fmt.Fprintf(out, "  %s (%d) - %s\n", c.Name, c.LearningCount, summary)
 			conceptFlags = append(conceptFlags, "-c "+c.Name)
 		}
-		fmt.Printf("\nLoad with: prog context %s -p %s --summary\n", strings.Join(conceptFlags, " "), item.Project)
+		fmt.Fprintf(out, "\nLoad with: prog context %s -p %s --summary\n", strings.Join(conceptFlags, " "), item.Project)
+	}
+}
+
+// formatEffort renders estimate vs. actual minutes, e.g. "Estimated 400m / Actual 520m".
+func formatEffort(estimate, actual int) string {
+	return fmt.Sprintf("Estimated %dm / Actual %dm", estimate, actual)
+}
+
+// formatBurndown summarizes estimated minutes left against those completed,
+// with the completed share of the total.
+func formatBurndown(remaining, completed int) string {
+	pct := completed * 100 / (remaining + completed)
+	return fmt.Sprintf("%dm remaining / %dm completed (%d%% done)", remaining, completed, pct)
+}
+
+// formatLogEntry renders a log as "[2024-01-02 15:04] (source) message",
+// omitting the parenthetical when the source is empty.
+func formatLogEntry(log model.Log) string {
+	ts := log.CreatedAt.Format("2006-01-02 15:04")
+	if log.Source == "" {
+		return fmt.Sprintf("[%s] %s", ts, log.Message)
 	}
+	return fmt.Sprintf("[%s] (%s) %s", ts, log.Source, log.Message)
 }
 
-func printStatusReport(report *db.StatusReport, showAll bool) {
+func printStatusReport(out io.Writer, report *db.StatusReport, showAll bool) {
 	project := report.Project
 	if project == "" {
 		project = "(all)"
 	}
-	fmt.Printf("Project: %s\n\n", project)
-
-	fmt.Printf("Summary: %d open, %d in progress, %d blocked, %d done, %d canceled (%d ready)\n\n",
-		report.Open, report.InProgress, report.Blocked, report.Done, report.Canceled, report.Ready)
+	fmt.Fprintf(out, "Project: %s\n\n", project)
+
+	fmt.Fprintf(out, "Summary: %d open, %s, %s, %s, %d canceled (%d ready)\n",
+		report.Open,
+		colorize(model.StatusInProgress, fmt.Sprintf("%d in progress", report.InProgress)),
+		colorize(model.StatusBlocked, fmt.Sprintf("%d blocked", report.Blocked)),
+		colorize(model.StatusDone, fmt.Sprintf("%d done", report.Done)),
+		report.Canceled, report.Ready)
+	if report.WIPLimit > 0 {
+		wip := fmt.Sprintf("WIP: %d/%d in progress", report.InProgress, report.WIPLimit)
+		if report.InProgress > report.WIPLimit {
+			wip = colorize(model.StatusBlocked, wip+" (over limit)")
+		}
+		fmt.Fprintln(out, wip)
+	}
+	if report.Stale > 0 {
+		fmt.Fprintln(out, colorize(model.StatusBlocked, fmt.Sprintf("Stale: %d in progress with no update for %d+ days (see prog stale)",
+			report.Stale, int(db.StaleAfter.Hours()/24))))
+	}
+	if report.EstimateMinutes > 0 || report.ActualMinutes > 0 {
+		fmt.Fprintf(out, "Effort: %s\n", formatEffort(report.EstimateMinutes, report.ActualMinutes))
+	}
+	if report.RemainingEstimate > 0 || report.CompletedEstimate > 0 {
+		fmt.Fprintf(out, "Burndown: %s\n", formatBurndown(report.RemainingEstimate, report.CompletedEstimate))
+	}
+	fmt.Fprintln(out)
 
 	// Show project in output when viewing all projects
 	showProject := report.Project == ""
 
 	if len(report.RecentDone) > 0 {
-		fmt.Println("Recently completed:")
+		fmt.Fprintln(out, colorize(model.StatusDone, "Recently completed:"))
 		for _, item := range report.RecentDone {
-			fmt.Printf("  %s\n", formatStatusItem(item, showProject, false))
+			fmt.Fprintf(out, "  %s\n", formatStatusItem(item, showProject, false))
 		}
-		fmt.Println()
+		fmt.Fprintln(out)
 	}
 
 	if len(report.InProgItems) > 0 {
-		fmt.Println("In progress:")
+		fmt.Fprintln(out, colorize(model.StatusInProgress, "In progress:"))
 		for _, item := range report.InProgItems {
-			fmt.Printf("  %s\n", formatStatusItem(item, showProject, false))
+			fmt.Fprintf(out, "  %s\n", formatStatusItem(item, showProject, false))
+		}
+		fmt.Fprintln(out)
+	}
+
+	if len(report.Epics) > 0 {
+		fmt.Fprintln(out, "Epics:")
+		for _, e := range report.Epics {
+			fmt.Fprintf(out, "  %s (%d/%d done)\n", formatStatusItem(e.Epic, showProject, false), e.Done, e.Total)
 		}
-		fmt.Println()
+		fmt.Fprintln(out)
 	}
 
 	if len(report.BlockedItems) > 0 {
-		fmt.Println("Blocked:")
+		fmt.Fprintln(out, colorize(model.StatusBlocked, "Blocked:"))
 		for _, item := range report.BlockedItems {
-			fmt.Printf("  %s\n", formatStatusItem(item, showProject, false))
+			if item.BlockReason != "" {
+				fmt.Fprintf(out, "  %s: %s\n", formatStatusItem(item, showProject, false), item.BlockReason)
+			} else {
+				fmt.Fprintf(out, "  %s\n", formatStatusItem(item, showProject, false))
+			}
 		}
-		fmt.Println()
+		fmt.Fprintln(out)
 	}
 
 	if len(report.ReadyItems) > 0 {
-		fmt.Println("Ready for work:")
+		fmt.Fprintln(out, "Ready for work:")
 		readyLimit := 10
 		displayItems := report.ReadyItems
 		remaining := 0
@@ -2243,10 +6493,10 @@ func printStatusReport(report *db.StatusReport, showAll bool) {
 			remaining = len(report.ReadyItems) - readyLimit
 		}
 		for _, item := range displayItems {
-			fmt.Printf("  %s\n", formatStatusItem(item, showProject, true))
+			fmt.Fprintf(out, "  %s\n", formatStatusItem(item, showProject, true))
 		}
 		if remaining > 0 {
-			fmt.Printf("  (+%d more, use --all to see all)\n", remaining)
+			fmt.Fprintf(out, "  (+%d more, use --all to see all)\n", remaining)
 		}
 	}
 }
@@ -2267,26 +6517,26 @@ func formatStatusItem(item model.Item, showProject, showPriority bool) string {
 	return strings.Join(parts, " ")
 }
 
-func printConceptsTable(concepts []model.Concept) {
-	fmt.Printf("%-20s %10s  %-12s  %s\n", "NAME", "LEARNINGS", "LAST UPDATED", "SUMMARY")
+func printConceptsTable(out io.Writer, concepts []model.Concept) {
+	fmt.Fprintf(out, "%-20s %10s  %-12s  %s\n", "NAME", "LEARNINGS", "LAST UPDATED", "SUMMARY")
 	for _, c := range concepts {
-		ago := formatTimeAgo(c.LastUpdated)
+		ago := humanizeTime(c.LastUpdated)
 		summary := c.Summary
 		if len(summary) > 40 {
 			summary = summary[:37] + "..."
 		}
-		fmt.Printf("%-20s %10d  %-12s  %s\n", c.Name, c.LearningCount, ago, summary)
+		fmt.Fprintf(out, "%-20s %10d  %-12s  %s\n", c.Name, c.LearningCount, ago, summary)
 	}
 }
 
-func printConceptsStats(stats []db.ConceptStats) {
-	fmt.Printf("%-20s %6s  %s\n", "CONCEPT", "COUNT", "OLDEST")
+func printConceptsStats(out io.Writer, stats []db.ConceptStats) {
+	fmt.Fprintf(out, "%-20s %6s  %s\n", "CONCEPT", "COUNT", "OLDEST")
 	for _, s := range stats {
 		oldest := "-"
 		if s.OldestAge != nil {
 			oldest = formatDurationShort(*s.OldestAge)
 		}
-		fmt.Printf("%-20s %6d  %s\n", s.Name, s.LearningCount, oldest)
+		fmt.Fprintf(out, "%-20s %6d  %s\n", s.Name, s.LearningCount, oldest)
 	}
 }
 
@@ -2302,22 +6552,40 @@ func formatDurationShort(d time.Duration) string {
 	return "<1h"
 }
 
-func printLabelsTable(labels []model.Label) {
-	fmt.Printf("%-20s  %-12s  %s\n", "NAME", "CREATED", "COLOR")
+// formatCycleTime renders how long a task was worked on, as "2d3h", "3h42m"
+// or "15m".
+func formatCycleTime(d time.Duration) string {
+	d = d.Round(time.Minute)
+	days := int(d.Hours()) / 24
+	hours := int(d.Hours()) % 24
+	minutes := int(d.Minutes()) % 60
+	switch {
+	case days > 0:
+		return fmt.Sprintf("%dd%dh", days, hours)
+	case hours > 0:
+		return fmt.Sprintf("%dh%dm", hours, minutes)
+	case minutes > 0:
+		return fmt.Sprintf("%dm", minutes)
+	}
+	return "<1m"
+}
+
+func printLabelsTable(out io.Writer, labels []model.Label) {
+	fmt.Fprintf(out, "%-20s  %-12s  %s\n", "NAME", "CREATED", "COLOR")
 	for _, l := range labels {
-		ago := formatTimeAgo(l.CreatedAt)
+		ago := humanizeTime(l.CreatedAt)
 		color := l.Color
 		if color == "" {
 			color = "-"
 		}
-		fmt.Printf("%-20s  %-12s  %s\n", l.Name, ago, color)
+		fmt.Fprintf(out, "%-20s  %-12s  %s\n", l.Name, ago, color)
 	}
 }
 
-func printLearnings(learnings []model.Learning) {
+func printLearnings(out io.Writer, learnings []model.Learning) {
 	for i, l := range learnings {
 		if i > 0 {
-			fmt.Println()
+			fmt.Fprintln(out)
 		}
 
 		// Header with ID, status, and age
@@ -2325,25 +6593,25 @@ func printLearnings(learnings []model.Learning) {
 		if l.Status == model.LearningStatusStale {
 			status = " [stale]"
 		}
-		fmt.Printf("## %s%s (%s)\n", l.ID, status, formatTimeAgo(l.CreatedAt))
+		fmt.Fprintf(out, "## %s%s (%s)\n", l.ID, status, humanizeTime(l.CreatedAt))
 
 		// Summary
-		fmt.Println(l.Summary)
+		fmt.Fprintln(out, l.Summary)
 
 		// Detail if present
 		if l.Detail != "" {
-			fmt.Printf("\n%s\n", l.Detail)
+			fmt.Fprintf(out, "\n%s\n", l.Detail)
 		}
 
 		// Metadata
 		if len(l.Concepts) > 0 {
-			fmt.Printf("\nConcepts: %s\n", strings.Join(l.Concepts, ", "))
+			fmt.Fprintf(out, "\nConcepts: %s\n", strings.Join(l.Concepts, ", "))
 		}
 		if len(l.Files) > 0 {
-			fmt.Printf("Files: %s\n", strings.Join(l.Files, ", "))
+			fmt.Fprintf(out, "Files: %s\n", strings.Join(l.Files, ", "))
 		}
 		if l.TaskID != nil {
-			fmt.Printf("Task: %s\n", *l.TaskID)
+			fmt.Fprintf(out, "Task: %s\n", *l.TaskID)
 		}
 	}
 }
@@ -2359,7 +6627,7 @@ type LearningJSON struct {
 	Status    string   `json:"status"`
 }
 
-func printLearningsJSON(learnings []model.Learning) error {
+func printLearningsJSON(out io.Writer, learnings []model.Learning) error {
 	output := make([]LearningJSON, 0, len(learnings))
 	for _, l := range learnings {
 		lj := LearningJSON{
@@ -2380,21 +6648,31 @@ func printLearningsJSON(learnings []model.Learning) error {
 	if err != nil {
 		return fmt.Errorf("failed to marshal JSON: %w", err)
 	}
-	fmt.Println(string(b))
+	fmt.Fprintln(out, string(b))
 	return nil
 }
 
-func printLearningSummaries(learnings []model.Learning, requestedConcepts []string, conceptSummaries map[string]string) {
+// writeStatusJSON writes report as indented JSON (see api.NewStatusJSON).
+func writeStatusJSON(out io.Writer, report *db.StatusReport) error {
+	b, err := json.MarshalIndent(api.NewStatusJSON(report), "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal JSON: %w", err)
+	}
+	_, err = fmt.Fprintln(out, string(b))
+	return err
+}
+
+func printLearningSummaries(out io.Writer, learnings []model.Learning, requestedConcepts []string, conceptSummaries map[string]string) {
 	// Print concept headers with summaries
 	for _, conceptName := range requestedConcepts {
 		summary := conceptSummaries[conceptName]
 		if summary == "" {
 			summary = "(no summary)"
 		}
-		fmt.Printf("%s: %s\n", conceptName, summary)
+		fmt.Fprintf(out, "%s: %s\n", conceptName, summary)
 	}
 	if len(requestedConcepts) > 0 {
-		fmt.Println()
+		fmt.Fprintln(out)
 	}
 
 	// Print one-liner per learning
@@ -2403,11 +6681,11 @@ func printLearningSummaries(learnings []model.Learning, requestedConcepts []stri
 		if l.Status == model.LearningStatusStale {
 			status = " [stale]"
 		}
-		fmt.Printf("  %s: %s%s\n", l.ID, l.Summary, status)
+		fmt.Fprintf(out, "  %s: %s%s\n", l.ID, l.Summary, status)
 	}
 }
 
-func printAllLearningSummaries(learnings []model.Learning, conceptSummaries map[string]string) {
+func printAllLearningSummaries(out io.Writer, learnings []model.Learning, conceptSummaries map[string]string) {
 	// Group learnings by concept
 	type conceptGroup struct {
 		summary   string
@@ -2431,42 +6709,107 @@ func printAllLearningSummaries(learnings []model.Learning, conceptSummaries map[
 	// Print grouped by concept
 	for i, conceptName := range conceptOrder {
 		if i > 0 {
-			fmt.Println()
+			fmt.Fprintln(out)
 		}
 		group := groups[conceptName]
 		summary := group.summary
 		if summary == "" {
 			summary = "(no summary)"
 		}
-		fmt.Printf("%s: %s\n", conceptName, summary)
+		fmt.Fprintf(out, "%s: %s\n", conceptName, summary)
 
 		for _, l := range group.learnings {
 			status := ""
 			if l.Status == model.LearningStatusStale {
 				status = " [stale]"
 			}
-			fmt.Printf("  %s: %s%s\n", l.ID, l.Summary, status)
+			fmt.Fprintf(out, "  %s: %s%s\n", l.ID, l.Summary, status)
 		}
 	}
 }
 
-func formatTimeAgo(t time.Time) string {
-	d := time.Since(t)
+// humanizeTime describes t relative to now, e.g. "2 hours ago" or "in 3 days".
+func humanizeTime(t time.Time) string {
+	d := time.Until(t)
+	future := d > 0
+	if !future {
+		d = -d
+	}
+	// Round so a due date "+3d" reads "in 3 days" rather than "in 2 days"
+	// a moment after it was set.
+	d = d.Round(time.Minute)
+
+	var amount string
 	switch {
 	case d < time.Minute:
 		return "just now"
 	case d < time.Hour:
-		return fmt.Sprintf("%dm ago", int(d.Minutes()))
+		amount = pluralize(int(d.Minutes()), "minute")
 	case d < 24*time.Hour:
-		return fmt.Sprintf("%dh ago", int(d.Hours()))
-	case d < 7*24*time.Hour:
-		return fmt.Sprintf("%dd ago", int(d.Hours()/24))
+		amount = pluralize(int(d.Hours()), "hour")
+	case d < 30*24*time.Hour:
+		amount = pluralize(int(d.Hours()/24), "day")
+	case d < 365*24*time.Hour:
+		amount = pluralize(int(d.Hours()/(24*30)), "month")
 	default:
-		return t.Format("2006-01-02")
+		amount = pluralize(int(d.Hours()/(24*365)), "year")
+	}
+
+	if future {
+		return "in " + amount
+	}
+	return amount + " ago"
+}
+
+func pluralize(n int, unit string) string {
+	if n == 1 {
+		return "1 " + unit
+	}
+	return fmt.Sprintf("%d %ss", n, unit)
+}
+
+// writeDepGraphDOT renders dependency edges as a Graphviz digraph.
+func writeDepGraphDOT(out io.Writer, edges []db.DepEdge) {
+	fmt.Fprintln(out, "digraph deps {")
+	fmt.Fprintln(out, "  rankdir=LR;")
+	fmt.Fprintln(out, "  node [shape=box];")
+
+	seen := make(map[string]bool)
+	node := func(id, title, status string) {
+		if seen[id] {
+			return
+		}
+		seen[id] = true
+		attrs := fmt.Sprintf(`label="%s\n%s"`, dotEscape(id), dotEscape(title))
+		switch model.Status(status) {
+		case model.StatusDone:
+			attrs += ", color=green"
+		case model.StatusBlocked:
+			attrs += ", color=red"
+		}
+		fmt.Fprintf(out, "  %s [%s];\n", dotQuote(id), attrs)
+	}
+	for _, e := range edges {
+		node(e.ItemID, e.ItemTitle, e.ItemStatus)
+		node(e.DependsOnID, e.DependsOnTitle, e.DependsOnStatus)
+	}
+	for _, e := range edges {
+		fmt.Fprintf(out, "  %s -> %s;\n", dotQuote(e.DependsOnID), dotQuote(e.ItemID))
 	}
+	fmt.Fprintln(out, "}")
+}
+
+// dotQuote returns s as a quoted DOT identifier.
+func dotQuote(s string) string {
+	return `"` + dotEscape(s) + `"`
+}
+
+// dotEscape escapes backslashes and quotes for use inside a DOT string.
+func dotEscape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s)
 }
 
-func printDepGraph(edges []db.DepEdge) {
+func printDepGraph(out io.Writer, edges []db.DepEdge) {
 	// Group by item
 	type depInfo struct {
 		title  string
@@ -2486,19 +6829,22 @@ func printDepGraph(edges []db.DepEdge) {
 
 	for _, id := range order {
 		info := items[id]
-		fmt.Printf("%s [%s] %s\n", id, info.status, info.title)
+		fmt.Fprintf(out, "%s [%s] %s\n", id, info.status, info.title)
 		for i, dep := range info.deps {
 			prefix := "├──"
 			if i == len(info.deps)-1 {
 				prefix = "└──"
 			}
-			fmt.Printf("  %s %s [%s] %s\n", prefix, dep.DependsOnID, dep.DependsOnStatus, dep.DependsOnTitle)
+			fmt.Fprintf(out, "  %s %s [%s] %s\n", prefix, dep.DependsOnID, dep.DependsOnStatus, dep.DependsOnTitle)
 		}
 	}
 }
 
-func printPrimeContent(report *db.StatusReport, stats []db.ConceptStats) {
-	fmt.Println(`# Prog CLI Context
+// primeReadyLimit caps ready tasks listed by prime to keep it token-efficient.
+const primeReadyLimit = 5
+
+func printPrimeContent(out io.Writer, report *db.StatusReport, stats []db.ConceptStats) {
+	fmt.Fprintln(out, `# Prog CLI Context
 
 This project uses 'prog' for cross-session task management.
 Run 'prog status' to see current state.
@@ -2618,54 +6964,76 @@ prog ready -p myproject        # Ready in project`)
 	}
 
 	if len(highCount) > 0 || len(oldLearnings) > 0 {
-		fmt.Println(`
+		fmt.Fprintln(out, `
 
 ## Maintenance`)
 
 		if len(highCount) > 0 {
-			fmt.Println("\nConcepts with 5+ learnings (check for redundancy):")
+			fmt.Fprintln(out, "\nConcepts with 5+ learnings (check for redundancy):")
 			for _, c := range highCount {
-				fmt.Printf("  %s (%d learnings)\n", c.Name, c.LearningCount)
+				fmt.Fprintf(out, "  %s (%d learnings)\n", c.Name, c.LearningCount)
 			}
 		}
 
 		if len(oldLearnings) > 0 {
-			fmt.Println("\nConcepts with old learnings (check for staleness):")
+			fmt.Fprintln(out, "\nConcepts with old learnings (check for staleness):")
 			for _, c := range oldLearnings {
 				oldest := formatDurationShort(*c.OldestAge)
-				fmt.Printf("  %s (oldest: %s)\n", c.Name, oldest)
+				fmt.Fprintf(out, "  %s (oldest: %s)\n", c.Name, oldest)
 			}
 		}
 
-		fmt.Println(`
+		fmt.Fprintln(out, `
 Run 'prog compact' for compaction guidance.`)
 	}
 
-	fmt.Println("\n## Current State")
+	fmt.Fprintln(out, "\n## Current State")
 
 	if report != nil {
 		if len(report.InProgItems) > 0 {
-			fmt.Println("\nIn progress:")
+			fmt.Fprintln(out, "\nIn progress:")
 			for _, item := range report.InProgItems {
-				fmt.Printf("  [%s] %s\n", item.ID, item.Title)
+				fmt.Fprintf(out, "  [%s] %s\n", item.ID, item.Title)
 			}
 		}
 
 		if len(report.BlockedItems) > 0 {
-			fmt.Println("\nBlocked:")
+			fmt.Fprintln(out, "\nBlocked:")
 			for _, item := range report.BlockedItems {
-				fmt.Printf("  [%s] %s\n", item.ID, item.Title)
+				if item.BlockReason != "" {
+					fmt.Fprintf(out, "  [%s] %s: %s\n", item.ID, item.Title, item.BlockReason)
+				} else {
+					fmt.Fprintf(out, "  [%s] %s\n", item.ID, item.Title)
+				}
+			}
+		}
+
+		if len(report.ReadyItems) > 0 {
+			fmt.Fprintln(out, "\nReady:")
+			for i, item := range report.ReadyItems {
+				if i == primeReadyLimit {
+					fmt.Fprintf(out, "  (+%d more)\n", len(report.ReadyItems)-primeReadyLimit)
+					break
+				}
+				fmt.Fprintf(out, "  [%s] %s\n", item.ID, item.Title)
+			}
+		}
+
+		if len(report.RecentDone) > 0 {
+			fmt.Fprintln(out, "\nRecently done:")
+			for _, item := range report.RecentDone {
+				fmt.Fprintf(out, "  [%s] %s\n", item.ID, item.Title)
 			}
 		}
 
-		fmt.Println("\nRun 'prog ready [-p project]' to find unblocked work.")
+		fmt.Fprintln(out, "\nRun 'prog ready [-p project]' to find unblocked work.")
 	} else {
-		fmt.Println("\n(No database connection - run 'prog init' if needed)")
+		fmt.Fprintln(out, "\n(No database connection - run 'prog init' if needed)")
 	}
 }
 
-func printCompactContent(stats []db.ConceptStats) {
-	fmt.Println(`# Compact Learnings
+func printCompactContent(out io.Writer, stats []db.ConceptStats) {
+	fmt.Fprintln(out, `# Compact Learnings
 
 Groom learnings and concepts using two phases: **discovery** then **selection**.
 
@@ -2683,9 +7051,9 @@ Compaction keeps the knowledge base high-signal and navigable.
 
 Scan all learning summaries grouped by concept:
 
-` + "```" + `bash
+`+"```"+`bash
 prog context -p <project> --summary   # All learnings, grouped by concept
-` + "```" + `
+`+"```"+`
 
 Flag candidates:
 - **Redundant**: Similar summaries (potential duplicates)
@@ -2699,13 +7067,13 @@ Present candidates to user. Confirm which to address.
 
 Load full detail only for flagged candidates:
 
-` + "```" + `bash
+`+"```"+`bash
 prog context --id lrn-abc123          # Specific learning
-` + "```" + `
+`+"```"+`
 
 For each candidate, determine action:
-- **Archive**: Redundant or superseded → ` + "`prog learn stale <id> --reason \"...\"`" + `
-- **Update**: Valid but unclear → ` + "`prog learn edit <id> --summary \"...\"`" + `
+- **Archive**: Redundant or superseded → `+"`prog learn stale <id> --reason \"...\"`"+`
+- **Update**: Valid but unclear → `+"`prog learn edit <id> --summary \"...\"`"+`
 - **Consolidate**: Merge related → archive originals, create new combined learning
 - **Keep**: No changes needed
 
@@ -2726,14 +7094,14 @@ After each batch:
 
 	// Show current stats if available
 	if len(stats) > 0 {
-		fmt.Println("\n## Current Stats")
-		fmt.Printf("\n%-20s %6s  %s\n", "CONCEPT", "COUNT", "OLDEST")
+		fmt.Fprintln(out, "\n## Current Stats")
+		fmt.Fprintf(out, "\n%-20s %6s  %s\n", "CONCEPT", "COUNT", "OLDEST")
 		for _, s := range stats {
 			oldest := "-"
 			if s.OldestAge != nil {
 				oldest = formatDurationShort(*s.OldestAge)
 			}
-			fmt.Printf("%-20s %6d  %s\n", s.Name, s.LearningCount, oldest)
+			fmt.Fprintf(out, "%-20s %6d  %s\n", s.Name, s.LearningCount, oldest)
 		}
 
 		// Highlight compaction candidates
@@ -2744,7 +7112,7 @@ After each batch:
 			}
 		}
 		if len(candidates) > 0 {
-			fmt.Printf("\nCompaction candidates (5+ learnings): %s\n", strings.Join(candidates, ", "))
+			fmt.Fprintf(out, "\nCompaction candidates (5+ learnings): %s\n", strings.Join(candidates, ", "))
 		}
 	}
 }
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/baiirun/prog/internal/model"
+)
+
+func TestPickNext(t *testing.T) {
+	now := time.Now()
+	items := []model.Item{
+		{ID: "ts-low", Priority: 3, CreatedAt: now.Add(-3 * time.Hour)},
+		{ID: "ts-newhigh", Priority: 1, CreatedAt: now},
+		{ID: "ts-oldhigh", Priority: 1, CreatedAt: now.Add(-time.Hour)},
+		{ID: "ts-med", Priority: 2, CreatedAt: now.Add(-2 * time.Hour)},
+	}
+
+	got, ok := pickNext(items)
+	if !ok {
+		t.Fatal("expected an item")
+	}
+	if got.ID != "ts-oldhigh" {
+		t.Errorf("pickNext = %s, want ts-oldhigh", got.ID)
+	}
+	if items[0].ID != "ts-low" {
+		t.Error("pickNext should not reorder the input slice")
+	}
+}
+
+func TestPickNext_Empty(t *testing.T) {
+	if _, ok := pickNext(nil); ok {
+		t.Error("expected no item for empty input")
+	}
+}
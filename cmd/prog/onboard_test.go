@@ -2,6 +2,7 @@ package main
 
 import (
 	"encoding/json"
+	"io"
 	"os"
 	"path/filepath"
 	"strings"
@@ -23,8 +24,8 @@ func TestOnboard_CreatesNewFile(t *testing.T) {
 	}
 	defer func() { _ = os.Chdir(oldWd) }()
 
-	output := captureOutput(func() {
-		if err := runOnboardWithSettings(false, settingsPath); err != nil {
+	output := captureOutput(func(out io.Writer) {
+		if err := runOnboardWithSettings(out, false, settingsPath); err != nil {
 			t.Fatalf("runOnboard failed: %v", err)
 		}
 	})
@@ -72,8 +73,8 @@ func TestOnboard_AppendsToExisting(t *testing.T) {
 	}
 	defer func() { _ = os.Chdir(oldWd) }()
 
-	output := captureOutput(func() {
-		if err := runOnboardWithSettings(false, settingsPath); err != nil {
+	output := captureOutput(func(out io.Writer) {
+		if err := runOnboardWithSettings(out, false, settingsPath); err != nil {
 			t.Fatalf("runOnboard failed: %v", err)
 		}
 	})
@@ -127,8 +128,8 @@ func TestOnboard_Idempotent(t *testing.T) {
 	}
 	defer func() { _ = os.Chdir(oldWd) }()
 
-	output := captureOutput(func() {
-		if err := runOnboardWithSettings(false, settingsPath); err != nil {
+	output := captureOutput(func(out io.Writer) {
+		if err := runOnboardWithSettings(out, false, settingsPath); err != nil {
 			t.Fatalf("runOnboard failed: %v", err)
 		}
 	})
@@ -173,8 +174,8 @@ func TestOnboard_LowercaseFile(t *testing.T) {
 	}
 	defer func() { _ = os.Chdir(oldWd) }()
 
-	output := captureOutput(func() {
-		if err := runOnboardWithSettings(false, settingsPath); err != nil {
+	output := captureOutput(func(out io.Writer) {
+		if err := runOnboardWithSettings(out, false, settingsPath); err != nil {
 			t.Fatalf("runOnboard failed: %v", err)
 		}
 	})
@@ -218,8 +219,8 @@ func TestOnboard_SnippetContent(t *testing.T) {
 	}
 	defer func() { _ = os.Chdir(oldWd) }()
 
-	captureOutput(func() {
-		if err := runOnboardWithSettings(false, settingsPath); err != nil {
+	captureOutput(func(out io.Writer) {
+		if err := runOnboardWithSettings(out, false, settingsPath); err != nil {
 			t.Fatalf("runOnboard failed: %v", err)
 		}
 	})
@@ -267,8 +268,8 @@ func TestOnboard_ForceReplacesSection(t *testing.T) {
 	}
 	defer func() { _ = os.Chdir(oldWd) }()
 
-	output := captureOutput(func() {
-		if err := runOnboardWithSettings(true, settingsPath); err != nil {
+	output := captureOutput(func(out io.Writer) {
+		if err := runOnboardWithSettings(out, true, settingsPath); err != nil {
 			t.Fatalf("runOnboard --force failed: %v", err)
 		}
 	})
@@ -325,8 +326,8 @@ This should be preserved.
 	}
 	defer func() { _ = os.Chdir(oldWd) }()
 
-	captureOutput(func() {
-		if err := runOnboardWithSettings(true, settingsPath); err != nil {
+	captureOutput(func(out io.Writer) {
+		if err := runOnboardWithSettings(out, true, settingsPath); err != nil {
 			t.Fatalf("runOnboard --force failed: %v", err)
 		}
 	})
@@ -637,8 +638,8 @@ func TestOnboard_InstallsHook(t *testing.T) {
 	}
 	defer func() { _ = os.Chdir(oldWd) }()
 
-	output := captureOutput(func() {
-		if err := runOnboardWithSettings(false, settingsPath); err != nil {
+	output := captureOutput(func(out io.Writer) {
+		if err := runOnboardWithSettings(out, false, settingsPath); err != nil {
 			t.Fatalf("runOnboard failed: %v", err)
 		}
 	})
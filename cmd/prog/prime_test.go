@@ -2,8 +2,8 @@ package main
 
 import (
 	"bytes"
+	"fmt"
 	"io"
-	"os"
 	"path/filepath"
 	"strings"
 	"testing"
@@ -13,24 +13,17 @@ import (
 	"github.com/baiirun/prog/internal/model"
 )
 
-func captureOutput(f func()) string {
-	old := os.Stdout
-	r, w, _ := os.Pipe()
-	os.Stdout = w
-
-	f()
-
-	_ = w.Close()
-	os.Stdout = old
-
+// captureOutput runs f with a buffer standing in for the command's output
+// and returns what was written.
+func captureOutput(f func(out io.Writer)) string {
 	var buf bytes.Buffer
-	_, _ = io.Copy(&buf, r)
+	f(&buf)
 	return buf.String()
 }
 
 func TestPrintPrimeContent_NilReport(t *testing.T) {
-	output := captureOutput(func() {
-		printPrimeContent(nil, nil)
+	output := captureOutput(func(out io.Writer) {
+		printPrimeContent(out, nil, nil)
 	})
 
 	// Should contain core sections
@@ -64,12 +57,12 @@ func TestPrintPrimeContent_WithReport(t *testing.T) {
 			{ID: "ts-111111", Title: "Working on this"},
 		},
 		BlockedItems: []model.Item{
-			{ID: "ts-222222", Title: "Stuck here"},
+			{ID: "ts-222222", Title: "Stuck here", BlockReason: "waiting on API keys"},
 		},
 	}
 
-	output := captureOutput(func() {
-		printPrimeContent(report, nil)
+	output := captureOutput(func(out io.Writer) {
+		printPrimeContent(out, report, nil)
 	})
 
 	// Should contain in-progress items
@@ -87,6 +80,9 @@ func TestPrintPrimeContent_WithReport(t *testing.T) {
 	if !strings.Contains(output, "Stuck here") {
 		t.Error("missing blocked item title")
 	}
+	if !strings.Contains(output, "Stuck here: waiting on API keys") {
+		t.Error("missing block reason")
+	}
 
 	// Should prompt for ready command
 	if !strings.Contains(output, "Run 'prog ready [-p project]'") {
@@ -107,8 +103,8 @@ func TestPrintPrimeContent_EmptyReport(t *testing.T) {
 		BlockedItems: []model.Item{},
 	}
 
-	output := captureOutput(func() {
-		printPrimeContent(report, nil)
+	output := captureOutput(func(out io.Writer) {
+		printPrimeContent(out, report, nil)
 	})
 
 	// Should NOT contain "In progress:" section when empty
@@ -123,8 +119,8 @@ func TestPrintPrimeContent_EmptyReport(t *testing.T) {
 }
 
 func TestPrintPrimeContent_MandatoryLanguage(t *testing.T) {
-	output := captureOutput(func() {
-		printPrimeContent(nil, nil)
+	output := captureOutput(func(out io.Writer) {
+		printPrimeContent(out, nil, nil)
 	})
 
 	// Should contain strong MUST/NEVER language
@@ -137,8 +133,8 @@ func TestPrintPrimeContent_MandatoryLanguage(t *testing.T) {
 }
 
 func TestPrintPrimeContent_EssentialCommands(t *testing.T) {
-	output := captureOutput(func() {
-		printPrimeContent(nil, nil)
+	output := captureOutput(func(out io.Writer) {
+		printPrimeContent(out, nil, nil)
 	})
 
 	// Should contain key commands
@@ -165,8 +161,8 @@ func TestPrintPrimeContent_EssentialCommands(t *testing.T) {
 }
 
 func TestPrintPrimeContent_ContextRetrieval(t *testing.T) {
-	output := captureOutput(func() {
-		printPrimeContent(nil, nil)
+	output := captureOutput(func(out io.Writer) {
+		printPrimeContent(out, nil, nil)
 	})
 
 	// Should contain Starting Work section
@@ -226,8 +222,8 @@ func TestPrimeCommand_Integration(t *testing.T) {
 		t.Fatalf("failed to get status: %v", err)
 	}
 
-	output := captureOutput(func() {
-		printPrimeContent(report, nil)
+	output := captureOutput(func(out io.Writer) {
+		printPrimeContent(out, report, nil)
 	})
 
 	// Should contain the test task
@@ -239,3 +235,31 @@ func TestPrimeCommand_Integration(t *testing.T) {
 		t.Error("should prompt to run prog ready")
 	}
 }
+
+func TestPrintPrimeContent_ReadyAndRecentDone(t *testing.T) {
+	var ready []model.Item
+	for i := 0; i < primeReadyLimit+2; i++ {
+		ready = append(ready, model.Item{ID: fmt.Sprintf("ts-rdy%03d", i), Title: "Ready task"})
+	}
+	report := &db.StatusReport{
+		ReadyItems: ready,
+		RecentDone: []model.Item{{ID: "ts-done01", Title: "Shipped it"}},
+	}
+
+	output := captureOutput(func(out io.Writer) {
+		printPrimeContent(out, report, nil)
+	})
+
+	if !strings.Contains(output, "Ready:\n  [ts-rdy000] Ready task") {
+		t.Error("missing ready section")
+	}
+	if strings.Contains(output, fmt.Sprintf("ts-rdy%03d", primeReadyLimit)) {
+		t.Error("ready list should be capped")
+	}
+	if !strings.Contains(output, "(+2 more)") {
+		t.Error("missing overflow count for ready tasks")
+	}
+	if !strings.Contains(output, "Recently done:\n  [ts-done01] Shipped it") {
+		t.Error("missing recently done section")
+	}
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/baiirun/prog/internal/model"
+)
+
+func TestReadySignature(t *testing.T) {
+	items := []model.Item{
+		{ID: "ts-aaa111", Title: "One", Priority: 1},
+		{ID: "ts-bbb222", Title: "Two", Priority: 2, Labels: []string{"bug"}},
+	}
+	base := readySignature(items)
+
+	same := []model.Item{
+		{ID: "ts-aaa111", Title: "One", Priority: 1},
+		{ID: "ts-bbb222", Title: "Two", Priority: 2, Labels: []string{"bug"}},
+	}
+	if readySignature(same) != base {
+		t.Error("identical item sets should have the same signature")
+	}
+
+	changed := []model.Item{
+		{ID: "ts-aaa111", Title: "One", Priority: 1},
+		{ID: "ts-bbb222", Title: "Two", Priority: 1, Labels: []string{"bug"}},
+	}
+	if readySignature(changed) == base {
+		t.Error("priority change should change the signature")
+	}
+
+	if readySignature(items[:1]) == base {
+		t.Error("removing an item should change the signature")
+	}
+}
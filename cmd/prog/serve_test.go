@@ -0,0 +1,23 @@
+package main
+
+import "testing"
+
+func TestServeAddr(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{":8080", "127.0.0.1:8080"},
+		{"127.0.0.1:9000", "127.0.0.1:9000"},
+		{"0.0.0.0:8080", "0.0.0.0:8080"},
+		{"[::1]:8080", "[::1]:8080"},
+	}
+	for _, tt := range tests {
+		got, err := serveAddr(tt.in)
+		if err != nil || got != tt.want {
+			t.Errorf("serveAddr(%q) = %q, %v; want %q", tt.in, got, err, tt.want)
+		}
+	}
+	if _, err := serveAddr("8080"); err == nil {
+		t.Error("expected error for an address without a port separator")
+	}
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestHumanizeTime(t *testing.T) {
+	// Offsets are padded so the test doesn't straddle a unit boundary
+	now := time.Now()
+	tests := []struct {
+		t    time.Time
+		want string
+	}{
+		{now.Add(-10 * time.Second), "just now"},
+		{now.Add(-(time.Minute + 10*time.Second)), "1 minute ago"},
+		{now.Add(-(2*time.Hour + time.Minute)), "2 hours ago"},
+		{now.Add(-(5*24*time.Hour + time.Hour)), "5 days ago"},
+		{now.Add(-(65 * 24 * time.Hour)), "2 months ago"},
+		{now.Add(-(400 * 24 * time.Hour)), "1 year ago"},
+		{now.Add(3*24*time.Hour + time.Hour), "in 3 days"},
+		{now.Add(45*time.Minute + 30*time.Second), "in 45 minutes"},
+	}
+	for _, tt := range tests {
+		if got := humanizeTime(tt.t); got != tt.want {
+			t.Errorf("humanizeTime(now%+v) = %q, want %q", tt.t.Sub(now).Round(time.Second), got, tt.want)
+		}
+	}
+}
+
+func TestFormatCycleTime(t *testing.T) {
+	tests := []struct {
+		d    time.Duration
+		want string
+	}{
+		{20 * time.Second, "<1m"},
+		{15 * time.Minute, "15m"},
+		{3*time.Hour + 42*time.Minute, "3h42m"},
+		{2*time.Hour + 59*time.Minute + 50*time.Second, "3h0m"},
+		{50*time.Hour + 10*time.Minute, "2d2h"},
+	}
+	for _, tt := range tests {
+		if got := formatCycleTime(tt.d); got != tt.want {
+			t.Errorf("formatCycleTime(%v) = %q, want %q", tt.d, got, tt.want)
+		}
+	}
+}
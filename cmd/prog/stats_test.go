@@ -0,0 +1,70 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/baiirun/prog/internal/db"
+)
+
+func TestParseSince(t *testing.T) {
+	now := time.Date(2024, 5, 10, 15, 30, 0, 0, time.Local)
+
+	tests := []struct {
+		value string
+		want  time.Time
+	}{
+		{"8w", now.AddDate(0, 0, -56)},
+		{"30d", now.AddDate(0, 0, -30)},
+		{"2024-01-01", time.Date(2024, 1, 1, 0, 0, 0, 0, time.Local)},
+	}
+	for _, tt := range tests {
+		got, err := parseSince(tt.value, now)
+		if err != nil {
+			t.Errorf("parseSince(%q) error: %v", tt.value, err)
+			continue
+		}
+		if !got.Equal(tt.want) {
+			t.Errorf("parseSince(%q) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+
+	for _, value := range []string{"", "w", "8", "+8w", "-3d", "8m", "yesterday"} {
+		if _, err := parseSince(value, now); err == nil {
+			t.Errorf("parseSince(%q) expected error", value)
+		}
+	}
+}
+
+func TestParseUntil(t *testing.T) {
+	now := time.Date(2024, 5, 10, 15, 30, 0, 0, time.Local)
+
+	// A date includes the whole day; a lookback is an exact instant
+	if got, _ := parseUntil("2024-05-01", now); !got.Equal(time.Date(2024, 5, 2, 0, 0, 0, 0, time.Local)) {
+		t.Errorf("parseUntil(2024-05-01) = %v, want the next midnight", got)
+	}
+	if got, _ := parseUntil("1d", now); !got.Equal(now.AddDate(0, 0, -1)) {
+		t.Errorf("parseUntil(1d) = %v", got)
+	}
+	if _, err := parseUntil("May 1", now); err == nil || !strings.Contains(err.Error(), "invalid --until") {
+		t.Errorf("parseUntil(May 1) error = %v, want invalid --until", err)
+	}
+}
+
+func TestPrintCompletionStats(t *testing.T) {
+	monday := time.Date(2024, 5, 6, 0, 0, 0, 0, time.Local)
+	var buf bytes.Buffer
+	printCompletionStats(&buf, []db.WeekCount{
+		{Start: monday, Count: 3},
+		{Start: monday.AddDate(0, 0, 7), Count: 0},
+	})
+
+	out := buf.String()
+	for _, want := range []string{"2024-05-06    3 ###\n", "2024-05-13    0\n", "Total: 3 over 2 weeks (avg 1.5/week)"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+}
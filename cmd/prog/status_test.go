@@ -0,0 +1,67 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/baiirun/prog/internal/api"
+	"github.com/baiirun/prog/internal/db"
+	"github.com/baiirun/prog/internal/model"
+)
+
+func TestWriteStatusJSON(t *testing.T) {
+	report := &db.StatusReport{
+		Project:      "test",
+		Open:         1,
+		Blocked:      1,
+		Ready:        1,
+		BlockedItems: []model.Item{{ID: "ts-aaa111", Project: "test", Type: model.ItemTypeTask, Title: "Stuck", Status: model.StatusBlocked, Priority: 2, BlockReason: "waiting on API"}},
+		ReadyItems:   []model.Item{{ID: "ts-bbb222", Project: "test", Type: model.ItemTypeTask, Title: "Go", Status: model.StatusOpen, Priority: 1, Labels: []string{"bug"}}},
+		Epics:        []db.EpicStatus{{Epic: model.Item{ID: "ep-ccc333", Project: "test", Title: "Epic"}, Done: 1, Total: 3}},
+	}
+
+	var buf bytes.Buffer
+	if err := writeStatusJSON(&buf, report); err != nil {
+		t.Fatalf("writeStatusJSON failed: %v", err)
+	}
+	out := buf.String()
+
+	// Empty lists must be [] so consumers don't special-case null
+	for _, want := range []string{`"recent_done": []`, `"in_progress": []`} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %s in output:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "null") {
+		t.Errorf("expected no null values in output:\n%s", out)
+	}
+
+	var got api.StatusJSON
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("output is not valid JSON: %v", err)
+	}
+	if got.Counts.Blocked != 1 || got.Counts.Ready != 1 {
+		t.Errorf("counts = %+v, want 1 blocked and 1 ready", got.Counts)
+	}
+	if len(got.Blocked) != 1 || got.Blocked[0].BlockReason != "waiting on API" {
+		t.Errorf("blocked = %+v, want one item with its reason", got.Blocked)
+	}
+	if len(got.Ready) != 1 || len(got.Ready[0].Labels) != 1 {
+		t.Errorf("ready = %+v, want one labeled item", got.Ready)
+	}
+	want := api.EpicProgressJSON{ID: "ep-ccc333", Project: "test", Title: "Epic", Done: 1, Total: 3}
+	if len(got.Epics) != 1 || got.Epics[0] != want {
+		t.Errorf("epics = %+v, want [%+v]", got.Epics, want)
+	}
+}
+
+func TestFormatBurndown(t *testing.T) {
+	if got, want := formatBurndown(90, 30), "90m remaining / 30m completed (25% done)"; got != want {
+		t.Errorf("formatBurndown(90, 30) = %q, want %q", got, want)
+	}
+	if got, want := formatBurndown(0, 45), "0m remaining / 45m completed (100% done)"; got != want {
+		t.Errorf("formatBurndown(0, 45) = %q, want %q", got, want)
+	}
+}
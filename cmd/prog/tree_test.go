@@ -0,0 +1,31 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/baiirun/prog/internal/db"
+	"github.com/baiirun/prog/internal/model"
+)
+
+func TestPrintTree(t *testing.T) {
+	roots := []*db.TreeNode{
+		{
+			Item: model.Item{ID: "ep-aaa111", Title: "Epic", Status: model.StatusOpen},
+			Children: []*db.TreeNode{
+				{Item: model.Item{ID: "ts-bbb222", Title: "Child", Status: model.StatusDone}},
+			},
+		},
+		{Item: model.Item{ID: "ts-ccc333", Title: "Orphan", Status: model.StatusBlocked}},
+	}
+
+	var buf bytes.Buffer
+	printTree(&buf, roots, 0)
+
+	want := "[open] ep-aaa111 Epic\n" +
+		"  [done] ts-bbb222 Child\n" +
+		"[blocked] ts-ccc333 Orphan\n"
+	if buf.String() != want {
+		t.Errorf("printTree output:\n%s\nwant:\n%s", buf.String(), want)
+	}
+}
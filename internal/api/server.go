@@ -0,0 +1,257 @@
+// Package api serves prog's items and status reports as a small JSON REST
+// API over net/http, for dashboards and remote agents.
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/baiirun/prog/internal/db"
+	"github.com/baiirun/prog/internal/model"
+)
+
+// maxBodySize bounds request bodies.
+const maxBodySize = 1 << 20
+
+type server struct {
+	db *db.DB
+}
+
+// NewHandler returns the API's routes:
+//
+//	GET  /items              list items (?project=, ?status=, ?type=, ?parent=)
+//	POST /items              create an item
+//	GET  /items/{id}         show one item (ids may be abbreviated)
+//	POST /items/{id}/status  change an item's status
+//	GET  /status             project status report (?project=)
+//
+// Errors are {"error": "..."} with 404 for missing items, 409 for
+// dependency cycles and locked items, 400 for other invalid input, and 500
+// for anything else.
+func NewHandler(database *db.DB) http.Handler {
+	s := &server{db: database}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /items", s.listItems)
+	mux.HandleFunc("POST /items", s.createItem)
+	mux.HandleFunc("GET /items/{id}", s.getItem)
+	mux.HandleFunc("POST /items/{id}/status", s.setStatus)
+	mux.HandleFunc("GET /status", s.status)
+	return mux
+}
+
+func (s *server) listItems(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	filter := db.ListFilter{
+		Project: q.Get("project"),
+		Type:    q.Get("type"),
+		Parent:  q.Get("parent"),
+	}
+	if v := q.Get("status"); v != "" {
+		status := model.Status(v)
+		filter.Status = &status
+	}
+
+	items, err := s.db.ListItemsFiltered(filter)
+	if err != nil {
+		writeError(w, err, http.StatusInternalServerError)
+		return
+	}
+	if err := s.db.PopulateItemLabels(items); err != nil {
+		writeError(w, err, http.StatusInternalServerError)
+		return
+	}
+	if items == nil {
+		items = []model.Item{}
+	}
+	writeJSON(w, http.StatusOK, items)
+}
+
+// createRequest is the body of POST /items. Project defaults to the
+// ?project= query parameter.
+type createRequest struct {
+	Title       string         `json:"title"`
+	Project     string         `json:"project"`
+	Type        model.ItemType `json:"type"`     // defaults to task
+	Priority    string         `json:"priority"` // high, medium, low, or 1-3; defaults to medium
+	Description string         `json:"description"`
+	Parent      string         `json:"parent"`
+	DependsOn   []string       `json:"depends_on"`
+}
+
+func (s *server) createItem(w http.ResponseWriter, r *http.Request) {
+	var req createRequest
+	if !decodeBody(w, r, &req) {
+		return
+	}
+	if strings.TrimSpace(req.Title) == "" {
+		writeError(w, fmt.Errorf("title is required"), http.StatusBadRequest)
+		return
+	}
+	if req.Project == "" {
+		req.Project = r.URL.Query().Get("project")
+	}
+	if req.Type == "" {
+		req.Type = model.ItemTypeTask
+	}
+	priority := model.PriorityMedium
+	if req.Priority != "" {
+		p, err := model.ParsePriority(req.Priority)
+		if err != nil {
+			writeError(w, err, http.StatusBadRequest)
+			return
+		}
+		priority = p
+	}
+	if req.Parent != "" {
+		parent, err := s.db.ResolveID(req.Parent)
+		if err != nil {
+			writeError(w, err, http.StatusInternalServerError)
+			return
+		}
+		req.Parent = parent
+	}
+	for i, dep := range req.DependsOn {
+		resolved, err := s.db.ResolveID(dep)
+		if err != nil {
+			writeError(w, err, http.StatusInternalServerError)
+			return
+		}
+		req.DependsOn[i] = resolved
+	}
+
+	item := &model.Item{
+		Project:     req.Project,
+		Type:        req.Type,
+		Title:       req.Title,
+		Description: req.Description,
+		Status:      model.StatusOpen,
+		Priority:    priority,
+		PrioritySet: req.Priority != "",
+	}
+	if req.Parent != "" {
+		item.ParentID = &req.Parent
+	}
+	if err := s.db.CreateItemWithDeps(item, req.DependsOn, nil); err != nil {
+		writeError(w, err, http.StatusInternalServerError)
+		return
+	}
+	s.db.BackupQuiet()
+
+	w.Header().Set("Location", "/items/"+item.ID)
+	s.writeItem(w, http.StatusCreated, item.ID)
+}
+
+func (s *server) getItem(w http.ResponseWriter, r *http.Request) {
+	id, err := s.db.ResolveID(r.PathValue("id"))
+	if err != nil {
+		writeError(w, err, http.StatusInternalServerError)
+		return
+	}
+	s.writeItem(w, http.StatusOK, id)
+}
+
+// statusRequest is the body of POST /items/{id}/status.
+type statusRequest struct {
+	Status model.Status `json:"status"`
+	Reason string       `json:"reason"` // required when blocking
+	Force  bool         `json:"force"`  // skip transition rules
+}
+
+func (s *server) setStatus(w http.ResponseWriter, r *http.Request) {
+	id, err := s.db.ResolveID(r.PathValue("id"))
+	if err != nil {
+		writeError(w, err, http.StatusInternalServerError)
+		return
+	}
+	var req statusRequest
+	if !decodeBody(w, r, &req) {
+		return
+	}
+
+	ids := []string{id}
+	switch {
+	case req.Status == model.StatusBlocked:
+		err = s.db.BlockItems(ids, req.Reason, req.Force)
+	case req.Force:
+		err = s.db.ForceUpdateStatuses(ids, req.Status, "")
+	default:
+		err = s.db.UpdateStatuses(ids, req.Status, "")
+	}
+	if err != nil {
+		writeError(w, err, http.StatusInternalServerError)
+		return
+	}
+	s.db.BackupQuiet()
+
+	s.writeItem(w, http.StatusOK, id)
+}
+
+func (s *server) status(w http.ResponseWriter, r *http.Request) {
+	report, err := s.db.ProjectStatus(r.URL.Query().Get("project"))
+	if err != nil {
+		writeError(w, err, http.StatusInternalServerError)
+		return
+	}
+	for _, items := range [][]model.Item{report.RecentDone, report.InProgItems, report.BlockedItems, report.ReadyItems} {
+		if err := s.db.PopulateItemLabels(items); err != nil {
+			writeError(w, err, http.StatusInternalServerError)
+			return
+		}
+	}
+	writeJSON(w, http.StatusOK, NewStatusJSON(report))
+}
+
+// writeItem responds with the item, including its labels and tags.
+func (s *server) writeItem(w http.ResponseWriter, code int, id string) {
+	item, err := s.db.GetItem(id)
+	if err != nil {
+		writeError(w, err, http.StatusInternalServerError)
+		return
+	}
+	items := []model.Item{*item}
+	if err := s.db.PopulateItemLabels(items); err != nil {
+		writeError(w, err, http.StatusInternalServerError)
+		return
+	}
+	if items[0].Tags, err = s.db.GetItemTags(id); err != nil {
+		writeError(w, err, http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, code, items[0])
+}
+
+// decodeBody unmarshals the JSON request body into v, writing a 400 and
+// returning false if it is malformed or has unknown fields.
+func decodeBody(w http.ResponseWriter, r *http.Request, v any) bool {
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBodySize))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		writeError(w, fmt.Errorf("invalid request body: %w", err), http.StatusBadRequest)
+		return false
+	}
+	return true
+}
+
+func writeJSON(w http.ResponseWriter, code int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// writeError responds with err, as 404 if it wraps db.ErrNotFound, 409 if it
+// wraps db.ErrCycle or db.ErrLocked, 400 if it wraps one of db's other
+// validation errors, and with code otherwise.
+func writeError(w http.ResponseWriter, err error, code int) {
+	switch {
+	case errors.Is(err, db.ErrNotFound):
+		code = http.StatusNotFound
+	case errors.Is(err, db.ErrCycle), errors.Is(err, db.ErrLocked):
+		code = http.StatusConflict
+	case errors.Is(err, db.ErrInvalid), errors.Is(err, db.ErrInvalidStatus), errors.Is(err, db.ErrSelfDependency):
+		code = http.StatusBadRequest
+	}
+	writeJSON(w, code, map[string]string{"error": err.Error()})
+}
@@ -0,0 +1,157 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/baiirun/prog/internal/db"
+	"github.com/baiirun/prog/internal/model"
+)
+
+func setupTestHandler(t *testing.T) (http.Handler, *db.DB) {
+	t.Helper()
+	t.Setenv("HOME", t.TempDir()) // in case a backup strays from the database
+	database, err := db.Open(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("failed to open db: %v", err)
+	}
+	if err := database.Init(); err != nil {
+		t.Fatalf("failed to init db: %v", err)
+	}
+	t.Cleanup(func() { _ = database.Close() })
+	return NewHandler(database), database
+}
+
+// do sends a request to h and decodes the JSON response into v, returning the
+// status code.
+func do(t *testing.T, h http.Handler, method, target, body string, v any) int {
+	t.Helper()
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if v != nil {
+		if err := json.Unmarshal(rec.Body.Bytes(), v); err != nil {
+			t.Fatalf("%s %s returned %q: %v", method, target, rec.Body.String(), err)
+		}
+	}
+	return rec.Code
+}
+
+func TestHandler_ItemLifecycle(t *testing.T) {
+	h, _ := setupTestHandler(t)
+
+	var created model.Item
+	code := do(t, h, "POST", "/items?project=api", `{"title":"Ship it","priority":"high"}`, &created)
+	if code != http.StatusCreated {
+		t.Fatalf("create returned %d", code)
+	}
+	if created.Project != "api" || created.Priority != model.PriorityHigh || created.Type != model.ItemTypeTask {
+		t.Errorf("unexpected created item: %+v", created)
+	}
+
+	var got model.Item
+	if code := do(t, h, "GET", "/items/"+created.ID, "", &got); code != http.StatusOK || got.Title != "Ship it" {
+		t.Errorf("get returned %d, %+v", code, got)
+	}
+
+	if code := do(t, h, "POST", "/items/"+created.ID+"/status", `{"status":"blocked"}`, nil); code != http.StatusBadRequest {
+		t.Errorf("block without reason returned %d, want 400", code)
+	}
+	code = do(t, h, "POST", "/items/"+created.ID+"/status", `{"status":"blocked","reason":"waiting on review"}`, &got)
+	if code != http.StatusOK || got.Status != model.StatusBlocked || got.BlockReason != "waiting on review" {
+		t.Errorf("block returned %d, %+v", code, got)
+	}
+	code = do(t, h, "POST", "/items/"+created.ID+"/status", `{"status":"done","force":true}`, &got)
+	if code != http.StatusOK || got.Status != model.StatusDone {
+		t.Errorf("done returned %d, %+v", code, got)
+	}
+}
+
+func TestHandler_ListAndStatus(t *testing.T) {
+	h, _ := setupTestHandler(t)
+
+	do(t, h, "POST", "/items", `{"title":"A","project":"one"}`, nil)
+	do(t, h, "POST", "/items", `{"title":"B","project":"two"}`, nil)
+
+	var items []model.Item
+	if code := do(t, h, "GET", "/items?project=one", "", &items); code != http.StatusOK || len(items) != 1 || items[0].Title != "A" {
+		t.Errorf("list project=one returned %d, %+v", code, items)
+	}
+	var raw json.RawMessage
+	do(t, h, "GET", "/items?status=done", "", &raw)
+	if string(raw) != "[]" {
+		t.Errorf("empty list = %s, want []", raw)
+	}
+
+	var report StatusJSON
+	if code := do(t, h, "GET", "/status?project=two", "", &report); code != http.StatusOK {
+		t.Fatalf("status returned %d", code)
+	}
+	if report.Project != "two" || report.Counts.Open != 1 || len(report.Ready) != 1 {
+		t.Errorf("unexpected status report: %+v", report)
+	}
+}
+
+func TestHandler_Errors(t *testing.T) {
+	h, _ := setupTestHandler(t)
+
+	var a, b model.Item
+	do(t, h, "POST", "/items", `{"title":"A"}`, &a)
+	do(t, h, "POST", "/items", `{"title":"B","depends_on":["`+a.ID+`"]}`, &b)
+
+	tests := []struct {
+		method, target, body string
+		wantCode             int
+		wantErr              string
+	}{
+		{"GET", "/items/ts-nope00", "", http.StatusNotFound, "not found"},
+		{"POST", "/items/ts-nope00/status", `{"status":"done"}`, http.StatusNotFound, "not found"},
+		{"POST", "/items", `{"title":" "}`, http.StatusBadRequest, "title is required"},
+		{"POST", "/items", `{"title":"x","priority":"urgent"}`, http.StatusBadRequest, "invalid priority"},
+		{"POST", "/items", `{"title":"x","colour":"red"}`, http.StatusBadRequest, "unknown field"},
+		{"POST", "/items", `{"title":"x","depends_on":["ts-nope00"]}`, http.StatusNotFound, "not found"},
+		{"GET", "/items?status=bogus", "", http.StatusBadRequest, "invalid status"},
+		{"POST", "/items/" + a.ID + "/status", `{"status":"bogus"}`, http.StatusBadRequest, "invalid status"},
+		{"POST", "/items/" + a.ID + "/status", `{"status":"blocked"}`, http.StatusBadRequest, "block reason is required"},
+		{"POST", "/items", `{"title":"x","type":"story"}`, http.StatusBadRequest, "invalid item type"},
+		{"POST", "/items", `{"title":"x","parent":"` + a.ID + `"}`, http.StatusBadRequest, "parent must be an epic"},
+	}
+	for _, tt := range tests {
+		var resp map[string]string
+		code := do(t, h, tt.method, tt.target, tt.body, &resp)
+		if code != tt.wantCode || !strings.Contains(resp["error"], tt.wantErr) {
+			t.Errorf("%s %s = %d %q, want %d with error containing %q", tt.method, tt.target, code, resp["error"], tt.wantCode, tt.wantErr)
+		}
+	}
+}
+
+func TestHandler_CreateWithBadParentLeavesNoItem(t *testing.T) {
+	h, database := setupTestHandler(t)
+
+	var task model.Item
+	do(t, h, "POST", "/items?project=api", `{"title":"Not an epic"}`, &task)
+	if code := do(t, h, "POST", "/items?project=api", `{"title":"Child","parent":"`+task.ID+`"}`, nil); code != http.StatusBadRequest {
+		t.Fatalf("create with task parent returned %d, want 400", code)
+	}
+	items, err := database.ListItems("api", nil)
+	if err != nil {
+		t.Fatalf("ListItems failed: %v", err)
+	}
+	if len(items) != 1 {
+		t.Errorf("expected only the parent task, got %d items", len(items))
+	}
+}
+
+func TestHandler_InternalErrors(t *testing.T) {
+	h, database := setupTestHandler(t)
+	_ = database.Close()
+
+	var resp map[string]string
+	if code := do(t, h, "GET", "/items", "", &resp); code != http.StatusInternalServerError {
+		t.Errorf("list on a closed database returned %d %q, want 500", code, resp["error"])
+	}
+}
@@ -0,0 +1,112 @@
+package api
+
+import (
+	"github.com/baiirun/prog/internal/db"
+	"github.com/baiirun/prog/internal/model"
+)
+
+// StatusJSON is the JSON serialization format for status reports.
+type StatusJSON struct {
+	Project           string             `json:"project"`
+	Counts            StatusCountsJSON   `json:"counts"`
+	EstimateMinutes   int                `json:"estimate_minutes"`
+	ActualMinutes     int                `json:"actual_minutes"`
+	WIPLimit          int                `json:"wip_limit"` // 0 means unlimited
+	RemainingEstimate int                `json:"remaining_estimate_minutes"`
+	CompletedEstimate int                `json:"completed_estimate_minutes"`
+	RecentDone        []StatusItemJSON   `json:"recent_done"`
+	InProgress        []StatusItemJSON   `json:"in_progress"`
+	Blocked           []StatusItemJSON   `json:"blocked"`
+	Ready             []StatusItemJSON   `json:"ready"`
+	Epics             []EpicProgressJSON `json:"epics"`
+}
+
+// StatusCountsJSON holds the per-status item counts of a status report.
+type StatusCountsJSON struct {
+	Open       int `json:"open"`
+	InProgress int `json:"in_progress"`
+	Blocked    int `json:"blocked"`
+	Done       int `json:"done"`
+	Canceled   int `json:"canceled"`
+	Ready      int `json:"ready"`
+	Stale      int `json:"stale"` // in progress with no recent update
+}
+
+// StatusItemJSON is an item as it appears in a status report.
+type StatusItemJSON struct {
+	ID          string   `json:"id"`
+	Project     string   `json:"project"`
+	Type        string   `json:"type"`
+	Title       string   `json:"title"`
+	Status      string   `json:"status"`
+	Priority    int      `json:"priority"`
+	Labels      []string `json:"labels"`
+	BlockReason string   `json:"block_reason,omitempty"`
+}
+
+// EpicProgressJSON is an open epic with the completion counts of its children.
+type EpicProgressJSON struct {
+	ID      string `json:"id"`
+	Project string `json:"project"`
+	Title   string `json:"title"`
+	Done    int    `json:"done"`
+	Total   int    `json:"total"`
+}
+
+// NewStatusJSON converts report to its JSON form. Empty lists become [] rather
+// than null so consumers don't have to special-case them.
+func NewStatusJSON(report *db.StatusReport) StatusJSON {
+	output := StatusJSON{
+		Project: report.Project,
+		Counts: StatusCountsJSON{
+			Open:       report.Open,
+			InProgress: report.InProgress,
+			Blocked:    report.Blocked,
+			Done:       report.Done,
+			Canceled:   report.Canceled,
+			Ready:      report.Ready,
+			Stale:      report.Stale,
+		},
+		EstimateMinutes:   report.EstimateMinutes,
+		ActualMinutes:     report.ActualMinutes,
+		WIPLimit:          report.WIPLimit,
+		RemainingEstimate: report.RemainingEstimate,
+		CompletedEstimate: report.CompletedEstimate,
+		RecentDone:        statusItemsJSON(report.RecentDone),
+		InProgress:        statusItemsJSON(report.InProgItems),
+		Blocked:           statusItemsJSON(report.BlockedItems),
+		Ready:             statusItemsJSON(report.ReadyItems),
+		Epics:             make([]EpicProgressJSON, 0, len(report.Epics)),
+	}
+	for _, e := range report.Epics {
+		output.Epics = append(output.Epics, EpicProgressJSON{
+			ID:      e.Epic.ID,
+			Project: e.Epic.Project,
+			Title:   e.Epic.Title,
+			Done:    e.Done,
+			Total:   e.Total,
+		})
+	}
+	return output
+}
+
+func statusItemsJSON(items []model.Item) []StatusItemJSON {
+	output := make([]StatusItemJSON, 0, len(items))
+	for _, item := range items {
+		ij := StatusItemJSON{
+			ID:          item.ID,
+			Project:     item.Project,
+			Type:        string(item.Type),
+			Title:       item.Title,
+			Status:      string(item.Status),
+			Priority:    item.Priority,
+			Labels:      item.Labels,
+			BlockReason: item.BlockReason,
+		}
+		if ij.Labels == nil {
+			ij.Labels = []string{}
+		}
+		output = append(output, ij)
+	}
+	return output
+}
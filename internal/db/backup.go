@@ -10,28 +10,49 @@ import (
 )
 
 const (
-	// MaxBackups is the maximum number of backups to keep
+	// MaxBackups is the maximum number of backups to keep per database
 	MaxBackups = 10
-	// BackupDir is the subdirectory for backups within the prog directory
+	// BackupDir is the subdirectory for backups, beside the database file
 	BackupDir = "backups"
 )
 
-// BackupPath returns the path to the backups directory
-func BackupPath() (string, error) {
-	home, err := os.UserHomeDir()
-	if err != nil {
-		return "", fmt.Errorf("failed to get home directory: %w", err)
+// backupTimeFormat stamps backup filenames.
+const backupTimeFormat = "2006-01-02T15-04-05"
+
+// BackupPath returns the backups directory for the database at dbPath. Each
+// database keeps its backups beside it, so a project-local or scratch
+// database never mixes with, or prunes, the global one's.
+func BackupPath(dbPath string) string {
+	return filepath.Join(filepath.Dir(dbPath), BackupDir)
+}
+
+// backupPrefix is how backup filenames of the database at dbPath start:
+// its name without extension, so prog.db is backed up as prog-<time>.db.
+func backupPrefix(dbPath string) string {
+	base := filepath.Base(dbPath)
+	return strings.TrimSuffix(base, filepath.Ext(base)) + "-"
+}
+
+// isBackupOf reports whether name is a backup file of the database at
+// dbPath. The timestamp must parse, so backups of prog-x.db aren't taken
+// for backups of prog.db.
+func isBackupOf(name, dbPath string) bool {
+	stamp, ok := strings.CutPrefix(name, backupPrefix(dbPath))
+	if !ok {
+		return false
 	}
-	return filepath.Join(home, ".prog", BackupDir), nil
+	stamp, ok = strings.CutSuffix(stamp, ".db")
+	if !ok {
+		return false
+	}
+	_, err := time.Parse(backupTimeFormat, stamp)
+	return err == nil
 }
 
-// Backup creates a backup of the database.
+// Backup creates a backup of the database in BackupPath.
 // Returns the path to the backup file.
 func (db *DB) Backup() (string, error) {
-	backupDir, err := BackupPath()
-	if err != nil {
-		return "", err
-	}
+	backupDir := BackupPath(db.path)
 
 	// Ensure backup directory exists
 	if err := os.MkdirAll(backupDir, 0755); err != nil {
@@ -39,17 +60,23 @@ func (db *DB) Backup() (string, error) {
 	}
 
 	// Generate timestamped filename
-	timestamp := time.Now().Format("2006-01-02T15-04-05")
-	backupFile := filepath.Join(backupDir, fmt.Sprintf("prog-%s.db", timestamp))
+	timestamp := time.Now().Format(backupTimeFormat)
+	backupFile := filepath.Join(backupDir, backupPrefix(db.path)+timestamp+".db")
+
+	// VACUUM INTO refuses to overwrite, and a backup taken in the same
+	// second supersedes the earlier one anyway
+	if err := os.Remove(backupFile); err != nil && !os.IsNotExist(err) {
+		return "", fmt.Errorf("failed to replace backup: %w", err)
+	}
 
 	// Use SQLite's backup via VACUUM INTO for a consistent snapshot
-	_, err = db.Exec(fmt.Sprintf("VACUUM INTO '%s'", backupFile))
+	_, err := db.Exec(fmt.Sprintf("VACUUM INTO '%s'", backupFile))
 	if err != nil {
 		return "", fmt.Errorf("failed to create backup: %w", err)
 	}
 
 	// Prune old backups
-	if err := pruneBackups(backupDir, MaxBackups); err != nil {
+	if err := pruneBackups(db.path, MaxBackups); err != nil {
 		// Log but don't fail the backup
 		fmt.Fprintf(os.Stderr, "warning: failed to prune old backups: %v\n", err)
 	}
@@ -63,12 +90,11 @@ func (db *DB) BackupQuiet() {
 	_, _ = db.Backup()
 }
 
-// ListBackups returns a list of available backup files, newest first.
-func ListBackups() ([]BackupInfo, error) {
-	backupDir, err := BackupPath()
-	if err != nil {
-		return nil, err
-	}
+// ListBackups returns the backups of the database at dbPath, newest first.
+// It only reads the backups directory, so it works when the database itself
+// can't be opened.
+func ListBackups(dbPath string) ([]BackupInfo, error) {
+	backupDir := BackupPath(dbPath)
 
 	entries, err := os.ReadDir(backupDir)
 	if err != nil {
@@ -84,7 +110,7 @@ func ListBackups() ([]BackupInfo, error) {
 			continue
 		}
 		name := entry.Name()
-		if !strings.HasPrefix(name, "prog-") || !strings.HasSuffix(name, ".db") {
+		if !isBackupOf(name, dbPath) {
 			continue
 		}
 
@@ -117,67 +143,30 @@ type BackupInfo struct {
 	ModTime time.Time
 }
 
-// pruneBackups removes old backups, keeping only the newest 'keep' backups.
-func pruneBackups(backupDir string, keep int) error {
-	entries, err := os.ReadDir(backupDir)
+// pruneBackups removes old backups of the database at dbPath, keeping only
+// the newest 'keep'. Other databases' backups in the directory are left alone.
+func pruneBackups(dbPath string, keep int) error {
+	backups, err := ListBackups(dbPath)
 	if err != nil {
 		return err
 	}
 
-	// Filter to only backup files
-	type backupFile struct {
-		name    string
-		modTime time.Time
-	}
-	var backups []backupFile
-
-	for _, entry := range entries {
-		if entry.IsDir() {
-			continue
-		}
-		name := entry.Name()
-		if !strings.HasPrefix(name, "prog-") || !strings.HasSuffix(name, ".db") {
-			continue
-		}
-		info, err := entry.Info()
-		if err != nil {
-			continue
-		}
-		backups = append(backups, backupFile{name: name, modTime: info.ModTime()})
-	}
-
-	// Sort oldest first
-	sort.Slice(backups, func(i, j int) bool {
-		return backups[i].modTime.Before(backups[j].modTime)
-	})
-
 	// Remove oldest backups if we have more than 'keep'
-	toRemove := len(backups) - keep
-	if toRemove <= 0 {
-		return nil
-	}
-
-	for i := 0; i < toRemove; i++ {
-		path := filepath.Join(backupDir, backups[i].name)
-		if err := os.Remove(path); err != nil {
-			return fmt.Errorf("failed to remove old backup %s: %w", backups[i].name, err)
+	for _, b := range backups[min(keep, len(backups)):] {
+		if err := os.Remove(b.Path); err != nil {
+			return fmt.Errorf("failed to remove old backup %s: %w", b.Name, err)
 		}
 	}
 
 	return nil
 }
 
-// Restore copies a backup file to the main database location.
+// Restore copies a backup file over the database at dbPath.
 // The database connection should be closed before calling this.
-func Restore(backupPath string) error {
-	dbPath, err := DefaultPath()
-	if err != nil {
-		return err
-	}
-
+func Restore(backupPath, dbPath string) error {
 	// Verify backup exists
 	if _, err := os.Stat(backupPath); err != nil {
-		return fmt.Errorf("backup file not found: %w", err)
+		return fmt.Errorf("backup file %w: %w", ErrNotFound, err)
 	}
 
 	// Read backup
@@ -186,6 +175,14 @@ func Restore(backupPath string) error {
 		return fmt.Errorf("failed to read backup: %w", err)
 	}
 
+	// Drop any WAL left beside the old database so it isn't replayed over
+	// the restored file
+	for _, suffix := range []string{"-wal", "-shm"} {
+		if err := os.Remove(dbPath + suffix); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove %s file: %w", suffix, err)
+		}
+	}
+
 	// Write to main database
 	if err := os.WriteFile(dbPath, data, 0644); err != nil {
 		return fmt.Errorf("failed to write database: %w", err)
@@ -0,0 +1,74 @@
+package db
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestBackup_BesideDatabase(t *testing.T) {
+	db := setupTestDB(t)
+	dir := filepath.Dir(db.path)
+
+	path, err := db.Backup()
+	if err != nil {
+		t.Fatalf("Backup failed: %v", err)
+	}
+	if filepath.Dir(path) != filepath.Join(dir, BackupDir) {
+		t.Errorf("backup at %s, want in %s", path, filepath.Join(dir, BackupDir))
+	}
+
+	// Another database in the same directory keeps separate backups
+	other := filepath.Join(dir, "test-other.db")
+	stamp := time.Now().Add(-time.Hour).Format(backupTimeFormat)
+	foreign := filepath.Join(BackupPath(other), "test-other-"+stamp+".db")
+	if err := os.WriteFile(foreign, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	backups, err := ListBackups(db.path)
+	if err != nil {
+		t.Fatalf("ListBackups failed: %v", err)
+	}
+	if len(backups) != 1 || backups[0].Path != path {
+		t.Errorf("backups = %+v, want only %s", backups, path)
+	}
+	if others, _ := ListBackups(other); len(others) != 1 || others[0].Path != foreign {
+		t.Errorf("other backups = %+v, want only %s", others, foreign)
+	}
+}
+
+func TestPruneBackups_PerDatabase(t *testing.T) {
+	dir := t.TempDir()
+	mine := filepath.Join(dir, "prog.db")
+	other := filepath.Join(dir, "scratch.db")
+	if err := os.MkdirAll(BackupPath(mine), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	for i := range 4 {
+		at := start.Add(time.Duration(i) * time.Minute)
+		for _, prefix := range []string{"prog-", "scratch-"} {
+			path := filepath.Join(BackupPath(mine), prefix+at.Format(backupTimeFormat)+".db")
+			if err := os.WriteFile(path, nil, 0644); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.Chtimes(path, at, at); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	if err := pruneBackups(mine, 2); err != nil {
+		t.Fatalf("pruneBackups failed: %v", err)
+	}
+	kept, _ := ListBackups(mine)
+	if len(kept) != 2 || kept[0].Name != "prog-"+start.Add(3*time.Minute).Format(backupTimeFormat)+".db" {
+		t.Errorf("kept = %+v, want the newest 2", kept)
+	}
+	if others, _ := ListBackups(other); len(others) != 4 {
+		t.Errorf("pruning prog.db removed scratch.db backups: %d left", len(others))
+	}
+}
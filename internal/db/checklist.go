@@ -0,0 +1,186 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/baiirun/prog/internal/model"
+)
+
+// AddChecklistItem appends a step to an item's checklist and returns its
+// position.
+func (db *DB) AddChecklistItem(itemID, text string) (int, error) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return 0, fmt.Errorf("checklist text cannot be empty")
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var count int
+	if err := tx.QueryRow(`SELECT COUNT(*) FROM items WHERE id = ?`, itemID).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to check item: %w", err)
+	}
+	if count == 0 {
+		return 0, fmt.Errorf("item %w: %s (use 'prog list' to see available items)", ErrNotFound, itemID)
+	}
+
+	var position int
+	err = tx.QueryRow(`SELECT COALESCE(MAX(position), 0) + 1 FROM checklist_items WHERE item_id = ?`, itemID).Scan(&position)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read checklist: %w", err)
+	}
+	_, err = tx.Exec(`INSERT INTO checklist_items (item_id, text, done, position, created_at) VALUES (?, ?, 0, ?, ?)`,
+		itemID, text, position, db.Now())
+	if err != nil {
+		return 0, fmt.Errorf("failed to add checklist item: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return position, nil
+}
+
+// GetChecklist returns an item's checklist in order.
+func (db *DB) GetChecklist(itemID string) ([]model.ChecklistItem, error) {
+	rows, err := db.Query(`
+		SELECT item_id, position, text, done, created_at FROM checklist_items
+		WHERE item_id = ? ORDER BY position`, itemID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get checklist: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var steps []model.ChecklistItem
+	for rows.Next() {
+		var c model.ChecklistItem
+		if err := rows.Scan(&c.ItemID, &c.Position, &c.Text, &c.Done, &c.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan checklist item: %w", err)
+		}
+		steps = append(steps, c)
+	}
+	return steps, rows.Err()
+}
+
+// ToggleChecklistItem flips step n of an item's checklist between done and
+// not done, returning the updated step.
+func (db *DB) ToggleChecklistItem(itemID string, n int) (*model.ChecklistItem, error) {
+	result, err := db.Exec(`UPDATE checklist_items SET done = 1 - done WHERE item_id = ? AND position = ?`, itemID, n)
+	if err != nil {
+		return nil, fmt.Errorf("failed to toggle checklist item: %w", err)
+	}
+	rows, _ := result.RowsAffected()
+	if rows == 0 {
+		return nil, noChecklistItem(db, itemID, n)
+	}
+
+	var c model.ChecklistItem
+	err = db.QueryRow(`
+		SELECT item_id, position, text, done, created_at FROM checklist_items
+		WHERE item_id = ? AND position = ?`, itemID, n).Scan(&c.ItemID, &c.Position, &c.Text, &c.Done, &c.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get checklist item: %w", err)
+	}
+	return &c, nil
+}
+
+// RemoveChecklistItem deletes step n of an item's checklist. Later steps
+// move up so positions stay contiguous.
+func (db *DB) RemoveChecklistItem(itemID string, n int) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	result, err := tx.Exec(`DELETE FROM checklist_items WHERE item_id = ? AND position = ?`, itemID, n)
+	if err != nil {
+		return fmt.Errorf("failed to remove checklist item: %w", err)
+	}
+	rows, _ := result.RowsAffected()
+	if rows == 0 {
+		return noChecklistItem(tx, itemID, n)
+	}
+	_, err = tx.Exec(`UPDATE checklist_items SET position = position - 1 WHERE item_id = ? AND position > ?`, itemID, n)
+	if err != nil {
+		return fmt.Errorf("failed to renumber checklist: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// MoveChecklistItem moves step from of an item's checklist to position to,
+// shifting the steps in between.
+func (db *DB) MoveChecklistItem(itemID string, from, to int) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var count int
+	if err := tx.QueryRow(`SELECT COUNT(*) FROM checklist_items WHERE item_id = ?`, itemID).Scan(&count); err != nil {
+		return fmt.Errorf("failed to read checklist: %w", err)
+	}
+	if from < 1 || from > count {
+		return noChecklistItem(tx, itemID, from)
+	}
+	if to < 1 || to > count {
+		return fmt.Errorf("invalid position: %d (checklist has %d)", to, count)
+	}
+	if from == to {
+		return nil
+	}
+
+	var id int64
+	err = tx.QueryRow(`SELECT id FROM checklist_items WHERE item_id = ? AND position = ?`, itemID, from).Scan(&id)
+	if err != nil {
+		return fmt.Errorf("failed to get checklist item: %w", err)
+	}
+	if from < to {
+		_, err = tx.Exec(`UPDATE checklist_items SET position = position - 1 WHERE item_id = ? AND position > ? AND position <= ?`, itemID, from, to)
+	} else {
+		_, err = tx.Exec(`UPDATE checklist_items SET position = position + 1 WHERE item_id = ? AND position >= ? AND position < ?`, itemID, to, from)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to renumber checklist: %w", err)
+	}
+	if _, err := tx.Exec(`UPDATE checklist_items SET position = ? WHERE id = ?`, to, id); err != nil {
+		return fmt.Errorf("failed to move checklist item: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// rowQuerier is implemented by both *DB and *sql.Tx.
+type rowQuerier interface {
+	QueryRow(query string, args ...any) *sql.Row
+}
+
+// noChecklistItem explains why step n of itemID couldn't be found: the item
+// is missing, or the position is out of range.
+func noChecklistItem(q rowQuerier, itemID string, n int) error {
+	var count int
+	if err := q.QueryRow(`SELECT COUNT(*) FROM items WHERE id = ?`, itemID).Scan(&count); err != nil {
+		return fmt.Errorf("failed to check item: %w", err)
+	}
+	if count == 0 {
+		return fmt.Errorf("item %w: %s (use 'prog list' to see available items)", ErrNotFound, itemID)
+	}
+	if err := q.QueryRow(`SELECT COUNT(*) FROM checklist_items WHERE item_id = ?`, itemID).Scan(&count); err != nil {
+		return fmt.Errorf("failed to read checklist: %w", err)
+	}
+	return fmt.Errorf("item %s has no checklist step %d (checklist has %d)", itemID, n, count)
+}
@@ -0,0 +1,118 @@
+package db
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/baiirun/prog/internal/model"
+)
+
+// checklistTexts returns the item's checklist as "text" or "text*" (done)
+// joined by commas, in position order.
+func checklistTexts(t *testing.T, db *DB, itemID string) string {
+	t.Helper()
+	steps, err := db.GetChecklist(itemID)
+	if err != nil {
+		t.Fatalf("GetChecklist failed: %v", err)
+	}
+	var texts []string
+	for i, s := range steps {
+		if s.Position != i+1 {
+			t.Fatalf("positions not contiguous: %+v", steps)
+		}
+		if s.Done {
+			texts = append(texts, s.Text+"*")
+		} else {
+			texts = append(texts, s.Text)
+		}
+	}
+	return strings.Join(texts, ",")
+}
+
+func TestChecklist(t *testing.T) {
+	db := setupTestDB(t)
+	item := createTestItem(t, db, "Ship release")
+
+	for i, text := range []string{"a", "b", "c", "d"} {
+		n, err := db.AddChecklistItem(item.ID, text)
+		if err != nil {
+			t.Fatalf("AddChecklistItem failed: %v", err)
+		}
+		if n != i+1 {
+			t.Errorf("step %q got position %d, want %d", text, n, i+1)
+		}
+	}
+
+	step, err := db.ToggleChecklistItem(item.ID, 2)
+	if err != nil {
+		t.Fatalf("ToggleChecklistItem failed: %v", err)
+	}
+	if !step.Done || step.Text != "b" {
+		t.Errorf("toggled step = %+v, want b done", step)
+	}
+
+	if err := db.MoveChecklistItem(item.ID, 4, 1); err != nil {
+		t.Fatalf("MoveChecklistItem failed: %v", err)
+	}
+	if got := checklistTexts(t, db, item.ID); got != "d,a,b*,c" {
+		t.Errorf("after move up = %s, want d,a,b*,c", got)
+	}
+	if err := db.MoveChecklistItem(item.ID, 1, 3); err != nil {
+		t.Fatalf("MoveChecklistItem failed: %v", err)
+	}
+	if got := checklistTexts(t, db, item.ID); got != "a,b*,d,c" {
+		t.Errorf("after move down = %s, want a,b*,d,c", got)
+	}
+
+	if err := db.RemoveChecklistItem(item.ID, 1); err != nil {
+		t.Fatalf("RemoveChecklistItem failed: %v", err)
+	}
+	if got := checklistTexts(t, db, item.ID); got != "b*,d,c" {
+		t.Errorf("after remove = %s, want b*,d,c", got)
+	}
+
+	if _, err := db.ToggleChecklistItem(item.ID, 9); err == nil || !strings.Contains(err.Error(), "no checklist step 9") {
+		t.Errorf("toggle out of range = %v", err)
+	}
+	if err := db.MoveChecklistItem(item.ID, 1, 4); err == nil {
+		t.Error("expected error moving past the end")
+	}
+	if _, err := db.AddChecklistItem(item.ID, "  "); err == nil {
+		t.Error("expected error for empty text")
+	}
+	if _, err := db.AddChecklistItem("ts-missing", "x"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("add to missing item = %v, want ErrNotFound", err)
+	}
+}
+
+func TestChecklist_MergeAndDelete(t *testing.T) {
+	db := setupTestDB(t)
+	from := createTestItemWithProject(t, db, "From", "test", model.StatusOpen, 2)
+	into := createTestItemWithProject(t, db, "Into", "test", model.StatusOpen, 2)
+
+	for _, s := range []struct{ id, text string }{{into.ID, "x"}, {from.ID, "y"}, {from.ID, "z"}} {
+		if _, err := db.AddChecklistItem(s.id, s.text); err != nil {
+			t.Fatalf("AddChecklistItem failed: %v", err)
+		}
+	}
+
+	// from's steps follow into's own
+	if _, err := db.MergeItems(from.ID, into.ID); err != nil {
+		t.Fatalf("MergeItems failed: %v", err)
+	}
+	if got := checklistTexts(t, db, into.ID); got != "x,y,z" {
+		t.Errorf("merged checklist = %s, want x,y,z", got)
+	}
+
+	if err := db.DeleteItem(into.ID); err != nil {
+		t.Fatalf("DeleteItem failed: %v", err)
+	}
+	var count int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM checklist_items`).Scan(&count); err != nil {
+		t.Fatalf("failed to count checklist items: %v", err)
+	}
+	if count != 0 {
+		t.Errorf("expected checklist deleted with the item, %d steps remain", count)
+	}
+}
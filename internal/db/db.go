@@ -1,6 +1,7 @@
 // Package db provides SQLite database operations for the prog task system.
 //
-// The database is stored at ~/.prog/prog.db by default.
+// The database is stored at ~/.prog/prog.db by default, or in a
+// project-local .prog directory found by LocalPath.
 // Use Open() to connect and Init() to create the schema.
 package db
 
@@ -9,13 +10,15 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"time"
 
+	"github.com/baiirun/prog/internal/model"
 	_ "modernc.org/sqlite"
 )
 
 // SchemaVersion is the current schema version.
 // Increment this when adding new migrations.
-const SchemaVersion = 2
+const SchemaVersion = 24
 
 // baseSchema is the original schema (version 1).
 // New tables should be added via migrations, not here.
@@ -139,12 +142,213 @@ CREATE TABLE IF NOT EXISTS item_labels (
 CREATE INDEX IF NOT EXISTS idx_labels_project ON labels(project);
 CREATE INDEX IF NOT EXISTS idx_item_labels_item ON item_labels(item_id);
 CREATE INDEX IF NOT EXISTS idx_item_labels_label ON item_labels(label_id);
+`,
+	// Version 3: Add free-form tags (not project-scoped)
+	`
+CREATE TABLE IF NOT EXISTS tags (
+	item_id TEXT REFERENCES items(id),
+	tag TEXT NOT NULL,
+	PRIMARY KEY (item_id, tag)
+);
+
+CREATE INDEX IF NOT EXISTS idx_tags_tag ON tags(tag);
+`,
+	// Version 4: Add due dates
+	`
+ALTER TABLE items ADD COLUMN due_at DATETIME;
+`,
+	// Version 5: Add archiving
+	`
+ALTER TABLE items ADD COLUMN archived INTEGER NOT NULL DEFAULT 0;
+`,
+	// Version 6: Add log authorship
+	`
+ALTER TABLE logs ADD COLUMN source TEXT NOT NULL DEFAULT '';
+`,
+	// Version 7: Add effort tracking
+	`
+ALTER TABLE items ADD COLUMN estimate_minutes INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE items ADD COLUMN actual_minutes INTEGER NOT NULL DEFAULT 0;
+`,
+	// Version 8: Add status history for undo
+	`
+CREATE TABLE IF NOT EXISTS status_history (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	item_id TEXT NOT NULL REFERENCES items(id),
+	old_status TEXT NOT NULL,
+	new_status TEXT NOT NULL,
+	changed_at DATETIME NOT NULL,
+	undone INTEGER NOT NULL DEFAULT 0
+);
+
+CREATE INDEX IF NOT EXISTS idx_status_history_item ON status_history(item_id);
+`,
+	// Version 9: Add completion timestamp, backfilled from updated_at
+	`
+ALTER TABLE items ADD COLUMN done_at DATETIME;
+UPDATE items SET done_at = updated_at WHERE status = 'done';
+`,
+	// Version 10: Add block reason, backfilled from the latest "Blocked: " log
+	`
+ALTER TABLE items ADD COLUMN block_reason TEXT NOT NULL DEFAULT '';
+UPDATE items SET block_reason = COALESCE((
+	SELECT substr(message, 10) FROM logs
+	WHERE logs.item_id = items.id AND message LIKE 'Blocked: %'
+	ORDER BY logs.id DESC LIMIT 1
+), '') WHERE status = 'blocked';
+`,
+	// Version 11: Add per-project WIP limit (0 means unlimited)
+	`
+ALTER TABLE projects ADD COLUMN wip_limit INTEGER NOT NULL DEFAULT 0;
+`,
+	// Version 12: Add recurrence for tasks that repeat when completed
+	`
+ALTER TABLE items ADD COLUMN recurrence TEXT NOT NULL DEFAULT '';
+`,
+	// Version 13: Add start timestamp, backfilled from the first move to in_progress
+	`
+ALTER TABLE items ADD COLUMN started_at DATETIME;
+UPDATE items SET started_at = (
+	SELECT MIN(changed_at) FROM status_history
+	WHERE status_history.item_id = items.id AND new_status = 'in_progress'
+);
+`,
+	// Version 14: Add pinned key/value notes on items
+	`
+CREATE TABLE IF NOT EXISTS notes (
+	item_id TEXT NOT NULL REFERENCES items(id),
+	key TEXT NOT NULL,
+	value TEXT NOT NULL,
+	updated_at DATETIME NOT NULL,
+	PRIMARY KEY (item_id, key)
+);
+`,
+	// Version 15: Add opt-in auto-completion of epics whose children are all done
+	`
+ALTER TABLE projects ADD COLUMN auto_close_epics INTEGER NOT NULL DEFAULT 0;
+`,
+	// Version 16: Add item locks that guard status and description changes
+	`
+ALTER TABLE items ADD COLUMN locked INTEGER NOT NULL DEFAULT 0;
+`,
+	// Version 17: Drop duplicate dependency edges and keep them unique
+	`
+DELETE FROM deps WHERE rowid NOT IN (
+	SELECT MIN(rowid) FROM deps GROUP BY item_id, depends_on
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_deps_edge ON deps(item_id, depends_on);
+`,
+	// Version 18: Add an event trail for changes not kept elsewhere
+	// (description edits, dependency additions)
+	`
+CREATE TABLE IF NOT EXISTS events (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	item_id TEXT NOT NULL REFERENCES items(id),
+	kind TEXT NOT NULL,
+	detail TEXT NOT NULL,
+	created_at DATETIME NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_events_item ON events(item_id);
+`,
+	// Version 19: Add snoozing, which hides items from ready and list until a time
+	`
+ALTER TABLE items ADD COLUMN snoozed_until DATETIME;
+`,
+	// Version 20: Add named templates for recreating task structures
+	`
+CREATE TABLE IF NOT EXISTS templates (
+	name TEXT PRIMARY KEY,
+	body TEXT NOT NULL,
+	created_at DATETIME NOT NULL,
+	updated_at DATETIME NOT NULL
+);
+`,
+	// Version 21: Add per-item checklists of small steps
+	`
+CREATE TABLE IF NOT EXISTS checklist_items (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	item_id TEXT NOT NULL REFERENCES items(id),
+	text TEXT NOT NULL,
+	done INTEGER NOT NULL DEFAULT 0,
+	position INTEGER NOT NULL,
+	created_at DATETIME NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_checklist_items_item ON checklist_items(item_id, position);
+`,
+	// Version 22: Add loose links between items that don't gate readiness
+	`
+CREATE TABLE IF NOT EXISTS links (
+	from_id TEXT NOT NULL REFERENCES items(id),
+	to_id TEXT NOT NULL REFERENCES items(id),
+	kind TEXT NOT NULL,
+	created_at DATETIME NOT NULL,
+	PRIMARY KEY (from_id, to_id, kind)
+);
+
+CREATE INDEX IF NOT EXISTS idx_links_to ON links(to_id);
+`,
+	// Version 23: Record whether an item's priority was chosen explicitly,
+	// backfilled from the old rule that anything but medium was
+	`
+ALTER TABLE items ADD COLUMN priority_set INTEGER NOT NULL DEFAULT 0;
+
+UPDATE items SET priority_set = 1 WHERE priority != 2;
+`,
+	// Version 24: Record whether a block waits on dependencies, so finishing
+	// them reopens it; backfilled from blocks made together with a dependency
+	`
+ALTER TABLE items ADD COLUMN blocked_on_deps INTEGER NOT NULL DEFAULT 0;
+
+UPDATE items SET blocked_on_deps = 1
+WHERE status = 'blocked' AND EXISTS (
+	SELECT 1 FROM events e
+	WHERE e.item_id = items.id AND e.kind = 'dep' AND e.created_at = (
+		SELECT MAX(changed_at) FROM status_history h
+		WHERE h.item_id = items.id AND h.new_status = 'blocked'
+	)
+);
 `,
 }
 
 // DB wraps a SQL database connection with task-specific operations.
 type DB struct {
 	*sql.DB
+	path           string         // File the database was opened from
+	Clock          Clock          // Source of timestamps; nil means the system clock
+	MaxDescription int            // Description size limit in bytes; 0 means DefaultMaxDescription
+	IDFormat       model.IDFormat // Format of IDs generated for new items
+}
+
+// DefaultMaxDescription is the description size limit used when
+// DB.MaxDescription is unset.
+const DefaultMaxDescription = 64 * 1024
+
+// Clock supplies the current time. Tests can inject a fixed clock to make
+// timestamps deterministic.
+type Clock interface {
+	Now() time.Time
+}
+
+// checkDescription rejects descriptions over the configured size limit.
+func (db *DB) checkDescription(desc string) error {
+	limit := db.MaxDescription
+	if limit <= 0 {
+		limit = DefaultMaxDescription
+	}
+	if len(desc) > limit {
+		return invalidf("description too long: %d bytes (max %d)", len(desc), limit)
+	}
+	return nil
+}
+
+// Now returns the current time from the DB's clock.
+func (db *DB) Now() time.Time {
+	if db.Clock == nil {
+		return time.Now()
+	}
+	return db.Clock.Now()
 }
 
 // DefaultPath returns the default database path (~/.prog/prog.db)
@@ -160,6 +364,36 @@ func DefaultPath() (string, error) {
 	return filepath.Join(home, ".prog", "prog.db"), nil
 }
 
+// LocalDir marks a project-local database: a directory of this name in the
+// working directory or any ancestor holds the database for everything below
+// it, the way .git marks a repository.
+const LocalDir = ".prog"
+
+// LocalPath returns the database in the nearest LocalDir at or above dir,
+// and false if there is none. The home directory's own .prog holds the
+// global database, so the search stops there.
+func LocalPath(dir string) (string, bool) {
+	home, _ := os.UserHomeDir()
+	for {
+		if home != "" && dir == home {
+			return "", false
+		}
+		candidate := filepath.Join(dir, LocalDir)
+		if info, err := os.Stat(candidate); err == nil && info.IsDir() {
+			return filepath.Join(candidate, "prog.db"), true
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", false
+		}
+		dir = parent
+	}
+}
+
+// BusyTimeout is how long a write waits for another connection's lock to
+// clear before failing.
+const BusyTimeout = 5 * time.Second
+
 // Open opens or creates the database at the given path
 func Open(path string) (*DB, error) {
 	// Ensure directory exists
@@ -168,18 +402,27 @@ func Open(path string) (*DB, error) {
 		return nil, fmt.Errorf("failed to create directory: %w", err)
 	}
 
-	db, err := sql.Open("sqlite", path)
+	// Pragmas go in the DSN so every pooled connection gets them, not just
+	// the first. WAL lets readers proceed during a write, and the busy
+	// timeout makes concurrent writers wait instead of failing with
+	// "database is locked".
+	dsn := fmt.Sprintf("%s?_pragma=foreign_keys(1)&_pragma=busy_timeout(%d)&_pragma=journal_mode(WAL)",
+		path, BusyTimeout.Milliseconds())
+	db, err := sql.Open("sqlite", dsn)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open database: %w", err)
 	}
 
-	// Enable foreign keys
-	if _, err := db.Exec("PRAGMA foreign_keys = ON"); err != nil {
+	// sql.Open is lazy; connect now so pragma errors surface here
+	if err := db.Ping(); err != nil {
 		_ = db.Close()
-		return nil, fmt.Errorf("failed to enable foreign keys: %w", err)
+		if IsCorrupt(err) {
+			return nil, fmt.Errorf("failed to open database: %w: %w", ErrCorrupt, err)
+		}
+		return nil, fmt.Errorf("failed to open database: %w", err)
 	}
 
-	return &DB{db}, nil
+	return &DB{DB: db, path: path}, nil
 }
 
 // Init creates the schema for a fresh database.
@@ -217,11 +460,13 @@ func (db *DB) Migrate() error {
 		if err != nil {
 			return fmt.Errorf("failed to check tables: %w", err)
 		}
-		if exists {
-			currentVersion = 1
-			if err := db.setSchemaVersion(1); err != nil {
-				return fmt.Errorf("failed to set legacy version: %w", err)
-			}
+		if !exists {
+			// Uninitialized database: nothing to migrate until Init creates the schema
+			return nil
+		}
+		currentVersion = 1
+		if err := db.setSchemaVersion(1); err != nil {
+			return fmt.Errorf("failed to set legacy version: %w", err)
 		}
 	}
 
@@ -258,6 +503,22 @@ func (db *DB) setSchemaVersion(version int) error {
 	return err
 }
 
+// CheckInitialized returns ErrNotInitialized if Init has never created the
+// schema, and wraps ErrCorrupt if the schema can't be read.
+func (db *DB) CheckInitialized() error {
+	exists, err := db.tableExists("items")
+	if err != nil {
+		if IsCorrupt(err) {
+			return fmt.Errorf("failed to read schema: %w: %w", ErrCorrupt, err)
+		}
+		return fmt.Errorf("failed to read schema: %w", err)
+	}
+	if !exists {
+		return ErrNotInitialized
+	}
+	return nil
+}
+
 // tableExists checks if a table exists in the database.
 func (db *DB) tableExists(name string) (bool, error) {
 	var count int
@@ -1,8 +1,16 @@
 package db
 
 import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
 	"testing"
 	"time"
 
@@ -43,6 +51,92 @@ func TestOpen(t *testing.T) {
 	}
 }
 
+func TestOpen_Pragmas(t *testing.T) {
+	db := setupTestDB(t)
+
+	// Hold several connections at once so pooled ones are checked too
+	for range 3 {
+		conn, err := db.Conn(context.Background())
+		if err != nil {
+			t.Fatalf("failed to get connection: %v", err)
+		}
+		defer func() { _ = conn.Close() }()
+
+		var mode string
+		var timeout, fk int
+		if err := conn.QueryRowContext(context.Background(), `PRAGMA journal_mode`).Scan(&mode); err != nil {
+			t.Fatalf("journal_mode: %v", err)
+		}
+		if err := conn.QueryRowContext(context.Background(), `PRAGMA busy_timeout`).Scan(&timeout); err != nil {
+			t.Fatalf("busy_timeout: %v", err)
+		}
+		if err := conn.QueryRowContext(context.Background(), `PRAGMA foreign_keys`).Scan(&fk); err != nil {
+			t.Fatalf("foreign_keys: %v", err)
+		}
+		if mode != "wal" || timeout != int(BusyTimeout.Milliseconds()) || fk != 1 {
+			t.Errorf("pragmas = %s/%d/%d, want wal/%d/1", mode, timeout, fk, BusyTimeout.Milliseconds())
+		}
+	}
+}
+
+func TestOpen_ConcurrentWriters(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.db")
+	setup, err := Open(path)
+	if err != nil {
+		t.Fatalf("failed to open db: %v", err)
+	}
+	if err := setup.Init(); err != nil {
+		t.Fatalf("failed to init db: %v", err)
+	}
+	_ = setup.Close()
+
+	// Separate handles behave like two agent processes sharing the file
+	const writers, perWriter = 2, 25
+	errs := make(chan error, writers)
+	for w := range writers {
+		go func() {
+			db, err := Open(path)
+			if err != nil {
+				errs <- err
+				return
+			}
+			defer func() { _ = db.Close() }()
+			for i := range perWriter {
+				item := &model.Item{
+					ID:      model.GenerateID(model.ItemTypeTask),
+					Project: "test",
+					Type:    model.ItemTypeTask,
+					Title:   fmt.Sprintf("writer %d item %d", w, i),
+					Status:  model.StatusOpen,
+				}
+				if err := db.CreateItem(item); err != nil {
+					errs <- err
+					return
+				}
+			}
+			errs <- nil
+		}()
+	}
+	for range writers {
+		if err := <-errs; err != nil {
+			t.Fatalf("concurrent write failed: %v", err)
+		}
+	}
+
+	db, err := Open(path)
+	if err != nil {
+		t.Fatalf("failed to reopen db: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+	items, err := db.ListItems("test", nil)
+	if err != nil {
+		t.Fatalf("failed to list: %v", err)
+	}
+	if len(items) != writers*perWriter {
+		t.Errorf("got %d items, want %d", len(items), writers*perWriter)
+	}
+}
+
 func TestDefaultPath(t *testing.T) {
 	path, err := DefaultPath()
 	if err != nil {
@@ -58,6 +152,31 @@ func TestDefaultPath(t *testing.T) {
 	}
 }
 
+func TestLocalPath(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	project := filepath.Join(home, "code", "app")
+	deep := filepath.Join(project, "internal", "pkg")
+	if err := os.MkdirAll(deep, 0755); err != nil {
+		t.Fatal(err)
+	}
+	// The global ~/.prog is never mistaken for a local database
+	if err := os.Mkdir(filepath.Join(home, LocalDir), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if path, ok := LocalPath(deep); ok {
+		t.Errorf("found %q, want no local database", path)
+	}
+
+	if err := os.Mkdir(filepath.Join(project, LocalDir), 0755); err != nil {
+		t.Fatal(err)
+	}
+	path, ok := LocalPath(deep)
+	if want := filepath.Join(project, LocalDir, "prog.db"); !ok || path != want {
+		t.Errorf("LocalPath = %q, %v; want %q", path, ok, want)
+	}
+}
+
 func contains(s, substr string) bool {
 	return len(s) >= len(substr) && (s == substr || len(s) > 0 && contains(s[1:], substr))
 }
@@ -94,6 +213,70 @@ func TestCreateItem(t *testing.T) {
 	}
 }
 
+func TestCreateItem_GeneratesID(t *testing.T) {
+	db := setupTestDB(t)
+	db.IDFormat = model.IDFormat{Bytes: 5, Base32: true}
+
+	item := &model.Item{Project: "test", Type: model.ItemTypeEpic, Title: "Epic", Status: model.StatusOpen}
+	if err := db.CreateItem(item); err != nil {
+		t.Fatalf("failed to create item: %v", err)
+	}
+	// 5 bytes encode to 8 base32 chars
+	if !strings.HasPrefix(item.ID, "ep-") || len(item.ID) != 11 {
+		t.Errorf("ID = %q, want ep- plus 8 chars", item.ID)
+	}
+}
+
+func TestCreateItem_RetriesIDCollision(t *testing.T) {
+	db := setupTestDB(t)
+
+	// The first two generated IDs are the same; later ones are random
+	zeros := make([]byte, 2*model.DefaultIDBytes)
+	model.IDRand = io.MultiReader(bytes.NewReader(zeros), rand.Reader)
+	t.Cleanup(func() { model.IDRand = rand.Reader })
+
+	first := &model.Item{Project: "test", Type: model.ItemTypeTask, Title: "First", Status: model.StatusOpen}
+	if err := db.CreateItem(first); err != nil {
+		t.Fatalf("failed to create item: %v", err)
+	}
+	second := &model.Item{Project: "test", Type: model.ItemTypeTask, Title: "Second", Status: model.StatusOpen}
+	if err := db.CreateItem(second); err != nil {
+		t.Fatalf("expected collision to be retried, got: %v", err)
+	}
+	if second.ID == first.ID {
+		t.Fatalf("expected a fresh ID, still %s", second.ID)
+	}
+
+	got, err := db.GetItem(first.ID)
+	if err != nil {
+		t.Fatalf("failed to get item: %v", err)
+	}
+	if got.Title != "First" {
+		t.Errorf("original item title = %q, want First", got.Title)
+	}
+}
+
+func TestCreateItem_SuppliedIDConflict(t *testing.T) {
+	db := setupTestDB(t)
+
+	first := &model.Item{ID: "ts-aaaaaa", Project: "test", Type: model.ItemTypeTask, Title: "First", Status: model.StatusOpen}
+	if err := db.CreateItem(first); err != nil {
+		t.Fatalf("failed to create item: %v", err)
+	}
+
+	// A caller-chosen ID, as on import, is never swapped for another
+	second := &model.Item{ID: "ts-aaaaaa", Project: "test", Type: model.ItemTypeTask, Title: "Second", Status: model.StatusOpen}
+	if err := db.CreateItem(second); err == nil || !isIDConflict(err) {
+		t.Fatalf("expected an ID conflict, got: %v", err)
+	}
+	if second.ID != "ts-aaaaaa" {
+		t.Errorf("ID = %s, want the supplied ts-aaaaaa", second.ID)
+	}
+	if items, _ := db.ListItems("test", nil); len(items) != 1 {
+		t.Errorf("expected only the first item, got %d", len(items))
+	}
+}
+
 func TestCreateItem_InvalidType(t *testing.T) {
 	db := setupTestDB(t)
 
@@ -128,6 +311,59 @@ func TestCreateItem_InvalidStatus(t *testing.T) {
 	}
 }
 
+func TestCreateItem_PriorityBounds(t *testing.T) {
+	db := setupTestDB(t)
+
+	tests := []struct {
+		priority int
+		wantErr  bool
+	}{
+		{-1, true},
+		{1, false},
+		{3, false},
+		{4, true},
+		{99, true},
+	}
+
+	for _, tt := range tests {
+		item := &model.Item{
+			ID:       model.GenerateID(model.ItemTypeTask),
+			Project:  "test",
+			Type:     model.ItemTypeTask,
+			Title:    "Test",
+			Status:   model.StatusOpen,
+			Priority: tt.priority,
+		}
+		err := db.CreateItem(item)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("priority %d: error = %v, wantErr %v", tt.priority, err, tt.wantErr)
+		}
+	}
+}
+
+func TestCreateItem_ZeroPriorityDefaultsToMedium(t *testing.T) {
+	db := setupTestDB(t)
+
+	item := &model.Item{
+		ID:      "ts-123456",
+		Project: "test",
+		Type:    model.ItemTypeTask,
+		Title:   "Test",
+		Status:  model.StatusOpen,
+	}
+	if err := db.CreateItem(item); err != nil {
+		t.Fatalf("failed to create item: %v", err)
+	}
+
+	got, err := db.GetItem(item.ID)
+	if err != nil {
+		t.Fatalf("failed to get item: %v", err)
+	}
+	if got.Priority != model.PriorityMedium {
+		t.Errorf("priority = %d, want %d", got.Priority, model.PriorityMedium)
+	}
+}
+
 func TestGetItem_NotFound(t *testing.T) {
 	db := setupTestDB(t)
 
@@ -181,210 +417,1516 @@ func TestUpdateStatus_InvalidStatus(t *testing.T) {
 	}
 }
 
-func TestAppendDescription(t *testing.T) {
+func TestUpdateStatus_ReopenFromDoneLogs(t *testing.T) {
 	db := setupTestDB(t)
 
 	item := &model.Item{
-		ID:          model.GenerateID(model.ItemTypeTask),
-		Project:     "test",
-		Type:        model.ItemTypeTask,
-		Title:       "Test",
-		Description: "Initial",
-		Status:      model.StatusOpen,
-		CreatedAt:   time.Now(),
-		UpdatedAt:   time.Now(),
+		ID:        model.GenerateID(model.ItemTypeTask),
+		Project:   "test",
+		Type:      model.ItemTypeTask,
+		Title:     "Test",
+		Status:    model.StatusDone,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
 	}
 	if err := db.CreateItem(item); err != nil {
 		t.Fatalf("failed to create item: %v", err)
 	}
 
-	if err := db.AppendDescription(item.ID, "Appended text"); err != nil {
-		t.Fatalf("failed to append: %v", err)
+	if err := db.UpdateStatus(item.ID, model.StatusOpen); err != nil {
+		t.Fatalf("failed to reopen: %v", err)
 	}
 
-	got, _ := db.GetItem(item.ID)
-	if got.Description == "Initial" {
-		t.Error("description was not appended")
+	logs, err := db.GetLogs(item.ID)
+	if err != nil {
+		t.Fatalf("failed to get logs: %v", err)
+	}
+	if len(logs) != 1 {
+		t.Fatalf("expected 1 log, got %d", len(logs))
+	}
+	if logs[0].Message != "Reopened from done" {
+		t.Errorf("log = %q, want %q", logs[0].Message, "Reopened from done")
 	}
 }
 
-func TestSetParent(t *testing.T) {
+func TestUpdateStatus_NoReopenLogForOtherTransitions(t *testing.T) {
 	db := setupTestDB(t)
 
-	epic := &model.Item{
-		ID:        model.GenerateID(model.ItemTypeEpic),
-		Project:   "test",
-		Type:      model.ItemTypeEpic,
-		Title:     "Test Epic",
-		Status:    model.StatusOpen,
-		CreatedAt: time.Now(),
-		UpdatedAt: time.Now(),
-	}
-	if err := db.CreateItem(epic); err != nil {
-		t.Fatalf("failed to create epic: %v", err)
-	}
-
-	task := &model.Item{
+	item := &model.Item{
 		ID:        model.GenerateID(model.ItemTypeTask),
 		Project:   "test",
 		Type:      model.ItemTypeTask,
-		Title:     "Test Task",
+		Title:     "Test",
 		Status:    model.StatusOpen,
 		CreatedAt: time.Now(),
 		UpdatedAt: time.Now(),
 	}
-	if err := db.CreateItem(task); err != nil {
-		t.Fatalf("failed to create task: %v", err)
+	if err := db.CreateItem(item); err != nil {
+		t.Fatalf("failed to create item: %v", err)
 	}
 
-	if err := db.SetParent(task.ID, epic.ID); err != nil {
-		t.Fatalf("failed to set parent: %v", err)
+	for _, status := range []model.Status{model.StatusInProgress, model.StatusDone, model.StatusCanceled} {
+		if err := db.UpdateStatus(item.ID, status); err != nil {
+			t.Fatalf("failed to update status to %s: %v", status, err)
+		}
 	}
 
-	got, _ := db.GetItem(task.ID)
-	if got.ParentID == nil {
-		t.Fatal("expected parent ID to be set")
-	}
-	if *got.ParentID != epic.ID {
-		t.Errorf("parent = %q, want %q", *got.ParentID, epic.ID)
+	logs, _ := db.GetLogs(item.ID)
+	if len(logs) != 0 {
+		t.Errorf("expected no logs, got %d", len(logs))
 	}
 }
 
-func TestSetParent_NotEpic(t *testing.T) {
+func TestUpdateStatuses(t *testing.T) {
 	db := setupTestDB(t)
 
-	task1 := &model.Item{
-		ID:        model.GenerateID(model.ItemTypeTask),
-		Project:   "test",
-		Type:      model.ItemTypeTask,
-		Title:     "Task 1",
-		Status:    model.StatusOpen,
-		CreatedAt: time.Now(),
-		UpdatedAt: time.Now(),
-	}
-	if err := db.CreateItem(task1); err != nil {
-		t.Fatalf("failed to create task1: %v", err)
-	}
+	a := createTestItem(t, db, "A")
+	b := createTestItem(t, db, "B")
 
-	task2 := &model.Item{
-		ID:        model.GenerateID(model.ItemTypeTask),
-		Project:   "test",
-		Type:      model.ItemTypeTask,
-		Title:     "Task 2",
-		Status:    model.StatusOpen,
-		CreatedAt: time.Now(),
-		UpdatedAt: time.Now(),
-	}
-	if err := db.CreateItem(task2); err != nil {
-		t.Fatalf("failed to create task2: %v", err)
+	if err := db.UpdateStatuses([]string{a.ID, b.ID}, model.StatusBlocked, "Blocked: waiting"); err != nil {
+		t.Fatalf("failed to update statuses: %v", err)
 	}
 
-	err := db.SetParent(task2.ID, task1.ID)
-	if err == nil {
-		t.Error("expected error when parent is not an epic")
+	for _, id := range []string{a.ID, b.ID} {
+		got, _ := db.GetItem(id)
+		if got.Status != model.StatusBlocked {
+			t.Errorf("%s: expected blocked, got %s", id, got.Status)
+		}
+		logs, _ := db.GetLogs(id)
+		if len(logs) != 1 || logs[0].Message != "Blocked: waiting" {
+			t.Errorf("%s: expected one block log, got %v", id, logs)
+		}
 	}
 }
 
-func TestSetDescription(t *testing.T) {
+func TestUpdateStatuses_AllOrNothing(t *testing.T) {
 	db := setupTestDB(t)
 
-	item := &model.Item{
-		ID:          model.GenerateID(model.ItemTypeTask),
-		Project:     "test",
-		Type:        model.ItemTypeTask,
-		Title:       "Test",
-		Description: "Original description",
-		Status:      model.StatusOpen,
-		CreatedAt:   time.Now(),
-		UpdatedAt:   time.Now(),
-	}
-	if err := db.CreateItem(item); err != nil {
-		t.Fatalf("failed to create item: %v", err)
-	}
+	a := createTestItem(t, db, "A")
+	b := createTestItem(t, db, "B")
 
-	if err := db.SetDescription(item.ID, "New description"); err != nil {
-		t.Fatalf("failed to set description: %v", err)
+	err := db.UpdateStatuses([]string{a.ID, "ts-nope01", b.ID, "ts-nope02"}, model.StatusDone, "")
+	if err == nil {
+		t.Fatal("expected error for missing ids")
+	}
+	if !strings.Contains(err.Error(), "ts-nope01, ts-nope02") {
+		t.Errorf("error should list missing ids, got: %v", err)
 	}
 
-	got, _ := db.GetItem(item.ID)
-	if got.Description != "New description" {
-		t.Errorf("description = %q, want %q", got.Description, "New description")
+	for _, id := range []string{a.ID, b.ID} {
+		got, _ := db.GetItem(id)
+		if got.Status != model.StatusOpen {
+			t.Errorf("%s: expected rollback to open, got %s", id, got.Status)
+		}
 	}
 }
 
-func TestSetDescription_EmptyToContent(t *testing.T) {
+func TestUpdateStatus_EpicWithUnfinishedChildren(t *testing.T) {
 	db := setupTestDB(t)
 
-	item := &model.Item{
-		ID:        model.GenerateID(model.ItemTypeTask),
-		Project:   "test",
-		Type:      model.ItemTypeTask,
-		Title:     "Test",
-		Status:    model.StatusOpen,
-		CreatedAt: time.Now(),
-		UpdatedAt: time.Now(),
+	epic := createTestEpic(t, db, "Epic", "")
+	done := createTestItem(t, db, "Done child")
+	open := createTestItem(t, db, "Open child")
+	canceled := createTestItem(t, db, "Canceled child")
+	for _, c := range []*model.Item{done, open, canceled} {
+		if err := db.SetParent(c.ID, epic.ID); err != nil {
+			t.Fatalf("failed to set parent: %v", err)
+		}
 	}
-	if err := db.CreateItem(item); err != nil {
-		t.Fatalf("failed to create item: %v", err)
+	if err := db.UpdateStatus(done.ID, model.StatusDone); err != nil {
+		t.Fatalf("failed to complete child: %v", err)
+	}
+	if err := db.UpdateStatus(canceled.ID, model.StatusCanceled); err != nil {
+		t.Fatalf("failed to cancel child: %v", err)
 	}
 
-	if err := db.SetDescription(item.ID, "Added description"); err != nil {
-		t.Fatalf("failed to set description: %v", err)
+	err := db.UpdateStatus(epic.ID, model.StatusDone)
+	if !errors.Is(err, ErrTransition) {
+		t.Fatalf("completing epic with open child = %v, want ErrTransition", err)
+	}
+	if !strings.Contains(err.Error(), "children not done: "+open.ID) {
+		t.Errorf("error should list open child, got: %v", err)
+	}
+	if got, _ := db.GetItem(epic.ID); got.Status != model.StatusOpen {
+		t.Errorf("epic should remain open, got %s", got.Status)
 	}
 
-	got, _ := db.GetItem(item.ID)
-	if got.Description != "Added description" {
-		t.Errorf("description = %q, want %q", got.Description, "Added description")
+	// Completing the child in the same batch is allowed
+	if err := db.UpdateStatuses([]string{epic.ID, open.ID}, model.StatusDone, ""); err != nil {
+		t.Fatalf("batch completion failed: %v", err)
 	}
 }
 
-func TestSetDescription_NotFound(t *testing.T) {
+func TestForceUpdateStatuses_EpicWithUnfinishedChildren(t *testing.T) {
 	db := setupTestDB(t)
 
-	err := db.SetDescription("nonexistent", "text")
-	if err == nil {
-		t.Error("expected error for nonexistent item")
+	epic := createTestEpic(t, db, "Epic", "")
+	child := createTestItem(t, db, "Child")
+	if err := db.SetParent(child.ID, epic.ID); err != nil {
+		t.Fatalf("failed to set parent: %v", err)
+	}
+
+	if err := db.ForceUpdateStatuses([]string{epic.ID}, model.StatusDone, ""); err != nil {
+		t.Fatalf("force completion failed: %v", err)
+	}
+	if got, _ := db.GetItem(epic.ID); got.Status != model.StatusDone {
+		t.Errorf("expected epic done, got %s", got.Status)
 	}
 }
 
-func TestSetParent_NotFound(t *testing.T) {
+func TestUpdateStatus_AutoCloseEpic(t *testing.T) {
 	db := setupTestDB(t)
 
-	epic := &model.Item{
-		ID:        model.GenerateID(model.ItemTypeEpic),
-		Project:   "test",
-		Type:      model.ItemTypeEpic,
-		Title:     "Epic",
-		Status:    model.StatusOpen,
-		CreatedAt: time.Now(),
-		UpdatedAt: time.Now(),
+	epic := createTestEpic(t, db, "Epic", "auto")
+	first := createTestItemWithProject(t, db, "First", "auto", model.StatusOpen, 2)
+	second := createTestItemWithProject(t, db, "Second", "auto", model.StatusOpen, 2)
+	for _, c := range []*model.Item{first, second} {
+		if err := db.SetParent(c.ID, epic.ID); err != nil {
+			t.Fatalf("failed to set parent: %v", err)
+		}
 	}
-	if err := db.CreateItem(epic); err != nil {
-		t.Fatalf("failed to create epic: %v", err)
+
+	// Off by default
+	if on, err := db.AutoCloseEpics("auto"); err != nil || on {
+		t.Fatalf("AutoCloseEpics = %v, %v; want false", on, err)
+	}
+	if err := db.SetAutoCloseEpics("auto", true); err != nil {
+		t.Fatalf("failed to enable auto-close: %v", err)
+	}
+	if err := db.SetAutoCloseEpics("nope", true); !errors.Is(err, ErrNotFound) {
+		t.Errorf("SetAutoCloseEpics on missing project = %v, want ErrNotFound", err)
 	}
 
-	// Nonexistent task
-	err := db.SetParent("nonexistent", epic.ID)
-	if err == nil {
-		t.Error("expected error for nonexistent task")
+	if err := db.UpdateStatus(first.ID, model.StatusDone); err != nil {
+		t.Fatalf("failed to complete first child: %v", err)
+	}
+	if got, _ := db.GetItem(epic.ID); got.Status != model.StatusOpen {
+		t.Errorf("epic = %s with a child still open, want open", got.Status)
+	}
+	if err := db.UpdateStatus(second.ID, model.StatusDone); err != nil {
+		t.Fatalf("failed to complete second child: %v", err)
+	}
+	if got, _ := db.GetItem(epic.ID); got.Status != model.StatusDone {
+		t.Errorf("epic = %s after last child done, want done", got.Status)
+	}
+	logs, _ := db.GetLogs(epic.ID)
+	if len(logs) != 1 || logs[0].Message != "Auto-completed: all children done" {
+		t.Errorf("epic logs = %+v", logs)
 	}
 
-	// Nonexistent parent
-	task := &model.Item{
-		ID:        model.GenerateID(model.ItemTypeTask),
-		Project:   "test",
-		Type:      model.ItemTypeTask,
-		Title:     "Task",
-		Status:    model.StatusOpen,
-		CreatedAt: time.Now(),
-		UpdatedAt: time.Now(),
+	// Projects without the setting keep their epics open
+	manual := createTestEpic(t, db, "Bucket", "manual")
+	child := createTestItemWithProject(t, db, "Child", "manual", model.StatusOpen, 2)
+	if err := db.SetParent(child.ID, manual.ID); err != nil {
+		t.Fatalf("failed to set parent: %v", err)
 	}
-	if err := db.CreateItem(task); err != nil {
-		t.Fatalf("failed to create task: %v", err)
+	if err := db.UpdateStatus(child.ID, model.StatusDone); err != nil {
+		t.Fatalf("failed to complete child: %v", err)
+	}
+	if got, _ := db.GetItem(manual.ID); got.Status != model.StatusOpen {
+		t.Errorf("epic = %s without auto-close, want open", got.Status)
 	}
+}
 
-	err = db.SetParent(task.ID, "nonexistent")
+func TestSetLocked(t *testing.T) {
+	db := setupTestDB(t)
+	item := createTestItem(t, db, "Contract")
+
+	if err := db.SetLocked(item.ID, true); err != nil {
+		t.Fatalf("failed to lock: %v", err)
+	}
+	if got, _ := db.GetItem(item.ID); !got.Locked {
+		t.Fatal("item should be locked")
+	}
+
+	for name, err := range map[string]error{
+		"UpdateStatus":      db.UpdateStatus(item.ID, model.StatusInProgress),
+		"SetDescription":    db.SetDescription(item.ID, "changed"),
+		"AppendDescription": db.AppendDescription(item.ID, "more"),
+	} {
+		if !errors.Is(err, ErrLocked) {
+			t.Errorf("%s on locked item = %v, want ErrLocked", name, err)
+		}
+	}
+	if got, _ := db.GetItem(item.ID); got.Status != model.StatusOpen || got.Description != "" {
+		t.Errorf("locked item changed: %+v", got)
+	}
+
+	if err := db.ForceUpdateStatuses([]string{item.ID}, model.StatusInProgress, ""); err != nil {
+		t.Errorf("forced status change failed: %v", err)
+	}
+	if err := db.ForceSetDescription(item.ID, "forced"); err != nil {
+		t.Errorf("forced description failed: %v", err)
+	}
+	if err := db.ForceAppendDescription(item.ID, "too"); err != nil {
+		t.Errorf("forced append failed: %v", err)
+	}
+
+	if err := db.SetLocked(item.ID, false); err != nil {
+		t.Fatalf("failed to unlock: %v", err)
+	}
+	if err := db.UpdateStatus(item.ID, model.StatusDone); err != nil {
+		t.Errorf("status change after unlock failed: %v", err)
+	}
+	if err := db.SetLocked("ts-nope00", true); !errors.Is(err, ErrNotFound) {
+		t.Errorf("SetLocked on missing item = %v, want ErrNotFound", err)
+	}
+}
+
+func TestUpdateStatus_IllegalTransition(t *testing.T) {
+	db := setupTestDB(t)
+	item := createTestItem(t, db, "Task")
+
+	if err := db.UpdateStatus(item.ID, model.StatusDone); err != nil {
+		t.Fatalf("failed to complete: %v", err)
+	}
+
+	err := db.UpdateStatus(item.ID, model.StatusBlocked)
+	if !errors.Is(err, ErrTransition) {
+		t.Fatalf("done -> blocked = %v, want ErrTransition", err)
+	}
+	if !strings.Contains(err.Error(), "done can move to: open, in_progress, canceled") {
+		t.Errorf("error should list allowed transitions, got: %v", err)
+	}
+	if got, _ := db.GetItem(item.ID); got.Status != model.StatusDone {
+		t.Errorf("status = %s, want done", got.Status)
+	}
+
+	if err := db.ForceUpdateStatuses([]string{item.ID}, model.StatusBlocked, ""); err != nil {
+		t.Fatalf("force update failed: %v", err)
+	}
+	if got, _ := db.GetItem(item.ID); got.Status != model.StatusBlocked {
+		t.Errorf("status = %s, want blocked", got.Status)
+	}
+}
+
+func TestResolveID(t *testing.T) {
+	db := setupTestDB(t)
+
+	for _, id := range []string{"ts-a1b2c3", "ts-a1ffff", "ep-a1b2d4", "ts-a1"} {
+		item := &model.Item{ID: id, Project: "test", Type: model.ItemTypeTask, Title: id, Status: model.StatusOpen}
+		if err := db.CreateItem(item); err != nil {
+			t.Fatalf("failed to create %s: %v", id, err)
+		}
+	}
+
+	tests := []struct {
+		prefix  string
+		want    string
+		wantErr string
+	}{
+		{prefix: "ts-a1b2c3", want: "ts-a1b2c3"},
+		{prefix: "ts-a1", want: "ts-a1"}, // exact match beats longer ids
+		{prefix: "a1b2c", want: "ts-a1b2c3"},
+		{prefix: "ts-a1b", want: "ts-a1b2c3"},
+		{prefix: "a1f", want: "ts-a1ffff"},
+		{prefix: "a1b2", wantErr: "ambiguous id a1b2: matches ep-a1b2d4, ts-a1b2c3"},
+		{prefix: "ffff", wantErr: "item not found: ffff"},
+		{prefix: "a1%", wantErr: "item not found"},
+		{prefix: "", wantErr: "item not found"},
+	}
+	for _, tt := range tests {
+		got, err := db.ResolveID(tt.prefix)
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("ResolveID(%q) error = %v, want containing %q", tt.prefix, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("ResolveID(%q) = %q, %v, want %q", tt.prefix, got, err, tt.want)
+		}
+	}
+}
+
+func TestUpdateStatus_DoneUnblocksDependents(t *testing.T) {
+	db := setupTestDB(t)
+
+	dep1 := createTestItem(t, db, "Dep 1")
+	dep2 := createTestItem(t, db, "Dep 2")
+	waiting := createTestItem(t, db, "Waiting on both")
+	inProgress := createTestItem(t, db, "In progress dependent")
+	for _, id := range []string{waiting.ID, inProgress.ID} {
+		if err := db.AddDep(id, dep1.ID); err != nil {
+			t.Fatalf("failed to add dep: %v", err)
+		}
+	}
+	if err := db.AddDep(waiting.ID, dep2.ID); err != nil {
+		t.Fatalf("failed to add dep: %v", err)
+	}
+	if err := db.UpdateStatus(waiting.ID, model.StatusBlocked); err != nil {
+		t.Fatalf("failed to block: %v", err)
+	}
+	if err := db.UpdateStatus(inProgress.ID, model.StatusInProgress); err != nil {
+		t.Fatalf("failed to start: %v", err)
+	}
+
+	// One dep still open: stays blocked
+	if err := db.UpdateStatus(dep1.ID, model.StatusDone); err != nil {
+		t.Fatalf("failed to complete dep1: %v", err)
+	}
+	got, _ := db.GetItem(waiting.ID)
+	if got.Status != model.StatusBlocked {
+		t.Fatalf("expected still blocked, got %s", got.Status)
+	}
+
+	// Last dep done: unblocked with a log entry
+	if err := db.UpdateStatus(dep2.ID, model.StatusDone); err != nil {
+		t.Fatalf("failed to complete dep2: %v", err)
+	}
+	got, _ = db.GetItem(waiting.ID)
+	if got.Status != model.StatusOpen {
+		t.Errorf("expected open after last dep done, got %s", got.Status)
+	}
+	logs, _ := db.GetLogs(waiting.ID)
+	if len(logs) != 1 || logs[0].Message != "Unblocked: "+dep2.ID+" completed" {
+		t.Errorf("unexpected logs: %v", logs)
+	}
+
+	// Non-blocked dependents are left alone
+	got, _ = db.GetItem(inProgress.ID)
+	if got.Status != model.StatusInProgress {
+		t.Errorf("in-progress dependent changed to %s", got.Status)
+	}
+}
+
+func TestAppendDescription(t *testing.T) {
+	db := setupTestDB(t)
+
+	item := &model.Item{
+		ID:          model.GenerateID(model.ItemTypeTask),
+		Project:     "test",
+		Type:        model.ItemTypeTask,
+		Title:       "Test",
+		Description: "Initial",
+		Status:      model.StatusOpen,
+		CreatedAt:   time.Now(),
+		UpdatedAt:   time.Now(),
+	}
+	if err := db.CreateItem(item); err != nil {
+		t.Fatalf("failed to create item: %v", err)
+	}
+
+	if err := db.AppendDescription(item.ID, "Appended text"); err != nil {
+		t.Fatalf("failed to append: %v", err)
+	}
+
+	got, _ := db.GetItem(item.ID)
+	if got.Description == "Initial" {
+		t.Error("description was not appended")
+	}
+}
+
+func TestAppendDescription_SingleNewline(t *testing.T) {
+	db := setupTestDB(t)
+	item := createTestItem(t, db, "Task")
+
+	for _, chunk := range []string{"First  \n\n", "Second\t", "Third"} {
+		if err := db.AppendDescription(item.ID, chunk); err != nil {
+			t.Fatalf("failed to append: %v", err)
+		}
+	}
+
+	got, _ := db.GetItem(item.ID)
+	if got.Description != "First\nSecond\nThird" {
+		t.Errorf("description = %q, want %q", got.Description, "First\nSecond\nThird")
+	}
+}
+
+func TestDescriptionLimit(t *testing.T) {
+	db := setupTestDB(t)
+	db.MaxDescription = 10
+	item := createTestItem(t, db, "Task")
+
+	if err := db.SetDescription(item.ID, "0123456789"); err != nil {
+		t.Fatalf("description at the limit should be allowed: %v", err)
+	}
+	if err := db.SetDescription(item.ID, "01234567890"); err == nil || !strings.Contains(err.Error(), "description too long") {
+		t.Errorf("expected too-long error from SetDescription, got %v", err)
+	}
+	if err := db.AppendDescription(item.ID, "x"); err == nil {
+		t.Error("expected too-long error from AppendDescription")
+	}
+
+	got, _ := db.GetItem(item.ID)
+	if got.Description != "0123456789" {
+		t.Errorf("description changed by rejected writes: %q", got.Description)
+	}
+}
+
+func TestSetParent(t *testing.T) {
+	db := setupTestDB(t)
+
+	epic := &model.Item{
+		ID:        model.GenerateID(model.ItemTypeEpic),
+		Project:   "test",
+		Type:      model.ItemTypeEpic,
+		Title:     "Test Epic",
+		Status:    model.StatusOpen,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+	if err := db.CreateItem(epic); err != nil {
+		t.Fatalf("failed to create epic: %v", err)
+	}
+
+	task := &model.Item{
+		ID:        model.GenerateID(model.ItemTypeTask),
+		Project:   "test",
+		Type:      model.ItemTypeTask,
+		Title:     "Test Task",
+		Status:    model.StatusOpen,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+	if err := db.CreateItem(task); err != nil {
+		t.Fatalf("failed to create task: %v", err)
+	}
+
+	if err := db.SetParent(task.ID, epic.ID); err != nil {
+		t.Fatalf("failed to set parent: %v", err)
+	}
+
+	got, _ := db.GetItem(task.ID)
+	if got.ParentID == nil {
+		t.Fatal("expected parent ID to be set")
+	}
+	if *got.ParentID != epic.ID {
+		t.Errorf("parent = %q, want %q", *got.ParentID, epic.ID)
+	}
+}
+
+func TestSetParent_NotEpic(t *testing.T) {
+	db := setupTestDB(t)
+
+	task1 := &model.Item{
+		ID:        model.GenerateID(model.ItemTypeTask),
+		Project:   "test",
+		Type:      model.ItemTypeTask,
+		Title:     "Task 1",
+		Status:    model.StatusOpen,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+	if err := db.CreateItem(task1); err != nil {
+		t.Fatalf("failed to create task1: %v", err)
+	}
+
+	task2 := &model.Item{
+		ID:        model.GenerateID(model.ItemTypeTask),
+		Project:   "test",
+		Type:      model.ItemTypeTask,
+		Title:     "Task 2",
+		Status:    model.StatusOpen,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+	if err := db.CreateItem(task2); err != nil {
+		t.Fatalf("failed to create task2: %v", err)
+	}
+
+	err := db.SetParent(task2.ID, task1.ID)
 	if err == nil {
-		t.Error("expected error for nonexistent parent")
+		t.Error("expected error when parent is not an epic")
+	}
+}
+
+func TestSetDescription(t *testing.T) {
+	db := setupTestDB(t)
+
+	item := &model.Item{
+		ID:          model.GenerateID(model.ItemTypeTask),
+		Project:     "test",
+		Type:        model.ItemTypeTask,
+		Title:       "Test",
+		Description: "Original description",
+		Status:      model.StatusOpen,
+		CreatedAt:   time.Now(),
+		UpdatedAt:   time.Now(),
+	}
+	if err := db.CreateItem(item); err != nil {
+		t.Fatalf("failed to create item: %v", err)
+	}
+
+	if err := db.SetDescription(item.ID, "New description"); err != nil {
+		t.Fatalf("failed to set description: %v", err)
+	}
+
+	got, _ := db.GetItem(item.ID)
+	if got.Description != "New description" {
+		t.Errorf("description = %q, want %q", got.Description, "New description")
+	}
+}
+
+func TestSetDescription_EmptyToContent(t *testing.T) {
+	db := setupTestDB(t)
+
+	item := &model.Item{
+		ID:        model.GenerateID(model.ItemTypeTask),
+		Project:   "test",
+		Type:      model.ItemTypeTask,
+		Title:     "Test",
+		Status:    model.StatusOpen,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+	if err := db.CreateItem(item); err != nil {
+		t.Fatalf("failed to create item: %v", err)
+	}
+
+	if err := db.SetDescription(item.ID, "Added description"); err != nil {
+		t.Fatalf("failed to set description: %v", err)
+	}
+
+	got, _ := db.GetItem(item.ID)
+	if got.Description != "Added description" {
+		t.Errorf("description = %q, want %q", got.Description, "Added description")
+	}
+}
+
+func TestSetDescription_NotFound(t *testing.T) {
+	db := setupTestDB(t)
+
+	err := db.SetDescription("nonexistent", "text")
+	if err == nil {
+		t.Error("expected error for nonexistent item")
+	}
+}
+
+func TestSetParent_NotFound(t *testing.T) {
+	db := setupTestDB(t)
+
+	epic := &model.Item{
+		ID:        model.GenerateID(model.ItemTypeEpic),
+		Project:   "test",
+		Type:      model.ItemTypeEpic,
+		Title:     "Epic",
+		Status:    model.StatusOpen,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+	if err := db.CreateItem(epic); err != nil {
+		t.Fatalf("failed to create epic: %v", err)
+	}
+
+	// Nonexistent task
+	err := db.SetParent("nonexistent", epic.ID)
+	if err == nil {
+		t.Error("expected error for nonexistent task")
+	}
+
+	// Nonexistent parent
+	task := &model.Item{
+		ID:        model.GenerateID(model.ItemTypeTask),
+		Project:   "test",
+		Type:      model.ItemTypeTask,
+		Title:     "Task",
+		Status:    model.StatusOpen,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+	if err := db.CreateItem(task); err != nil {
+		t.Fatalf("failed to create task: %v", err)
+	}
+
+	err = db.SetParent(task.ID, "nonexistent")
+	if err == nil {
+		t.Error("expected error for nonexistent parent")
+	}
+}
+
+// createExplicitPriorityItem creates an open task in project "test" whose
+// priority was chosen explicitly, as with add --priority.
+func createExplicitPriorityItem(t *testing.T, db *DB, title string, priority int) *model.Item {
+	t.Helper()
+	item := &model.Item{Project: "test", Type: model.ItemTypeTask, Title: title, Status: model.StatusOpen, Priority: priority, PrioritySet: true}
+	if err := db.CreateItem(item); err != nil {
+		t.Fatalf("failed to create item: %v", err)
+	}
+	return item
+}
+
+func TestInheritParentPriority(t *testing.T) {
+	db := setupTestDB(t)
+
+	epic := createTestEpic(t, db, "Epic", "test")
+	if _, _, err := db.AdjustPriority(epic.ID, -1); err != nil {
+		t.Fatalf("failed to raise epic priority: %v", err)
+	}
+	defaulted := createTestItemWithProject(t, db, "Defaulted", "test", model.StatusOpen, model.PriorityMedium)
+	explicit := createExplicitPriorityItem(t, db, "Explicit", model.PriorityLow)
+	explicitMedium := createExplicitPriorityItem(t, db, "Explicit medium", model.PriorityMedium)
+	orphan := createTestItemWithProject(t, db, "Orphan", "test", model.StatusOpen, model.PriorityMedium)
+	for _, c := range []*model.Item{defaulted, explicit, explicitMedium} {
+		if err := db.SetParent(c.ID, epic.ID); err != nil {
+			t.Fatalf("failed to set parent: %v", err)
+		}
+	}
+
+	if changed, err := db.InheritParentPriority(defaulted.ID); err != nil || !changed {
+		t.Errorf("InheritParentPriority(defaulted) = %v, %v; want true", changed, err)
+	}
+	for _, c := range []*model.Item{explicit, explicitMedium} {
+		if changed, err := db.InheritParentPriority(c.ID); err != nil || changed {
+			t.Errorf("InheritParentPriority(%s) = %v, %v; want false", c.Title, changed, err)
+		}
+	}
+	if _, err := db.InheritParentPriority(orphan.ID); err == nil {
+		t.Error("expected error for item without a parent")
+	}
+
+	if got, _ := db.GetItem(defaulted.ID); got.Priority != model.PriorityHigh {
+		t.Errorf("defaulted priority = %d, want high", got.Priority)
+	}
+	if got, _ := db.GetItem(explicit.ID); got.Priority != model.PriorityLow {
+		t.Errorf("explicit priority = %d, want low", got.Priority)
+	}
+	if got, _ := db.GetItem(explicitMedium.ID); got.Priority != model.PriorityMedium {
+		t.Errorf("explicit medium priority = %d, want medium", got.Priority)
+	}
+}
+
+func TestReprioritizeEpic(t *testing.T) {
+	db := setupTestDB(t)
+
+	epic := createTestEpic(t, db, "Epic", "test")
+	defaulted := createTestItemWithProject(t, db, "Defaulted", "test", model.StatusOpen, model.PriorityMedium)
+	explicit := createExplicitPriorityItem(t, db, "Explicit", model.PriorityMedium)
+	for _, c := range []*model.Item{defaulted, explicit} {
+		if err := db.SetParent(c.ID, epic.ID); err != nil {
+			t.Fatalf("failed to set parent: %v", err)
+		}
+	}
+
+	result, err := db.ReprioritizeEpic(epic.ID, model.PriorityHigh)
+	if err != nil {
+		t.Fatalf("ReprioritizeEpic failed: %v", err)
+	}
+	if result.OldPriority != model.PriorityMedium || !slices.Equal(result.Updated, []string{defaulted.ID}) || !slices.Equal(result.Skipped, []string{explicit.ID}) {
+		t.Errorf("unexpected result: %+v", result)
+	}
+
+	// Children that followed the epic keep following it
+	if _, err := db.ReprioritizeEpic(epic.ID, model.PriorityLow); err != nil {
+		t.Fatalf("ReprioritizeEpic failed: %v", err)
+	}
+	for id, want := range map[string]int{epic.ID: model.PriorityLow, defaulted.ID: model.PriorityLow, explicit.ID: model.PriorityMedium} {
+		if got, _ := db.GetItem(id); got.Priority != want {
+			t.Errorf("%s priority = %d, want %d", id, got.Priority, want)
+		}
+	}
+
+	if _, err := db.ReprioritizeEpic(defaulted.ID, model.PriorityHigh); err == nil {
+		t.Error("expected error reprioritizing a task")
+	}
+	if _, err := db.ReprioritizeEpic(epic.ID, 7); err == nil {
+		t.Error("expected error for invalid priority")
+	}
+}
+
+func TestClearParent(t *testing.T) {
+	db := setupTestDB(t)
+
+	epic := createTestEpic(t, db, "Epic", "test")
+	task := createTestItemWithProject(t, db, "Task", "test", model.StatusOpen, 2)
+	if err := db.SetParent(task.ID, epic.ID); err != nil {
+		t.Fatalf("failed to set parent: %v", err)
+	}
+
+	if err := db.ClearParent(task.ID); err != nil {
+		t.Fatalf("ClearParent failed: %v", err)
+	}
+	got, err := db.GetItem(task.ID)
+	if err != nil {
+		t.Fatalf("failed to get item: %v", err)
+	}
+	if got.ParentID != nil {
+		t.Errorf("expected no parent, got %q", *got.ParentID)
+	}
+
+	// Detaching again is an error: there is nothing to detach
+	if err := db.ClearParent(task.ID); err == nil || !strings.Contains(err.Error(), "no parent") {
+		t.Errorf("expected no-parent error, got %v", err)
+	}
+	if err := db.ClearParent("ts-missing"); err == nil {
+		t.Error("expected error for missing item")
+	}
+}
+
+type fixedClock struct{ t time.Time }
+
+func (c fixedClock) Now() time.Time { return c.t }
+
+func TestClock_TimestampsUseInjectedClock(t *testing.T) {
+	db := setupTestDB(t)
+	created := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	db.Clock = fixedClock{created}
+
+	item := &model.Item{
+		ID:      "ts-clock1",
+		Project: "test",
+		Type:    model.ItemTypeTask,
+		Title:   "Clocked",
+		Status:  model.StatusOpen,
+	}
+	if err := db.CreateItem(item); err != nil {
+		t.Fatalf("failed to create item: %v", err)
+	}
+
+	got, _ := db.GetItem(item.ID)
+	if !got.CreatedAt.Equal(created) || !got.UpdatedAt.Equal(created) {
+		t.Errorf("timestamps = %v / %v, want %v", got.CreatedAt, got.UpdatedAt, created)
+	}
+
+	updated := created.Add(time.Hour)
+	db.Clock = fixedClock{updated}
+	if err := db.UpdateStatus(item.ID, model.StatusInProgress); err != nil {
+		t.Fatalf("failed to update status: %v", err)
+	}
+	if err := db.AddLog(item.ID, "progress", ""); err != nil {
+		t.Fatalf("failed to add log: %v", err)
+	}
+
+	got, _ = db.GetItem(item.ID)
+	if !got.UpdatedAt.Equal(updated) {
+		t.Errorf("updated_at = %v, want %v", got.UpdatedAt, updated)
+	}
+	logs, _ := db.GetLogs(item.ID)
+	if len(logs) != 1 || !logs[0].CreatedAt.Equal(updated) {
+		t.Errorf("log created_at = %v, want %v", logs, updated)
+	}
+}
+
+func TestMoveItem(t *testing.T) {
+	db := setupTestDB(t)
+
+	epic := &model.Item{ID: "ep-move01", Project: "old", Type: model.ItemTypeEpic, Title: "Epic", Status: model.StatusOpen}
+	if err := db.CreateItem(epic); err != nil {
+		t.Fatalf("failed to create epic: %v", err)
+	}
+	child := &model.Item{ID: "ts-move01", Project: "old", Type: model.ItemTypeTask, Title: "Child", Status: model.StatusOpen, ParentID: &epic.ID}
+	if err := db.CreateItem(child); err != nil {
+		t.Fatalf("failed to create child: %v", err)
+	}
+
+	// Without --with-children only the epic moves
+	moved, err := db.MoveItem(epic.ID, "new", false)
+	if err != nil {
+		t.Fatalf("failed to move: %v", err)
+	}
+	if moved != 1 {
+		t.Errorf("moved = %d, want 1", moved)
+	}
+	got, _ := db.GetItem(child.ID)
+	if got.Project != "old" {
+		t.Errorf("child project = %q, want old", got.Project)
+	}
+
+	moved, err = db.MoveItem(epic.ID, "newer", true)
+	if err != nil {
+		t.Fatalf("failed to move with children: %v", err)
+	}
+	if moved != 2 {
+		t.Errorf("moved = %d, want 2", moved)
+	}
+	for _, id := range []string{epic.ID, child.ID} {
+		got, _ := db.GetItem(id)
+		if got.Project != "newer" {
+			t.Errorf("%s project = %q, want newer", id, got.Project)
+		}
+	}
+
+	projects, _ := db.ListProjects()
+	if !slices.Contains(projects, "newer") {
+		t.Errorf("expected destination project to be created, got %v", projects)
+	}
+}
+
+func TestMoveItem_NotFound(t *testing.T) {
+	db := setupTestDB(t)
+
+	_, err := db.MoveItem("ts-nonexistent", "new", false)
+	if err == nil || !strings.Contains(err.Error(), "item not found") {
+		t.Errorf("expected not-found error, got %v", err)
+	}
+}
+
+func TestAddTime(t *testing.T) {
+	db := setupTestDB(t)
+
+	item := &model.Item{ID: "ts-time01", Project: "test", Type: model.ItemTypeTask, Title: "Timed", Status: model.StatusOpen, EstimateMinutes: 60}
+	if err := db.CreateItem(item); err != nil {
+		t.Fatalf("failed to create item: %v", err)
+	}
+
+	for _, m := range []int{30, 45} {
+		if err := db.AddTime(item.ID, m); err != nil {
+			t.Fatalf("failed to add time: %v", err)
+		}
+	}
+
+	got, _ := db.GetItem(item.ID)
+	if got.EstimateMinutes != 60 || got.ActualMinutes != 75 {
+		t.Errorf("effort = %d/%d, want 60/75", got.EstimateMinutes, got.ActualMinutes)
+	}
+
+	for _, m := range []int{0, -10} {
+		if err := db.AddTime(item.ID, m); !errors.Is(err, ErrInvalid) {
+			t.Errorf("error for %d minutes = %v, want ErrInvalid", m, err)
+		}
+	}
+	if err := db.AddTime("ts-nonexistent", 10); err == nil {
+		t.Error("expected error for nonexistent item")
+	}
+}
+
+func TestCreateItem_NegativeEstimate(t *testing.T) {
+	db := setupTestDB(t)
+
+	item := &model.Item{ID: "ts-neg001", Project: "test", Type: model.ItemTypeTask, Title: "Bad", Status: model.StatusOpen, EstimateMinutes: -5}
+	if err := db.CreateItem(item); err == nil {
+		t.Error("expected error for negative estimate")
+	}
+}
+
+func TestMigrate_UninitializedDatabase(t *testing.T) {
+	db, err := Open(filepath.Join(t.TempDir(), "empty.db"))
+	if err != nil {
+		t.Fatalf("failed to open db: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	if err := db.Migrate(); err != nil {
+		t.Fatalf("migrate on empty db should be a no-op, got: %v", err)
+	}
+	if err := db.Init(); err != nil {
+		t.Fatalf("init after migrate failed: %v", err)
+	}
+	version, _ := db.getSchemaVersion()
+	if version != SchemaVersion {
+		t.Errorf("schema version = %d, want %d", version, SchemaVersion)
+	}
+}
+
+func TestCloneItem(t *testing.T) {
+	db := setupTestDB(t)
+
+	epic := createTestEpic(t, db, "Epic", "test")
+	src := createTestItemWithProject(t, db, "Deploy service", "test", model.StatusInProgress, model.PriorityHigh)
+	if err := db.SetDescription(src.ID, "Run the deploy script"); err != nil {
+		t.Fatalf("failed to set description: %v", err)
+	}
+	if err := db.SetParent(src.ID, epic.ID); err != nil {
+		t.Fatalf("failed to set parent: %v", err)
+	}
+	if err := db.AddTag(src.ID, "ops"); err != nil {
+		t.Fatalf("failed to add tag: %v", err)
+	}
+	if err := db.AddLog(src.ID, "deployed", ""); err != nil {
+		t.Fatalf("failed to add log: %v", err)
+	}
+
+	clone, err := db.CloneItem(src.ID, "")
+	if err != nil {
+		t.Fatalf("CloneItem failed: %v", err)
+	}
+	if clone.ID == src.ID || !strings.HasPrefix(clone.ID, "ts-") {
+		t.Errorf("clone id = %s, want a fresh task id", clone.ID)
+	}
+
+	got, err := db.GetItem(clone.ID)
+	if err != nil {
+		t.Fatalf("clone not stored: %v", err)
+	}
+	if got.Title != "Deploy service" || got.Description != "Run the deploy script" ||
+		got.Priority != model.PriorityHigh || got.Project != "test" {
+		t.Errorf("clone fields not copied: %+v", got)
+	}
+	if got.Status != model.StatusOpen {
+		t.Errorf("clone status = %s, want open", got.Status)
+	}
+	if got.ParentID == nil || *got.ParentID != epic.ID {
+		t.Errorf("clone parent = %v, want %s", got.ParentID, epic.ID)
+	}
+	if tags, _ := db.GetItemTags(clone.ID); !slices.Equal(tags, []string{"ops"}) {
+		t.Errorf("clone tags = %v, want [ops]", tags)
+	}
+	if logs, _ := db.GetLogs(clone.ID); len(logs) != 0 {
+		t.Errorf("clone should have no logs, got %d", len(logs))
+	}
+
+	// A new title is set on the clone as it's created
+	renamed, err := db.CloneItem(src.ID, "Deploy billing service")
+	if err != nil {
+		t.Fatalf("CloneItem with title failed: %v", err)
+	}
+	if got, _ := db.GetItem(renamed.ID); got.Title != "Deploy billing service" || got.Description != "Run the deploy script" {
+		t.Errorf("renamed clone = %+v, want the new title and copied description", got)
+	}
+}
+
+func TestCloneItem_NotFound(t *testing.T) {
+	db := setupTestDB(t)
+
+	if _, err := db.CloneItem("ts-nope00", ""); err == nil {
+		t.Error("expected error for nonexistent item")
+	}
+}
+
+func TestCreateItemWithDeps(t *testing.T) {
+	db := setupTestDB(t)
+
+	a := createTestItem(t, db, "Schema")
+	b := createTestItem(t, db, "API")
+
+	item := &model.Item{Project: "test", Type: model.ItemTypeTask, Title: "Deploy", Status: model.StatusOpen}
+	if err := db.CreateItemWithDeps(item, []string{a.ID, b.ID}, nil); err != nil {
+		t.Fatalf("CreateItemWithDeps failed: %v", err)
+	}
+	deps, err := db.GetDeps(item.ID)
+	if err != nil {
+		t.Fatalf("failed to get deps: %v", err)
+	}
+	slices.Sort(deps)
+	want := []string{a.ID, b.ID}
+	slices.Sort(want)
+	if !slices.Equal(deps, want) {
+		t.Errorf("deps = %v, want %v", deps, want)
+	}
+
+	// An unknown dependency aborts the whole creation
+	bad := &model.Item{Project: "test", Type: model.ItemTypeTask, Title: "Orphan", Status: model.StatusOpen}
+	err = db.CreateItemWithDeps(bad, []string{a.ID, "ts-nope00"}, nil)
+	if err == nil || !strings.Contains(err.Error(), "ts-nope00") {
+		t.Fatalf("expected not found error, got %v", err)
+	}
+	if _, err := db.GetItem(bad.ID); err == nil {
+		t.Error("item should not be created when a dependency is missing")
+	}
+
+	// Blocking an item it also depends on would close a cycle
+	looped := &model.Item{Project: "test", Type: model.ItemTypeTask, Title: "Loop", Status: model.StatusOpen}
+	err = db.CreateItemWithDeps(looped, []string{a.ID}, []string{a.ID})
+	if !errors.Is(err, ErrCycle) {
+		t.Fatalf("expected ErrCycle, got %v", err)
+	}
+	if _, err := db.GetItem(looped.ID); err == nil {
+		t.Error("item should not be created when --blocks closes a cycle")
+	}
+
+	blocker := &model.Item{Project: "test", Type: model.ItemTypeTask, Title: "Blocker", Status: model.StatusOpen}
+	if err := db.CreateItemWithDeps(blocker, nil, []string{a.ID}); err != nil {
+		t.Fatalf("CreateItemWithDeps with blocks failed: %v", err)
+	}
+	if deps, _ := db.GetDeps(a.ID); !slices.Equal(deps, []string{blocker.ID}) {
+		t.Errorf("blocked item deps = %v, want [%s]", deps, blocker.ID)
+	}
+}
+
+func TestCompleteItems_Recurs(t *testing.T) {
+	db := setupTestDB(t)
+	now := time.Date(2024, 5, 10, 12, 0, 0, 0, time.UTC)
+	db.Clock = fixedClock{now}
+
+	due := time.Date(2024, 5, 8, 0, 0, 0, 0, time.UTC)
+	src := &model.Item{
+		Project:         "test",
+		Type:            model.ItemTypeTask,
+		Title:           "Update dependencies",
+		Status:          model.StatusOpen,
+		DueAt:           &due,
+		EstimateMinutes: 30,
+		Recurrence:      "weekly",
+	}
+	if err := db.CreateItem(src); err != nil {
+		t.Fatalf("failed to create item: %v", err)
+	}
+	if err := db.AddTag(src.ID, "chore"); err != nil {
+		t.Fatalf("failed to add tag: %v", err)
+	}
+
+	next, err := db.CompleteItems([]string{src.ID}, "", false)
+	if err != nil {
+		t.Fatalf("CompleteItems failed: %v", err)
+	}
+	if next[src.ID] == nil {
+		t.Fatalf("expected a next occurrence, got %v", next)
+	}
+	got, err := db.GetItem(next[src.ID].ID)
+	if err != nil {
+		t.Fatalf("next occurrence not stored: %v", err)
+	}
+	wantDue := time.Date(2024, 5, 15, 0, 0, 0, 0, time.UTC)
+	if got.DueAt == nil || !got.DueAt.Equal(wantDue) {
+		t.Errorf("next due = %v, want %v", got.DueAt, wantDue)
+	}
+	if got.Status != model.StatusOpen || got.Recurrence != "weekly" || got.EstimateMinutes != 30 {
+		t.Errorf("next occurrence fields not carried over: %+v", got)
+	}
+	if tags, _ := db.GetItemTags(got.ID); !slices.Equal(tags, []string{"chore"}) {
+		t.Errorf("next occurrence tags = %v, want [chore]", tags)
+	}
+
+	// Completed weeks late: skip ahead past now
+	late := time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC)
+	src.ID = ""
+	src.DueAt = &late
+	if err := db.CreateItem(src); err != nil {
+		t.Fatalf("failed to create item: %v", err)
+	}
+	if err := db.UpdateStatus(src.ID, model.StatusDone); err != nil {
+		t.Fatalf("UpdateStatus failed: %v", err)
+	}
+	items, _ := db.ListItems("test", nil)
+	var lateNext *model.Item
+	for i := range items {
+		if items[i].Status == model.StatusOpen && items[i].DueAt != nil && items[i].DueAt.After(now) && items[i].ID != got.ID {
+			lateNext = &items[i]
+		}
+	}
+	if lateNext == nil || lateNext.DueAt.Sub(now) > 7*24*time.Hour {
+		t.Errorf("late next occurrence = %+v, want one due within a week after %v", lateNext, now)
+	}
+
+	// One-off items don't recur
+	plain := createTestItem(t, db, "One-off")
+	if next, err := db.CompleteItems([]string{plain.ID}, "", false); err != nil || len(next) != 0 {
+		t.Errorf("CompleteItems on one-off = %v, %v; want no occurrences", next, err)
+	}
+}
+
+func TestCompleteItems_DoneTwiceRecursOnce(t *testing.T) {
+	db := setupTestDB(t)
+
+	src := &model.Item{Project: "test", Type: model.ItemTypeTask, Title: "Water plants", Status: model.StatusOpen, Recurrence: "weekly"}
+	if err := db.CreateItem(src); err != nil {
+		t.Fatalf("failed to create item: %v", err)
+	}
+	if _, err := db.CompleteItems([]string{src.ID}, "", false); err != nil {
+		t.Fatalf("CompleteItems failed: %v", err)
+	}
+	next, err := db.CompleteItems([]string{src.ID}, "", false)
+	if err != nil {
+		t.Fatalf("second CompleteItems failed: %v", err)
+	}
+	if len(next) != 0 {
+		t.Errorf("completing a done item created %v, want nothing", next)
+	}
+	// The same goes for the plain status paths used by the API
+	if err := db.ForceUpdateStatuses([]string{src.ID}, model.StatusDone, ""); err != nil {
+		t.Fatalf("ForceUpdateStatuses failed: %v", err)
+	}
+
+	items, _ := db.ListItems("test", nil)
+	if len(items) != 2 {
+		t.Errorf("expected the item and one next occurrence, got %d items", len(items))
+	}
+}
+
+func TestCreateItem_InvalidRecurrence(t *testing.T) {
+	db := setupTestDB(t)
+
+	item := &model.Item{Project: "test", Type: model.ItemTypeTask, Title: "Task", Status: model.StatusOpen, Recurrence: "hourly"}
+	err := db.CreateItem(item)
+	if err == nil || !strings.Contains(err.Error(), "invalid recurrence") {
+		t.Errorf("expected invalid recurrence error, got %v", err)
+	}
+}
+
+func TestMergeItems(t *testing.T) {
+	db := setupTestDB(t)
+
+	from := createTestItemWithProject(t, db, "Fix login", "test", model.StatusOpen, 2)
+	into := createTestItemWithProject(t, db, "Repair auth", "test", model.StatusOpen, 2)
+	upstream := createTestItemWithProject(t, db, "Upstream", "test", model.StatusOpen, 2)
+	downstream := createTestItemWithProject(t, db, "Downstream", "test", model.StatusOpen, 2)
+
+	if err := db.SetDescription(from.ID, "Token expires early"); err != nil {
+		t.Fatalf("failed to set description: %v", err)
+	}
+	if err := db.SetDescription(into.ID, "Auth is flaky"); err != nil {
+		t.Fatalf("failed to set description: %v", err)
+	}
+	if err := db.AddLog(from.ID, "reproduced", ""); err != nil {
+		t.Fatalf("failed to add log: %v", err)
+	}
+	if err := db.AddTag(from.ID, "auth"); err != nil {
+		t.Fatalf("failed to add tag: %v", err)
+	}
+	for _, dep := range [][2]string{
+		{from.ID, upstream.ID},   // from depends on upstream
+		{downstream.ID, from.ID}, // downstream depends on from
+		{from.ID, into.ID},       // would become a self-reference
+	} {
+		if err := db.AddDep(dep[0], dep[1]); err != nil {
+			t.Fatalf("failed to add dep: %v", err)
+		}
+	}
+
+	result, err := db.MergeItems(from.ID, into.ID)
+	if err != nil {
+		t.Fatalf("MergeItems failed: %v", err)
+	}
+	if result.Logs != 1 || result.Deps != 2 || result.Children != 0 {
+		t.Errorf("result = %+v, want 1 log, 2 deps, 0 children", result)
+	}
+
+	if _, err := db.GetItem(from.ID); err == nil {
+		t.Error("merged item should be deleted")
+	}
+	got, err := db.GetItem(into.ID)
+	if err != nil {
+		t.Fatalf("failed to get item: %v", err)
+	}
+	if got.Description != "Auth is flaky\n\nToken expires early" {
+		t.Errorf("description = %q", got.Description)
+	}
+	if deps, _ := db.GetDeps(into.ID); !slices.Equal(deps, []string{upstream.ID}) {
+		t.Errorf("deps of merged item = %v, want [%s]", deps, upstream.ID)
+	}
+	if deps, _ := db.GetDeps(downstream.ID); !slices.Equal(deps, []string{into.ID}) {
+		t.Errorf("deps of downstream = %v, want [%s]", deps, into.ID)
+	}
+	if tags, _ := db.GetItemTags(into.ID); !slices.Equal(tags, []string{"auth"}) {
+		t.Errorf("tags = %v, want [auth]", tags)
+	}
+	logs, _ := db.GetLogs(into.ID)
+	if len(logs) != 2 {
+		t.Fatalf("expected moved log plus merge log, got %d", len(logs))
+	}
+
+	if _, err := db.MergeItems(into.ID, into.ID); err == nil {
+		t.Error("expected error merging an item into itself")
+	}
+}
+
+func TestMergeItems_RejectsCycle(t *testing.T) {
+	db := setupTestDB(t)
+
+	from := createTestItemWithProject(t, db, "A", "test", model.StatusOpen, 2)
+	into := createTestItemWithProject(t, db, "B", "test", model.StatusOpen, 2)
+	middle := createTestItemWithProject(t, db, "C", "test", model.StatusOpen, 2)
+	// into -> middle -> from: merging from into into closes the loop
+	if err := db.AddDep(into.ID, middle.ID); err != nil {
+		t.Fatalf("failed to add dep: %v", err)
+	}
+	if err := db.AddDep(middle.ID, from.ID); err != nil {
+		t.Fatalf("failed to add dep: %v", err)
+	}
+
+	if _, err := db.MergeItems(from.ID, into.ID); err == nil || !strings.Contains(err.Error(), "cycle") {
+		t.Fatalf("expected cycle error, got %v", err)
+	}
+	if _, err := db.GetItem(from.ID); err != nil {
+		t.Error("rejected merge should leave the item in place")
+	}
+}
+
+func TestAdjustPriority(t *testing.T) {
+	db := setupTestDB(t)
+	item := createTestItemWithProject(t, db, "Task", "test", model.StatusOpen, model.PriorityMedium)
+
+	steps := []struct {
+		delta    int
+		old, new int
+	}{
+		{-1, model.PriorityMedium, model.PriorityHigh},
+		{-1, model.PriorityHigh, model.PriorityHigh}, // clamped
+		{1, model.PriorityHigh, model.PriorityMedium},
+		{5, model.PriorityMedium, model.PriorityLow}, // clamped
+	}
+	for _, s := range steps {
+		oldP, newP, err := db.AdjustPriority(item.ID, s.delta)
+		if err != nil {
+			t.Fatalf("AdjustPriority(%d) failed: %v", s.delta, err)
+		}
+		if oldP != s.old || newP != s.new {
+			t.Errorf("AdjustPriority(%d) = %d -> %d, want %d -> %d", s.delta, oldP, newP, s.old, s.new)
+		}
+	}
+
+	got, _ := db.GetItem(item.ID)
+	if got.Priority != model.PriorityLow {
+		t.Errorf("priority = %d, want %d", got.Priority, model.PriorityLow)
+	}
+
+	// Clamped no-ops aren't logged
+	logs, _ := db.GetLogs(item.ID)
+	var msgs []string
+	for _, l := range logs {
+		msgs = append(msgs, l.Message)
+	}
+	want := []string{"Priority: medium -> high", "Priority: high -> medium", "Priority: medium -> low"}
+	if !slices.Equal(msgs, want) {
+		t.Errorf("logs = %v, want %v", msgs, want)
+	}
+
+	if _, _, err := db.AdjustPriority("ts-nope00", 1); err == nil {
+		t.Error("expected error for nonexistent item")
+	}
+}
+
+func TestBlockItems(t *testing.T) {
+	db := setupTestDB(t)
+	a := createTestItem(t, db, "A")
+	b := createTestItem(t, db, "B")
+
+	if err := db.BlockItems([]string{a.ID, b.ID}, "waiting on API keys", false); err != nil {
+		t.Fatalf("BlockItems failed: %v", err)
+	}
+	for _, id := range []string{a.ID, b.ID} {
+		got, _ := db.GetItem(id)
+		if got.Status != model.StatusBlocked || got.BlockReason != "waiting on API keys" {
+			t.Errorf("%s = %s/%q, want blocked with reason", id, got.Status, got.BlockReason)
+		}
+		logs, _ := db.GetLogs(id)
+		if len(logs) != 1 || logs[0].Message != "Blocked: waiting on API keys" {
+			t.Errorf("%s logs = %v, want block log", id, logs)
+		}
+	}
+
+	// Leaving blocked clears the reason but keeps the log
+	if err := db.UpdateStatus(a.ID, model.StatusInProgress); err != nil {
+		t.Fatalf("failed to start: %v", err)
+	}
+	got, _ := db.GetItem(a.ID)
+	if got.BlockReason != "" {
+		t.Errorf("block reason = %q after unblocking, want empty", got.BlockReason)
+	}
+
+	if err := db.BlockItems([]string{a.ID}, "  ", false); err == nil {
+		t.Error("expected error for empty reason")
+	}
+}
+
+func TestBlockItemsOn(t *testing.T) {
+	db := setupTestDB(t)
+	a := createTestItem(t, db, "A")
+	dep := createTestItem(t, db, "Dep")
+
+	if err := db.BlockItemsOn([]string{a.ID}, dep.ID, "waiting on dep", false); err != nil {
+		t.Fatalf("BlockItemsOn failed: %v", err)
+	}
+	deps, _ := db.GetDeps(a.ID)
+	if len(deps) != 1 || deps[0] != dep.ID {
+		t.Errorf("deps = %v, want [%s]", deps, dep.ID)
+	}
+
+	// A cycle rolls back the whole block, including items without one
+	other := createTestItem(t, db, "Other")
+	if err := db.BlockItemsOn([]string{other.ID, dep.ID}, a.ID, "loop", false); !errors.Is(err, ErrCycle) {
+		t.Errorf("expected ErrCycle, got %v", err)
+	}
+	for _, id := range []string{other.ID, dep.ID} {
+		if got, _ := db.GetItem(id); got.Status != model.StatusOpen {
+			t.Errorf("%s status = %s after rejected block, want open", id, got.Status)
+		}
+		if deps, _ := db.GetDeps(id); len(deps) != 0 {
+			t.Errorf("%s deps = %v after rejected block, want none", id, deps)
+		}
+	}
+
+	// Completing the dependency clears the block
+	if err := db.UpdateStatus(dep.ID, model.StatusDone); err != nil {
+		t.Fatalf("failed to complete dep: %v", err)
+	}
+	got, _ := db.GetItem(a.ID)
+	if got.Status != model.StatusOpen || got.BlockReason != "" {
+		t.Errorf("a = %s/%q, want open with no reason", got.Status, got.BlockReason)
+	}
+
+	if err := db.BlockItemsOn([]string{a.ID}, dep.ID, "already done", false); err == nil {
+		t.Error("expected error blocking on a done item")
+	}
+}
+
+func TestUpdateStatus_DoneKeepsManualBlock(t *testing.T) {
+	db := setupTestDB(t)
+	dep := createTestItem(t, db, "Dep")
+	manual := createTestItem(t, db, "Waiting on legal")
+	reblocked := createTestItem(t, db, "Blocked on dep, then on legal")
+	for _, id := range []string{manual.ID, reblocked.ID} {
+		if err := db.AddDep(id, dep.ID); err != nil {
+			t.Fatalf("failed to add dep: %v", err)
+		}
+	}
+	if err := db.BlockItems([]string{manual.ID}, "waiting on legal", false); err != nil {
+		t.Fatalf("failed to block: %v", err)
+	}
+	if err := db.BlockItemsOn([]string{reblocked.ID}, dep.ID, "needs dep", false); err != nil {
+		t.Fatalf("failed to block on dep: %v", err)
+	}
+	if err := db.BlockItems([]string{reblocked.ID}, "waiting on legal", false); err != nil {
+		t.Fatalf("failed to re-block: %v", err)
+	}
+
+	if err := db.UpdateStatus(dep.ID, model.StatusDone); err != nil {
+		t.Fatalf("failed to complete dep: %v", err)
+	}
+	for _, id := range []string{manual.ID, reblocked.ID} {
+		got, _ := db.GetItem(id)
+		if got.Status != model.StatusBlocked || got.BlockReason != "waiting on legal" {
+			t.Errorf("%s = %s/%q, want still blocked on legal", id, got.Status, got.BlockReason)
+		}
+	}
+}
+
+func TestMigrate_BackfillsBlockedOnDeps(t *testing.T) {
+	db := setupTestDB(t)
+	dep := createTestItem(t, db, "Dep")
+	onDep := createTestItem(t, db, "Blocked on dep")
+	manual := createTestItem(t, db, "Blocked by hand")
+	if err := db.BlockItemsOn([]string{onDep.ID}, dep.ID, "needs dep", false); err != nil {
+		t.Fatalf("failed to block on dep: %v", err)
+	}
+	if err := db.AddDep(manual.ID, dep.ID); err != nil {
+		t.Fatalf("failed to add dep: %v", err)
+	}
+	if err := db.BlockItems([]string{manual.ID}, "waiting on legal", false); err != nil {
+		t.Fatalf("failed to block: %v", err)
+	}
+
+	// Re-run the v24 backfill as if upgrading from v23
+	if _, err := db.Exec(`UPDATE items SET blocked_on_deps = 0`); err != nil {
+		t.Fatalf("failed to reset: %v", err)
+	}
+	v24 := migrations[24-2]
+	if _, err := db.Exec(v24[strings.Index(v24, "UPDATE"):]); err != nil {
+		t.Fatalf("backfill failed: %v", err)
+	}
+
+	if err := db.UpdateStatus(dep.ID, model.StatusDone); err != nil {
+		t.Fatalf("failed to complete dep: %v", err)
+	}
+	if got, _ := db.GetItem(onDep.ID); got.Status != model.StatusOpen {
+		t.Errorf("block made with its dependency = %s, want reopened", got.Status)
+	}
+	if got, _ := db.GetItem(manual.ID); got.Status != model.StatusBlocked {
+		t.Errorf("manual block = %s, want still blocked", got.Status)
+	}
+}
+
+func TestMigrate_BackfillsBlockReason(t *testing.T) {
+	db := setupTestDB(t)
+	item := createTestItem(t, db, "Stuck")
+	if err := db.UpdateStatuses([]string{item.ID}, model.StatusBlocked, "Blocked: old reason"); err != nil {
+		t.Fatalf("failed to block: %v", err)
+	}
+	if err := db.UpdateStatuses([]string{item.ID}, model.StatusBlocked, "Blocked: newer reason"); err != nil {
+		t.Fatalf("failed to block: %v", err)
+	}
+
+	// Re-run the v10 backfill as if upgrading from v9; migrations[i] upgrades to i+2
+	v10 := migrations[10-2]
+	if _, err := db.Exec(v10[strings.Index(v10, "UPDATE"):]); err != nil {
+		t.Fatalf("backfill failed: %v", err)
+	}
+	got, _ := db.GetItem(item.ID)
+	if got.BlockReason != "newer reason" {
+		t.Errorf("block reason = %q, want %q", got.BlockReason, "newer reason")
+	}
+}
+
+func TestMigrate_BackfillsPrioritySet(t *testing.T) {
+	db := setupTestDB(t)
+	low := createTestItemWithProject(t, db, "Low", "test", model.StatusOpen, model.PriorityLow)
+	medium := createTestItemWithProject(t, db, "Medium", "test", model.StatusOpen, model.PriorityMedium)
+
+	// Re-run the v23 backfill as if upgrading from v22
+	v23 := migrations[23-2]
+	if _, err := db.Exec(v23[strings.Index(v23, "UPDATE"):]); err != nil {
+		t.Fatalf("backfill failed: %v", err)
+	}
+	if got, _ := db.GetItem(low.ID); !got.PrioritySet {
+		t.Error("expected a non-default priority to count as explicit")
+	}
+	if got, _ := db.GetItem(medium.ID); got.PrioritySet {
+		t.Error("expected a medium priority to count as unset")
+	}
+}
+
+func TestSuggestIDs(t *testing.T) {
+	db := setupTestDB(t)
+
+	for _, id := range []string{"ts-a1b2c3", "ts-a1b2c4", "ts-ffffff", "ep-a1b2c3"} {
+		item := &model.Item{ID: id, Project: "test", Type: model.ItemTypeTask, Title: id, Status: model.StatusOpen}
+		if err := db.CreateItem(item); err != nil {
+			t.Fatalf("failed to create %s: %v", id, err)
+		}
+	}
+
+	tests := []struct {
+		id   string
+		n    int
+		want string
+	}{
+		{"ts-a1b2x3", 3, "ts-a1b2c3,ts-a1b2c4"},
+		{"ts-a1b2x3", 1, "ts-a1b2c3"},
+		{"a1b2c5", 3, "ep-a1b2c3,ts-a1b2c3,ts-a1b2c4"},
+		{"ts-000000", 3, ""},
+		{"ffff", 3, ""}, // too short to guess at
+	}
+	for _, tt := range tests {
+		got, err := db.SuggestIDs(tt.id, tt.n)
+		if err != nil {
+			t.Fatalf("SuggestIDs(%q) failed: %v", tt.id, err)
+		}
+		if strings.Join(got, ",") != tt.want {
+			t.Errorf("SuggestIDs(%q, %d) = %v, want %s", tt.id, tt.n, got, tt.want)
+		}
+	}
+
+	_, err := db.ResolveID("ts-a1b2x3")
+	if !errors.Is(err, ErrNotFound) || !strings.Contains(err.Error(), "did you mean ts-a1b2c3, ts-a1b2c4?") {
+		t.Errorf("ResolveID typo error = %v", err)
+	}
+}
+
+func TestIDCompletions(t *testing.T) {
+	db := setupTestDB(t)
+
+	items := []struct {
+		id       string
+		project  string
+		status   model.Status
+		priority int
+	}{
+		{"ts-a1b2c3", "test", model.StatusOpen, 2},
+		{"ts-a1ffff", "test", model.StatusInProgress, 1},
+		{"ts-a19999", "other", model.StatusOpen, 2},
+		{"ts-a10000", "test", model.StatusDone, 1},
+		{"ep-a1eeee", "test", model.StatusBlocked, 3},
+	}
+	for _, it := range items {
+		item := &model.Item{ID: it.id, Project: it.project, Type: model.ItemTypeTask, Title: it.id, Status: it.status, Priority: it.priority}
+		if err := db.CreateItem(item); err != nil {
+			t.Fatalf("failed to create %s: %v", it.id, err)
+		}
+	}
+
+	tests := []struct {
+		prefix  string
+		project string
+		limit   int
+		want    string
+	}{
+		{"ts-a1", "", 10, "ts-a1ffff,ts-a19999,ts-a1b2c3"}, // newest first within a priority
+		{"a1", "test", 10, "ts-a1ffff,ts-a1b2c3,ep-a1eeee"},
+		{"a1", "test", 1, "ts-a1ffff"},
+		{"", "other", 10, "ts-a19999"},
+		{"a1_", "", 10, ""}, // LIKE wildcards are literal
+	}
+	for _, tt := range tests {
+		got, err := db.IDCompletions(tt.prefix, tt.project, tt.limit)
+		if err != nil {
+			t.Fatalf("IDCompletions(%q) failed: %v", tt.prefix, err)
+		}
+		var ids []string
+		for _, item := range got {
+			ids = append(ids, item.ID)
+		}
+		if strings.Join(ids, ",") != tt.want {
+			t.Errorf("IDCompletions(%q, %q, %d) = %v, want %s", tt.prefix, tt.project, tt.limit, ids, tt.want)
+		}
 	}
 }
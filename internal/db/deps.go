@@ -1,33 +1,160 @@
 package db
 
 import (
+	"database/sql"
 	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/baiirun/prog/internal/model"
 )
 
+// DepCheck describes how a dependency edge affects the dependent item.
+type DepCheck struct {
+	DependsOnStatus model.Status // status of the item depended on
+	Ready           bool         // whether the dependent is ready to start
+}
+
 // AddDep adds a dependency between items.
+// Returns ErrSelfDependency if the items are the same, and ErrCycle if the
+// new edge would otherwise introduce a dependency cycle. Adding an edge
+// that already exists succeeds without changing anything.
 func (db *DB) AddDep(itemID, dependsOnID string) error {
+	if itemID == dependsOnID {
+		return fmt.Errorf("%w: %s", ErrSelfDependency, itemID)
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
 	// Verify both items exist
 	var count int
-	err := db.QueryRow(`SELECT COUNT(*) FROM items WHERE id IN (?, ?)`, itemID, dependsOnID).Scan(&count)
+	err = tx.QueryRow(`SELECT COUNT(*) FROM items WHERE id IN (?, ?)`, itemID, dependsOnID).Scan(&count)
 	if err != nil {
 		return fmt.Errorf("failed to verify items: %w", err)
 	}
 	if count != 2 {
-		return fmt.Errorf("one or both items not found: %s, %s (use 'tasks list' to see available items)", itemID, dependsOnID)
+		return fmt.Errorf("one or both items %w: %s, %s (use 'prog list' to see available items)", ErrNotFound, itemID, dependsOnID)
+	}
+
+	if err := addCheckedDepTx(tx, itemID, dependsOnID, db.Now()); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// addCheckedDepTx adds the edge itemID -> dependsOnID within tx like
+// addDepTx, first rejecting self-dependencies and edges that would close a
+// cycle. Both items must exist.
+func addCheckedDepTx(tx *sql.Tx, itemID, dependsOnID string, now time.Time) error {
+	if itemID == dependsOnID {
+		return fmt.Errorf("%w: %s", ErrSelfDependency, itemID)
+	}
+
+	var exists bool
+	err := tx.QueryRow(`SELECT EXISTS(SELECT 1 FROM deps WHERE item_id = ? AND depends_on = ?)`,
+		itemID, dependsOnID).Scan(&exists)
+	if err != nil {
+		return fmt.Errorf("failed to check dependency: %w", err)
+	}
+	if exists {
+		return nil
+	}
+
+	// The new edge closes a cycle if itemID is already reachable from dependsOnID
+	path, err := findDepPath(tx, dependsOnID, itemID)
+	if err != nil {
+		return err
+	}
+	if path != nil {
+		cycle := append([]string{itemID}, path...)
+		return fmt.Errorf("%w: %s", ErrCycle, strings.Join(cycle, " -> "))
 	}
+	return addDepTx(tx, itemID, dependsOnID, now)
+}
 
-	_, err = db.Exec(`
+// addDepTx adds the edge itemID -> dependsOnID within tx and records it in
+// the item's history. An existing edge is left as is.
+func addDepTx(tx *sql.Tx, itemID, dependsOnID string, now time.Time) error {
+	res, err := tx.Exec(`
 		INSERT OR IGNORE INTO deps (item_id, depends_on) VALUES (?, ?)`,
 		itemID, dependsOnID)
 	if err != nil {
 		return fmt.Errorf("failed to add dependency: %w", err)
 	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return nil
+	}
+	return recordEventTx(tx, itemID, EventDep, "depends on "+dependsOnID, now)
+}
+
+// CheckDep reports the status of dependsOnID and recomputes whether itemID
+// is ready to start, i.e. open, unarchived, and with every transitive
+// dependency done. Call it after AddDep to explain the new edge's effect.
+func (db *DB) CheckDep(itemID, dependsOnID string) (*DepCheck, error) {
+	var check DepCheck
+	err := db.QueryRow(`SELECT status FROM items WHERE id = ?`, dependsOnID).Scan(&check.DependsOnStatus)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("item %w: %s (use 'prog list' to see available items)", ErrNotFound, dependsOnID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get item status: %w", err)
+	}
+
+	err = db.QueryRow(`
+		WITH RECURSIVE dep_chain(depends_on) AS (
+		    SELECT depends_on FROM deps WHERE item_id = ?
+		    UNION
+		    SELECT d.depends_on FROM dep_chain c
+		    JOIN deps d ON d.item_id = c.depends_on
+		)
+		SELECT COUNT(*) FROM items
+		WHERE id = ? AND status = 'open' AND archived = 0
+		  AND NOT EXISTS (
+		    SELECT 1 FROM dep_chain c
+		    JOIN items i ON c.depends_on = i.id
+		    WHERE i.status != 'done'
+		  )`, itemID, itemID).Scan(&check.Ready)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check readiness: %w", err)
+	}
+	return &check, nil
+}
+
+// RemoveDep removes the dependency of itemID on dependsOnID.
+func (db *DB) RemoveDep(itemID, dependsOnID string) error {
+	result, err := db.Exec(`DELETE FROM deps WHERE item_id = ? AND depends_on = ?`, itemID, dependsOnID)
+	if err != nil {
+		return fmt.Errorf("failed to remove dependency: %w", err)
+	}
+
+	rows, _ := result.RowsAffected()
+	if rows == 0 {
+		return fmt.Errorf("dependency %w: %s does not depend on %s", ErrNotFound, itemID, dependsOnID)
+	}
 	return nil
 }
 
 // GetDeps returns the IDs of items that the given item depends on.
 func (db *DB) GetDeps(itemID string) ([]string, error) {
-	rows, err := db.Query(`SELECT depends_on FROM deps WHERE item_id = ?`, itemID)
+	return getDeps(db, itemID)
+}
+
+// querier is implemented by both *DB and *sql.Tx.
+type querier interface {
+	Query(query string, args ...any) (*sql.Rows, error)
+}
+
+// getDeps returns the IDs of items that itemID depends on, read through q.
+func getDeps(q querier, itemID string) ([]string, error) {
+	rows, err := q.Query(`SELECT depends_on FROM deps WHERE item_id = ?`, itemID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get dependencies: %w", err)
 	}
@@ -44,6 +171,59 @@ func (db *DB) GetDeps(itemID string) ([]string, error) {
 	return deps, rows.Err()
 }
 
+// GetDependents returns the IDs of items that depend on the given item.
+func (db *DB) GetDependents(itemID string) ([]string, error) {
+	rows, err := db.Query(`SELECT item_id FROM deps WHERE depends_on = ? ORDER BY item_id`, itemID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get dependents: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var dependents []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan dependent: %w", err)
+		}
+		dependents = append(dependents, id)
+	}
+	return dependents, rows.Err()
+}
+
+// findDepPath walks dependency edges depth-first from "from" and returns the
+// path of IDs leading to "to" (inclusive of both ends), or nil if unreachable.
+func findDepPath(q querier, from, to string) ([]string, error) {
+	visited := make(map[string]bool)
+
+	var walk func(id string) ([]string, error)
+	walk = func(id string) ([]string, error) {
+		if id == to {
+			return []string{id}, nil
+		}
+		if visited[id] {
+			return nil, nil
+		}
+		visited[id] = true
+
+		deps, err := getDeps(q, id)
+		if err != nil {
+			return nil, err
+		}
+		for _, dep := range deps {
+			path, err := walk(dep)
+			if err != nil {
+				return nil, err
+			}
+			if path != nil {
+				return append([]string{id}, path...), nil
+			}
+		}
+		return nil, nil
+	}
+
+	return walk(from)
+}
+
 // HasUnmetDeps returns true if the item has dependencies that are not done.
 func (db *DB) HasUnmetDeps(itemID string) (bool, error) {
 	var count int
@@ -101,3 +281,91 @@ func (db *DB) GetAllDeps(project string) ([]DepEdge, error) {
 	}
 	return edges, rows.Err()
 }
+
+// PlanOrder sorts items into an execution plan: every item comes after the
+// items it depends on, and among items whose dependencies are placed, higher
+// priority goes first, then the existing order. Dependencies on items outside
+// the slice are ignored. If the items' dependencies form a cycle, it returns
+// an error wrapping ErrCycle that names the cycle.
+func (db *DB) PlanOrder(items []model.Item) ([]model.Item, error) {
+	edges, err := db.GetAllDeps("")
+	if err != nil {
+		return nil, err
+	}
+
+	index := make(map[string]int, len(items))
+	for i, item := range items {
+		index[item.ID] = i
+	}
+	pending := make([]int, len(items))      // unplaced dependencies per item
+	dependents := make([][]int, len(items)) // items waiting on each item
+	deps := make([][]int, len(items))
+	for _, e := range edges {
+		from, ok1 := index[e.ItemID]
+		to, ok2 := index[e.DependsOnID]
+		if !ok1 || !ok2 {
+			continue
+		}
+		pending[from]++
+		dependents[to] = append(dependents[to], from)
+		deps[from] = append(deps[from], to)
+	}
+
+	// before reports whether item i should be placed ahead of item j
+	before := func(i, j int) bool {
+		if items[i].Priority != items[j].Priority {
+			return items[i].Priority < items[j].Priority
+		}
+		return i < j
+	}
+
+	var ready []int
+	for i := range items {
+		if pending[i] == 0 {
+			ready = append(ready, i)
+		}
+	}
+	plan := make([]model.Item, 0, len(items))
+	placed := make([]bool, len(items))
+	for len(ready) > 0 {
+		best := 0
+		for k := 1; k < len(ready); k++ {
+			if before(ready[k], ready[best]) {
+				best = k
+			}
+		}
+		i := ready[best]
+		ready = append(ready[:best], ready[best+1:]...)
+		plan = append(plan, items[i])
+		placed[i] = true
+		for _, d := range dependents[i] {
+			pending[d]--
+			if pending[d] == 0 {
+				ready = append(ready, d)
+			}
+		}
+	}
+	if len(plan) == len(items) {
+		return plan, nil
+	}
+
+	// Every unplaced item waits on another unplaced one, so following those
+	// edges from any of them must loop
+	start := slices.Index(placed, false)
+	seen := map[int]int{}
+	var path []string
+	for i := start; ; {
+		if at, ok := seen[i]; ok {
+			cycle := append(path[at:], items[i].ID)
+			return nil, fmt.Errorf("%w: %s", ErrCycle, strings.Join(cycle, " -> "))
+		}
+		seen[i] = len(path)
+		path = append(path, items[i].ID)
+		for _, d := range deps[i] {
+			if !placed[d] {
+				i = d
+				break
+			}
+		}
+	}
+}
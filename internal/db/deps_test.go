@@ -1,6 +1,9 @@
 package db
 
 import (
+	"errors"
+	"slices"
+	"strings"
 	"testing"
 	"time"
 
@@ -73,7 +76,7 @@ func TestAddDep_Duplicate(t *testing.T) {
 		t.Fatalf("failed to add dep: %v", err)
 	}
 
-	// Adding duplicate should not error (INSERT OR IGNORE)
+	// Adding duplicate should not error
 	if err := db.AddDep(task2.ID, task1.ID); err != nil {
 		t.Errorf("duplicate dep should not error: %v", err)
 	}
@@ -82,6 +85,11 @@ func TestAddDep_Duplicate(t *testing.T) {
 	if len(deps) != 1 {
 		t.Errorf("expected 1 dep after duplicate, got %d", len(deps))
 	}
+
+	// The schema itself refuses a second copy of the edge
+	if _, err := db.Exec(`INSERT INTO deps (item_id, depends_on) VALUES (?, ?)`, task2.ID, task1.ID); err == nil {
+		t.Error("expected raw duplicate insert to violate the unique constraint")
+	}
 }
 
 func TestHasUnmetDeps(t *testing.T) {
@@ -142,6 +150,37 @@ func TestGetDeps_Empty(t *testing.T) {
 	}
 }
 
+func TestGetDependents(t *testing.T) {
+	db := setupTestDB(t)
+
+	base := createTestItem(t, db, "Base")
+	a := createTestItem(t, db, "A")
+	b := createTestItem(t, db, "B")
+	for _, id := range []string{a.ID, b.ID} {
+		if err := db.AddDep(id, base.ID); err != nil {
+			t.Fatalf("failed to add dep: %v", err)
+		}
+	}
+
+	dependents, err := db.GetDependents(base.ID)
+	if err != nil {
+		t.Fatalf("failed to get dependents: %v", err)
+	}
+	want := []string{a.ID, b.ID}
+	slices.Sort(want)
+	if !slices.Equal(dependents, want) {
+		t.Errorf("dependents = %v, want %v", dependents, want)
+	}
+
+	dependents, err = db.GetDependents(a.ID)
+	if err != nil {
+		t.Fatalf("failed to get dependents: %v", err)
+	}
+	if len(dependents) != 0 {
+		t.Errorf("expected no dependents of a leaf, got %v", dependents)
+	}
+}
+
 func TestGetAllDeps(t *testing.T) {
 	db := setupTestDB(t)
 
@@ -244,3 +283,216 @@ func TestGetAllDeps_Empty(t *testing.T) {
 		t.Errorf("expected 0 edges, got %d", len(edges))
 	}
 }
+
+func TestAddDep_SelfCycle(t *testing.T) {
+	db := setupTestDB(t)
+
+	task := createTestItem(t, db, "Task")
+
+	err := db.AddDep(task.ID, task.ID)
+	if err == nil {
+		t.Fatal("expected error for self-dependency")
+	}
+	if !errors.Is(err, ErrSelfDependency) || errors.Is(err, ErrCycle) {
+		t.Errorf("error = %v, want ErrSelfDependency only", err)
+	}
+	want := "an item cannot depend on itself: " + task.ID
+	if err.Error() != want {
+		t.Errorf("error = %q, want %q", err.Error(), want)
+	}
+
+	// block --on checks before blocking anything
+	err = db.BlockItemsOn([]string{task.ID}, task.ID, "waiting", false)
+	if !errors.Is(err, ErrSelfDependency) {
+		t.Errorf("BlockItemsOn self error = %v, want ErrSelfDependency", err)
+	}
+	if got, _ := db.GetItem(task.ID); got.Status != model.StatusOpen {
+		t.Errorf("status = %s after failed block, want open", got.Status)
+	}
+}
+
+func TestAddDep_ThreeNodeCycle(t *testing.T) {
+	db := setupTestDB(t)
+
+	a := createTestItem(t, db, "A")
+	b := createTestItem(t, db, "B")
+	c := createTestItem(t, db, "C")
+
+	// a -> b -> c
+	if err := db.AddDep(a.ID, b.ID); err != nil {
+		t.Fatalf("failed to add dep: %v", err)
+	}
+	if err := db.AddDep(b.ID, c.ID); err != nil {
+		t.Fatalf("failed to add dep: %v", err)
+	}
+
+	// c -> a would close the loop
+	err := db.AddDep(c.ID, a.ID)
+	if err == nil {
+		t.Fatal("expected error for three-node cycle")
+	}
+	want := "dependency would create a cycle: " + c.ID + " -> " + a.ID + " -> " + b.ID + " -> " + c.ID
+	if err.Error() != want {
+		t.Errorf("error = %q, want %q", err.Error(), want)
+	}
+
+	// The rejected edge must not have been inserted
+	deps, _ := db.GetDeps(c.ID)
+	if len(deps) != 0 {
+		t.Errorf("expected no deps on %s, got %v", c.ID, deps)
+	}
+}
+
+func TestAddDep_DiamondIsNotCycle(t *testing.T) {
+	db := setupTestDB(t)
+
+	a := createTestItem(t, db, "A")
+	b := createTestItem(t, db, "B")
+	c := createTestItem(t, db, "C")
+	d := createTestItem(t, db, "D")
+
+	// a -> b -> d, a -> c -> d
+	for _, edge := range [][2]string{{a.ID, b.ID}, {a.ID, c.ID}, {b.ID, d.ID}, {c.ID, d.ID}} {
+		if err := db.AddDep(edge[0], edge[1]); err != nil {
+			t.Fatalf("unexpected error adding %s -> %s: %v", edge[0], edge[1], err)
+		}
+	}
+}
+
+func TestRemoveDep(t *testing.T) {
+	db := setupTestDB(t)
+
+	task1 := createTestItem(t, db, "Task 1")
+	task2 := createTestItem(t, db, "Task 2")
+
+	if err := db.AddDep(task2.ID, task1.ID); err != nil {
+		t.Fatalf("failed to add dep: %v", err)
+	}
+
+	if err := db.RemoveDep(task2.ID, task1.ID); err != nil {
+		t.Fatalf("failed to remove dep: %v", err)
+	}
+
+	deps, err := db.GetDeps(task2.ID)
+	if err != nil {
+		t.Fatalf("failed to get deps: %v", err)
+	}
+	if len(deps) != 0 {
+		t.Errorf("expected 0 deps after removal, got %d", len(deps))
+	}
+}
+
+func TestRemoveDep_NotFound(t *testing.T) {
+	db := setupTestDB(t)
+
+	task1 := createTestItem(t, db, "Task 1")
+	task2 := createTestItem(t, db, "Task 2")
+
+	err := db.RemoveDep(task2.ID, task1.ID)
+	if err == nil {
+		t.Fatal("expected error removing nonexistent dep")
+	}
+	if !strings.Contains(err.Error(), "dependency not found") {
+		t.Errorf("error should mention 'dependency not found', got: %v", err)
+	}
+}
+
+func TestCheckDep(t *testing.T) {
+	db := setupTestDB(t)
+
+	done := createTestItem(t, db, "Done")
+	blocked := createTestItem(t, db, "Blocked")
+	open := createTestItem(t, db, "Open")
+	a := createTestItem(t, db, "A")
+	if err := db.UpdateStatus(done.ID, model.StatusDone); err != nil {
+		t.Fatalf("failed to update status: %v", err)
+	}
+	if err := db.UpdateStatus(blocked.ID, model.StatusBlocked); err != nil {
+		t.Fatalf("failed to update status: %v", err)
+	}
+
+	if err := db.AddDep(a.ID, done.ID); err != nil {
+		t.Fatalf("failed to add dep: %v", err)
+	}
+	check, err := db.CheckDep(a.ID, done.ID)
+	if err != nil {
+		t.Fatalf("CheckDep failed: %v", err)
+	}
+	if check.DependsOnStatus != model.StatusDone || !check.Ready {
+		t.Errorf("done dep: got %+v, want done and ready", check)
+	}
+
+	if err := db.AddDep(a.ID, blocked.ID); err != nil {
+		t.Fatalf("failed to add dep: %v", err)
+	}
+	check, err = db.CheckDep(a.ID, blocked.ID)
+	if err != nil {
+		t.Fatalf("CheckDep failed: %v", err)
+	}
+	if check.DependsOnStatus != model.StatusBlocked || check.Ready {
+		t.Errorf("blocked dep: got %+v, want blocked and not ready", check)
+	}
+
+	// Readiness follows transitive deps: open depends on a, which is held back
+	if err := db.AddDep(open.ID, a.ID); err != nil {
+		t.Fatalf("failed to add dep: %v", err)
+	}
+	check, err = db.CheckDep(open.ID, a.ID)
+	if err != nil {
+		t.Fatalf("CheckDep failed: %v", err)
+	}
+	if check.DependsOnStatus != model.StatusOpen || check.Ready {
+		t.Errorf("transitive dep: got %+v, want open and not ready", check)
+	}
+
+	if _, err := db.CheckDep(a.ID, "ts-missing"); err == nil {
+		t.Error("expected error for missing item")
+	}
+}
+
+func TestPlanOrder(t *testing.T) {
+	db := setupTestDB(t)
+
+	design := createTestItemWithProject(t, db, "Design", "test", model.StatusOpen, 3)
+	build := createTestItemWithProject(t, db, "Build", "test", model.StatusOpen, 1)
+	docs := createTestItemWithProject(t, db, "Docs", "test", model.StatusOpen, 2)
+	urgent := createTestItemWithProject(t, db, "Hotfix", "test", model.StatusOpen, 1)
+	for _, dep := range [][2]string{{build.ID, design.ID}, {docs.ID, build.ID}} {
+		if err := db.AddDep(dep[0], dep[1]); err != nil {
+			t.Fatalf("failed to add dep: %v", err)
+		}
+	}
+
+	items := []model.Item{*docs, *build, *design, *urgent}
+	plan, err := db.PlanOrder(items)
+	if err != nil {
+		t.Fatalf("PlanOrder failed: %v", err)
+	}
+	var titles []string
+	for _, item := range plan {
+		titles = append(titles, item.Title)
+	}
+	if got := strings.Join(titles, ","); got != "Hotfix,Design,Build,Docs" {
+		t.Errorf("plan = %s, want Hotfix,Design,Build,Docs", got)
+	}
+
+	// Dependencies outside the listed items don't hold anything back
+	plan, err = db.PlanOrder([]model.Item{*docs, *urgent})
+	if err != nil || len(plan) != 2 || plan[0].ID != urgent.ID {
+		t.Errorf("partial plan = %v, %v", plan, err)
+	}
+
+	// AddDep refuses cycles, so close one behind its back
+	if _, err := db.Exec(`INSERT INTO deps (item_id, depends_on) VALUES (?, ?)`, design.ID, docs.ID); err != nil {
+		t.Fatalf("failed to insert dep: %v", err)
+	}
+	_, err = db.PlanOrder(items)
+	if !errors.Is(err, ErrCycle) {
+		t.Fatalf("expected ErrCycle, got %v", err)
+	}
+	for _, id := range []string{design.ID, build.ID, docs.ID} {
+		if !strings.Contains(err.Error(), id) {
+			t.Errorf("cycle error %q does not name %s", err, id)
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package db
+
+import (
+	"fmt"
+)
+
+// Problem is one inconsistency found by Diagnose.
+type Problem struct {
+	Kind    string // integrity, dangling_dep, orphaned_log, or bad_parent
+	Detail  string
+	Fixable bool // whether Repair cleans it up
+}
+
+// Diagnose runs SQLite's integrity check and looks for rows that reference
+// missing items: dependency edges, logs, and parent links (including parents
+// that aren't epics). It reports problems without changing anything.
+func (db *DB) Diagnose() ([]Problem, error) {
+	var problems []Problem
+
+	rows, err := db.Query(`PRAGMA integrity_check`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to run integrity check: %w", err)
+	}
+	for rows.Next() {
+		var msg string
+		if err := rows.Scan(&msg); err != nil {
+			_ = rows.Close()
+			return nil, fmt.Errorf("failed to scan integrity check: %w", err)
+		}
+		if msg != "ok" {
+			problems = append(problems, Problem{Kind: "integrity", Detail: msg})
+		}
+	}
+	_ = rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to run integrity check: %w", err)
+	}
+
+	checks := []struct {
+		kind   string
+		query  string
+		detail func(a, b string) string
+	}{
+		{
+			kind: "dangling_dep",
+			query: `
+				SELECT item_id, depends_on FROM deps
+				WHERE item_id NOT IN (SELECT id FROM items)
+				   OR depends_on NOT IN (SELECT id FROM items)
+				ORDER BY item_id, depends_on`,
+			detail: func(a, b string) string { return fmt.Sprintf("dependency %s -> %s references a missing item", a, b) },
+		},
+		{
+			kind: "orphaned_log",
+			query: `
+				SELECT CAST(id AS TEXT), item_id FROM logs
+				WHERE item_id NOT IN (SELECT id FROM items)
+				ORDER BY id`,
+			detail: func(a, b string) string { return fmt.Sprintf("log #%s belongs to missing item %s", a, b) },
+		},
+		{
+			kind: "bad_parent",
+			query: `
+				SELECT i.id, i.parent_id FROM items i
+				LEFT JOIN items p ON p.id = i.parent_id
+				WHERE i.parent_id IS NOT NULL AND (p.id IS NULL OR p.type != 'epic')
+				ORDER BY i.id`,
+			detail: func(a, b string) string {
+				return fmt.Sprintf("%s has parent %s, which is missing or not an epic", a, b)
+			},
+		},
+	}
+	for _, c := range checks {
+		rows, err := db.Query(c.query)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check %s: %w", c.kind, err)
+		}
+		for rows.Next() {
+			var a, b string
+			if err := rows.Scan(&a, &b); err != nil {
+				_ = rows.Close()
+				return nil, fmt.Errorf("failed to scan %s: %w", c.kind, err)
+			}
+			problems = append(problems, Problem{Kind: c.kind, Detail: c.detail(a, b), Fixable: true})
+		}
+		_ = rows.Close()
+		if err := rows.Err(); err != nil {
+			return nil, fmt.Errorf("failed to check %s: %w", c.kind, err)
+		}
+	}
+	return problems, nil
+}
+
+// Repair fixes what Diagnose marks fixable, in one transaction: it deletes
+// dangling dependency edges and orphaned logs, and detaches items from
+// missing or non-epic parents. It returns the number of rows changed.
+func (db *DB) Repair() (int, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	fixes := []struct {
+		what  string
+		query string
+		args  []any
+	}{
+		{"remove dangling deps", `
+			DELETE FROM deps
+			WHERE item_id NOT IN (SELECT id FROM items)
+			   OR depends_on NOT IN (SELECT id FROM items)`, nil},
+		{"remove orphaned logs", `
+			DELETE FROM logs WHERE item_id NOT IN (SELECT id FROM items)`, nil},
+		{"clear bad parents", `
+			UPDATE items SET parent_id = NULL, updated_at = ?
+			WHERE parent_id IS NOT NULL
+			  AND parent_id NOT IN (SELECT id FROM items WHERE type = 'epic')`, []any{db.Now()}},
+	}
+
+	fixed := 0
+	for _, f := range fixes {
+		result, err := tx.Exec(f.query, f.args...)
+		if err != nil {
+			return 0, fmt.Errorf("failed to %s: %w", f.what, err)
+		}
+		n, _ := result.RowsAffected()
+		fixed += int(n)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return fixed, nil
+}
@@ -0,0 +1,79 @@
+package db
+
+import (
+	"testing"
+
+	"github.com/baiirun/prog/internal/model"
+)
+
+func TestDiagnoseAndRepair(t *testing.T) {
+	db := setupTestDB(t)
+
+	gone := createTestItem(t, db, "Gone")
+	kept := createTestItem(t, db, "Kept")
+	child := createTestItem(t, db, "Child")
+	if err := db.AddDep(kept.ID, gone.ID); err != nil {
+		t.Fatalf("failed to add dep: %v", err)
+	}
+	if err := db.AddLog(gone.ID, "note", ""); err != nil {
+		t.Fatalf("failed to add log: %v", err)
+	}
+
+	problems, err := db.Diagnose()
+	if err != nil {
+		t.Fatalf("Diagnose failed: %v", err)
+	}
+	if len(problems) != 0 {
+		t.Fatalf("expected a clean database, got %+v", problems)
+	}
+
+	// Simulate manual edits that bypass foreign keys. Pin one connection so
+	// the pragma applies to the statements that follow.
+	db.SetMaxOpenConns(1)
+	for _, stmt := range []string{
+		`PRAGMA foreign_keys = OFF`,
+		`DELETE FROM items WHERE id = '` + gone.ID + `'`,
+		`UPDATE items SET parent_id = '` + kept.ID + `' WHERE id = '` + child.ID + `'`,
+		`PRAGMA foreign_keys = ON`,
+	} {
+		if _, err := db.Exec(stmt); err != nil {
+			t.Fatalf("%s: %v", stmt, err)
+		}
+	}
+
+	problems, err = db.Diagnose()
+	if err != nil {
+		t.Fatalf("Diagnose failed: %v", err)
+	}
+	kinds := map[string]int{}
+	for _, p := range problems {
+		kinds[p.Kind]++
+		if !p.Fixable {
+			t.Errorf("expected %s to be fixable", p.Kind)
+		}
+	}
+	want := map[string]int{"dangling_dep": 1, "orphaned_log": 1, "bad_parent": 1}
+	for kind, n := range want {
+		if kinds[kind] != n {
+			t.Errorf("%s problems = %d, want %d (all: %+v)", kind, kinds[kind], n, problems)
+		}
+	}
+
+	fixed, err := db.Repair()
+	if err != nil {
+		t.Fatalf("Repair failed: %v", err)
+	}
+	if fixed != 3 {
+		t.Errorf("fixed = %d, want 3", fixed)
+	}
+	if problems, _ := db.Diagnose(); len(problems) != 0 {
+		t.Errorf("expected no problems after repair, got %+v", problems)
+	}
+	got, err := db.GetItem(child.ID)
+	if err != nil {
+		t.Fatalf("failed to get item: %v", err)
+	}
+	if got.ParentID != nil || got.Status != model.StatusOpen {
+		t.Errorf("child = parent %v, status %s; want detached and untouched", got.ParentID, got.Status)
+	}
+}
@@ -0,0 +1,216 @@
+package db
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"time"
+)
+
+// dumpTables lists the tables a dump covers, parents before children.
+// learnings_fts is rebuilt by triggers as learnings are loaded.
+var dumpTables = []string{
+	"projects", "items", "deps", "links", "logs", "tags", "labels", "item_labels",
+	"notes", "checklist_items", "status_history", "events", "concepts", "learnings",
+	"learning_concepts", "templates",
+}
+
+// Dump is a snapshot of a whole database: every row of every table, keyed
+// by column name, with ids kept as stored so logs, deps and labels stay
+// wired to their items.
+type Dump struct {
+	SchemaVersion int                         `json:"schema_version"`
+	Tables        map[string][]map[string]any `json:"tables"`
+}
+
+// column is one column of a table, from PRAGMA table_info.
+type column struct {
+	name     string
+	datetime bool
+}
+
+func (db *DB) tableColumns(table string) ([]column, error) {
+	rows, err := db.Query(`SELECT name, type FROM pragma_table_info(?) ORDER BY cid`, table)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s columns: %w", table, err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var cols []column
+	for rows.Next() {
+		var name, typ string
+		if err := rows.Scan(&name, &typ); err != nil {
+			return nil, fmt.Errorf("failed to scan %s column: %w", table, err)
+		}
+		cols = append(cols, column{name: name, datetime: strings.EqualFold(typ, "DATETIME")})
+	}
+	return cols, rows.Err()
+}
+
+// Dump reads every table into a Dump.
+func (db *DB) Dump() (*Dump, error) {
+	dump := &Dump{SchemaVersion: SchemaVersion, Tables: make(map[string][]map[string]any, len(dumpTables))}
+	for _, table := range dumpTables {
+		cols, err := db.tableColumns(table)
+		if err != nil {
+			return nil, err
+		}
+		names := make([]string, len(cols))
+		for i, c := range cols {
+			names[i] = c.name
+		}
+
+		rows, err := db.Query(`SELECT ` + strings.Join(names, ", ") + ` FROM ` + table + ` ORDER BY rowid`)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", table, err)
+		}
+		records := []map[string]any{}
+		for rows.Next() {
+			values := make([]any, len(cols))
+			dest := make([]any, len(cols))
+			for i := range values {
+				dest[i] = &values[i]
+			}
+			if err := rows.Scan(dest...); err != nil {
+				_ = rows.Close()
+				return nil, fmt.Errorf("failed to scan %s row: %w", table, err)
+			}
+			record := make(map[string]any, len(cols))
+			for i, name := range names {
+				if b, ok := values[i].([]byte); ok {
+					values[i] = string(b)
+				}
+				record[name] = values[i]
+			}
+			records = append(records, record)
+		}
+		err = rows.Err()
+		_ = rows.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", table, err)
+		}
+		dump.Tables[table] = records
+	}
+	return dump, nil
+}
+
+// ReadDump decodes a dump written by Dump, keeping integers exact.
+func ReadDump(r io.Reader) (*Dump, error) {
+	dec := json.NewDecoder(r)
+	dec.UseNumber()
+	var dump Dump
+	if err := dec.Decode(&dump); err != nil {
+		return nil, fmt.Errorf("failed to parse dump: %w", err)
+	}
+	return &dump, nil
+}
+
+// Load writes dump into the database in a single transaction and returns the
+// number of rows loaded per table. The database must be empty unless force
+// is set, in which case its contents are replaced. Dumps from an older
+// schema load with defaults for newer columns; dumps from a newer one are
+// refused.
+func (db *DB) Load(dump *Dump, force bool) (map[string]int, error) {
+	if dump.SchemaVersion > SchemaVersion {
+		return nil, fmt.Errorf("dump is from schema version %d, newer than this prog's %d; upgrade prog first", dump.SchemaVersion, SchemaVersion)
+	}
+	for table := range dump.Tables {
+		if !slices.Contains(dumpTables, table) {
+			return nil, fmt.Errorf("unknown table in dump: %s", table)
+		}
+	}
+	columns := make(map[string]map[string]column, len(dumpTables))
+	for _, table := range dumpTables {
+		cols, err := db.tableColumns(table)
+		if err != nil {
+			return nil, err
+		}
+		columns[table] = make(map[string]column, len(cols))
+		for _, c := range cols {
+			columns[table][c.name] = c
+		}
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	// Rows may reference ones later in the dump (a parent epic created after
+	// its child), so check foreign keys once everything is in
+	if _, err := tx.Exec(`PRAGMA defer_foreign_keys = ON`); err != nil {
+		return nil, fmt.Errorf("failed to defer foreign keys: %w", err)
+	}
+
+	existing := 0
+	for _, table := range dumpTables {
+		var n int
+		if err := tx.QueryRow(`SELECT COUNT(*) FROM ` + table).Scan(&n); err != nil {
+			return nil, fmt.Errorf("failed to count %s: %w", table, err)
+		}
+		existing += n
+	}
+	if existing > 0 && !force {
+		return nil, fmt.Errorf("database is not empty (%d rows); use --force to replace its contents", existing)
+	}
+	for _, table := range slices.Backward(dumpTables) {
+		if _, err := tx.Exec(`DELETE FROM ` + table); err != nil {
+			return nil, fmt.Errorf("failed to clear %s: %w", table, err)
+		}
+	}
+
+	counts := make(map[string]int, len(dump.Tables))
+	for _, table := range dumpTables {
+		byName := columns[table]
+		for _, record := range dump.Tables[table] {
+			names := make([]string, 0, len(record))
+			for name := range record {
+				if _, ok := byName[name]; !ok {
+					return nil, fmt.Errorf("unknown column in dump: %s.%s", table, name)
+				}
+				names = append(names, name)
+			}
+			slices.Sort(names)
+
+			args := make([]any, len(names))
+			for i, name := range names {
+				args[i] = loadValue(byName[name], record[name])
+			}
+			placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(names)), ", ")
+			query := `INSERT INTO ` + table + ` (` + strings.Join(names, ", ") + `) VALUES (` + placeholders + `)`
+			if _, err := tx.Exec(query, args...); err != nil {
+				return nil, fmt.Errorf("failed to load %s row: %w", table, err)
+			}
+			counts[table]++
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return counts, nil
+}
+
+// loadValue converts a decoded JSON value back to what the column stores:
+// integers for numbers and time.Time for timestamps, so loaded rows are
+// written in the same format as ones created normally.
+func loadValue(col column, v any) any {
+	switch v := v.(type) {
+	case json.Number:
+		if n, err := v.Int64(); err == nil {
+			return n
+		}
+		f, _ := v.Float64()
+		return f
+	case string:
+		if col.datetime {
+			if t, err := time.Parse(time.RFC3339Nano, v); err == nil {
+				return t
+			}
+		}
+	}
+	return v
+}
@@ -0,0 +1,132 @@
+package db
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/baiirun/prog/internal/model"
+)
+
+// roundTrip dumps src, serializes and re-reads the dump, and loads it into
+// dst.
+func roundTrip(t *testing.T, src, dst *DB, force bool) (map[string]int, error) {
+	t.Helper()
+	dump, err := src.Dump()
+	if err != nil {
+		t.Fatalf("Dump failed: %v", err)
+	}
+	b, err := json.Marshal(dump)
+	if err != nil {
+		t.Fatalf("failed to marshal dump: %v", err)
+	}
+	decoded, err := ReadDump(bytes.NewReader(b))
+	if err != nil {
+		t.Fatalf("ReadDump failed: %v", err)
+	}
+	return dst.Load(decoded, force)
+}
+
+func TestDumpLoad_RoundTrip(t *testing.T) {
+	src := setupTestDB(t)
+
+	epic := createTestEpic(t, src, "Auth", "test")
+	task := createTestItem(t, src, "Login")
+	other := createTestItem(t, src, "Schema")
+	if err := src.SetParent(task.ID, epic.ID); err != nil {
+		t.Fatalf("failed to set parent: %v", err)
+	}
+	if err := src.AddDep(task.ID, other.ID); err != nil {
+		t.Fatalf("failed to add dep: %v", err)
+	}
+	if err := src.UpdateStatus(other.ID, model.StatusInProgress); err != nil {
+		t.Fatalf("failed to start: %v", err)
+	}
+	if err := src.AddLog(task.ID, "drafted", "alice"); err != nil {
+		t.Fatalf("failed to add log: %v", err)
+	}
+	if err := src.AddTag(task.ID, "backend"); err != nil {
+		t.Fatalf("failed to add tag: %v", err)
+	}
+	if err := src.AddLabelToItem(task.ID, "test", "bug"); err != nil {
+		t.Fatalf("failed to add label: %v", err)
+	}
+	if err := src.SetNote(task.ID, "pr", "123"); err != nil {
+		t.Fatalf("failed to set note: %v", err)
+	}
+	if err := src.CreateLearning(&model.Learning{Project: "test", Summary: "Tokens expire hourly", Status: model.LearningStatusActive, Concepts: []string{"auth"}}); err != nil {
+		t.Fatalf("failed to create learning: %v", err)
+	}
+
+	dst := setupTestDB(t)
+	counts, err := roundTrip(t, src, dst, false)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if counts["items"] != 3 || counts["deps"] != 1 || counts["learnings"] != 1 {
+		t.Errorf("unexpected counts: %v", counts)
+	}
+
+	for _, id := range []string{epic.ID, task.ID, other.ID} {
+		want, _ := src.GetItem(id)
+		got, err := dst.GetItem(id)
+		if err != nil {
+			t.Fatalf("item %s missing after load: %v", id, err)
+		}
+		if !got.UpdatedAt.Equal(want.UpdatedAt) || (want.StartedAt != nil) != (got.StartedAt != nil) {
+			t.Errorf("item %s timestamps changed: %+v vs %+v", id, got, want)
+		}
+		got.CreatedAt, got.UpdatedAt, got.StartedAt = want.CreatedAt, want.UpdatedAt, want.StartedAt
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("item %s = %+v, want %+v", id, got, want)
+		}
+	}
+
+	wantLogs, _ := src.GetLogs(task.ID)
+	gotLogs, _ := dst.GetLogs(task.ID)
+	if len(gotLogs) != len(wantLogs) || gotLogs[0].ID != wantLogs[0].ID || gotLogs[0].Source != "alice" {
+		t.Errorf("logs = %+v, want %+v", gotLogs, wantLogs)
+	}
+	if deps, _ := dst.GetDeps(task.ID); len(deps) != 1 || deps[0] != other.ID {
+		t.Errorf("deps = %v", deps)
+	}
+	if tags, _ := dst.GetItemTags(task.ID); len(tags) != 1 || tags[0] != "backend" {
+		t.Errorf("tags = %v", tags)
+	}
+	if notes, _ := dst.GetNotes(task.ID); len(notes) != 1 || notes[0].Value != "123" {
+		t.Errorf("notes = %+v", notes)
+	}
+	if learnings, err := dst.SearchLearnings("test", "expire", false); err != nil || len(learnings) != 1 {
+		t.Errorf("learning search after load = %v, %v", learnings, err)
+	}
+}
+
+func TestLoad_RefusesNonEmpty(t *testing.T) {
+	src := setupTestDB(t)
+	createTestItem(t, src, "From dump")
+	dst := setupTestDB(t)
+	existing := createTestItem(t, dst, "Already here")
+
+	if _, err := roundTrip(t, src, dst, false); err == nil || !strings.Contains(err.Error(), "not empty") {
+		t.Fatalf("expected not-empty error, got %v", err)
+	}
+	if _, err := dst.GetItem(existing.ID); err != nil {
+		t.Errorf("refused load changed the database: %v", err)
+	}
+
+	if _, err := roundTrip(t, src, dst, true); err != nil {
+		t.Fatalf("forced load failed: %v", err)
+	}
+	if _, err := dst.GetItem(existing.ID); err == nil {
+		t.Error("forced load kept the old contents")
+	}
+
+	if _, err := dst.Load(&Dump{SchemaVersion: SchemaVersion + 1}, true); err == nil {
+		t.Error("expected error loading a dump from a newer schema")
+	}
+	if _, err := dst.Load(&Dump{SchemaVersion: SchemaVersion, Tables: map[string][]map[string]any{"sqlite_master": nil}}, true); err == nil {
+		t.Error("expected error for unknown table")
+	}
+}
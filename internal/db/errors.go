@@ -0,0 +1,83 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+// Sentinel errors wrapped by DB methods, so callers can tell failure kinds
+// apart with errors.Is instead of matching message text.
+var (
+	// ErrNotFound means a referenced item, project, label, learning,
+	// concept, log, or dependency doesn't exist.
+	ErrNotFound = errors.New("not found")
+
+	// ErrInvalidStatus means a status value isn't one of model's statuses.
+	ErrInvalidStatus = errors.New("invalid status")
+
+	// ErrCycle means a dependency change would make an item transitively
+	// depend on itself.
+	ErrCycle = errors.New("dependency would create a cycle")
+
+	// ErrSelfDependency means an item was asked to depend on itself
+	// directly, the most common cycle, reported on its own.
+	ErrSelfDependency = errors.New("an item cannot depend on itself")
+
+	// ErrLocked means an unforced change was attempted on a locked item.
+	ErrLocked = errors.New("item is locked")
+
+	// ErrTransition means an unforced status change was refused: the new
+	// status can't be reached from the current one, or an epic being
+	// completed still has unfinished children.
+	ErrTransition = errors.New("status change not allowed")
+
+	// ErrWIPLimit means starting items would put a project over its WIP
+	// limit. The db package only warns through CheckWIP; callers that want
+	// the limit enforced return it themselves.
+	ErrWIPLimit = errors.New("WIP limit exceeded")
+
+	// ErrInvalid means a request was rejected as invalid input, such as an
+	// unknown item type or a disallowed status change, rather than failing.
+	// ErrTransition errors wrap it too.
+	ErrInvalid = errors.New("invalid input")
+
+	// ErrNotInitialized means the database has no schema yet: it was never
+	// set up with Init.
+	ErrNotInitialized = errors.New("database not initialized")
+
+	// ErrCorrupt means the database couldn't be opened because SQLite
+	// reported the file as damaged or as not being a database at all.
+	ErrCorrupt = errors.New("database is corrupt")
+)
+
+// invalidf formats an error that wraps ErrInvalid, and anything wrapped with
+// %w, without adding to the message.
+func invalidf(format string, args ...any) error {
+	return &invalidError{fmt.Errorf(format, args...)}
+}
+
+type invalidError struct{ err error }
+
+func (e *invalidError) Error() string   { return e.err.Error() }
+func (e *invalidError) Unwrap() []error { return []error{ErrInvalid, e.err} }
+
+// IsCorrupt reports whether err wraps ErrCorrupt or a SQLite error saying
+// the file is malformed or not a database.
+func IsCorrupt(err error) bool {
+	if errors.Is(err, ErrCorrupt) {
+		return true
+	}
+	var sqliteErr *sqlite.Error
+	if !errors.As(err, &sqliteErr) {
+		return false
+	}
+	// Extended result codes carry the primary code in the low byte
+	switch sqliteErr.Code() & 0xff {
+	case sqlite3.SQLITE_CORRUPT, sqlite3.SQLITE_NOTADB:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,73 @@
+package db
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/baiirun/prog/internal/model"
+)
+
+func TestSentinelErrors(t *testing.T) {
+	db := setupTestDB(t)
+
+	a := createTestItem(t, db, "A")
+	b := createTestItem(t, db, "B")
+	if err := db.AddDep(a.ID, b.ID); err != nil {
+		t.Fatalf("failed to add dep: %v", err)
+	}
+
+	_, getErr := db.GetItem("ts-nope00")
+	_, resolveErr := db.ResolveID("zzzzzz")
+	_, learningErr := db.GetLearning("lrn-nope00")
+	tests := []struct {
+		name   string
+		err    error
+		target error
+	}{
+		{"GetItem", getErr, ErrNotFound},
+		{"ResolveID", resolveErr, ErrNotFound},
+		{"GetLearning", learningErr, ErrNotFound},
+		{"UpdateStatus missing", db.UpdateStatus("ts-nope00", model.StatusDone), ErrNotFound},
+		{"SetWIPLimit", db.SetWIPLimit("nope", 2), ErrNotFound},
+		{"AddDep missing", db.AddDep(a.ID, "ts-nope00"), ErrNotFound},
+		{"RemoveDep missing", db.RemoveDep(b.ID, a.ID), ErrNotFound},
+		{"UpdateStatus invalid", db.UpdateStatus(a.ID, model.Status("finished")), ErrInvalidStatus},
+		{"AddDep cycle", db.AddDep(b.ID, a.ID), ErrCycle},
+	}
+	for _, tt := range tests {
+		if !errors.Is(tt.err, tt.target) {
+			t.Errorf("%s: error %v does not wrap %v", tt.name, tt.err, tt.target)
+		}
+	}
+
+	// Wrapping keeps the original messages
+	if getErr.Error() != "item not found: ts-nope00 (use 'prog list' to see available items)" {
+		t.Errorf("unexpected message: %v", getErr)
+	}
+}
+
+func TestCheckInitialized(t *testing.T) {
+	if err := setupTestDB(t).CheckInitialized(); err != nil {
+		t.Errorf("initialized db: %v", err)
+	}
+
+	fresh, err := Open(filepath.Join(t.TempDir(), "fresh.db"))
+	if err != nil {
+		t.Fatalf("failed to open db: %v", err)
+	}
+	defer func() { _ = fresh.Close() }()
+	if err := fresh.CheckInitialized(); !errors.Is(err, ErrNotInitialized) {
+		t.Errorf("fresh db = %v, want ErrNotInitialized", err)
+	}
+
+	garbage := filepath.Join(t.TempDir(), "garbage.db")
+	if err := os.WriteFile(garbage, []byte(strings.Repeat("not a database ", 100)), 0644); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+	if _, err := Open(garbage); !errors.Is(err, ErrCorrupt) || !IsCorrupt(err) {
+		t.Errorf("Open(garbage) = %v, want ErrCorrupt", err)
+	}
+}
@@ -0,0 +1,258 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/baiirun/prog/internal/model"
+)
+
+// recordStatusChangeTx appends a status transition to the history within tx.
+// No-op transitions are not recorded.
+func recordStatusChangeTx(tx *sql.Tx, itemID string, oldStatus, newStatus model.Status, now time.Time) error {
+	if oldStatus == newStatus {
+		return nil
+	}
+	_, err := tx.Exec(`
+		INSERT INTO status_history (item_id, old_status, new_status, changed_at)
+		VALUES (?, ?, ?, ?)`,
+		itemID, oldStatus, newStatus, now)
+	if err != nil {
+		return fmt.Errorf("failed to record status change: %w", err)
+	}
+	return nil
+}
+
+// Event kinds in an item's history.
+const (
+	EventCreated     = "created"
+	EventStatus      = "status"
+	EventLog         = "log"
+	EventDescription = "description"
+	EventDep         = "dep"
+)
+
+// Event is one entry in an item's audit trail.
+type Event struct {
+	Kind    string // one of the Event* kinds
+	Message string
+	Source  string // who wrote a log entry; empty for other kinds
+	At      time.Time
+}
+
+// recordEventTx appends an event of kind to an item's trail within tx.
+// Only changes with no record of their own (description edits, dependency
+// additions) are stored this way; ItemHistory reads the rest from their
+// own tables.
+func recordEventTx(tx *sql.Tx, itemID, kind, detail string, now time.Time) error {
+	_, err := tx.Exec(`
+		INSERT INTO events (item_id, kind, detail, created_at) VALUES (?, ?, ?, ?)`,
+		itemID, kind, detail, now)
+	if err != nil {
+		return fmt.Errorf("failed to record event: %w", err)
+	}
+	return nil
+}
+
+// ItemHistory returns everything that happened to an item, oldest first:
+// its creation, status changes, log entries, description edits and
+// dependency additions.
+func (db *DB) ItemHistory(id string) ([]Event, error) {
+	var created time.Time
+	err := db.QueryRow(`SELECT created_at FROM items WHERE id = ?`, id).Scan(&created)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("item %w: %s (use 'prog list' to see available items)", ErrNotFound, id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get item: %w", err)
+	}
+	events := []Event{{Kind: EventCreated, Message: "created", At: created}}
+
+	changes, err := db.GetStatusHistory(id)
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range changes {
+		msg := fmt.Sprintf("%s -> %s", c.OldStatus, c.NewStatus)
+		if c.Undone {
+			msg += " (undone)"
+		}
+		events = append(events, Event{Kind: EventStatus, Message: msg, At: c.ChangedAt})
+	}
+
+	logs, err := db.GetLogs(id)
+	if err != nil {
+		return nil, err
+	}
+	for _, l := range logs {
+		events = append(events, Event{Kind: EventLog, Message: l.Message, Source: l.Source, At: l.CreatedAt})
+	}
+
+	rows, err := db.Query(`SELECT kind, detail, created_at FROM events WHERE item_id = ? ORDER BY id ASC`, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get events: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+	for rows.Next() {
+		var e Event
+		if err := rows.Scan(&e.Kind, &e.Message, &e.At); err != nil {
+			return nil, fmt.Errorf("failed to scan event: %w", err)
+		}
+		events = append(events, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get events: %w", err)
+	}
+
+	// Merge by time in Go: logs are stored in UTC while the other sources
+	// keep their zone offset, so timestamps don't compare as strings
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].At.Before(events[j].At)
+	})
+	return events, nil
+}
+
+// GetStatusHistory returns an item's status changes, oldest first.
+func (db *DB) GetStatusHistory(itemID string) ([]model.StatusChange, error) {
+	rows, err := db.Query(`
+		SELECT id, item_id, old_status, new_status, changed_at, undone
+		FROM status_history WHERE item_id = ? ORDER BY id ASC`, itemID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get status history: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var changes []model.StatusChange
+	for rows.Next() {
+		var c model.StatusChange
+		if err := rows.Scan(&c.ID, &c.ItemID, &c.OldStatus, &c.NewStatus, &c.ChangedAt, &c.Undone); err != nil {
+			return nil, fmt.Errorf("failed to scan status change: %w", err)
+		}
+		changes = append(changes, c)
+	}
+	return changes, rows.Err()
+}
+
+// UndoStatus reverts an item to the status it had before its most recent
+// change and returns the restored status. The reverted change stays in the
+// history marked as undone, so repeated undos walk further back.
+func (db *DB) UndoStatus(itemID string) (model.Status, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return "", fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var current model.Status
+	err = tx.QueryRow(`SELECT status FROM items WHERE id = ?`, itemID).Scan(&current)
+	if err == sql.ErrNoRows {
+		return "", fmt.Errorf("item %w: %s (use 'prog list' to see available items)", ErrNotFound, itemID)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get status: %w", err)
+	}
+
+	var changeID int64
+	var oldStatus model.Status
+	err = tx.QueryRow(`
+		SELECT id, old_status FROM status_history
+		WHERE item_id = ? AND undone = 0
+		ORDER BY id DESC LIMIT 1`, itemID).Scan(&changeID, &oldStatus)
+	if err == sql.ErrNoRows {
+		return "", fmt.Errorf("no status history to undo for %s", itemID)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get status history: %w", err)
+	}
+
+	now := db.Now()
+	if _, err := tx.Exec(`UPDATE items SET status = ?, updated_at = ?, started_at = `+startedAtExpr+`, done_at = `+doneAtExpr+`,
+		block_reason = `+blockReasonExpr+`, blocked_on_deps = `+blockedOnDepsExpr+`
+		WHERE id = ?`,
+		oldStatus, now, oldStatus, now, oldStatus, now, oldStatus, oldStatus, itemID); err != nil {
+		return "", fmt.Errorf("failed to update status: %w", err)
+	}
+	if _, err := tx.Exec(`UPDATE status_history SET undone = 1 WHERE id = ?`, changeID); err != nil {
+		return "", fmt.Errorf("failed to update status history: %w", err)
+	}
+	msg := fmt.Sprintf("Undo: status %s -> %s", current, oldStatus)
+	if err := addLogTx(tx, itemID, msg, now); err != nil {
+		return "", err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return "", fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return oldStatus, nil
+}
+
+// Activity is one entry in the recent activity feed: a log entry or a status
+// change on an item.
+type Activity struct {
+	ItemID  string
+	Title   string
+	Message string // log text, or "old -> new" for status changes
+	Status  bool   // true for status changes
+	At      time.Time
+}
+
+// RecentActivity returns the most recent log entries and status changes,
+// newest first, limited to limit entries in total.
+func (db *DB) RecentActivity(project string, limit int) ([]Activity, error) {
+	if limit <= 0 {
+		return nil, nil
+	}
+
+	scope := ``
+	args := []any{}
+	if project != "" {
+		scope = ` WHERE i.project = ?`
+		args = append(args, project)
+	}
+	args = append(args, limit)
+
+	// Each source is ordered by insertion, then the two are merged by time in Go:
+	// logs are stored in UTC while status changes keep their zone offset, so
+	// their timestamps don't compare as strings.
+	var activity []Activity
+	queries := []struct {
+		query  string
+		status bool
+	}{
+		{`SELECT l.item_id, i.title, l.message, l.created_at
+			FROM logs l JOIN items i ON l.item_id = i.id` + scope + `
+			ORDER BY l.id DESC LIMIT ?`, false},
+		{`SELECT h.item_id, i.title, h.old_status || ' -> ' || h.new_status, h.changed_at
+			FROM status_history h JOIN items i ON h.item_id = i.id` + scope + `
+			ORDER BY h.id DESC LIMIT ?`, true},
+	}
+	for _, q := range queries {
+		rows, err := db.Query(q.query, args...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get recent activity: %w", err)
+		}
+		for rows.Next() {
+			a := Activity{Status: q.status}
+			if err := rows.Scan(&a.ItemID, &a.Title, &a.Message, &a.At); err != nil {
+				_ = rows.Close()
+				return nil, fmt.Errorf("failed to scan activity: %w", err)
+			}
+			activity = append(activity, a)
+		}
+		err = rows.Err()
+		_ = rows.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get recent activity: %w", err)
+		}
+	}
+
+	sort.SliceStable(activity, func(i, j int) bool {
+		return activity[i].At.After(activity[j].At)
+	})
+	if len(activity) > limit {
+		activity = activity[:limit]
+	}
+	return activity, nil
+}
@@ -0,0 +1,206 @@
+package db
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/baiirun/prog/internal/model"
+)
+
+func TestStatusHistory_Recorded(t *testing.T) {
+	db := setupTestDB(t)
+
+	item := createTestItem(t, db, "Test")
+	for _, s := range []model.Status{model.StatusInProgress, model.StatusInProgress, model.StatusDone} {
+		if err := db.UpdateStatus(item.ID, s); err != nil {
+			t.Fatalf("failed to update status: %v", err)
+		}
+	}
+
+	history, err := db.GetStatusHistory(item.ID)
+	if err != nil {
+		t.Fatalf("failed to get history: %v", err)
+	}
+	// The repeated in_progress is a no-op and isn't recorded
+	if len(history) != 2 {
+		t.Fatalf("expected 2 changes, got %d", len(history))
+	}
+	if history[0].OldStatus != model.StatusOpen || history[0].NewStatus != model.StatusInProgress {
+		t.Errorf("first change = %s -> %s", history[0].OldStatus, history[0].NewStatus)
+	}
+	if history[1].OldStatus != model.StatusInProgress || history[1].NewStatus != model.StatusDone {
+		t.Errorf("second change = %s -> %s", history[1].OldStatus, history[1].NewStatus)
+	}
+}
+
+func TestUndoStatus(t *testing.T) {
+	db := setupTestDB(t)
+
+	item := createTestItem(t, db, "Test")
+	_ = db.UpdateStatus(item.ID, model.StatusInProgress)
+	_ = db.UpdateStatus(item.ID, model.StatusDone)
+
+	status, err := db.UndoStatus(item.ID)
+	if err != nil {
+		t.Fatalf("failed to undo: %v", err)
+	}
+	if status != model.StatusInProgress {
+		t.Errorf("restored = %s, want in_progress", status)
+	}
+
+	// Undo again walks further back
+	status, err = db.UndoStatus(item.ID)
+	if err != nil {
+		t.Fatalf("failed to undo again: %v", err)
+	}
+	if status != model.StatusOpen {
+		t.Errorf("restored = %s, want open", status)
+	}
+	got, _ := db.GetItem(item.ID)
+	if got.Status != model.StatusOpen {
+		t.Errorf("status = %s, want open", got.Status)
+	}
+
+	// History is kept, marked undone
+	history, _ := db.GetStatusHistory(item.ID)
+	if len(history) != 2 || !history[0].Undone || !history[1].Undone {
+		t.Errorf("expected 2 undone changes, got %+v", history)
+	}
+
+	_, err = db.UndoStatus(item.ID)
+	if err == nil || !strings.Contains(err.Error(), "no status history") {
+		t.Errorf("expected no-history error, got %v", err)
+	}
+}
+
+func TestUndoStatus_NotFound(t *testing.T) {
+	db := setupTestDB(t)
+
+	if _, err := db.UndoStatus("ts-nonexistent"); err == nil {
+		t.Error("expected error for nonexistent item")
+	}
+}
+
+func TestDeleteItem_RemovesStatusHistory(t *testing.T) {
+	db := setupTestDB(t)
+
+	item := createTestItem(t, db, "Test")
+	_ = db.UpdateStatus(item.ID, model.StatusDone)
+	if err := db.DeleteItem(item.ID); err != nil {
+		t.Fatalf("failed to delete: %v", err)
+	}
+
+	history, _ := db.GetStatusHistory(item.ID)
+	if len(history) != 0 {
+		t.Errorf("expected history to be deleted, got %d rows", len(history))
+	}
+}
+
+func TestRecentActivity(t *testing.T) {
+	db := setupTestDB(t)
+	base := time.Date(2024, 5, 1, 9, 0, 0, 0, time.FixedZone("EST", -5*3600))
+
+	a := createTestItemWithProject(t, db, "A", "proj", model.StatusOpen, model.PriorityMedium)
+	other := createTestItemWithProject(t, db, "Other", "elsewhere", model.StatusOpen, model.PriorityMedium)
+
+	db.Clock = fixedClock{base}
+	if err := db.UpdateStatus(a.ID, model.StatusInProgress); err != nil {
+		t.Fatalf("failed to start: %v", err)
+	}
+	db.Clock = fixedClock{base.Add(time.Minute)}
+	if err := db.AddLog(a.ID, "progress", ""); err != nil {
+		t.Fatalf("failed to log: %v", err)
+	}
+	db.Clock = fixedClock{base.Add(2 * time.Minute)}
+	if err := db.UpdateStatus(a.ID, model.StatusDone); err != nil {
+		t.Fatalf("failed to complete: %v", err)
+	}
+	if err := db.AddLog(other.ID, "elsewhere", ""); err != nil {
+		t.Fatalf("failed to log: %v", err)
+	}
+
+	activity, err := db.RecentActivity("proj", 20)
+	if err != nil {
+		t.Fatalf("RecentActivity failed: %v", err)
+	}
+	var got []string
+	for _, act := range activity {
+		got = append(got, act.Message)
+	}
+	want := []string{"in_progress -> done", "progress", "open -> in_progress"}
+	if !slices.Equal(got, want) {
+		t.Errorf("activity = %v, want %v", got, want)
+	}
+	if !activity[0].Status || activity[1].Status {
+		t.Errorf("status flags = %v/%v, want true/false", activity[0].Status, activity[1].Status)
+	}
+
+	limited, err := db.RecentActivity("", 2)
+	if err != nil {
+		t.Fatalf("RecentActivity failed: %v", err)
+	}
+	if len(limited) != 2 {
+		t.Errorf("expected 2 entries with limit, got %d", len(limited))
+	}
+}
+
+func TestItemHistory(t *testing.T) {
+	db := setupTestDB(t)
+	item := createTestItem(t, db, "Audit me")
+	other := createTestItem(t, db, "Prerequisite")
+	at := time.Now()
+	step := func() { at = at.Add(time.Minute); db.Clock = fixedClock{at} }
+	step()
+	if err := db.AddDep(item.ID, other.ID); err != nil {
+		t.Fatalf("failed to add dep: %v", err)
+	}
+	if err := db.AddDep(item.ID, other.ID); err != nil {
+		t.Fatalf("failed to re-add dep: %v", err)
+	}
+	step()
+	if err := db.ForceUpdateStatuses([]string{item.ID}, model.StatusInProgress, ""); err != nil {
+		t.Fatalf("failed to start: %v", err)
+	}
+	step()
+	if err := db.AddLog(item.ID, "halfway", "alice"); err != nil {
+		t.Fatalf("failed to add log: %v", err)
+	}
+	step()
+	if err := db.SetDescription(item.ID, "new plan"); err != nil {
+		t.Fatalf("failed to set description: %v", err)
+	}
+	step()
+	if err := db.AppendDescription(item.ID, "and more"); err != nil {
+		t.Fatalf("failed to append description: %v", err)
+	}
+
+	events, err := db.ItemHistory(item.ID)
+	if err != nil {
+		t.Fatalf("ItemHistory failed: %v", err)
+	}
+	var got []string
+	for _, e := range events {
+		got = append(got, e.Kind+": "+e.Message)
+	}
+	want := []string{
+		"created: created",
+		"dep: depends on " + other.ID, // added once despite the repeat
+		"status: open -> in_progress",
+		"log: halfway",
+		"description: replaced, now 8 bytes",
+		"description: appended 8 bytes",
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("history = %q, want %q", got, want)
+	}
+	if events[3].Source != "alice" {
+		t.Errorf("log source = %q, want alice", events[3].Source)
+	}
+
+	if _, err := db.ItemHistory("ts-nope00"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("missing item error = %v, want ErrNotFound", err)
+	}
+}
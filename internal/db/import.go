@@ -0,0 +1,297 @@
+package db
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/baiirun/prog/internal/model"
+)
+
+// ImportItem describes one item in an import file. Parent and Deps refer to
+// other items in the same file by Key, not by database id.
+type ImportItem struct {
+	Key             string         `json:"key"`
+	Title           string         `json:"title"`
+	Type            model.ItemType `json:"type,omitempty"`         // defaults to task
+	Priority        int            `json:"priority,omitempty"`     // defaults to medium
+	PrioritySet     *bool          `json:"priority_set,omitempty"` // defaults to whether priority is given
+	Description     string         `json:"description,omitempty"`
+	Parent          string         `json:"parent,omitempty"`
+	Deps            []string       `json:"deps,omitempty"`
+	Status          model.Status   `json:"status,omitempty"` // defaults to open
+	Tags            []string       `json:"tags,omitempty"`
+	DueAt           *time.Time     `json:"due_at,omitempty"`
+	EstimateMinutes int            `json:"estimate_minutes,omitempty"`
+	Recurrence      string         `json:"recurrence,omitempty"`
+	Logs            []ImportLog    `json:"logs,omitempty"`
+}
+
+// ImportLog is a log entry carried along with an imported item.
+type ImportLog struct {
+	Message   string    `json:"message"`
+	Source    string    `json:"source,omitempty"`
+	CreatedAt time.Time `json:"created_at"` // zero means the time of import
+}
+
+// ImportItems creates every item in a single transaction and returns a map
+// from each item's key to its generated id. It is all-or-nothing: an invalid
+// item, duplicate key, unresolved parent or dep key, or dependency cycle
+// aborts the import before anything is written.
+func (db *DB) ImportItems(project string, specs []ImportItem) (map[string]string, error) {
+	byKey := make(map[string]ImportItem, len(specs))
+	for i, spec := range specs {
+		if spec.Key == "" {
+			return nil, invalidf("import item %d (%q) has no key", i+1, spec.Title)
+		}
+		if strings.TrimSpace(spec.Title) == "" {
+			return nil, invalidf("import item %s has no title", spec.Key)
+		}
+		if _, dup := byKey[spec.Key]; dup {
+			return nil, invalidf("duplicate import key: %s", spec.Key)
+		}
+		if spec.Status != "" && !spec.Status.IsValid() {
+			return nil, fmt.Errorf("import item %s has %w: %s", spec.Key, ErrInvalidStatus, spec.Status)
+		}
+		byKey[spec.Key] = spec
+	}
+
+	var unresolved []string
+	for _, spec := range specs {
+		if spec.Parent != "" {
+			parent, ok := byKey[spec.Parent]
+			switch {
+			case !ok:
+				unresolved = append(unresolved, spec.Parent)
+			case importType(parent) != model.ItemTypeEpic:
+				return nil, invalidf("parent of %s must be an epic: %s is a %s", spec.Key, spec.Parent, importType(parent))
+			}
+		}
+		for _, dep := range spec.Deps {
+			if dep == spec.Key {
+				return nil, fmt.Errorf("%w: %s", ErrSelfDependency, spec.Key)
+			}
+			if _, ok := byKey[dep]; !ok {
+				unresolved = append(unresolved, dep)
+			}
+		}
+	}
+	if len(unresolved) > 0 {
+		return nil, invalidf("unresolved import keys: %s (no changes made)", strings.Join(unresolved, ", "))
+	}
+	if cycle := importDepCycle(specs, byKey); cycle != nil {
+		return nil, fmt.Errorf("%w: %s", ErrCycle, strings.Join(cycle, " -> "))
+	}
+
+	ids := make(map[string]string, len(specs))
+	items := make([]*model.Item, len(specs))
+	for i, spec := range specs {
+		item := &model.Item{
+			Project:         project,
+			Type:            importType(spec),
+			Title:           spec.Title,
+			Description:     spec.Description,
+			Status:          model.StatusOpen,
+			Priority:        spec.Priority,
+			PrioritySet:     spec.Priority != 0,
+			DueAt:           spec.DueAt,
+			EstimateMinutes: spec.EstimateMinutes,
+			Recurrence:      spec.Recurrence,
+		}
+		if spec.PrioritySet != nil {
+			item.PrioritySet = *spec.PrioritySet
+		}
+		if spec.Status != "" {
+			item.Status = spec.Status
+		}
+		if err := db.prepareItem(item); err != nil {
+			return nil, fmt.Errorf("import item %s: %w", spec.Key, err)
+		}
+		items[i] = item
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	for i, item := range items {
+		// Every id here is generated, so a collision just means another try
+		for attempt := 1; ; attempt++ {
+			err := createItemTx(tx, item)
+			if err == nil {
+				break
+			}
+			if !isIDConflict(err) || attempt == maxIDAttempts {
+				return nil, err
+			}
+			item.ID = db.IDFormat.Generate(item.Type)
+		}
+		ids[specs[i].Key] = item.ID
+		for _, tag := range specs[i].Tags {
+			if tag = NormalizeTag(tag); tag == "" {
+				continue
+			}
+			if _, err := tx.Exec(`INSERT OR IGNORE INTO tags (item_id, tag) VALUES (?, ?)`, item.ID, tag); err != nil {
+				return nil, fmt.Errorf("failed to add tag: %w", err)
+			}
+		}
+		for _, l := range specs[i].Logs {
+			at := l.CreatedAt
+			if at.IsZero() {
+				at = item.CreatedAt
+			}
+			_, err := tx.Exec(`
+				INSERT INTO logs (item_id, message, source, created_at) VALUES (?, ?, ?, ?)`,
+				item.ID, l.Message, l.Source, logTime(at))
+			if err != nil {
+				return nil, fmt.Errorf("failed to add log: %w", err)
+			}
+		}
+	}
+
+	// Link parents and deps once every item exists, so file order doesn't matter
+	for i, spec := range specs {
+		if spec.Parent != "" {
+			parentID := ids[spec.Parent]
+			items[i].ParentID = &parentID
+			_, err := tx.Exec(`UPDATE items SET parent_id = ? WHERE id = ?`, parentID, items[i].ID)
+			if err != nil {
+				return nil, fmt.Errorf("failed to set parent: %w", err)
+			}
+		}
+		for _, dep := range spec.Deps {
+			if err := addDepTx(tx, ids[spec.Key], ids[dep], db.Now()); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return ids, nil
+}
+
+// ExportItem returns the item and, for an epic, its children (recursively)
+// in the import format, so the result can be fed back to ImportItems in
+// another database. Keys are the original ids. Dependencies on items outside
+// the exported set are dropped, since they couldn't be resolved on import.
+func (db *DB) ExportItem(id string) ([]ImportItem, error) {
+	root, err := db.GetItem(id)
+	if err != nil {
+		return nil, err
+	}
+
+	items := []model.Item{*root}
+	for i := 0; i < len(items); i++ {
+		if items[i].Type != model.ItemTypeEpic {
+			continue
+		}
+		children, err := db.ListChildren(items[i].ID)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, children...)
+	}
+
+	exported := make(map[string]bool, len(items))
+	for _, item := range items {
+		exported[item.ID] = true
+	}
+
+	specs := make([]ImportItem, 0, len(items))
+	for _, item := range items {
+		spec := ImportItem{
+			Key:             item.ID,
+			Title:           item.Title,
+			Type:            item.Type,
+			Priority:        item.Priority,
+			PrioritySet:     &item.PrioritySet,
+			Description:     item.Description,
+			Status:          item.Status,
+			DueAt:           item.DueAt,
+			EstimateMinutes: item.EstimateMinutes,
+			Recurrence:      item.Recurrence,
+		}
+		if spec.Tags, err = db.GetItemTags(item.ID); err != nil {
+			return nil, err
+		}
+		if item.ParentID != nil && exported[*item.ParentID] {
+			spec.Parent = *item.ParentID
+		}
+
+		deps, err := db.GetDeps(item.ID)
+		if err != nil {
+			return nil, err
+		}
+		for _, dep := range deps {
+			if exported[dep] {
+				spec.Deps = append(spec.Deps, dep)
+			}
+		}
+		slices.Sort(spec.Deps)
+
+		logs, err := db.GetLogs(item.ID)
+		if err != nil {
+			return nil, err
+		}
+		for _, l := range logs {
+			spec.Logs = append(spec.Logs, ImportLog{Message: l.Message, Source: l.Source, CreatedAt: l.CreatedAt})
+		}
+		specs = append(specs, spec)
+	}
+	return specs, nil
+}
+
+func importType(spec ImportItem) model.ItemType {
+	if spec.Type == "" {
+		return model.ItemTypeTask
+	}
+	return spec.Type
+}
+
+// importDepCycle returns the keys along a dependency cycle among specs, or
+// nil if there is none.
+func importDepCycle(specs []ImportItem, byKey map[string]ImportItem) []string {
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := make(map[string]int, len(specs))
+	var path []string
+
+	var visit func(key string) []string
+	visit = func(key string) []string {
+		switch state[key] {
+		case visiting:
+			for i, k := range path {
+				if k == key {
+					return append(append([]string{}, path[i:]...), key)
+				}
+			}
+		case visited:
+			return nil
+		}
+		state[key] = visiting
+		path = append(path, key)
+		for _, dep := range byKey[key].Deps {
+			if cycle := visit(dep); cycle != nil {
+				return cycle
+			}
+		}
+		path = path[:len(path)-1]
+		state[key] = visited
+		return nil
+	}
+
+	for _, spec := range specs {
+		if cycle := visit(spec.Key); cycle != nil {
+			return cycle
+		}
+	}
+	return nil
+}
@@ -0,0 +1,267 @@
+package db
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/baiirun/prog/internal/model"
+)
+
+func TestImportItems(t *testing.T) {
+	db := setupTestDB(t)
+
+	// Children listed before their parent resolve just the same
+	ids, err := db.ImportItems("seed", []ImportItem{
+		{Key: "login", Title: "Login endpoint", Parent: "auth", Deps: []string{"schema"}},
+		{Key: "schema", Title: "Design user schema", Parent: "auth", Priority: model.PriorityHigh},
+		{Key: "auth", Title: "Auth system", Type: model.ItemTypeEpic},
+	})
+	if err != nil {
+		t.Fatalf("ImportItems failed: %v", err)
+	}
+	if len(ids) != 3 {
+		t.Fatalf("expected 3 ids, got %v", ids)
+	}
+
+	epic, err := db.GetItem(ids["auth"])
+	if err != nil {
+		t.Fatalf("epic not created: %v", err)
+	}
+	if epic.Type != model.ItemTypeEpic || epic.Project != "seed" {
+		t.Errorf("epic = %s in %q, want epic in seed", epic.Type, epic.Project)
+	}
+
+	schema, _ := db.GetItem(ids["schema"])
+	if schema.Priority != model.PriorityHigh {
+		t.Errorf("schema priority = %d, want %d", schema.Priority, model.PriorityHigh)
+	}
+	login, _ := db.GetItem(ids["login"])
+	if login.Priority != model.PriorityMedium {
+		t.Errorf("login priority = %d, want default %d", login.Priority, model.PriorityMedium)
+	}
+	if login.ParentID == nil || *login.ParentID != ids["auth"] {
+		t.Errorf("login parent = %v, want %s", login.ParentID, ids["auth"])
+	}
+
+	deps, _ := db.GetDeps(ids["login"])
+	if len(deps) != 1 || deps[0] != ids["schema"] {
+		t.Errorf("login deps = %v, want [%s]", deps, ids["schema"])
+	}
+}
+
+func TestImportItems_RollsBackOnError(t *testing.T) {
+	tests := []struct {
+		name  string
+		specs []ImportItem
+		want  string
+		is    error
+	}{
+		{
+			name: "unresolved dep",
+			specs: []ImportItem{
+				{Key: "a", Title: "A", Deps: []string{"missing"}},
+			},
+			want: "unresolved import keys: missing",
+			is:   ErrInvalid,
+		},
+		{
+			name: "unresolved parent",
+			specs: []ImportItem{
+				{Key: "a", Title: "A", Parent: "nope"},
+			},
+			want: "unresolved import keys: nope",
+			is:   ErrInvalid,
+		},
+		{
+			name: "parent not epic",
+			specs: []ImportItem{
+				{Key: "a", Title: "A"},
+				{Key: "b", Title: "B", Parent: "a"},
+			},
+			want: "must be an epic",
+			is:   ErrInvalid,
+		},
+		{
+			name: "duplicate key",
+			specs: []ImportItem{
+				{Key: "a", Title: "A"},
+				{Key: "a", Title: "A again"},
+			},
+			want: "duplicate import key: a",
+			is:   ErrInvalid,
+		},
+		{
+			name: "cycle",
+			specs: []ImportItem{
+				{Key: "a", Title: "A", Deps: []string{"b"}},
+				{Key: "b", Title: "B", Deps: []string{"a"}},
+			},
+			want: "cycle: a -> b -> a",
+			is:   ErrCycle,
+		},
+		{
+			name: "invalid priority",
+			specs: []ImportItem{
+				{Key: "a", Title: "A"},
+				{Key: "b", Title: "B", Priority: 9},
+			},
+			want: "invalid priority",
+			is:   ErrInvalid,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := setupTestDB(t)
+
+			_, err := db.ImportItems("seed", tt.specs)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("error = %v, want containing %q", err, tt.want)
+			}
+			if !errors.Is(err, tt.is) {
+				t.Errorf("error = %v, want %v", err, tt.is)
+			}
+
+			items, _ := db.ListItems("", nil)
+			if len(items) != 0 {
+				t.Errorf("expected no items after failed import, got %d", len(items))
+			}
+		})
+	}
+}
+
+func TestExportItem_RoundTrip(t *testing.T) {
+	src := setupTestDB(t)
+
+	epic := createTestEpic(t, src, "Auth", "src")
+	schema := createTestItemWithProject(t, src, "Schema", "src", model.StatusDone, model.PriorityHigh)
+	due := time.Date(2026, 5, 1, 17, 0, 0, 0, time.UTC)
+	login := &model.Item{Project: "src", Type: model.ItemTypeTask, Title: "Login", Status: model.StatusOpen,
+		DueAt: &due, EstimateMinutes: 90, Recurrence: "weekly"}
+	if err := src.CreateItem(login); err != nil {
+		t.Fatalf("failed to create login: %v", err)
+	}
+	if err := src.AddTag(login.ID, "backend"); err != nil {
+		t.Fatalf("failed to tag: %v", err)
+	}
+	explicit := createExplicitPriorityItem(t, src, "Explicit medium", model.PriorityMedium)
+	if err := src.SetParent(explicit.ID, epic.ID); err != nil {
+		t.Fatalf("failed to set parent: %v", err)
+	}
+	outside := createTestItemWithProject(t, src, "Outside", "src", model.StatusOpen, 2)
+	for _, id := range []string{schema.ID, login.ID} {
+		if err := src.SetParent(id, epic.ID); err != nil {
+			t.Fatalf("failed to set parent: %v", err)
+		}
+	}
+	if err := src.AddDep(login.ID, schema.ID); err != nil {
+		t.Fatalf("failed to add dep: %v", err)
+	}
+	if err := src.AddDep(login.ID, outside.ID); err != nil {
+		t.Fatalf("failed to add dep: %v", err)
+	}
+	if err := src.AddLog(login.ID, "started on handlers", "agent"); err != nil {
+		t.Fatalf("failed to add log: %v", err)
+	}
+
+	specs, err := src.ExportItem(epic.ID)
+	if err != nil {
+		t.Fatalf("ExportItem failed: %v", err)
+	}
+	if len(specs) != 4 || specs[0].Key != epic.ID {
+		t.Fatalf("expected epic then 3 children, got %+v", specs)
+	}
+
+	// Go through JSON, as 'prog show --export | prog import' does
+	body, err := json.Marshal(specs)
+	if err != nil {
+		t.Fatalf("failed to encode: %v", err)
+	}
+	var decoded []ImportItem
+	if err := json.Unmarshal(body, &decoded); err != nil {
+		t.Fatalf("failed to decode: %v", err)
+	}
+
+	dst := setupTestDB(t)
+	ids, err := dst.ImportItems("dst", decoded)
+	if err != nil {
+		t.Fatalf("re-import failed: %v", err)
+	}
+
+	gotSchema, _ := dst.GetItem(ids[schema.ID])
+	if gotSchema.Status != model.StatusDone || gotSchema.Priority != model.PriorityHigh {
+		t.Errorf("schema = %s/%d, want done/high", gotSchema.Status, gotSchema.Priority)
+	}
+	if gotSchema.ParentID == nil || *gotSchema.ParentID != ids[epic.ID] {
+		t.Errorf("schema parent = %v, want %s", gotSchema.ParentID, ids[epic.ID])
+	}
+
+	gotLogin, _ := dst.GetItem(ids[login.ID])
+	if gotLogin.DueAt == nil || !gotLogin.DueAt.Equal(due) || gotLogin.EstimateMinutes != 90 || gotLogin.Recurrence != "weekly" {
+		t.Errorf("login = due %v, estimate %d, recurrence %q; want the exported values", gotLogin.DueAt, gotLogin.EstimateMinutes, gotLogin.Recurrence)
+	}
+	if tags, _ := dst.GetItemTags(ids[login.ID]); len(tags) != 1 || tags[0] != "backend" {
+		t.Errorf("login tags = %v, want [backend]", tags)
+	}
+
+	// A defaulted priority stays defaulted, and an explicit medium explicit
+	if gotLogin.PrioritySet {
+		t.Error("login priority marked explicit after round trip")
+	}
+	if gotExplicit, _ := dst.GetItem(ids[explicit.ID]); !gotExplicit.PrioritySet {
+		t.Error("explicit medium priority lost after round trip")
+	}
+
+	// The dependency on an item outside the subtree is dropped
+	deps, _ := dst.GetDeps(ids[login.ID])
+	if len(deps) != 1 || deps[0] != ids[schema.ID] {
+		t.Errorf("login deps = %v, want [%s]", deps, ids[schema.ID])
+	}
+	logs, _ := dst.GetLogs(ids[login.ID])
+	if len(logs) != 1 || logs[0].Message != "started on handlers" || logs[0].Source != "agent" {
+		t.Errorf("login logs = %+v, want the exported log", logs)
+	}
+
+	if _, err := src.ExportItem("ts-missing"); err == nil {
+		t.Error("expected error for missing item")
+	}
+}
+
+func TestImportItems_InvalidStatus(t *testing.T) {
+	db := setupTestDB(t)
+
+	_, err := db.ImportItems("seed", []ImportItem{{Key: "a", Title: "A", Status: "later"}})
+	if err == nil || !strings.Contains(err.Error(), "invalid status") {
+		t.Errorf("expected invalid status error, got %v", err)
+	}
+}
+
+func TestImportItems_RetriesIDCollision(t *testing.T) {
+	db := setupTestDB(t)
+
+	// The first two generated IDs are the same; later ones are random
+	zeros := make([]byte, 2*model.DefaultIDBytes)
+	model.IDRand = io.MultiReader(bytes.NewReader(zeros), rand.Reader)
+	t.Cleanup(func() { model.IDRand = rand.Reader })
+
+	existing := createTestItem(t, db, "Existing")
+	ids, err := db.ImportItems("seed", []ImportItem{{Key: "a", Title: "A"}})
+	if err != nil {
+		t.Fatalf("expected collision to be retried, got: %v", err)
+	}
+	if ids["a"] == existing.ID {
+		t.Fatalf("expected a fresh ID, still %s", ids["a"])
+	}
+	if got, _ := db.GetItem(ids["a"]); got == nil || got.Title != "A" {
+		t.Errorf("imported item = %+v, want A under the returned id", got)
+	}
+	if got, _ := db.GetItem(existing.ID); got.Title != "Existing" {
+		t.Errorf("existing item title = %q, want Existing", got.Title)
+	}
+}
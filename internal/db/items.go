@@ -2,128 +2,1118 @@ package db
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
+	"slices"
+	"sort"
+	"strings"
 	"time"
+	"unicode"
 
 	"github.com/baiirun/prog/internal/model"
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
 )
 
 // CreateItem inserts a new item into the database.
 // If the item has a project, it will be auto-created if it doesn't exist.
 func (db *DB) CreateItem(item *model.Item) error {
+	return db.CreateItemWithDeps(item, nil, nil)
+}
+
+// CreateItemWithDeps inserts a new item that depends on each of dependsOn
+// and blocks each of blocks (they depend on it), in a single transaction: if
+// any of those items or the item's parent doesn't exist, the parent isn't an
+// epic, or an edge would close a dependency cycle, nothing is created.
+func (db *DB) CreateItemWithDeps(item *model.Item, dependsOn, blocks []string) error {
+	generated := item.ID == ""
+	if err := db.prepareItem(item); err != nil {
+		return err
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if item.ParentID != nil {
+		if err := checkParent(tx, *item.ParentID); err != nil {
+			return err
+		}
+	}
+
+	// A colliding generated ID is replaced with a fresh one rather than
+	// failing; an ID the caller chose is kept, so the conflict is an error
+	for attempt := 1; ; attempt++ {
+		err := createItemTx(tx, item)
+		if err == nil {
+			break
+		}
+		if !generated || !isIDConflict(err) || attempt == maxIDAttempts {
+			return err
+		}
+		item.ID = db.IDFormat.Generate(item.Type)
+	}
+
+	now := db.Now()
+	var missing []string
+	for _, id := range append(slices.Clone(dependsOn), blocks...) {
+		var exists bool
+		if err := tx.QueryRow(`SELECT EXISTS(SELECT 1 FROM items WHERE id = ?)`, id).Scan(&exists); err != nil {
+			return fmt.Errorf("failed to verify items: %w", err)
+		}
+		if !exists {
+			missing = append(missing, id)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("items %w: %s (use 'prog list' to see available items)", ErrNotFound, strings.Join(missing, ", "))
+	}
+	for _, dep := range dependsOn {
+		if err := addDepTx(tx, item.ID, dep, now); err != nil {
+			return err
+		}
+	}
+	// Checked, since the item already depends on dependsOn by now
+	for _, blocked := range blocks {
+		if err := addCheckedDepTx(tx, blocked, item.ID, now); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// maxIDAttempts bounds how many IDs CreateItem tries before giving up on
+// collisions between generated IDs.
+const maxIDAttempts = 5
+
+// isIDConflict reports whether err is a primary key violation on items.
+func isIDConflict(err error) bool {
+	var sqliteErr *sqlite.Error
+	return errors.As(err, &sqliteErr) && sqliteErr.Code() == sqlite3.SQLITE_CONSTRAINT_PRIMARYKEY
+}
+
+// prepareItem validates a new item and fills in defaults for the ID,
+// priority and timestamps.
+func (db *DB) prepareItem(item *model.Item) error {
 	if !item.Type.IsValid() {
-		return fmt.Errorf("invalid item type: %s", item.Type)
+		return invalidf("invalid item type: %s", item.Type)
+	}
+	if item.ID == "" {
+		item.ID = db.IDFormat.Generate(item.Type)
+	}
+	if !item.Status.IsValid() {
+		return fmt.Errorf("%w: %s", ErrInvalidStatus, item.Status)
+	}
+	if item.Priority == 0 {
+		item.Priority = model.PriorityMedium
+	}
+	if !model.ValidRecurrence(item.Recurrence) {
+		return invalidf("invalid recurrence: %s (valid: %s)", item.Recurrence, strings.Join(model.Recurrences, ", "))
+	}
+	if item.EstimateMinutes < 0 || item.ActualMinutes < 0 {
+		return invalidf("effort minutes cannot be negative")
+	}
+	if item.CreatedAt.IsZero() {
+		item.CreatedAt = db.Now()
+	}
+	if item.UpdatedAt.IsZero() {
+		item.UpdatedAt = item.CreatedAt
+	}
+	if item.Status == model.StatusInProgress && item.StartedAt == nil {
+		item.StartedAt = &item.UpdatedAt
+	}
+	if item.Status == model.StatusDone && item.DoneAt == nil {
+		item.DoneAt = &item.UpdatedAt
+	}
+	if !model.ValidPriority(item.Priority) {
+		return invalidf("invalid priority: %d (valid: 1=high, 2=medium, 3=low)", item.Priority)
+	}
+	return db.checkDescription(item.Description)
+}
+
+// createItemTx inserts a prepared item within tx, creating its project if
+// needed.
+func createItemTx(tx *sql.Tx, item *model.Item) error {
+	if item.Project != "" {
+		_, err := tx.Exec(`
+			INSERT INTO projects (name, created_at, updated_at)
+			VALUES (?, ?, ?)
+			ON CONFLICT(name) DO NOTHING`,
+			item.Project, item.CreatedAt, item.CreatedAt,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to ensure project: %w", err)
+		}
+	}
+
+	_, err := tx.Exec(`
+		INSERT INTO items (id, project, type, title, description, status, priority, parent_id, created_at, updated_at, due_at, archived,
+			estimate_minutes, actual_minutes, started_at, done_at, block_reason, recurrence, locked, snoozed_until, priority_set)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		item.ID, item.Project, item.Type, item.Title, item.Description,
+		item.Status, item.Priority, item.ParentID, item.CreatedAt, item.UpdatedAt, item.DueAt,
+		item.Archived, item.EstimateMinutes, item.ActualMinutes, item.StartedAt, item.DoneAt, item.BlockReason, item.Recurrence,
+		item.Locked, snoozeTime(item.SnoozedUntil), item.PrioritySet,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create item: %w", err)
+	}
+	return nil
+}
+
+// itemColumns lists the items columns in the order scanItem expects.
+const itemColumns = `id, project, type, title, description, status, priority, parent_id, created_at, updated_at, due_at, archived,
+	estimate_minutes, actual_minutes, started_at, done_at, block_reason, recurrence, locked, snoozed_until, priority_set`
+
+// rowScanner is implemented by both *sql.Row and *sql.Rows.
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+// scanItem scans a row selected with itemColumns into an Item.
+func scanItem(row rowScanner) (model.Item, error) {
+	var item model.Item
+	var parentID sql.NullString
+	var dueAt, startedAt, doneAt, snoozedUntil sql.NullTime
+	err := row.Scan(
+		&item.ID, &item.Project, &item.Type, &item.Title, &item.Description,
+		&item.Status, &item.Priority, &parentID, &item.CreatedAt, &item.UpdatedAt, &dueAt,
+		&item.Archived, &item.EstimateMinutes, &item.ActualMinutes, &startedAt, &doneAt, &item.BlockReason, &item.Recurrence,
+		&item.Locked, &snoozedUntil, &item.PrioritySet,
+	)
+	if err != nil {
+		return item, err
+	}
+	if parentID.Valid {
+		item.ParentID = &parentID.String
+	}
+	if dueAt.Valid {
+		item.DueAt = &dueAt.Time
+	}
+	if startedAt.Valid {
+		item.StartedAt = &startedAt.Time
+	}
+	if doneAt.Valid {
+		item.DoneAt = &doneAt.Time
+	}
+	if snoozedUntil.Valid {
+		item.SnoozedUntil = &snoozedUntil.Time
+	}
+	return item, nil
+}
+
+// GetItem retrieves an item by ID.
+func (db *DB) GetItem(id string) (*model.Item, error) {
+	row := db.QueryRow(`SELECT `+itemColumns+` FROM items WHERE id = ?`, id)
+
+	item, err := scanItem(row)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("item %w: %s (use 'prog list' to see available items)", ErrNotFound, id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get item: %w", err)
+	}
+	return &item, nil
+}
+
+// ResolveID expands an abbreviated item id to the full id. The prefix may
+// include the type prefix ("ts-a1b2") or omit it ("a1b2"). Exact matches win;
+// otherwise exactly one item must match, and an ambiguous prefix errors with
+// the candidates.
+func (db *DB) ResolveID(prefix string) (string, error) {
+	var id string
+	err := db.QueryRow(`SELECT id FROM items WHERE id = ?`, prefix).Scan(&id)
+	if err == nil {
+		return id, nil
+	}
+	if err != sql.ErrNoRows {
+		return "", fmt.Errorf("failed to resolve id: %w", err)
+	}
+
+	pattern := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(prefix) + "%"
+	rows, err := db.Query(`
+		SELECT id FROM items
+		WHERE id LIKE ? ESCAPE '\' OR substr(id, 4) LIKE ? ESCAPE '\'
+		ORDER BY id
+		LIMIT 11`, pattern, pattern)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve id: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var matches []string
+	for rows.Next() {
+		if err := rows.Scan(&id); err != nil {
+			return "", fmt.Errorf("failed to scan id: %w", err)
+		}
+		matches = append(matches, id)
+	}
+	if err := rows.Err(); err != nil {
+		return "", fmt.Errorf("failed to resolve id: %w", err)
+	}
+
+	switch {
+	case prefix == "" || len(matches) == 0:
+		suggestions, err := db.SuggestIDs(prefix, 3)
+		if err != nil {
+			return "", err
+		}
+		if len(suggestions) > 0 {
+			return "", fmt.Errorf("item %w: %s (did you mean %s?)", ErrNotFound, prefix, strings.Join(suggestions, ", "))
+		}
+		return "", fmt.Errorf("item %w: %s (use 'prog list' to see available items)", ErrNotFound, prefix)
+	case len(matches) == 1:
+		return matches[0], nil
+	case len(matches) > 10:
+		return "", invalidf("ambiguous id %s: matches %s, ... (type more characters)", prefix, strings.Join(matches[:10], ", "))
+	default:
+		return "", invalidf("ambiguous id %s: matches %s (type more characters)", prefix, strings.Join(matches, ", "))
+	}
+}
+
+// maxSuggestDistance is the most edits an id may be from a mistyped one to be
+// suggested in its place.
+const maxSuggestDistance = 2
+
+// SuggestIDs returns up to n existing ids within a couple of typos of id,
+// closest first, for "did you mean" hints. An id typed without its type
+// prefix ("a1b2c3") is compared against ids without theirs.
+func (db *DB) SuggestIDs(id string, n int) ([]string, error) {
+	if id == "" || n <= 0 {
+		return nil, nil
+	}
+	rows, err := db.Query(`SELECT id FROM items`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list ids: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	type candidate struct {
+		id       string
+		distance int
+	}
+	var candidates []candidate
+	for rows.Next() {
+		var existing string
+		if err := rows.Scan(&existing); err != nil {
+			return nil, fmt.Errorf("failed to scan id: %w", err)
+		}
+		compared := existing
+		if !strings.Contains(id, "-") {
+			if _, rest, ok := strings.Cut(existing, "-"); ok {
+				compared = rest
+			}
+		}
+		// Short inputs are within two edits of too much to be useful
+		if d := levenshtein(id, compared); d <= maxSuggestDistance && d < len(id)/2 {
+			candidates = append(candidates, candidate{existing, d})
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list ids: %w", err)
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].distance != candidates[j].distance {
+			return candidates[i].distance < candidates[j].distance
+		}
+		return candidates[i].id < candidates[j].id
+	})
+	ids := make([]string, 0, min(n, len(candidates)))
+	for _, c := range candidates[:min(n, len(candidates))] {
+		ids = append(ids, c.id)
+	}
+	return ids, nil
+}
+
+// IDCompletions returns up to limit unfinished, unarchived items whose id
+// starts with prefix, with or without its type prefix, for shell completion.
+// Higher priority and recently updated items come first.
+func (db *DB) IDCompletions(prefix, project string, limit int) ([]model.Item, error) {
+	pattern := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(prefix) + "%"
+	query := `SELECT ` + itemColumns + ` FROM items
+		WHERE status IN ('open', 'in_progress', 'blocked') AND archived = 0
+		AND (id LIKE ? ESCAPE '\' OR substr(id, 4) LIKE ? ESCAPE '\')`
+	args := []any{pattern, pattern}
+	if project != "" {
+		query += ` AND project = ?`
+		args = append(args, project)
+	}
+	query += ` ORDER BY priority ASC, updated_at DESC, id LIMIT ?`
+	args = append(args, limit)
+	return db.queryItems(query, args...)
+}
+
+// levenshtein returns the number of single-character insertions, deletions
+// and substitutions needed to turn a into b.
+func levenshtein(a, b string) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
+
+// UpdateStatus changes an item's status.
+// Moving a done item back to open or in_progress records a "Reopened from done"
+// log entry in the same transaction, so the audit trail can't diverge.
+// Marking an item done reopens any blocked dependents whose dependencies are
+// now all done. An epic can't be marked done while any child is still open,
+// in progress, or blocked, and transitions not allowed by
+// model.Status.CanTransitionTo (such as done to blocked) are rejected. In
+// projects with auto-close on, completing an epic's last unfinished child
+// completes the epic too.
+func (db *DB) UpdateStatus(id string, status model.Status) error {
+	return db.UpdateStatuses([]string{id}, status, "")
+}
+
+// UpdateStatuses sets the status of every id in a single transaction.
+// It is all-or-nothing: if any id doesn't exist, no item is changed and the
+// error lists every missing id. A non-empty logMessage is logged on each item.
+// Completing a recurring item creates its next occurrence; see CompleteItems.
+func (db *DB) UpdateStatuses(ids []string, status model.Status, logMessage string) error {
+	_, err := db.updateStatuses(ids, status, logMessage, "", false)
+	return err
+}
+
+// ForceUpdateStatuses is UpdateStatuses without the transition rules of
+// model.Status.CanTransitionTo or the check that epics being marked done have
+// no unfinished children.
+func (db *DB) ForceUpdateStatuses(ids []string, status model.Status, logMessage string) error {
+	_, err := db.updateStatuses(ids, status, logMessage, "", true)
+	return err
+}
+
+// CompleteItems marks every id done like UpdateStatuses, or like
+// ForceUpdateStatuses with force, and returns the next occurrence created for
+// each recurring item, keyed by the completed item's id. Occurrences are
+// created in the same transaction, and only for items that weren't already
+// done, so completing an item twice doesn't repeat it twice.
+func (db *DB) CompleteItems(ids []string, logMessage string, force bool) (map[string]*model.Item, error) {
+	return db.updateStatuses(ids, model.StatusDone, logMessage, "", force)
+}
+
+// BlockItems marks every id blocked with reason, recording it both as the
+// item's BlockReason and as a "Blocked: " log entry. Like UpdateStatuses it is
+// all-or-nothing; force skips the transition rules.
+func (db *DB) BlockItems(ids []string, reason string, force bool) error {
+	if strings.TrimSpace(reason) == "" {
+		return invalidf("a block reason is required")
+	}
+	_, err := db.updateStatuses(ids, model.StatusBlocked, "Blocked: "+reason, reason, force)
+	return err
+}
+
+// BlockItemsOn blocks ids like BlockItems and makes each of them depend on
+// dependsOnID, so they are reopened automatically once it is done. The block
+// and the dependencies are applied in one transaction, so a dependency that
+// would close a cycle leaves every item unchanged.
+func (db *DB) BlockItemsOn(ids []string, dependsOnID, reason string, force bool) error {
+	if strings.TrimSpace(reason) == "" {
+		return invalidf("a block reason is required")
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var onStatus model.Status
+	err = tx.QueryRow(`SELECT status FROM items WHERE id = ?`, dependsOnID).Scan(&onStatus)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("item %w: %s (use 'prog list' to see available items)", ErrNotFound, dependsOnID)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get status: %w", err)
+	}
+	if onStatus == model.StatusDone {
+		return invalidf("%s is already done; there is nothing to wait on", dependsOnID)
+	}
+
+	now := db.Now()
+	if _, err := db.updateStatusesTx(tx, ids, model.StatusBlocked, "Blocked: "+reason, reason, force, now); err != nil {
+		return err
+	}
+	for _, id := range ids {
+		if err := addCheckedDepTx(tx, id, dependsOnID, now); err != nil {
+			return err
+		}
+		if _, err := tx.Exec(`UPDATE items SET blocked_on_deps = 1 WHERE id = ?`, id); err != nil {
+			return fmt.Errorf("failed to set block reason: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// updateStatuses sets the status of every id within one transaction. Items
+// that newly become done and recur get their next occurrence, which is
+// returned keyed by the completed item's id.
+func (db *DB) updateStatuses(ids []string, status model.Status, logMessage, blockReason string, force bool) (map[string]*model.Item, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	next, err := db.updateStatusesTx(tx, ids, status, logMessage, blockReason, force, db.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return next, nil
+}
+
+// updateStatusesTx is updateStatuses within tx.
+func (db *DB) updateStatusesTx(tx *sql.Tx, ids []string, status model.Status, logMessage, blockReason string, force bool, now time.Time) (map[string]*model.Item, error) {
+	if !status.IsValid() {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidStatus, status)
+	}
+
+	var missing, completed []string
+	for _, id := range ids {
+		prev, err := updateStatusTx(tx, id, status, now, force)
+		if err != nil {
+			return nil, err
+		}
+		if prev == "" {
+			missing = append(missing, id)
+			continue
+		}
+		if status == model.StatusDone && prev != model.StatusDone {
+			completed = append(completed, id)
+		}
+		if blockReason != "" {
+			// A fresh reason is a manual block until BlockItemsOn says otherwise
+			if _, err := tx.Exec(`UPDATE items SET block_reason = ?, blocked_on_deps = 0 WHERE id = ?`, blockReason, id); err != nil {
+				return nil, fmt.Errorf("failed to set block reason: %w", err)
+			}
+		}
+		if logMessage != "" {
+			if err := addLogTx(tx, id, logMessage, now); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	switch {
+	case len(missing) == 1 && len(ids) == 1:
+		return nil, fmt.Errorf("item %w: %s (use 'prog list' to see available items)", ErrNotFound, missing[0])
+	case len(missing) > 0:
+		return nil, fmt.Errorf("items %w: %s (no changes made)", ErrNotFound, strings.Join(missing, ", "))
+	}
+
+	// Checked after all updates so children completed in the same batch count
+	if status == model.StatusDone && !force {
+		for _, id := range ids {
+			open, err := unfinishedChildrenTx(tx, id)
+			if err != nil {
+				return nil, err
+			}
+			if len(open) > 0 {
+				return nil, invalidf("%w: cannot complete epic %s: children not done: %s",
+					ErrTransition, id, strings.Join(open, ", "))
+			}
+		}
+	}
+	if status == model.StatusDone {
+		for _, id := range ids {
+			if err := autoCloseParentTx(tx, id, now); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	// Created last so a new occurrence doesn't hold its parent epic open
+	next := make(map[string]*model.Item)
+	for _, id := range completed {
+		item, err := db.recurItemTx(tx, id, now)
+		if err != nil {
+			return nil, err
+		}
+		if item != nil {
+			next[id] = item
+		}
+	}
+	return next, nil
+}
+
+// unfinishedChildrenTx returns the ids of parentID's children that are not
+// done or canceled.
+func unfinishedChildrenTx(tx *sql.Tx, parentID string) ([]string, error) {
+	rows, err := tx.Query(`
+		SELECT id FROM items
+		WHERE parent_id = ? AND status NOT IN ('done', 'canceled')
+		ORDER BY id`, parentID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check children: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan child: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// autoCloseParentTx marks id's parent epic done, with a log entry, if its
+// project has auto-close on and none of its other children are unfinished.
+// Locked epics are left alone. Completing the epic can in turn complete its
+// own parent.
+func autoCloseParentTx(tx *sql.Tx, id string, now time.Time) error {
+	var parentID string
+	var parentStatus model.Status
+	var locked, autoClose bool
+	err := tx.QueryRow(`
+		SELECT p.id, p.status, p.locked, COALESCE(pr.auto_close_epics, 0)
+		FROM items i
+		JOIN items p ON p.id = i.parent_id
+		LEFT JOIN projects pr ON pr.name = p.project
+		WHERE i.id = ?`, id).Scan(&parentID, &parentStatus, &locked, &autoClose)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get parent: %w", err)
+	}
+	if !autoClose || locked || parentStatus == model.StatusDone || !parentStatus.CanTransitionTo(model.StatusDone) {
+		return nil
+	}
+	open, err := unfinishedChildrenTx(tx, parentID)
+	if err != nil || len(open) > 0 {
+		return err
+	}
+
+	if _, err := updateStatusTx(tx, parentID, model.StatusDone, now, false); err != nil {
+		return err
+	}
+	if err := addLogTx(tx, parentID, "Auto-completed: all children done", now); err != nil {
+		return err
+	}
+	return autoCloseParentTx(tx, parentID, now)
+}
+
+// startedAtExpr computes started_at for a status update taking (status, now)
+// parameters: the first transition to in_progress stamps it and it is kept
+// from then on.
+const startedAtExpr = `CASE WHEN ? = 'in_progress' THEN COALESCE(started_at, ?) ELSE started_at END`
+
+// doneAtExpr computes done_at for a status update taking (status, now)
+// parameters: the first transition to done stamps it, leaving done clears it.
+const doneAtExpr = `CASE WHEN ? = 'done' THEN COALESCE(done_at, ?) ELSE NULL END`
+
+// blockReasonExpr computes block_reason for a status update taking a (status)
+// parameter: the reason is kept while blocked and cleared otherwise.
+const blockReasonExpr = `CASE WHEN ? = 'blocked' THEN block_reason ELSE '' END`
+
+// blockedOnDepsExpr is blockReasonExpr for blocked_on_deps.
+const blockedOnDepsExpr = `CASE WHEN ? = 'blocked' THEN blocked_on_deps ELSE 0 END`
+
+// updateStatusTx sets an item's status within tx, logging reopens from done.
+// Unless force is set, locked items and illegal transitions are rejected.
+// It returns the item's previous status, or "" if the item doesn't exist.
+func updateStatusTx(tx *sql.Tx, id string, status model.Status, now time.Time, force bool) (model.Status, error) {
+	var current model.Status
+	var locked bool
+	err := tx.QueryRow(`SELECT status, locked FROM items WHERE id = ?`, id).Scan(&current, &locked)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get status: %w", err)
+	}
+	if locked && !force {
+		return "", LockedError(id)
+	}
+	if !force && !current.CanTransitionTo(status) {
+		allowed := make([]string, 0, len(current.Transitions()))
+		for _, s := range current.Transitions() {
+			allowed = append(allowed, string(s))
+		}
+		return "", invalidf("%w: cannot change %s from %s to %s (%s can move to: %s)",
+			ErrTransition, id, current, status, current, strings.Join(allowed, ", "))
+	}
+
+	_, err = tx.Exec(`
+		UPDATE items SET status = ?, updated_at = ?, started_at = `+startedAtExpr+`, done_at = `+doneAtExpr+`,
+			block_reason = `+blockReasonExpr+`, blocked_on_deps = `+blockedOnDepsExpr+`
+		WHERE id = ?`,
+		status, now, status, now, status, now, status, status, id)
+	if err != nil {
+		return "", fmt.Errorf("failed to update status: %w", err)
+	}
+	if err := recordStatusChangeTx(tx, id, current, status, now); err != nil {
+		return "", err
+	}
+
+	if current == model.StatusDone && (status == model.StatusOpen || status == model.StatusInProgress) {
+		if err := addLogTx(tx, id, "Reopened from done", now); err != nil {
+			return "", err
+		}
+	}
+
+	if status == model.StatusDone {
+		if err := unblockDependentsTx(tx, id, now); err != nil {
+			return "", err
+		}
+	}
+	return current, nil
+}
+
+// unblockDependentsTx moves blocked items that depend on doneID back to open
+// once all of their dependencies are done, logging why. Only blocks that wait
+// on dependencies are lifted: those made by BlockItemsOn or with no reason.
+// A block given a free-text reason is manual and stays until cleared.
+func unblockDependentsTx(tx *sql.Tx, doneID string, now time.Time) error {
+	rows, err := tx.Query(`
+		SELECT i.id FROM deps d
+		JOIN items i ON d.item_id = i.id
+		WHERE d.depends_on = ? AND i.status = 'blocked' AND i.locked = 0
+		  AND (i.blocked_on_deps = 1 OR i.block_reason = '')
+		  AND NOT EXISTS (
+		    SELECT 1 FROM deps d2
+		    JOIN items i2 ON d2.depends_on = i2.id
+		    WHERE d2.item_id = i.id AND i2.status != 'done'
+		  )`, doneID)
+	if err != nil {
+		return fmt.Errorf("failed to find dependents: %w", err)
+	}
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			_ = rows.Close()
+			return fmt.Errorf("failed to scan dependent: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	_ = rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to find dependents: %w", err)
+	}
+
+	for _, id := range ids {
+		_, err := tx.Exec(`UPDATE items SET status = 'open', updated_at = ?, block_reason = '', blocked_on_deps = 0 WHERE id = ?`, now, id)
+		if err != nil {
+			return fmt.Errorf("failed to unblock %s: %w", id, err)
+		}
+		if err := recordStatusChangeTx(tx, id, model.StatusBlocked, model.StatusOpen, now); err != nil {
+			return err
+		}
+		if err := addLogTx(tx, id, "Unblocked: "+doneID+" completed", now); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AppendDescription appends text to an item's description on a new line.
+// Trailing whitespace is trimmed so chunks are separated by exactly one
+// newline. It errors if the result would exceed the description size limit
+// or the item is locked.
+func (db *DB) AppendDescription(id string, text string) error {
+	return db.appendDescription(id, text, false)
+}
+
+// ForceAppendDescription is AppendDescription that ignores the item's lock.
+func (db *DB) ForceAppendDescription(id string, text string) error {
+	return db.appendDescription(id, text, true)
+}
+
+func (db *DB) appendDescription(id string, text string, force bool) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var desc string
+	var locked bool
+	err = tx.QueryRow(`SELECT COALESCE(description, ''), locked FROM items WHERE id = ?`, id).Scan(&desc, &locked)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("item %w: %s (use 'prog list' to see available items)", ErrNotFound, id)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get description: %w", err)
+	}
+	if locked && !force {
+		return LockedError(id)
+	}
+
+	desc = strings.TrimRightFunc(desc, unicode.IsSpace)
+	text = strings.TrimRightFunc(text, unicode.IsSpace)
+	if desc != "" {
+		desc += "\n"
+	}
+	desc += text
+	if err := db.checkDescription(desc); err != nil {
+		return err
+	}
+
+	now := db.Now()
+	if _, err := tx.Exec(`UPDATE items SET description = ?, updated_at = ? WHERE id = ?`, desc, now, id); err != nil {
+		return fmt.Errorf("failed to append description: %w", err)
+	}
+	if err := recordEventTx(tx, id, EventDescription, fmt.Sprintf("appended %d bytes", len(text)), now); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// AddTime adds minutes of actual effort to an item.
+func (db *DB) AddTime(id string, minutes int) error {
+	if minutes <= 0 {
+		return invalidf("minutes must be positive, got %d", minutes)
+	}
+
+	result, err := db.Exec(`
+		UPDATE items SET actual_minutes = actual_minutes + ?, updated_at = ? WHERE id = ?`,
+		minutes, db.Now(), id)
+	if err != nil {
+		return fmt.Errorf("failed to add time: %w", err)
+	}
+
+	rows, _ := result.RowsAffected()
+	if rows == 0 {
+		return fmt.Errorf("item %w: %s (use 'prog list' to see available items)", ErrNotFound, id)
+	}
+	return nil
+}
+
+// SetArchived archives or unarchives an item. Archived items are hidden from
+// list and ready by default but keep their history.
+func (db *DB) SetArchived(id string, archived bool) error {
+	result, err := db.Exec(`
+		UPDATE items SET archived = ?, updated_at = ? WHERE id = ?`,
+		archived, db.Now(), id)
+	if err != nil {
+		return fmt.Errorf("failed to set archived: %w", err)
+	}
+
+	rows, _ := result.RowsAffected()
+	if rows == 0 {
+		return fmt.Errorf("item %w: %s (use 'prog list' to see available items)", ErrNotFound, id)
+	}
+	return nil
+}
+
+// SetLocked locks or unlocks an item. Status and description changes to a
+// locked item fail with ErrLocked unless forced.
+func (db *DB) SetLocked(id string, locked bool) error {
+	result, err := db.Exec(`
+		UPDATE items SET locked = ?, updated_at = ? WHERE id = ?`,
+		locked, db.Now(), id)
+	if err != nil {
+		return fmt.Errorf("failed to set locked: %w", err)
+	}
+
+	rows, _ := result.RowsAffected()
+	if rows == 0 {
+		return fmt.Errorf("item %w: %s (use 'prog list' to see available items)", ErrNotFound, id)
+	}
+	return nil
+}
+
+// SetSnoozedUntil hides an item from ready and the default list until the
+// given time, after which it reappears on its own. nil wakes it now.
+func (db *DB) SetSnoozedUntil(id string, until *time.Time) error {
+	result, err := db.Exec(`
+		UPDATE items SET snoozed_until = ?, updated_at = ? WHERE id = ?`,
+		snoozeTime(until), db.Now(), id)
+	if err != nil {
+		return fmt.Errorf("failed to set snooze: %w", err)
 	}
-	if !item.Status.IsValid() {
-		return fmt.Errorf("invalid status: %s", item.Status)
+
+	rows, _ := result.RowsAffected()
+	if rows == 0 {
+		return fmt.Errorf("item %w: %s (use 'prog list' to see available items)", ErrNotFound, id)
 	}
+	return nil
+}
 
-	// Auto-create project if specified
-	if item.Project != "" {
-		if err := db.EnsureProject(item.Project); err != nil {
-			return err
-		}
+// snoozeTime normalizes a snooze time to UTC. Unlike most timestamps,
+// snoozed_until is compared with the current time in SQL, which only
+// works as text when every value has the same zone.
+func snoozeTime(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
 	}
+	utc := t.UTC()
+	return &utc
+}
 
-	_, err := db.Exec(`
-		INSERT INTO items (id, project, type, title, description, status, priority, parent_id, created_at, updated_at)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
-		item.ID, item.Project, item.Type, item.Title, item.Description,
-		item.Status, item.Priority, item.ParentID, item.CreatedAt, item.UpdatedAt,
-	)
+// notSnoozed is a WHERE condition keeping items that aren't snoozed past
+// its single argument, snoozeTime of the current time.
+const notSnoozed = `(snoozed_until IS NULL OR snoozed_until <= ?)`
+
+// LockedError reports that id is locked. Callers that check the lock
+// themselves use it to word the error the same way.
+func LockedError(id string) error {
+	return fmt.Errorf("%w: %s", ErrLocked, id)
+}
+
+// AdjustPriority adds delta to an item's priority number, clamped to the
+// valid range; a negative delta makes the item more urgent. A change is
+// logged on the item. It returns the priorities before and after.
+func (db *DB) AdjustPriority(id string, delta int) (oldPriority, newPriority int, err error) {
+	tx, err := db.Begin()
 	if err != nil {
-		return fmt.Errorf("failed to create item: %w", err)
+		return 0, 0, fmt.Errorf("failed to begin transaction: %w", err)
 	}
-	return nil
+	defer func() { _ = tx.Rollback() }()
+
+	err = tx.QueryRow(`SELECT priority FROM items WHERE id = ?`, id).Scan(&oldPriority)
+	if err == sql.ErrNoRows {
+		return 0, 0, fmt.Errorf("item %w: %s (use 'prog list' to see available items)", ErrNotFound, id)
+	}
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to get priority: %w", err)
+	}
+
+	newPriority = min(max(oldPriority+delta, model.PriorityHigh), model.PriorityLow)
+	if newPriority == oldPriority {
+		return oldPriority, newPriority, nil
+	}
+
+	if err := setPriorityTx(tx, id, oldPriority, newPriority, "", true, db.Now()); err != nil {
+		return 0, 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return oldPriority, newPriority, nil
 }
 
-// GetItem retrieves an item by ID.
-func (db *DB) GetItem(id string) (*model.Item, error) {
-	row := db.QueryRow(`
-		SELECT id, project, type, title, description, status, priority, parent_id, created_at, updated_at
-		FROM items WHERE id = ?`, id)
+// setPriorityTx changes id's priority from from to to within tx, logging the
+// change with an optional note. explicit records whether the priority was
+// chosen for the item, rather than inherited from its epic.
+func setPriorityTx(tx *sql.Tx, id string, from, to int, note string, explicit bool, now time.Time) error {
+	_, err := tx.Exec(`UPDATE items SET priority = ?, priority_set = ?, updated_at = ? WHERE id = ?`, to, explicit, now, id)
+	if err != nil {
+		return fmt.Errorf("failed to set priority: %w", err)
+	}
+	msg := fmt.Sprintf("Priority: %s -> %s", model.PriorityName(from), model.PriorityName(to))
+	if note != "" {
+		msg += " (" + note + ")"
+	}
+	return addLogTx(tx, id, msg, now)
+}
+
+// InheritParentPriority copies an item's parent epic priority to it unless
+// the item's priority was set explicitly. It reports whether the priority
+// changed.
+func (db *DB) InheritParentPriority(id string) (bool, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
 
-	item := &model.Item{}
+	var priority int
+	var prioritySet bool
 	var parentID sql.NullString
-	err := row.Scan(
-		&item.ID, &item.Project, &item.Type, &item.Title, &item.Description,
-		&item.Status, &item.Priority, &parentID, &item.CreatedAt, &item.UpdatedAt,
-	)
+	var parentPriority sql.NullInt64
+	err = tx.QueryRow(`
+		SELECT i.priority, i.priority_set, p.id, p.priority
+		FROM items i LEFT JOIN items p ON p.id = i.parent_id
+		WHERE i.id = ?`, id).Scan(&priority, &prioritySet, &parentID, &parentPriority)
 	if err == sql.ErrNoRows {
-		return nil, fmt.Errorf("item not found: %s (use 'tasks list' to see available items)", id)
+		return false, fmt.Errorf("item %w: %s (use 'prog list' to see available items)", ErrNotFound, id)
 	}
 	if err != nil {
-		return nil, fmt.Errorf("failed to get item: %w", err)
+		return false, fmt.Errorf("failed to get priority: %w", err)
+	}
+	if !parentID.Valid {
+		return false, fmt.Errorf("item has no parent: %s", id)
+	}
+	inherited := int(parentPriority.Int64)
+	if prioritySet || inherited == priority {
+		return false, nil
 	}
 
-	if parentID.Valid {
-		item.ParentID = &parentID.String
+	if err := setPriorityTx(tx, id, priority, inherited, "inherited from "+parentID.String, false, db.Now()); err != nil {
+		return false, err
 	}
-	return item, nil
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return true, nil
 }
 
-// UpdateStatus changes an item's status.
-func (db *DB) UpdateStatus(id string, status model.Status) error {
-	if !status.IsValid() {
-		return fmt.Errorf("invalid status: %s", status)
+// Reprioritization is the outcome of ReprioritizeEpic.
+type Reprioritization struct {
+	OldPriority int
+	Updated     []string // children moved to the new priority
+	Skipped     []string // children kept at a priority of their own
+}
+
+// ReprioritizeEpic sets an epic's priority and cascades it to the epic's
+// direct children whose priority was never set explicitly. Children with a
+// priority of their own are left alone. Every change is logged.
+func (db *DB) ReprioritizeEpic(epicID string, priority int) (*Reprioritization, error) {
+	if !model.ValidPriority(priority) {
+		return nil, fmt.Errorf("invalid priority: %d (valid: 1-3)", priority)
 	}
 
-	result, err := db.Exec(`
-		UPDATE items SET status = ?, updated_at = ? WHERE id = ?`,
-		status, time.Now(), id)
+	tx, err := db.Begin()
 	if err != nil {
-		return fmt.Errorf("failed to update status: %w", err)
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
 	}
+	defer func() { _ = tx.Rollback() }()
 
-	rows, _ := result.RowsAffected()
-	if rows == 0 {
-		return fmt.Errorf("item not found: %s (use 'tasks list' to see available items)", id)
+	var result Reprioritization
+	var itemType model.ItemType
+	err = tx.QueryRow(`SELECT type, priority FROM items WHERE id = ?`, epicID).Scan(&itemType, &result.OldPriority)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("item %w: %s (use 'prog list' to see available items)", ErrNotFound, epicID)
 	}
-	return nil
+	if err != nil {
+		return nil, fmt.Errorf("failed to get priority: %w", err)
+	}
+	if itemType != model.ItemTypeEpic {
+		return nil, fmt.Errorf("%s is a %s, not an epic", epicID, itemType)
+	}
+
+	type child struct {
+		id       string
+		priority int
+		explicit bool
+	}
+	rows, err := tx.Query(`SELECT id, priority, priority_set FROM items WHERE parent_id = ? ORDER BY id`, epicID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get children: %w", err)
+	}
+	var children []child
+	for rows.Next() {
+		var c child
+		if err := rows.Scan(&c.id, &c.priority, &c.explicit); err != nil {
+			_ = rows.Close()
+			return nil, fmt.Errorf("failed to scan child: %w", err)
+		}
+		children = append(children, c)
+	}
+	_ = rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get children: %w", err)
+	}
+
+	now := db.Now()
+	if result.OldPriority != priority {
+		if err := setPriorityTx(tx, epicID, result.OldPriority, priority, "", true, now); err != nil {
+			return nil, err
+		}
+	}
+	for _, c := range children {
+		if c.explicit {
+			result.Skipped = append(result.Skipped, c.id)
+			continue
+		}
+		if c.priority == priority {
+			continue
+		}
+		if err := setPriorityTx(tx, c.id, c.priority, priority, "inherited from "+epicID, false, now); err != nil {
+			return nil, err
+		}
+		result.Updated = append(result.Updated, c.id)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return &result, nil
 }
 
-// AppendDescription appends text to an item's description.
-func (db *DB) AppendDescription(id string, text string) error {
+// SetParent sets an item's parent to an epic.
+func (db *DB) SetParent(itemID, parentID string) error {
+	if err := checkParent(db, parentID); err != nil {
+		return err
+	}
+
+	// Update the item's parent
 	result, err := db.Exec(`
-		UPDATE items
-		SET description = COALESCE(description, '') || ? || char(10) || ?,
-		    updated_at = ?
-		WHERE id = ?`,
-		"\n", text, time.Now(), id)
+		UPDATE items SET parent_id = ?, updated_at = ? WHERE id = ?`,
+		parentID, db.Now(), itemID)
 	if err != nil {
-		return fmt.Errorf("failed to append description: %w", err)
+		return fmt.Errorf("failed to set parent: %w", err)
 	}
 
 	rows, _ := result.RowsAffected()
 	if rows == 0 {
-		return fmt.Errorf("item not found: %s (use 'tasks list' to see available items)", id)
+		return fmt.Errorf("item %w: %s (use 'prog list' to see available items)", ErrNotFound, itemID)
 	}
 	return nil
 }
 
-// SetParent sets an item's parent to an epic.
-func (db *DB) SetParent(itemID, parentID string) error {
-	// Verify parent exists and is an epic
+// checkParent verifies, through q, that parentID exists and is an epic.
+func checkParent(q rowQuerier, parentID string) error {
 	var itemType string
-	err := db.QueryRow(`SELECT type FROM items WHERE id = ?`, parentID).Scan(&itemType)
+	err := q.QueryRow(`SELECT type FROM items WHERE id = ?`, parentID).Scan(&itemType)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("parent %w: %s (use 'prog list' to see available items)", ErrNotFound, parentID)
+	}
 	if err != nil {
-		return fmt.Errorf("parent not found: %s (use 'tasks list' to see available items)", parentID)
+		return fmt.Errorf("failed to get parent: %w", err)
 	}
 	if itemType != string(model.ItemTypeEpic) {
-		return fmt.Errorf("parent must be an epic, got %s", itemType)
+		return invalidf("parent must be an epic, got %s", itemType)
 	}
+	return nil
+}
 
-	// Update the item's parent
-	result, err := db.Exec(`
-		UPDATE items SET parent_id = ?, updated_at = ? WHERE id = ?`,
-		parentID, time.Now(), itemID)
+// ClearParent detaches an item from its parent epic. It errors if the item
+// has no parent.
+func (db *DB) ClearParent(itemID string) error {
+	var parentID sql.NullString
+	err := db.QueryRow(`SELECT parent_id FROM items WHERE id = ?`, itemID).Scan(&parentID)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("item %w: %s (use 'prog list' to see available items)", ErrNotFound, itemID)
+	}
 	if err != nil {
-		return fmt.Errorf("failed to set parent: %w", err)
+		return fmt.Errorf("failed to get parent: %w", err)
+	}
+	if !parentID.Valid {
+		return fmt.Errorf("item has no parent: %s", itemID)
 	}
 
-	rows, _ := result.RowsAffected()
-	if rows == 0 {
-		return fmt.Errorf("item not found: %s (use 'tasks list' to see available items)", itemID)
+	_, err = db.Exec(`
+		UPDATE items SET parent_id = NULL, updated_at = ? WHERE id = ?`,
+		db.Now(), itemID)
+	if err != nil {
+		return fmt.Errorf("failed to clear parent: %w", err)
 	}
 	return nil
 }
@@ -139,33 +1129,118 @@ func (db *DB) SetProject(id string, project string) error {
 
 	result, err := db.Exec(`
 		UPDATE items SET project = ?, updated_at = ? WHERE id = ?`,
-		project, time.Now(), id)
+		project, db.Now(), id)
 	if err != nil {
 		return fmt.Errorf("failed to set project: %w", err)
 	}
 
 	rows, _ := result.RowsAffected()
 	if rows == 0 {
-		return fmt.Errorf("item not found: %s (use 'prog list' to see available items)", id)
+		return fmt.Errorf("item %w: %s (use 'prog list' to see available items)", ErrNotFound, id)
 	}
 	return nil
 }
 
-// SetDescription replaces an item's description entirely.
+// MoveItem moves an item to another project, creating the project if needed.
+// With withChildren, an epic's child tasks move too, in the same transaction.
+// It returns the number of items moved.
+func (db *DB) MoveItem(id, project string, withChildren bool) (int, error) {
+	if project == "" {
+		return 0, fmt.Errorf("destination project is required")
+	}
+	// Check the item first so a typo doesn't leave an empty project behind
+	if _, err := db.GetItem(id); err != nil {
+		return 0, err
+	}
+	if err := db.EnsureProject(project); err != nil {
+		return 0, err
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	now := db.Now()
+	result, err := tx.Exec(`
+		UPDATE items SET project = ?, updated_at = ? WHERE id = ?`,
+		project, now, id)
+	if err != nil {
+		return 0, fmt.Errorf("failed to move item: %w", err)
+	}
+	rows, _ := result.RowsAffected()
+	if rows == 0 {
+		return 0, fmt.Errorf("item %w: %s (use 'prog list' to see available items)", ErrNotFound, id)
+	}
+	moved := int(rows)
+
+	if withChildren {
+		result, err := tx.Exec(`
+			UPDATE items SET project = ?, updated_at = ? WHERE parent_id = ?`,
+			project, now, id)
+		if err != nil {
+			return 0, fmt.Errorf("failed to move children: %w", err)
+		}
+		children, _ := result.RowsAffected()
+		moved += int(children)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return moved, nil
+}
+
+// SetDescription replaces an item's description entirely. It errors if text
+// exceeds the description size limit or the item is locked.
 func (db *DB) SetDescription(id string, text string) error {
-	result, err := db.Exec(`
+	return db.setDescription(id, text, false)
+}
+
+// ForceSetDescription is SetDescription that ignores the item's lock.
+func (db *DB) ForceSetDescription(id string, text string) error {
+	return db.setDescription(id, text, true)
+}
+
+func (db *DB) setDescription(id string, text string, force bool) error {
+	if err := db.checkDescription(text); err != nil {
+		return err
+	}
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var locked bool
+	err = tx.QueryRow(`SELECT locked FROM items WHERE id = ?`, id).Scan(&locked)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("item %w: %s (use 'prog list' to see available items)", ErrNotFound, id)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to check lock: %w", err)
+	}
+	if locked && !force {
+		return LockedError(id)
+	}
+
+	now := db.Now()
+	_, err = tx.Exec(`
 		UPDATE items
 		SET description = ?,
 		    updated_at = ?
 		WHERE id = ?`,
-		text, time.Now(), id)
+		text, now, id)
 	if err != nil {
 		return fmt.Errorf("failed to set description: %w", err)
 	}
+	if err := recordEventTx(tx, id, EventDescription, fmt.Sprintf("replaced, now %d bytes", len(text)), now); err != nil {
+		return err
+	}
 
-	rows, _ := result.RowsAffected()
-	if rows == 0 {
-		return fmt.Errorf("item not found: %s (use 'tasks list' to see available items)", id)
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
 	}
 	return nil
 }
@@ -177,19 +1252,122 @@ func (db *DB) SetTitle(id string, title string) error {
 		SET title = ?,
 		    updated_at = ?
 		WHERE id = ?`,
-		title, time.Now(), id)
+		title, db.Now(), id)
 	if err != nil {
 		return fmt.Errorf("failed to set title: %w", err)
 	}
 
 	rows, _ := result.RowsAffected()
 	if rows == 0 {
-		return fmt.Errorf("item not found: %s (use 'prog list' to see available items)", id)
+		return fmt.Errorf("item %w: %s (use 'prog list' to see available items)", ErrNotFound, id)
+	}
+	return nil
+}
+
+// CloneItem copies an item's title, description, priority, type, project,
+// parent, and tags into a new open item with a fresh id. A non-empty title
+// replaces the copied one. Logs, dependencies, and status history are not
+// copied.
+func (db *DB) CloneItem(id, title string) (*model.Item, error) {
+	src, err := db.GetItem(id)
+	if err != nil {
+		return nil, err
+	}
+	clone := cloneOf(src)
+	if title != "" {
+		clone.Title = title
+	}
+	return db.insertClone(clone, src.ID)
+}
+
+// recurItemTx creates the next occurrence of a recurring item within tx: an
+// open clone due one recurrence period after the item's due date, or after
+// now if it had none. A late completion skips ahead so the new due date is in
+// the future. The estimate and recurrence carry over, so the clone repeats
+// too. It returns nil if the item doesn't recur.
+func (db *DB) recurItemTx(tx *sql.Tx, id string, now time.Time) (*model.Item, error) {
+	src, err := scanItem(tx.QueryRow(`SELECT `+itemColumns+` FROM items WHERE id = ?`, id))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get item: %w", err)
+	}
+	if src.Recurrence == "" {
+		return nil, nil
+	}
+
+	due := now
+	if src.DueAt != nil {
+		due = *src.DueAt
+	}
+	due = model.NextOccurrence(src.Recurrence, due)
+	for !due.After(now) {
+		due = model.NextOccurrence(src.Recurrence, due)
+	}
+
+	clone := cloneOf(&src)
+	clone.DueAt = &due
+	clone.EstimateMinutes = src.EstimateMinutes
+	clone.Recurrence = src.Recurrence
+	if err := db.prepareItem(clone); err != nil {
+		return nil, err
+	}
+	if err := insertCloneTx(tx, clone, src.ID); err != nil {
+		return nil, err
+	}
+	return clone, nil
+}
+
+// cloneOf returns an open copy of src's title, description, priority, type,
+// project, and parent.
+func cloneOf(src *model.Item) *model.Item {
+	return &model.Item{
+		Project:     src.Project,
+		Type:        src.Type,
+		Title:       src.Title,
+		Description: src.Description,
+		Status:      model.StatusOpen,
+		Priority:    src.Priority,
+		PrioritySet: src.PrioritySet,
+		ParentID:    src.ParentID,
+	}
+}
+
+// insertClone creates clone and copies the tags of the item srcID onto it.
+func (db *DB) insertClone(clone *model.Item, srcID string) (*model.Item, error) {
+	if err := db.prepareItem(clone); err != nil {
+		return nil, err
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if err := insertCloneTx(tx, clone, srcID); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return clone, nil
+}
+
+// insertCloneTx creates a prepared clone within tx and copies the tags of
+// the item srcID onto it.
+func insertCloneTx(tx *sql.Tx, clone *model.Item, srcID string) error {
+	if err := createItemTx(tx, clone); err != nil {
+		return err
+	}
+	_, err := tx.Exec(`INSERT INTO tags (item_id, tag) SELECT ?, tag FROM tags WHERE item_id = ?`, clone.ID, srcID)
+	if err != nil {
+		return fmt.Errorf("failed to copy tags: %w", err)
 	}
 	return nil
 }
 
-// DeleteItem removes an item and its associated logs and dependencies.
+// DeleteItem removes an item and its associated logs, tags, notes,
+// checklist, status history, dependencies, and links.
 func (db *DB) DeleteItem(id string) error {
 	// Check if item exists first
 	var count int
@@ -198,7 +1376,7 @@ func (db *DB) DeleteItem(id string) error {
 		return fmt.Errorf("failed to check item: %w", err)
 	}
 	if count == 0 {
-		return fmt.Errorf("item not found: %s (use 'tasks list' to see available items)", id)
+		return fmt.Errorf("item %w: %s (use 'prog list' to see available items)", ErrNotFound, id)
 	}
 
 	// Delete logs
@@ -207,12 +1385,48 @@ func (db *DB) DeleteItem(id string) error {
 		return fmt.Errorf("failed to delete logs: %w", err)
 	}
 
+	// Delete tags
+	_, err = db.Exec(`DELETE FROM tags WHERE item_id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete tags: %w", err)
+	}
+
+	// Delete notes
+	_, err = db.Exec(`DELETE FROM notes WHERE item_id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete notes: %w", err)
+	}
+
+	// Delete checklist
+	_, err = db.Exec(`DELETE FROM checklist_items WHERE item_id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete checklist: %w", err)
+	}
+
+	// Delete status history
+	_, err = db.Exec(`DELETE FROM status_history WHERE item_id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete status history: %w", err)
+	}
+
+	// Delete events
+	_, err = db.Exec(`DELETE FROM events WHERE item_id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete events: %w", err)
+	}
+
 	// Delete dependencies (both directions)
 	_, err = db.Exec(`DELETE FROM deps WHERE item_id = ? OR depends_on = ?`, id, id)
 	if err != nil {
 		return fmt.Errorf("failed to delete dependencies: %w", err)
 	}
 
+	// Delete links (both directions)
+	_, err = db.Exec(`DELETE FROM links WHERE from_id = ? OR to_id = ?`, id, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete links: %w", err)
+	}
+
 	// Delete the item
 	_, err = db.Exec(`DELETE FROM items WHERE id = ?`, id)
 	if err != nil {
@@ -221,3 +1435,161 @@ func (db *DB) DeleteItem(id string) error {
 
 	return nil
 }
+
+// MergeResult counts what MergeItems moved onto the surviving item.
+type MergeResult struct {
+	Logs     int // log entries moved
+	Deps     int // dependency edges rewritten (self-references and duplicates are dropped)
+	Children int // children re-parented
+}
+
+// MergeItems folds from into into and deletes from, in a single transaction.
+// from's logs, tags, labels, notes, checklist, learnings, and children move
+// to into, its description is appended to into's, and dependency edges and
+// links in both directions are rewritten to point at into. Edges that would make into
+// depend on itself are skipped, and a merge that would create a longer cycle
+// is rejected. Where both items have a note with the same key, into's is
+// kept. from's checklist steps are added after into's. A "Merged" log entry
+// is added to into.
+func (db *DB) MergeItems(from, into string) (*MergeResult, error) {
+	if from == into {
+		return nil, fmt.Errorf("cannot merge an item into itself: %s", from)
+	}
+	src, err := db.GetItem(from)
+	if err != nil {
+		return nil, err
+	}
+	dst, err := db.GetItem(into)
+	if err != nil {
+		return nil, err
+	}
+
+	description := dst.Description
+	if src.Description != "" {
+		if description != "" {
+			description += "\n\n"
+		}
+		description += src.Description
+	}
+	if err := db.checkDescription(description); err != nil {
+		return nil, err
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var result MergeResult
+	var children int
+	if err := tx.QueryRow(`SELECT COUNT(*) FROM items WHERE parent_id = ?`, from).Scan(&children); err != nil {
+		return nil, fmt.Errorf("failed to check children: %w", err)
+	}
+	if children > 0 {
+		if dst.Type != model.ItemTypeEpic {
+			return nil, fmt.Errorf("cannot merge epic %s into %s: %s has children and %s is not an epic", from, into, from, into)
+		}
+		if _, err := tx.Exec(`UPDATE items SET parent_id = ? WHERE parent_id = ?`, into, from); err != nil {
+			return nil, fmt.Errorf("failed to move children: %w", err)
+		}
+		result.Children = children
+	}
+
+	res, err := tx.Exec(`UPDATE logs SET item_id = ? WHERE item_id = ?`, into, from)
+	if err != nil {
+		return nil, fmt.Errorf("failed to move logs: %w", err)
+	}
+	logs, _ := res.RowsAffected()
+	result.Logs = int(logs)
+
+	depMoves := []string{
+		`INSERT OR IGNORE INTO deps (item_id, depends_on)
+			SELECT ?, depends_on FROM deps WHERE item_id = ? AND depends_on != ?`,
+		`INSERT OR IGNORE INTO deps (item_id, depends_on)
+			SELECT item_id, ? FROM deps WHERE depends_on = ? AND item_id != ?`,
+	}
+	for _, q := range depMoves {
+		res, err := tx.Exec(q, into, from, into)
+		if err != nil {
+			return nil, fmt.Errorf("failed to move dependencies: %w", err)
+		}
+		n, _ := res.RowsAffected()
+		result.Deps += int(n)
+	}
+	if _, err := tx.Exec(`DELETE FROM deps WHERE item_id = ? OR depends_on = ?`, from, from); err != nil {
+		return nil, fmt.Errorf("failed to delete dependencies: %w", err)
+	}
+	var cycle int
+	err = tx.QueryRow(`
+		WITH RECURSIVE reach(id) AS (
+			SELECT depends_on FROM deps WHERE item_id = ?
+			UNION
+			SELECT d.depends_on FROM deps d JOIN reach r ON d.item_id = r.id
+		)
+		SELECT COUNT(*) FROM reach WHERE id = ?`, into, into).Scan(&cycle)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check dependency cycles: %w", err)
+	}
+	if cycle > 0 {
+		return nil, fmt.Errorf("merging %s into %s: %w", from, into, ErrCycle)
+	}
+
+	moves := []struct{ query, what string }{
+		{`INSERT OR IGNORE INTO tags (item_id, tag) SELECT ?, tag FROM tags WHERE item_id = ?`, "tags"},
+		{`INSERT OR IGNORE INTO item_labels (item_id, label_id) SELECT ?, label_id FROM item_labels WHERE item_id = ?`, "labels"},
+		{`INSERT OR IGNORE INTO notes (item_id, key, value, updated_at)
+			SELECT ?, key, value, updated_at FROM notes WHERE item_id = ?`, "notes"},
+		{`UPDATE learnings SET task_id = ? WHERE task_id = ?`, "learnings"},
+	}
+	for _, m := range moves {
+		if _, err := tx.Exec(m.query, into, from); err != nil {
+			return nil, fmt.Errorf("failed to move %s: %w", m.what, err)
+		}
+	}
+	// Links move to into like deps; ones between the two items are dropped
+	_, err = tx.Exec(`UPDATE OR IGNORE links SET from_id = ? WHERE from_id = ? AND to_id != ?`, into, from, into)
+	if err != nil {
+		return nil, fmt.Errorf("failed to move links: %w", err)
+	}
+	_, err = tx.Exec(`UPDATE OR IGNORE links SET to_id = ? WHERE to_id = ? AND from_id != ?`, into, from, into)
+	if err != nil {
+		return nil, fmt.Errorf("failed to move links: %w", err)
+	}
+	if _, err := tx.Exec(`DELETE FROM links WHERE from_id = ? OR to_id = ?`, from, from); err != nil {
+		return nil, fmt.Errorf("failed to delete links: %w", err)
+	}
+
+	// Checklist steps follow into's own, in their original order
+	var steps int
+	if err := tx.QueryRow(`SELECT COALESCE(MAX(position), 0) FROM checklist_items WHERE item_id = ?`, into).Scan(&steps); err != nil {
+		return nil, fmt.Errorf("failed to read checklist: %w", err)
+	}
+	_, err = tx.Exec(`UPDATE checklist_items SET item_id = ?, position = position + ? WHERE item_id = ?`, into, steps, from)
+	if err != nil {
+		return nil, fmt.Errorf("failed to move checklist: %w", err)
+	}
+	for _, table := range []string{"tags", "item_labels", "notes", "status_history", "events"} {
+		if _, err := tx.Exec(`DELETE FROM `+table+` WHERE item_id = ?`, from); err != nil {
+			return nil, fmt.Errorf("failed to delete %s: %w", table, err)
+		}
+	}
+	if _, err := tx.Exec(`DELETE FROM items WHERE id = ?`, from); err != nil {
+		return nil, fmt.Errorf("failed to delete item: %w", err)
+	}
+
+	now := db.Now()
+	_, err = tx.Exec(`UPDATE items SET description = ?, actual_minutes = actual_minutes + ?, updated_at = ? WHERE id = ?`,
+		description, src.ActualMinutes, now, into)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update item: %w", err)
+	}
+	if err := addLogTx(tx, into, fmt.Sprintf("Merged %s: %s", from, src.Title), now); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return &result, nil
+}
@@ -2,7 +2,6 @@ package db
 
 import (
 	"fmt"
-	"time"
 
 	"github.com/baiirun/prog/internal/model"
 )
@@ -29,7 +28,7 @@ func (db *DB) GetLabelByName(project, name string) (*model.Label, error) {
 		FROM labels WHERE name = ? AND project = ?
 	`, name, project).Scan(&l.ID, &l.Name, &l.Project, &color, &l.CreatedAt, &l.UpdatedAt)
 	if err != nil {
-		return nil, fmt.Errorf("label not found: %s", name)
+		return nil, fmt.Errorf("label %w: %s", ErrNotFound, name)
 	}
 	if color != nil {
 		l.Color = *color
@@ -46,7 +45,7 @@ func (db *DB) GetLabel(id string) (*model.Label, error) {
 		FROM labels WHERE id = ?
 	`, id).Scan(&l.ID, &l.Name, &l.Project, &color, &l.CreatedAt, &l.UpdatedAt)
 	if err != nil {
-		return nil, fmt.Errorf("label not found: %s", id)
+		return nil, fmt.Errorf("label %w: %s", ErrNotFound, id)
 	}
 	if color != nil {
 		l.Color = *color
@@ -88,13 +87,13 @@ func (db *DB) RenameLabel(project, oldName, newName string) error {
 	result, err := db.Exec(`
 		UPDATE labels SET name = ?, updated_at = ?
 		WHERE name = ? AND project = ?
-	`, newName, time.Now(), oldName, project)
+	`, newName, db.Now(), oldName, project)
 	if err != nil {
 		return fmt.Errorf("failed to rename label: %w", err)
 	}
 	rows, _ := result.RowsAffected()
 	if rows == 0 {
-		return fmt.Errorf("label not found: %s", oldName)
+		return fmt.Errorf("label %w: %s", ErrNotFound, oldName)
 	}
 	return nil
 }
@@ -111,7 +110,7 @@ func (db *DB) DeleteLabel(project, name string) error {
 	var labelID string
 	err = tx.QueryRow(`SELECT id FROM labels WHERE name = ? AND project = ?`, name, project).Scan(&labelID)
 	if err != nil {
-		return fmt.Errorf("label not found: %s", name)
+		return fmt.Errorf("label %w: %s", ErrNotFound, name)
 	}
 
 	// Delete item associations
@@ -141,7 +140,7 @@ func (db *DB) EnsureLabel(project, name string) (*model.Label, error) {
 	}
 
 	// Create new label
-	now := time.Now()
+	now := db.Now()
 	label = &model.Label{
 		ID:        model.GenerateLabelID(),
 		Name:      name,
@@ -282,13 +281,13 @@ func (db *DB) SetLabelColor(project, name, color string) error {
 	result, err := db.Exec(`
 		UPDATE labels SET color = ?, updated_at = ?
 		WHERE name = ? AND project = ?
-	`, color, time.Now(), name, project)
+	`, color, db.Now(), name, project)
 	if err != nil {
 		return fmt.Errorf("failed to update label color: %w", err)
 	}
 	rows, _ := result.RowsAffected()
 	if rows == 0 {
-		return fmt.Errorf("label not found: %s", name)
+		return fmt.Errorf("label %w: %s", ErrNotFound, name)
 	}
 	return nil
 }
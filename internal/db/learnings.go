@@ -88,7 +88,7 @@ func (db *DB) GetLearning(id string) (*model.Learning, error) {
 		FROM learnings WHERE id = ?
 	`, id).Scan(&l.ID, &l.Project, &l.CreatedAt, &l.UpdatedAt, &taskID, &l.Summary, &l.Detail, &filesJSON, &l.Status)
 	if err != nil {
-		return nil, fmt.Errorf("learning not found: %s", id)
+		return nil, fmt.Errorf("learning %w: %s", ErrNotFound, id)
 	}
 	l.TaskID = taskID
 
@@ -177,7 +177,7 @@ func (db *DB) EnsureConcept(name, project string) error {
 		INSERT INTO concepts (id, name, project, last_updated)
 		VALUES (?, ?, ?, ?)
 		ON CONFLICT (name, project) DO NOTHING
-	`, model.GenerateConceptID(), name, project, time.Now())
+	`, model.GenerateConceptID(), name, project, db.Now())
 	return err
 }
 
@@ -186,13 +186,13 @@ func (db *DB) SetConceptSummary(name, project, summary string) error {
 	result, err := db.Exec(`
 		UPDATE concepts SET summary = ?, last_updated = ?
 		WHERE name = ? AND project = ?
-	`, summary, time.Now(), name, project)
+	`, summary, db.Now(), name, project)
 	if err != nil {
 		return fmt.Errorf("failed to update concept: %w", err)
 	}
 	rows, _ := result.RowsAffected()
 	if rows == 0 {
-		return fmt.Errorf("concept not found: %s", name)
+		return fmt.Errorf("concept %w: %s", ErrNotFound, name)
 	}
 	return nil
 }
@@ -202,13 +202,13 @@ func (db *DB) UpdateLearningSummary(id, summary string) error {
 	result, err := db.Exec(`
 		UPDATE learnings SET summary = ?, updated_at = ?
 		WHERE id = ?
-	`, summary, time.Now(), id)
+	`, summary, db.Now(), id)
 	if err != nil {
 		return fmt.Errorf("failed to update learning: %w", err)
 	}
 	rows, _ := result.RowsAffected()
 	if rows == 0 {
-		return fmt.Errorf("learning not found: %s", id)
+		return fmt.Errorf("learning %w: %s", ErrNotFound, id)
 	}
 	return nil
 }
@@ -218,13 +218,13 @@ func (db *DB) UpdateLearningStatus(id string, status model.LearningStatus) error
 	result, err := db.Exec(`
 		UPDATE learnings SET status = ?, updated_at = ?
 		WHERE id = ?
-	`, status, time.Now(), id)
+	`, status, db.Now(), id)
 	if err != nil {
 		return fmt.Errorf("failed to update learning status: %w", err)
 	}
 	rows, _ := result.RowsAffected()
 	if rows == 0 {
-		return fmt.Errorf("learning not found: %s", id)
+		return fmt.Errorf("learning %w: %s", ErrNotFound, id)
 	}
 	return nil
 }
@@ -234,13 +234,13 @@ func (db *DB) UpdateLearningDetail(id, detail string) error {
 	result, err := db.Exec(`
 		UPDATE learnings SET detail = ?, updated_at = ?
 		WHERE id = ?
-	`, detail, time.Now(), id)
+	`, detail, db.Now(), id)
 	if err != nil {
 		return fmt.Errorf("failed to update learning detail: %w", err)
 	}
 	rows, _ := result.RowsAffected()
 	if rows == 0 {
-		return fmt.Errorf("learning not found: %s", id)
+		return fmt.Errorf("learning %w: %s", ErrNotFound, id)
 	}
 	return nil
 }
@@ -266,7 +266,7 @@ func (db *DB) DeleteLearning(id string) error {
 	}
 	rows, _ := result.RowsAffected()
 	if rows == 0 {
-		return fmt.Errorf("learning not found: %s", id)
+		return fmt.Errorf("learning %w: %s", ErrNotFound, id)
 	}
 
 	if err := tx.Commit(); err != nil {
@@ -280,13 +280,13 @@ func (db *DB) RenameConcept(oldName, newName, project string) error {
 	result, err := db.Exec(`
 		UPDATE concepts SET name = ?, last_updated = ?
 		WHERE name = ? AND project = ?
-	`, newName, time.Now(), oldName, project)
+	`, newName, db.Now(), oldName, project)
 	if err != nil {
 		return fmt.Errorf("failed to rename concept: %w", err)
 	}
 	rows, _ := result.RowsAffected()
 	if rows == 0 {
-		return fmt.Errorf("concept not found: %s", oldName)
+		return fmt.Errorf("concept %w: %s", ErrNotFound, oldName)
 	}
 	return nil
 }
@@ -465,7 +465,7 @@ func (db *DB) ListConceptsWithStats(project string) ([]ConceptStats, error) {
 	defer rows.Close()
 
 	var stats []ConceptStats
-	now := time.Now()
+	now := db.Now()
 	for rows.Next() {
 		var s ConceptStats
 		var oldestStr *string
@@ -0,0 +1,95 @@
+package db
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/baiirun/prog/internal/model"
+)
+
+// AddLink records a loose link of kind from one item to another. Linking
+// is idempotent, and since "relates" has no direction, relating b to a is a
+// no-op when a already relates to b. Links never affect readiness.
+func (db *DB) AddLink(fromID, toID, kind string) error {
+	if !model.ValidLinkKind(kind) {
+		return fmt.Errorf("invalid link kind: %s (valid: %s)", kind, strings.Join(model.LinkKinds, ", "))
+	}
+	if fromID == toID {
+		return fmt.Errorf("an item cannot link to itself: %s", fromID)
+	}
+
+	var count int
+	err := db.QueryRow(`SELECT COUNT(*) FROM items WHERE id IN (?, ?)`, fromID, toID).Scan(&count)
+	if err != nil {
+		return fmt.Errorf("failed to verify items: %w", err)
+	}
+	if count != 2 {
+		return fmt.Errorf("one or both items %w: %s, %s (use 'prog list' to see available items)", ErrNotFound, fromID, toID)
+	}
+
+	if kind == "relates" {
+		var exists bool
+		err := db.QueryRow(`SELECT EXISTS(SELECT 1 FROM links WHERE from_id = ? AND to_id = ? AND kind = ?)`,
+			toID, fromID, kind).Scan(&exists)
+		if err != nil {
+			return fmt.Errorf("failed to check link: %w", err)
+		}
+		if exists {
+			return nil
+		}
+	}
+
+	_, err = db.Exec(`INSERT OR IGNORE INTO links (from_id, to_id, kind, created_at) VALUES (?, ?, ?, ?)`,
+		fromID, toID, kind, db.Now())
+	if err != nil {
+		return fmt.Errorf("failed to add link: %w", err)
+	}
+	return nil
+}
+
+// RemoveLink deletes the link of kind from one item to another. A
+// "relates" link is removed whichever way round it was added.
+func (db *DB) RemoveLink(fromID, toID, kind string) error {
+	query := `DELETE FROM links WHERE kind = ? AND from_id = ? AND to_id = ?`
+	args := []any{kind, fromID, toID}
+	if kind == "relates" {
+		query += ` OR kind = ? AND from_id = ? AND to_id = ?`
+		args = append(args, kind, toID, fromID)
+	}
+	result, err := db.Exec(query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to remove link: %w", err)
+	}
+
+	rows, _ := result.RowsAffected()
+	if rows == 0 {
+		verb := strings.TrimSuffix(kind, "s")
+		if kind == "relates" {
+			verb = "relate to"
+		}
+		return fmt.Errorf("link %w: %s does not %s %s", ErrNotFound, fromID, verb, toID)
+	}
+	return nil
+}
+
+// GetLinks returns every link to or from an item, oldest first.
+func (db *DB) GetLinks(itemID string) ([]model.Link, error) {
+	rows, err := db.Query(`
+		SELECT from_id, to_id, kind, created_at FROM links
+		WHERE from_id = ? OR to_id = ?
+		ORDER BY rowid`, itemID, itemID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get links: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var links []model.Link
+	for rows.Next() {
+		var l model.Link
+		if err := rows.Scan(&l.FromID, &l.ToID, &l.Kind, &l.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan link: %w", err)
+		}
+		links = append(links, l)
+	}
+	return links, rows.Err()
+}
@@ -0,0 +1,76 @@
+package db
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/baiirun/prog/internal/model"
+)
+
+func TestLinks(t *testing.T) {
+	db := setupTestDB(t)
+
+	a := createTestItemWithProject(t, db, "A", "test", model.StatusOpen, 2)
+	b := createTestItemWithProject(t, db, "B", "test", model.StatusOpen, 2)
+	c := createTestItemWithProject(t, db, "C", "test", model.StatusOpen, 2)
+
+	if err := db.AddLink(a.ID, b.ID, "relates"); err != nil {
+		t.Fatalf("AddLink failed: %v", err)
+	}
+	// relates has no direction, so the reverse link is the same one
+	if err := db.AddLink(b.ID, a.ID, "relates"); err != nil {
+		t.Fatalf("AddLink (reverse) failed: %v", err)
+	}
+	if err := db.AddLink(c.ID, a.ID, "duplicates"); err != nil {
+		t.Fatalf("AddLink failed: %v", err)
+	}
+	if err := db.AddLink(c.ID, a.ID, "duplicates"); err != nil {
+		t.Fatalf("AddLink (again) failed: %v", err)
+	}
+
+	links, err := db.GetLinks(a.ID)
+	if err != nil {
+		t.Fatalf("GetLinks failed: %v", err)
+	}
+	if len(links) != 2 || links[0].ToID != b.ID || links[1].FromID != c.ID || links[1].Kind != "duplicates" {
+		t.Errorf("links = %+v, want a relates b and c duplicates a", links)
+	}
+
+	// Links don't gate readiness, unlike deps
+	ready, _ := db.ReadyItems("test")
+	if len(ready) != 3 {
+		t.Errorf("expected all 3 items ready, got %d", len(ready))
+	}
+
+	if err := db.RemoveLink(b.ID, a.ID, "relates"); err != nil {
+		t.Errorf("RemoveLink (reverse) failed: %v", err)
+	}
+	if err := db.RemoveLink(a.ID, c.ID, "duplicates"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("RemoveLink in the wrong direction = %v, want ErrNotFound", err)
+	}
+
+	if err := db.AddLink(a.ID, b.ID, "follows"); err == nil {
+		t.Error("expected error for invalid kind")
+	}
+	if err := db.AddLink(a.ID, a.ID, "relates"); err == nil {
+		t.Error("expected error linking an item to itself")
+	}
+	if err := db.AddLink(a.ID, "ts-missing", "relates"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("link to missing item = %v, want ErrNotFound", err)
+	}
+
+	// Merging moves links to the surviving item; deleting removes them
+	if _, err := db.MergeItems(c.ID, b.ID); err != nil {
+		t.Fatalf("MergeItems failed: %v", err)
+	}
+	links, _ = db.GetLinks(a.ID)
+	if len(links) != 1 || links[0].FromID != b.ID || links[0].Kind != "duplicates" {
+		t.Errorf("links after merge = %+v, want b duplicates a", links)
+	}
+	if err := db.DeleteItem(b.ID); err != nil {
+		t.Fatalf("DeleteItem failed: %v", err)
+	}
+	if links, _ := db.GetLinks(a.ID); len(links) != 0 {
+		t.Errorf("links after delete = %+v, want none", links)
+	}
+}
@@ -1,26 +1,61 @@
 package db
 
 import (
+	"database/sql"
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/baiirun/prog/internal/model"
 )
 
-// AddLog adds a log entry to an item.
-func (db *DB) AddLog(itemID, message string) error {
+// AddLog adds a log entry to an item. source records who wrote it and may
+// be empty.
+func (db *DB) AddLog(itemID, message, source string) error {
 	_, err := db.Exec(`
-		INSERT INTO logs (item_id, message) VALUES (?, ?)`,
-		itemID, message)
+		INSERT INTO logs (item_id, message, source, created_at) VALUES (?, ?, ?, ?)`,
+		itemID, message, source, logTime(db.Now()))
 	if err != nil {
 		return fmt.Errorf("failed to add log: %w", err)
 	}
 	return nil
 }
 
+// addLogTx adds a log entry within an existing transaction.
+func addLogTx(tx *sql.Tx, itemID, message string, now time.Time) error {
+	_, err := tx.Exec(`
+		INSERT INTO logs (item_id, message, created_at) VALUES (?, ?, ?)`,
+		itemID, message, logTime(now))
+	if err != nil {
+		return fmt.Errorf("failed to add log: %w", err)
+	}
+	return nil
+}
+
+// logTime normalizes log timestamps to UTC, matching rows written by the
+// column's CURRENT_TIMESTAMP default so they sort together.
+func logTime(t time.Time) time.Time {
+	return t.UTC()
+}
+
+// DeleteLog removes a single log entry by its numeric id.
+func (db *DB) DeleteLog(logID int64) error {
+	result, err := db.Exec(`DELETE FROM logs WHERE id = ?`, logID)
+	if err != nil {
+		return fmt.Errorf("failed to delete log: %w", err)
+	}
+
+	rows, _ := result.RowsAffected()
+	if rows == 0 {
+		return fmt.Errorf("log %w: %d (use 'prog show <id>' to see log ids)", ErrNotFound, logID)
+	}
+	return nil
+}
+
 // GetLogs retrieves all logs for an item, ordered by creation time.
 func (db *DB) GetLogs(itemID string) ([]model.Log, error) {
 	rows, err := db.Query(`
-		SELECT id, item_id, message, created_at
+		SELECT id, item_id, message, source, created_at
 		FROM logs WHERE item_id = ? ORDER BY created_at ASC`, itemID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get logs: %w", err)
@@ -30,10 +65,55 @@ func (db *DB) GetLogs(itemID string) ([]model.Log, error) {
 	var logs []model.Log
 	for rows.Next() {
 		var log model.Log
-		if err := rows.Scan(&log.ID, &log.ItemID, &log.Message, &log.CreatedAt); err != nil {
+		if err := rows.Scan(&log.ID, &log.ItemID, &log.Message, &log.Source, &log.CreatedAt); err != nil {
 			return nil, fmt.Errorf("failed to scan log: %w", err)
 		}
 		logs = append(logs, log)
 	}
 	return logs, rows.Err()
 }
+
+// PruneLogs deletes all but the keep most recent logs of itemID and returns
+// how many were deleted.
+func (db *DB) PruneLogs(itemID string, keep int) (int, error) {
+	if keep < 0 {
+		return 0, fmt.Errorf("keep cannot be negative: %d", keep)
+	}
+	result, err := db.Exec(`
+		DELETE FROM logs WHERE item_id = ? AND id NOT IN (
+			SELECT id FROM logs WHERE item_id = ?
+			ORDER BY created_at DESC, id DESC LIMIT ?
+		)`, itemID, itemID, keep)
+	if err != nil {
+		return 0, fmt.Errorf("failed to prune logs: %w", err)
+	}
+	rows, _ := result.RowsAffected()
+	return int(rows), nil
+}
+
+// PruneLogsBefore deletes itemID's logs written before cutoff and returns how
+// many were deleted.
+func (db *DB) PruneLogsBefore(itemID string, cutoff time.Time) (int, error) {
+	logs, err := db.GetLogs(itemID)
+	if err != nil {
+		return 0, err
+	}
+
+	// Compare in Go: stored timestamps aren't all in the same text format
+	var ids []any
+	for _, log := range logs {
+		if log.CreatedAt.Before(cutoff) {
+			ids = append(ids, log.ID)
+		}
+	}
+	if len(ids) == 0 {
+		return 0, nil
+	}
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(ids)), ", ")
+	result, err := db.Exec(`DELETE FROM logs WHERE id IN (`+placeholders+`)`, ids...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to prune logs: %w", err)
+	}
+	rows, _ := result.RowsAffected()
+	return int(rows), nil
+}
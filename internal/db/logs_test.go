@@ -1,6 +1,8 @@
 package db
 
 import (
+	"fmt"
+	"strings"
 	"testing"
 	"time"
 
@@ -23,10 +25,10 @@ func TestAddLog(t *testing.T) {
 		t.Fatalf("failed to create item: %v", err)
 	}
 
-	if err := db.AddLog(item.ID, "First log"); err != nil {
+	if err := db.AddLog(item.ID, "First log", ""); err != nil {
 		t.Fatalf("failed to add log: %v", err)
 	}
-	if err := db.AddLog(item.ID, "Second log"); err != nil {
+	if err := db.AddLog(item.ID, "Second log", ""); err != nil {
 		t.Fatalf("failed to add second log: %v", err)
 	}
 
@@ -89,13 +91,13 @@ func TestGetLogs_Order(t *testing.T) {
 		t.Fatalf("failed to create item: %v", err)
 	}
 
-	if err := db.AddLog(item.ID, "First"); err != nil {
+	if err := db.AddLog(item.ID, "First", ""); err != nil {
 		t.Fatalf("failed to add log: %v", err)
 	}
-	if err := db.AddLog(item.ID, "Second"); err != nil {
+	if err := db.AddLog(item.ID, "Second", ""); err != nil {
 		t.Fatalf("failed to add log: %v", err)
 	}
-	if err := db.AddLog(item.ID, "Third"); err != nil {
+	if err := db.AddLog(item.ID, "Third", ""); err != nil {
 		t.Fatalf("failed to add log: %v", err)
 	}
 
@@ -106,3 +108,107 @@ func TestGetLogs_Order(t *testing.T) {
 		t.Error("logs not in chronological order")
 	}
 }
+
+func TestAddLog_Source(t *testing.T) {
+	db := setupTestDB(t)
+
+	item := createTestItem(t, db, "Test")
+	if err := db.AddLog(item.ID, "From agent", "claude"); err != nil {
+		t.Fatalf("failed to add log: %v", err)
+	}
+	if err := db.AddLog(item.ID, "Anonymous", ""); err != nil {
+		t.Fatalf("failed to add log: %v", err)
+	}
+
+	logs, err := db.GetLogs(item.ID)
+	if err != nil {
+		t.Fatalf("failed to get logs: %v", err)
+	}
+	if len(logs) != 2 {
+		t.Fatalf("expected 2 logs, got %d", len(logs))
+	}
+	if logs[0].Source != "claude" {
+		t.Errorf("source = %q, want claude", logs[0].Source)
+	}
+	if logs[1].Source != "" {
+		t.Errorf("source = %q, want empty", logs[1].Source)
+	}
+}
+
+func TestDeleteLog(t *testing.T) {
+	db := setupTestDB(t)
+	item := createTestItem(t, db, "Task")
+
+	for _, msg := range []string{"keep", "oops: secret"} {
+		if err := db.AddLog(item.ID, msg, ""); err != nil {
+			t.Fatalf("failed to add log: %v", err)
+		}
+	}
+	logs, _ := db.GetLogs(item.ID)
+
+	if err := db.DeleteLog(logs[1].ID); err != nil {
+		t.Fatalf("DeleteLog failed: %v", err)
+	}
+	logs, _ = db.GetLogs(item.ID)
+	if len(logs) != 1 || logs[0].Message != "keep" {
+		t.Errorf("logs after delete = %v, want only 'keep'", logs)
+	}
+
+	if err := db.DeleteLog(9999); err == nil {
+		t.Error("expected error for nonexistent log")
+	}
+}
+
+func TestPruneLogs(t *testing.T) {
+	db := setupTestDB(t)
+	item := createTestItem(t, db, "Chatty")
+	other := createTestItem(t, db, "Quiet")
+
+	start := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	for i := range 5 {
+		db.Clock = fixedClock{start.AddDate(0, 0, i)}
+		if err := db.AddLog(item.ID, fmt.Sprintf("entry %d", i), ""); err != nil {
+			t.Fatalf("failed to add log: %v", err)
+		}
+	}
+	if err := db.AddLog(other.ID, "untouched", ""); err != nil {
+		t.Fatalf("failed to add log: %v", err)
+	}
+
+	messages := func(id string) string {
+		t.Helper()
+		logs, err := db.GetLogs(id)
+		if err != nil {
+			t.Fatalf("failed to get logs: %v", err)
+		}
+		var msgs []string
+		for _, l := range logs {
+			msgs = append(msgs, l.Message)
+		}
+		return strings.Join(msgs, ",")
+	}
+
+	// Entries before Jan 2 go
+	n, err := db.PruneLogsBefore(item.ID, start.AddDate(0, 0, 1))
+	if err != nil || n != 1 {
+		t.Fatalf("PruneLogsBefore = %d, %v; want 1", n, err)
+	}
+	if got := messages(item.ID); got != "entry 1,entry 2,entry 3,entry 4" {
+		t.Errorf("after age prune: %s", got)
+	}
+
+	n, err = db.PruneLogs(item.ID, 2)
+	if err != nil || n != 2 {
+		t.Fatalf("PruneLogs = %d, %v; want 2", n, err)
+	}
+	if got := messages(item.ID); got != "entry 3,entry 4" {
+		t.Errorf("after count prune: %s", got)
+	}
+	if got := messages(other.ID); got != "untouched" {
+		t.Errorf("other item's logs = %s", got)
+	}
+
+	if _, err := db.PruneLogs(item.ID, -1); err == nil {
+		t.Error("expected error for negative keep")
+	}
+}
@@ -0,0 +1,74 @@
+package db
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/baiirun/prog/internal/model"
+)
+
+// SetNote pins a key/value note on an item, replacing any existing note with
+// the same key. Keys are trimmed; both key and value must be non-empty.
+func (db *DB) SetNote(itemID, key, value string) error {
+	key = strings.TrimSpace(key)
+	if key == "" {
+		return fmt.Errorf("note key cannot be empty")
+	}
+	if strings.TrimSpace(value) == "" {
+		return fmt.Errorf("note value cannot be empty (use --rm to delete a note)")
+	}
+
+	var count int
+	err := db.QueryRow(`SELECT COUNT(*) FROM items WHERE id = ?`, itemID).Scan(&count)
+	if err != nil {
+		return fmt.Errorf("failed to check item: %w", err)
+	}
+	if count == 0 {
+		return fmt.Errorf("item %w: %s (use 'prog list' to see available items)", ErrNotFound, itemID)
+	}
+
+	_, err = db.Exec(`
+		INSERT INTO notes (item_id, key, value, updated_at) VALUES (?, ?, ?, ?)
+		ON CONFLICT(item_id, key) DO UPDATE SET value = excluded.value, updated_at = excluded.updated_at`,
+		itemID, key, value, db.Now())
+	if err != nil {
+		return fmt.Errorf("failed to set note: %w", err)
+	}
+	return nil
+}
+
+// RemoveNote deletes the note with key from an item.
+func (db *DB) RemoveNote(itemID, key string) error {
+	key = strings.TrimSpace(key)
+
+	result, err := db.Exec(`DELETE FROM notes WHERE item_id = ? AND key = ?`, itemID, key)
+	if err != nil {
+		return fmt.Errorf("failed to remove note: %w", err)
+	}
+	rows, _ := result.RowsAffected()
+	if rows == 0 {
+		return fmt.Errorf("item %s has no note: %s", itemID, key)
+	}
+	return nil
+}
+
+// GetNotes returns an item's notes, sorted by key.
+func (db *DB) GetNotes(itemID string) ([]model.Note, error) {
+	rows, err := db.Query(`
+		SELECT item_id, key, value, updated_at FROM notes
+		WHERE item_id = ? ORDER BY key`, itemID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get notes: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var notes []model.Note
+	for rows.Next() {
+		var n model.Note
+		if err := rows.Scan(&n.ItemID, &n.Key, &n.Value, &n.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan note: %w", err)
+		}
+		notes = append(notes, n)
+	}
+	return notes, rows.Err()
+}
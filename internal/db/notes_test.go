@@ -0,0 +1,55 @@
+package db
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestSetNote(t *testing.T) {
+	db := setupTestDB(t)
+
+	item := createTestItem(t, db, "Task")
+
+	for _, n := range [][2]string{{"pr", "122"}, {"owner", "alice"}, {" pr ", "123"}} {
+		if err := db.SetNote(item.ID, n[0], n[1]); err != nil {
+			t.Fatalf("failed to set note %s: %v", n[0], err)
+		}
+	}
+
+	notes, err := db.GetNotes(item.ID)
+	if err != nil {
+		t.Fatalf("failed to get notes: %v", err)
+	}
+	if len(notes) != 2 || notes[0].Key != "owner" || notes[1].Key != "pr" || notes[1].Value != "123" {
+		t.Errorf("notes = %+v, want owner=alice and pr=123", notes)
+	}
+
+	if err := db.SetNote(item.ID, "", "x"); err == nil {
+		t.Error("expected error for empty key")
+	}
+	if err := db.SetNote(item.ID, "pr", " "); err == nil {
+		t.Error("expected error for empty value")
+	}
+	if err := db.SetNote("ts-nope00", "pr", "1"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestRemoveNote(t *testing.T) {
+	db := setupTestDB(t)
+
+	item := createTestItem(t, db, "Task")
+	if err := db.SetNote(item.ID, "pr", "123"); err != nil {
+		t.Fatalf("failed to set note: %v", err)
+	}
+
+	if err := db.RemoveNote(item.ID, "pr"); err != nil {
+		t.Fatalf("failed to remove note: %v", err)
+	}
+	if notes, _ := db.GetNotes(item.ID); len(notes) != 0 {
+		t.Errorf("notes = %+v after remove, want none", notes)
+	}
+	if err := db.RemoveNote(item.ID, "pr"); err == nil {
+		t.Error("expected error removing a missing note")
+	}
+}
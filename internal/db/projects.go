@@ -1,8 +1,11 @@
 package db
 
 import (
+	"database/sql"
 	"fmt"
-	"time"
+	"sort"
+
+	"github.com/baiirun/prog/internal/model"
 )
 
 // EnsureProject creates a project if it doesn't exist.
@@ -12,10 +15,236 @@ func (db *DB) EnsureProject(name string) error {
 		INSERT INTO projects (name, created_at, updated_at)
 		VALUES (?, ?, ?)
 		ON CONFLICT(name) DO NOTHING`,
-		name, time.Now(), time.Now(),
+		name, db.Now(), db.Now(),
 	)
 	if err != nil {
 		return fmt.Errorf("failed to ensure project: %w", err)
 	}
 	return nil
 }
+
+// RenameProject moves every item, label, concept and learning from project
+// old to project new in one transaction and renames the projects entry. It
+// refuses to rename onto a project that already has items; use MergeProject
+// to combine two projects.
+func (db *DB) RenameProject(old, new string) error {
+	return db.renameProject(old, new, false)
+}
+
+// MergeProject is RenameProject without the check for an existing
+// destination: items from old join those already in new. Labels and concepts
+// with the same name in both projects are combined.
+func (db *DB) MergeProject(old, new string) error {
+	return db.renameProject(old, new, true)
+}
+
+func (db *DB) renameProject(old, new string, merge bool) error {
+	if new == "" {
+		return fmt.Errorf("new project name is required")
+	}
+	if old == new {
+		return fmt.Errorf("project is already named %s", new)
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var exists bool
+	err = tx.QueryRow(`
+		SELECT EXISTS (SELECT 1 FROM projects WHERE name = ?)
+		    OR EXISTS (SELECT 1 FROM items WHERE project = ?)`, old, old).Scan(&exists)
+	if err != nil {
+		return fmt.Errorf("failed to check project: %w", err)
+	}
+	if !exists {
+		return fmt.Errorf("project %w: %s (use 'prog projects' to see available projects)", ErrNotFound, old)
+	}
+	if !merge {
+		var count int
+		if err := tx.QueryRow(`SELECT COUNT(*) FROM items WHERE project = ?`, new).Scan(&count); err != nil {
+			return fmt.Errorf("failed to check project: %w", err)
+		}
+		if count > 0 {
+			return fmt.Errorf("project %s already has %d items (use --merge to combine the projects)", new, count)
+		}
+	}
+
+	now := db.Now()
+	if _, err := tx.Exec(`UPDATE items SET project = ?, updated_at = ? WHERE project = ?`, new, now, old); err != nil {
+		return fmt.Errorf("failed to move items: %w", err)
+	}
+	if _, err := tx.Exec(`UPDATE learnings SET project = ? WHERE project = ?`, new, old); err != nil {
+		return fmt.Errorf("failed to move learnings: %w", err)
+	}
+
+	// Labels and concepts are unique per project, so same-named ones in the
+	// destination absorb the old project's links before the rest are renamed
+	if _, err := tx.Exec(`
+		INSERT OR IGNORE INTO item_labels (item_id, label_id)
+		SELECT il.item_id, dst.id FROM item_labels il
+		JOIN labels src ON src.id = il.label_id AND src.project = ?
+		JOIN labels dst ON dst.name = src.name AND dst.project = ?`, old, new); err != nil {
+		return fmt.Errorf("failed to merge labels: %w", err)
+	}
+	if _, err := tx.Exec(`
+		DELETE FROM item_labels WHERE label_id IN (
+		    SELECT src.id FROM labels src
+		    JOIN labels dst ON dst.name = src.name AND dst.project = ?
+		    WHERE src.project = ?)`, new, old); err != nil {
+		return fmt.Errorf("failed to merge labels: %w", err)
+	}
+	if _, err := tx.Exec(`
+		DELETE FROM labels WHERE project = ?
+		  AND name IN (SELECT name FROM labels WHERE project = ?)`, old, new); err != nil {
+		return fmt.Errorf("failed to merge labels: %w", err)
+	}
+	if _, err := tx.Exec(`UPDATE labels SET project = ?, updated_at = ? WHERE project = ?`, new, now, old); err != nil {
+		return fmt.Errorf("failed to move labels: %w", err)
+	}
+
+	if _, err := tx.Exec(`
+		INSERT OR IGNORE INTO learning_concepts (learning_id, concept_id)
+		SELECT lc.learning_id, dst.id FROM learning_concepts lc
+		JOIN concepts src ON src.id = lc.concept_id AND src.project = ?
+		JOIN concepts dst ON dst.name = src.name AND dst.project = ?`, old, new); err != nil {
+		return fmt.Errorf("failed to merge concepts: %w", err)
+	}
+	if _, err := tx.Exec(`
+		DELETE FROM learning_concepts WHERE concept_id IN (
+		    SELECT src.id FROM concepts src
+		    JOIN concepts dst ON dst.name = src.name AND dst.project = ?
+		    WHERE src.project = ?)`, new, old); err != nil {
+		return fmt.Errorf("failed to merge concepts: %w", err)
+	}
+	if _, err := tx.Exec(`
+		DELETE FROM concepts WHERE project = ?
+		  AND name IN (SELECT name FROM concepts WHERE project = ?)`, old, new); err != nil {
+		return fmt.Errorf("failed to merge concepts: %w", err)
+	}
+	if _, err := tx.Exec(`UPDATE concepts SET project = ? WHERE project = ?`, new, old); err != nil {
+		return fmt.Errorf("failed to move concepts: %w", err)
+	}
+
+	// Rename the old entry unless the destination already has one, in which
+	// case the destination's entry wins and the old one is dropped
+	if _, err := tx.Exec(`UPDATE OR IGNORE projects SET name = ?, updated_at = ? WHERE name = ?`, new, now, old); err != nil {
+		return fmt.Errorf("failed to rename project: %w", err)
+	}
+	if _, err := tx.Exec(`DELETE FROM projects WHERE name = ?`, old); err != nil {
+		return fmt.Errorf("failed to remove old project: %w", err)
+	}
+	if _, err := tx.Exec(`
+		INSERT INTO projects (name, created_at, updated_at)
+		VALUES (?, ?, ?)
+		ON CONFLICT(name) DO NOTHING`, new, now, now); err != nil {
+		return fmt.Errorf("failed to ensure project: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// SetWIPLimit caps how many of a project's items should be in progress at
+// once. A limit of 0 removes the cap.
+func (db *DB) SetWIPLimit(project string, limit int) error {
+	if limit < 0 {
+		return fmt.Errorf("WIP limit cannot be negative: %d", limit)
+	}
+	result, err := db.Exec(`UPDATE projects SET wip_limit = ?, updated_at = ? WHERE name = ?`, limit, db.Now(), project)
+	if err != nil {
+		return fmt.Errorf("failed to set WIP limit: %w", err)
+	}
+	rows, _ := result.RowsAffected()
+	if rows == 0 {
+		return fmt.Errorf("project %w: %s (use 'prog projects' to see available projects)", ErrNotFound, project)
+	}
+	return nil
+}
+
+// WIPLimit returns a project's WIP limit, or 0 if it has none or the project
+// doesn't exist.
+func (db *DB) WIPLimit(project string) (int, error) {
+	var limit int
+	err := db.QueryRow(`SELECT wip_limit FROM projects WHERE name = ?`, project).Scan(&limit)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to get WIP limit: %w", err)
+	}
+	return limit, nil
+}
+
+// SetAutoCloseEpics turns automatic completion of a project's epics on or
+// off. When on, an epic is marked done as soon as its last child is.
+func (db *DB) SetAutoCloseEpics(project string, on bool) error {
+	result, err := db.Exec(`UPDATE projects SET auto_close_epics = ?, updated_at = ? WHERE name = ?`, on, db.Now(), project)
+	if err != nil {
+		return fmt.Errorf("failed to set auto-close: %w", err)
+	}
+	rows, _ := result.RowsAffected()
+	if rows == 0 {
+		return fmt.Errorf("project %w: %s (use 'prog projects' to see available projects)", ErrNotFound, project)
+	}
+	return nil
+}
+
+// AutoCloseEpics reports whether a project's epics complete automatically,
+// which is false if the project doesn't exist.
+func (db *DB) AutoCloseEpics(project string) (bool, error) {
+	var on bool
+	err := db.QueryRow(`SELECT auto_close_epics FROM projects WHERE name = ?`, project).Scan(&on)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to get auto-close: %w", err)
+	}
+	return on, nil
+}
+
+// WIPExcess describes a project that would be over its WIP limit.
+type WIPExcess struct {
+	Project    string
+	InProgress int // in-progress count once the items are started
+	Limit      int
+}
+
+// CheckWIP reports the projects whose WIP limit would be exceeded if every
+// item in ids were started. Items already in progress don't count twice.
+func (db *DB) CheckWIP(ids []string) ([]WIPExcess, error) {
+	starting := make(map[string]int)
+	for _, id := range ids {
+		item, err := db.GetItem(id)
+		if err != nil {
+			return nil, err
+		}
+		if item.Status != model.StatusInProgress {
+			starting[item.Project]++
+		}
+	}
+
+	var excess []WIPExcess
+	for project, n := range starting {
+		var limit, current int
+		err := db.QueryRow(`
+			SELECT p.wip_limit, (SELECT COUNT(*) FROM items WHERE project = p.name AND status = 'in_progress')
+			FROM projects p WHERE p.name = ?`, project).Scan(&limit, &current)
+		if err == sql.ErrNoRows {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to check WIP limit: %w", err)
+		}
+		if limit > 0 && current+n > limit {
+			excess = append(excess, WIPExcess{Project: project, InProgress: current + n, Limit: limit})
+		}
+	}
+	sort.Slice(excess, func(i, j int) bool { return excess[i].Project < excess[j].Project })
+	return excess, nil
+}
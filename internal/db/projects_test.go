@@ -1,7 +1,11 @@
 package db
 
 import (
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/baiirun/prog/internal/model"
 )
 
 func TestEnsureProject(t *testing.T) {
@@ -42,3 +46,212 @@ func TestListProjectsEmpty(t *testing.T) {
 		t.Errorf("expected empty list, got %v", projects)
 	}
 }
+
+func TestRenameProject(t *testing.T) {
+	db := setupTestDB(t)
+
+	item := createTestItemWithProject(t, db, "Task", "old", model.StatusOpen, 2)
+	if err := db.AddLabelToItem(item.ID, "old", "bug"); err != nil {
+		t.Fatalf("failed to add label: %v", err)
+	}
+	learning := &model.Learning{
+		ID:        model.GenerateLearningID(),
+		Project:   "old",
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+		Summary:   "Learned something",
+		Status:    model.LearningStatusActive,
+		Concepts:  []string{"auth"},
+	}
+	if err := db.CreateLearning(learning); err != nil {
+		t.Fatalf("failed to create learning: %v", err)
+	}
+
+	if err := db.RenameProject("old", "new"); err != nil {
+		t.Fatalf("RenameProject failed: %v", err)
+	}
+
+	got, err := db.GetItem(item.ID)
+	if err != nil {
+		t.Fatalf("failed to get item: %v", err)
+	}
+	if got.Project != "new" {
+		t.Errorf("item project = %q, want new", got.Project)
+	}
+	labels, err := db.GetItemLabels(item.ID)
+	if err != nil {
+		t.Fatalf("failed to get labels: %v", err)
+	}
+	if len(labels) != 1 || labels[0].Project != "new" {
+		t.Errorf("labels = %+v, want bug in new", labels)
+	}
+	concepts, err := db.ListConcepts("new", false)
+	if err != nil {
+		t.Fatalf("failed to list concepts: %v", err)
+	}
+	if len(concepts) != 1 || concepts[0].Name != "auth" {
+		t.Errorf("concepts in new = %+v, want [auth]", concepts)
+	}
+	projects, err := db.ListProjects()
+	if err != nil {
+		t.Fatalf("failed to list projects: %v", err)
+	}
+	if len(projects) != 1 || projects[0] != "new" {
+		t.Errorf("projects = %v, want [new]", projects)
+	}
+
+	if err := db.RenameProject("missing", "other"); err == nil {
+		t.Error("expected error renaming a missing project")
+	}
+}
+
+func TestRenameProject_RefusesExistingDestination(t *testing.T) {
+	db := setupTestDB(t)
+
+	createTestItemWithProject(t, db, "A", "a", model.StatusOpen, 2)
+	createTestItemWithProject(t, db, "B", "b", model.StatusOpen, 2)
+
+	err := db.RenameProject("a", "b")
+	if err == nil || !strings.Contains(err.Error(), "--merge") {
+		t.Fatalf("expected error suggesting --merge, got %v", err)
+	}
+	items, err := db.ListItems("a", nil)
+	if err != nil {
+		t.Fatalf("failed to list items: %v", err)
+	}
+	if len(items) != 1 {
+		t.Errorf("expected project a untouched, got %d items", len(items))
+	}
+}
+
+func TestMergeProject(t *testing.T) {
+	db := setupTestDB(t)
+
+	a := createTestItemWithProject(t, db, "A", "a", model.StatusOpen, 2)
+	b := createTestItemWithProject(t, db, "B", "b", model.StatusOpen, 2)
+	for _, item := range []*model.Item{a, b} {
+		if err := db.AddLabelToItem(item.ID, item.Project, "bug"); err != nil {
+			t.Fatalf("failed to add label: %v", err)
+		}
+	}
+
+	if err := db.MergeProject("a", "b"); err != nil {
+		t.Fatalf("MergeProject failed: %v", err)
+	}
+
+	items, err := db.ListItems("b", nil)
+	if err != nil {
+		t.Fatalf("failed to list items: %v", err)
+	}
+	if len(items) != 2 {
+		t.Errorf("expected 2 items in b, got %d", len(items))
+	}
+
+	// The two bug labels collapse into b's, still attached to both items
+	labels, err := db.ListLabels("b")
+	if err != nil {
+		t.Fatalf("failed to list labels: %v", err)
+	}
+	if len(labels) != 1 {
+		t.Fatalf("expected one merged label, got %+v", labels)
+	}
+	for _, item := range []*model.Item{a, b} {
+		got, err := db.GetItemLabels(item.ID)
+		if err != nil {
+			t.Fatalf("failed to get labels: %v", err)
+		}
+		if len(got) != 1 || got[0].ID != labels[0].ID {
+			t.Errorf("labels of %s = %+v, want the merged label", item.Title, got)
+		}
+	}
+	if remaining, _ := db.ListLabels("a"); len(remaining) != 0 {
+		t.Errorf("expected no labels left in a, got %+v", remaining)
+	}
+}
+
+func TestProjectSummaries(t *testing.T) {
+	db := setupTestDB(t)
+
+	createTestItemWithProject(t, db, "A1", "alpha", model.StatusOpen, 2)
+	createTestItemWithProject(t, db, "A2", "alpha", model.StatusDone, 2)
+	createTestItemWithProject(t, db, "B1", "beta", model.StatusOpen, 2)
+	createTestItemWithProject(t, db, "B2", "beta", model.StatusOpen, 2)
+	createTestItemWithProject(t, db, "B3", "beta", model.StatusBlocked, 2)
+	createTestItemWithProject(t, db, "B4", "beta", model.StatusInProgress, 2)
+	if err := db.EnsureProject("empty"); err != nil {
+		t.Fatalf("failed to ensure project: %v", err)
+	}
+
+	summaries, err := db.ProjectSummaries()
+	if err != nil {
+		t.Fatalf("ProjectSummaries failed: %v", err)
+	}
+	want := []ProjectSummary{
+		{Name: "beta", Open: 2, InProgress: 1, Blocked: 1},
+		{Name: "alpha", Open: 1, Done: 1},
+		{Name: "empty"},
+	}
+	if len(summaries) != len(want) {
+		t.Fatalf("got %d summaries, want %d: %+v", len(summaries), len(want), summaries)
+	}
+	for i := range want {
+		if summaries[i] != want[i] {
+			t.Errorf("summaries[%d] = %+v, want %+v", i, summaries[i], want[i])
+		}
+	}
+}
+
+func TestCheckWIP(t *testing.T) {
+	db := setupTestDB(t)
+
+	running := createTestItemWithProject(t, db, "Running", "wip", model.StatusInProgress, 2)
+	a := createTestItemWithProject(t, db, "A", "wip", model.StatusOpen, 2)
+	b := createTestItemWithProject(t, db, "B", "wip", model.StatusOpen, 2)
+	other := createTestItemWithProject(t, db, "Other", "free", model.StatusOpen, 2)
+
+	// Unlimited by default
+	excess, err := db.CheckWIP([]string{a.ID, b.ID, other.ID})
+	if err != nil {
+		t.Fatalf("CheckWIP failed: %v", err)
+	}
+	if len(excess) != 0 {
+		t.Errorf("expected no excess without a limit, got %+v", excess)
+	}
+
+	if err := db.SetWIPLimit("wip", 2); err != nil {
+		t.Fatalf("SetWIPLimit failed: %v", err)
+	}
+
+	// Restarting an in-progress item doesn't count twice
+	excess, err = db.CheckWIP([]string{running.ID, a.ID})
+	if err != nil {
+		t.Fatalf("CheckWIP failed: %v", err)
+	}
+	if len(excess) != 0 {
+		t.Errorf("expected to fit within limit, got %+v", excess)
+	}
+
+	excess, err = db.CheckWIP([]string{a.ID, b.ID, other.ID})
+	if err != nil {
+		t.Fatalf("CheckWIP failed: %v", err)
+	}
+	want := WIPExcess{Project: "wip", InProgress: 3, Limit: 2}
+	if len(excess) != 1 || excess[0] != want {
+		t.Errorf("excess = %+v, want [%+v]", excess, want)
+	}
+
+	report, err := db.ProjectStatus("wip")
+	if err != nil {
+		t.Fatalf("ProjectStatus failed: %v", err)
+	}
+	if report.WIPLimit != 2 {
+		t.Errorf("report WIPLimit = %d, want 2", report.WIPLimit)
+	}
+
+	if err := db.SetWIPLimit("missing", 1); err == nil {
+		t.Error("expected error for missing project")
+	}
+	if err := db.SetWIPLimit("wip", -1); err == nil {
+		t.Error("expected error for negative limit")
+	}
+}
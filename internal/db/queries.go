@@ -1,23 +1,97 @@
 package db
 
 import (
-	"database/sql"
 	"fmt"
+	"iter"
+	"slices"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/baiirun/prog/internal/model"
 )
 
 // ListFilter contains optional filters for listing items.
 type ListFilter struct {
-	Project     string        // Filter by project
-	Status      *model.Status // Filter by status
-	Parent      string        // Filter by parent epic ID
-	Type        string        // Filter by item type (task, epic)
-	Blocking    string        // Show items that block this ID
-	BlockedBy   string        // Show items blocked by this ID
-	HasBlockers bool          // Show only items with unresolved blockers
-	NoBlockers  bool          // Show only items with no blockers
-	Labels      []string      // Filter by label names (AND - items must have all)
+	Project         string         // Filter by project
+	Status          *model.Status  // Filter by status
+	ExcludeStatuses []model.Status // Leave out items with any of these statuses
+	Parent          string         // Filter by parent epic ID; must name an epic
+	Type            string         // Filter by item type (task, epic)
+	Blocking        string         // Show items that block this ID
+	BlockedBy       string         // Show items blocked by this ID
+	HasBlockers     bool           // Show only items with unresolved blockers
+	NoBlockers      bool           // Show only items with no blockers
+	EmptyEpics      bool           // Show only epics with no children
+	Labels          []string       // Filter by label names (AND - items must have all)
+	Tag             string         // Filter by tag (normalized to lowercase)
+	Sort            string         // Sort key: priority (default), created, updated, status
+	Reverse         bool           // Flip the sort order
+	IncludeArchived bool           // Include archived items (hidden by default)
+	IncludeSnoozed  bool           // Include items snoozed into the future (hidden by default)
+	Priority        int            // Exact priority (1-3); 0 means any
+	MinPriority     int            // Only items at least this urgent (priority <= MinPriority); 0 means any
+	Limit           int            // Maximum items to return; 0 means no limit
+	Offset          int            // Items to skip before the first returned
+	Since           time.Time      // Only items whose DateField is at or after this; zero means any
+	Until           time.Time      // Only items whose DateField is before this; zero means any
+	DateField       string         // Timestamp Since and Until apply to: created (default) or updated
+}
+
+// inDateRange reports whether item's DateField timestamp falls within the
+// filter's Since/Until window.
+func (f ListFilter) inDateRange(item model.Item) bool {
+	t := item.CreatedAt
+	if f.DateField == "updated" {
+		t = item.UpdatedAt
+	}
+	return (f.Since.IsZero() || !t.Before(f.Since)) && (f.Until.IsZero() || t.Before(f.Until))
+}
+
+func (f ListFilter) hasDateRange() bool {
+	return !f.Since.IsZero() || !f.Until.IsZero()
+}
+
+// sortTerm is one column of an ORDER BY clause.
+type sortTerm struct {
+	expr string
+	desc bool
+}
+
+// listSortOrders maps sort keys to ORDER BY terms. Only whitelisted keys are
+// accepted so user input never reaches the SQL string.
+var listSortOrders = map[string][]sortTerm{
+	"priority": {{"priority", false}, {"created_at", true}},
+	"created":  {{"created_at", true}},
+	"updated":  {{"updated_at", true}},
+	"status": {
+		{"CASE status WHEN 'in_progress' THEN 0 WHEN 'open' THEN 1 WHEN 'blocked' THEN 2 WHEN 'done' THEN 3 ELSE 4 END", false},
+		{"priority", false},
+		{"created_at", true},
+	},
+}
+
+// listOrderBy builds the ORDER BY clause for a sort key.
+func listOrderBy(sortKey string, reverse bool) (string, error) {
+	if sortKey == "" {
+		sortKey = "priority"
+	}
+	terms, ok := listSortOrders[sortKey]
+	if !ok {
+		return "", invalidf("invalid sort: %s (valid: priority, created, updated, status)", sortKey)
+	}
+
+	// Break ties by id so pages from LIMIT/OFFSET don't overlap or skip items
+	terms = append(terms[:len(terms):len(terms)], sortTerm{"id", false})
+	parts := make([]string, len(terms))
+	for i, term := range terms {
+		dir := "ASC"
+		if term.desc != reverse {
+			dir = "DESC"
+		}
+		parts[i] = term.expr + " " + dir
+	}
+	return " ORDER BY " + strings.Join(parts, ", "), nil
 }
 
 // ListItems returns items filtered by project and/or status.
@@ -25,30 +99,147 @@ func (db *DB) ListItems(project string, status *model.Status) ([]model.Item, err
 	return db.ListItemsFiltered(ListFilter{Project: project, Status: status})
 }
 
-// ListItemsFiltered returns items matching the given filters.
+// ListItemsFiltered returns items matching the given filters, ordered by
+// filter.Sort (priority, then newest first, by default).
 func (db *DB) ListItemsFiltered(filter ListFilter) ([]model.Item, error) {
-	query := `SELECT id, project, type, title, description, status, priority, parent_id, created_at, updated_at FROM items WHERE 1=1`
+	orderBy, err := listOrderBy(filter.Sort, filter.Reverse)
+	if err != nil {
+		return nil, err
+	}
+	if filter.Limit < 0 || filter.Offset < 0 {
+		return nil, invalidf("limit and offset cannot be negative")
+	}
+
+	where, args, err := db.listWhere(filter)
+	if err != nil {
+		return nil, err
+	}
+	query := `SELECT ` + itemColumns + ` FROM items` + where + orderBy
+	if filter.hasDateRange() {
+		return db.listItemsInDateRange(filter, query, args)
+	}
+	if filter.Limit > 0 {
+		query += ` LIMIT ? OFFSET ?`
+		args = append(args, filter.Limit, filter.Offset)
+	} else if filter.Offset > 0 {
+		query += ` LIMIT -1 OFFSET ?`
+		args = append(args, filter.Offset)
+	}
+
+	return db.queryItems(query, args...)
+}
+
+// listItemsInDateRange runs query and applies the filter's date window, then
+// its offset and limit. The window is checked in Go: stored timestamps may
+// carry different zone offsets, so SQL string comparison isn't reliable.
+func (db *DB) listItemsInDateRange(filter ListFilter, query string, args []any) ([]model.Item, error) {
+	items, err := db.queryItems(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	var matched []model.Item
+	for _, item := range items {
+		if filter.inDateRange(item) {
+			matched = append(matched, item)
+		}
+	}
+	if filter.Offset >= len(matched) {
+		return nil, nil
+	}
+	matched = matched[filter.Offset:]
+	if filter.Limit > 0 && filter.Limit < len(matched) {
+		matched = matched[:filter.Limit]
+	}
+	return matched, nil
+}
+
+// CountItemsFiltered returns how many items match filter, ignoring its
+// sort, limit and offset.
+func (db *DB) CountItemsFiltered(filter ListFilter) (int, error) {
+	if filter.hasDateRange() {
+		filter.Limit, filter.Offset = 0, 0
+		items, err := db.ListItemsFiltered(filter)
+		return len(items), err
+	}
+	where, args, err := db.listWhere(filter)
+	if err != nil {
+		return 0, err
+	}
+	var count int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM items`+where, args...).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count items: %w", err)
+	}
+	return count, nil
+}
+
+// listWhere builds the WHERE clause and arguments for filter's conditions.
+func (db *DB) listWhere(filter ListFilter) (string, []any, error) {
+	query := ` WHERE 1=1`
 	args := []any{}
 
+	if filter.DateField != "" && filter.DateField != "created" && filter.DateField != "updated" {
+		return "", nil, invalidf("invalid date field: %s (valid: created, updated)", filter.DateField)
+	}
+
+	if !filter.IncludeArchived {
+		query += ` AND archived = 0`
+	}
+	if !filter.IncludeSnoozed {
+		now := db.Now()
+		query += ` AND ` + notSnoozed
+		args = append(args, snoozeTime(&now))
+	}
 	if filter.Project != "" {
 		query += ` AND project = ?`
 		args = append(args, filter.Project)
 	}
 	if filter.Status != nil {
 		if !filter.Status.IsValid() {
-			return nil, fmt.Errorf("invalid status: %s", *filter.Status)
+			return "", nil, fmt.Errorf("%w: %s", ErrInvalidStatus, *filter.Status)
 		}
 		query += ` AND status = ?`
 		args = append(args, *filter.Status)
 	}
+	if len(filter.ExcludeStatuses) > 0 {
+		placeholders := make([]string, len(filter.ExcludeStatuses))
+		for i, s := range filter.ExcludeStatuses {
+			if !s.IsValid() {
+				return "", nil, fmt.Errorf("%w: %s", ErrInvalidStatus, s)
+			}
+			placeholders[i] = "?"
+			args = append(args, s)
+		}
+		query += ` AND status NOT IN (` + strings.Join(placeholders, ", ") + `)`
+	}
 	if filter.Parent != "" {
+		parent, err := db.GetItem(filter.Parent)
+		if err != nil {
+			return "", nil, err
+		}
+		if parent.Type != model.ItemTypeEpic {
+			return "", nil, invalidf("not an epic: %s is a %s", filter.Parent, parent.Type)
+		}
 		query += ` AND parent_id = ?`
 		args = append(args, filter.Parent)
 	}
+	if filter.Priority != 0 {
+		if !model.ValidPriority(filter.Priority) {
+			return "", nil, invalidf("invalid priority: %d (valid: 1=high, 2=medium, 3=low)", filter.Priority)
+		}
+		query += ` AND priority = ?`
+		args = append(args, filter.Priority)
+	}
+	if filter.MinPriority != 0 {
+		if !model.ValidPriority(filter.MinPriority) {
+			return "", nil, invalidf("invalid priority: %d (valid: 1=high, 2=medium, 3=low)", filter.MinPriority)
+		}
+		query += ` AND priority <= ?`
+		args = append(args, filter.MinPriority)
+	}
 	if filter.Type != "" {
 		itemType := model.ItemType(filter.Type)
 		if !itemType.IsValid() {
-			return nil, fmt.Errorf("invalid type: %s (valid: task, epic)", filter.Type)
+			return "", nil, invalidf("invalid type: %s (valid: task, epic)", filter.Type)
 		}
 		query += ` AND type = ?`
 		args = append(args, filter.Type)
@@ -71,6 +262,9 @@ func (db *DB) ListItemsFiltered(filter ListFilter) ([]model.Item, error) {
 		// Items with no blockers (either no deps, or all deps are done)
 		query += ` AND id NOT IN (SELECT d.item_id FROM deps d JOIN items i ON d.depends_on = i.id WHERE i.status != 'done')`
 	}
+	if filter.EmptyEpics {
+		query += ` AND type = 'epic' AND id NOT IN (SELECT parent_id FROM items WHERE parent_id IS NOT NULL)`
+	}
 	if len(filter.Labels) > 0 {
 		// Items must have ALL specified labels (AND semantics)
 		// Build placeholder list for IN clause
@@ -93,28 +287,240 @@ func (db *DB) ListItemsFiltered(filter ListFilter) ([]model.Item, error) {
 		}
 		args = append(args, len(filter.Labels))
 	}
-	query += ` ORDER BY priority ASC, created_at ASC`
+	if filter.Tag != "" {
+		query += ` AND id IN (SELECT item_id FROM tags WHERE tag = ?)`
+		args = append(args, NormalizeTag(filter.Tag))
+	}
+	return query, args, nil
+}
 
-	return db.queryItems(query, args...)
+// ListChildren returns the direct children of an epic.
+func (db *DB) ListChildren(epicID string) ([]model.Item, error) {
+	return db.ListItemsFiltered(ListFilter{Parent: epicID})
+}
+
+// EmptyEpics returns the epics in project (all projects if empty) that have
+// no children at all, e.g. scaffolding that was never filled in. Archived
+// epics are skipped.
+func (db *DB) EmptyEpics(project string) ([]model.Item, error) {
+	return db.ListItemsFiltered(ListFilter{Project: project, EmptyEpics: true})
+}
+
+// TreeNode is an item with its children, for hierarchical display.
+type TreeNode struct {
+	Item     model.Item
+	Children []*TreeNode
+}
+
+// ItemTree loads a project's items in one query and assembles the parent/child
+// hierarchy. Roots are epics first, then tasks without a parent (or whose
+// parent isn't in the result set), each in list order.
+func (db *DB) ItemTree(project string) ([]*TreeNode, error) {
+	items, err := db.ListItemsFiltered(ListFilter{Project: project})
+	if err != nil {
+		return nil, err
+	}
+
+	nodes := make(map[string]*TreeNode, len(items))
+	for _, item := range items {
+		nodes[item.ID] = &TreeNode{Item: item}
+	}
+
+	var epics, orphans []*TreeNode
+	for _, item := range items {
+		node := nodes[item.ID]
+		if item.ParentID != nil {
+			if parent, ok := nodes[*item.ParentID]; ok {
+				parent.Children = append(parent.Children, node)
+				continue
+			}
+		}
+		if item.Type == model.ItemTypeEpic {
+			epics = append(epics, node)
+		} else {
+			orphans = append(orphans, node)
+		}
+	}
+	return append(epics, orphans...), nil
+}
+
+// SearchItems returns items whose title or description contains query
+// (case-insensitive). Title matches rank above description-only matches.
+func (db *DB) SearchItems(project, query string) ([]model.Item, error) {
+	// Escape LIKE wildcards so the query is matched literally
+	escaped := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(query)
+	pattern := "%" + escaped + "%"
+
+	sqlQuery := `
+		SELECT ` + itemColumns + `
+		FROM items
+		WHERE (title LIKE ? ESCAPE '\' OR description LIKE ? ESCAPE '\')`
+	args := []any{pattern, pattern}
+
+	if project != "" {
+		sqlQuery += ` AND project = ?`
+		args = append(args, project)
+	}
+	sqlQuery += ` ORDER BY CASE WHEN title LIKE ? ESCAPE '\' THEN 0 ELSE 1 END, priority ASC, created_at ASC`
+	args = append(args, pattern)
+
+	return db.queryItems(sqlQuery, args...)
+}
+
+// OverdueItems returns items past their due date that aren't done or canceled,
+// most overdue first.
+func (db *DB) OverdueItems(project string, now time.Time) ([]model.Item, error) {
+	query := `SELECT ` + itemColumns + ` FROM items
+		WHERE due_at IS NOT NULL AND status NOT IN ('done', 'canceled') AND archived = 0`
+	args := []any{}
+	if project != "" {
+		query += ` AND project = ?`
+		args = append(args, project)
+	}
+
+	items, err := db.queryItems(query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	// Compare in Go: stored timestamps may carry different zone offsets,
+	// so SQL string comparison isn't reliable.
+	var overdue []model.Item
+	for _, item := range items {
+		if item.DueAt.Before(now) {
+			overdue = append(overdue, item)
+		}
+	}
+	sort.SliceStable(overdue, func(i, j int) bool {
+		return overdue[i].DueAt.Before(*overdue[j].DueAt)
+	})
+	return overdue, nil
+}
+
+// StaleAfter is how long an in-progress item can go without an update
+// before status reports count it as stale.
+const StaleAfter = 3 * 24 * time.Hour
+
+// StaleInProgress returns in-progress items whose last update is more than
+// olderThan ago, least recently updated first. These are usually work an
+// agent or person started and then abandoned.
+func (db *DB) StaleInProgress(project string, olderThan time.Duration) ([]model.Item, error) {
+	if olderThan < 0 {
+		return nil, fmt.Errorf("invalid threshold: %s (must not be negative)", olderThan)
+	}
+	status := model.StatusInProgress
+	items, err := db.ListItemsFiltered(ListFilter{Project: project, Status: &status})
+	if err != nil {
+		return nil, err
+	}
+	stale := staleItems(items, db.Now().Add(-olderThan))
+	sort.SliceStable(stale, func(i, j int) bool {
+		return stale[i].UpdatedAt.Before(stale[j].UpdatedAt)
+	})
+	return stale, nil
+}
+
+// staleItems returns the items last updated before cutoff. Compare in Go:
+// stored timestamps may carry different zone offsets.
+func staleItems(items []model.Item, cutoff time.Time) []model.Item {
+	var stale []model.Item
+	for _, item := range items {
+		if item.UpdatedAt.Before(cutoff) {
+			stale = append(stale, item)
+		}
+	}
+	return stale
 }
 
-// ReadyItems returns items that are open and have no unmet dependencies.
+// WeekCount is the number of items completed in the week beginning Start.
+type WeekCount struct {
+	Start time.Time // Monday 00:00 in the local time zone
+	Count int
+}
+
+// CompletionStats counts done items per week from the week containing since
+// through the current week, oldest first. Weeks with no completions are
+// included with a zero count. Items are bucketed by when they were marked done.
+func (db *DB) CompletionStats(project string, since time.Time) ([]WeekCount, error) {
+	query := `SELECT done_at FROM items WHERE status = 'done' AND done_at IS NOT NULL`
+	args := []any{}
+	if project != "" {
+		query += ` AND project = ?`
+		args = append(args, project)
+	}
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query completions: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	first := weekStart(since)
+	last := weekStart(db.Now())
+	var weeks []WeekCount
+	for w := first; !w.After(last); w = w.AddDate(0, 0, 7) {
+		weeks = append(weeks, WeekCount{Start: w})
+	}
+
+	// Bucket in Go: stored timestamps may carry different zone offsets
+	for rows.Next() {
+		var doneAt time.Time
+		if err := rows.Scan(&doneAt); err != nil {
+			return nil, fmt.Errorf("failed to scan completion: %w", err)
+		}
+		w := weekStart(doneAt)
+		if w.Before(first) || w.After(last) {
+			continue
+		}
+		weeks[int(w.Sub(first).Hours()/24+0.5)/7].Count++
+	}
+	return weeks, rows.Err()
+}
+
+// weekStart returns local midnight on the Monday of t's week.
+func weekStart(t time.Time) time.Time {
+	t = t.In(time.Local)
+	offset := (int(t.Weekday()) + 6) % 7 // days since Monday
+	return time.Date(t.Year(), t.Month(), t.Day()-offset, 0, 0, 0, 0, time.Local)
+}
+
+// ReadyItems returns items that are open and whose dependencies are all done,
+// transitively: an incomplete dependency anywhere in the chain hides the item.
 func (db *DB) ReadyItems(project string) ([]model.Item, error) {
 	return db.ReadyItemsFiltered(project, nil)
 }
 
 // ReadyItemsFiltered returns ready items with optional label filtering.
+// Snoozed items are left out until their snooze ends.
 func (db *DB) ReadyItemsFiltered(project string, labels []string) ([]model.Item, error) {
+	return db.ReadyItemsTop(project, labels, 0)
+}
+
+// ReadyItemsTop is ReadyItemsFiltered limited to the n highest-priority
+// items (oldest first within a priority). n of 0 means no limit.
+func (db *DB) ReadyItemsTop(project string, labels []string, n int) ([]model.Item, error) {
+	if n < 0 {
+		return nil, fmt.Errorf("invalid limit: %d (must not be negative)", n)
+	}
+	// dep_chain holds every (item, ancestor) pair reachable through deps.
+	// UNION (not UNION ALL) discards repeats, so legacy cycles terminate.
 	query := `
-		SELECT id, project, type, title, description, status, priority, parent_id, created_at, updated_at
+		WITH RECURSIVE dep_chain(item_id, depends_on) AS (
+		    SELECT item_id, depends_on FROM deps
+		    UNION
+		    SELECT c.item_id, d.depends_on FROM dep_chain c
+		    JOIN deps d ON d.item_id = c.depends_on
+		)
+		SELECT ` + itemColumns + `
 		FROM items
-		WHERE status = 'open'
+		WHERE status = 'open' AND archived = 0 AND ` + notSnoozed + `
 		  AND id NOT IN (
-		    SELECT d.item_id FROM deps d
-		    JOIN items i ON d.depends_on = i.id
+		    SELECT c.item_id FROM dep_chain c
+		    JOIN items i ON c.depends_on = i.id
 		    WHERE i.status != 'done'
 		  )`
-	args := []any{}
+	now := db.Now()
+	args := []any{snoozeTime(&now)}
 
 	if project != "" {
 		query += ` AND project = ?`
@@ -142,23 +548,240 @@ func (db *DB) ReadyItemsFiltered(project string, labels []string) ([]model.Item,
 		args = append(args, len(labels))
 	}
 	query += ` ORDER BY priority ASC, created_at ASC`
+	if n > 0 {
+		query += ` LIMIT ?`
+		args = append(args, n)
+	}
 
 	return db.queryItems(query, args...)
 }
 
+// Ready score weights, tunable. ReadyScored ranks each ready item by
+//
+//	score = readyPriorityWeight * (4 - priority)
+//	      + readyAgeWeight * min(days since created, readyMaxAgeDays)
+//	      + readyDependentWeight * direct dependents
+//
+// so a high-priority task scores 30, a week of waiting adds 3.5, and each
+// task waiting on it adds 5. Age is capped so old low-priority work drifts
+// up without ever outranking fresh high-priority work on age alone.
+const (
+	readyPriorityWeight  = 10.0
+	readyAgeWeight       = 0.5
+	readyMaxAgeDays      = 30.0
+	readyDependentWeight = 5.0
+)
+
+// ScoredItem is a ready item with its rank from ReadyScored.
+type ScoredItem struct {
+	model.Item
+	Score      float64
+	Dependents int // items that depend directly on this one
+}
+
+// ReadyScored returns ready items ranked by score, highest first.
+func (db *DB) ReadyScored(project string) ([]ScoredItem, error) {
+	return db.ReadyScoredFiltered(project, nil)
+}
+
+// ReadyScoredFiltered returns ready items with optional label filtering,
+// ranked by score. Equal scores keep the ReadyItems order.
+func (db *DB) ReadyScoredFiltered(project string, labels []string) ([]ScoredItem, error) {
+	items, err := db.ReadyItemsFiltered(project, labels)
+	if err != nil {
+		return nil, err
+	}
+
+	now := db.Now()
+	scored := make([]ScoredItem, 0, len(items))
+	for _, item := range items {
+		dependents, err := db.GetDependents(item.ID)
+		if err != nil {
+			return nil, err
+		}
+		ageDays := min(max(now.Sub(item.CreatedAt).Hours()/24, 0), readyMaxAgeDays)
+		score := readyPriorityWeight*float64(4-item.Priority) +
+			readyAgeWeight*ageDays +
+			readyDependentWeight*float64(len(dependents))
+		scored = append(scored, ScoredItem{Item: item, Score: score, Dependents: len(dependents)})
+	}
+	sort.SliceStable(scored, func(i, j int) bool {
+		return scored[i].Score > scored[j].Score
+	})
+	return scored, nil
+}
+
+// IndependentReady groups the ready items of project into sets that can be
+// worked in parallel. Two ready items land in the same group when they are
+// linked through unfinished dependencies in either direction, such as by a
+// task waiting on both, so items in different groups never touch the same
+// work. A done prerequisite links nothing: it no longer holds anyone up.
+// Groups and the items within them keep the ReadyItems order.
+func (db *DB) IndependentReady(project string) ([][]model.Item, error) {
+	return db.IndependentReadyFiltered(project, nil)
+}
+
+// IndependentReadyFiltered is IndependentReady with optional label
+// filtering. Filtered-out items still link the groups they connect.
+func (db *DB) IndependentReadyFiltered(project string, labels []string) ([][]model.Item, error) {
+	items, err := db.ReadyItemsFiltered(project, labels)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.Query(`
+		SELECT d.item_id, d.depends_on FROM deps d
+		JOIN items i ON d.depends_on = i.id
+		WHERE i.status != 'done'`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get dependencies: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	// Union-find over the undirected graph of unfinished dependencies
+	parent := make(map[string]string)
+	var find func(id string) string
+	find = func(id string) string {
+		p, ok := parent[id]
+		if !ok || p == id {
+			return id
+		}
+		root := find(p)
+		parent[id] = root
+		return root
+	}
+	for rows.Next() {
+		var a, b string
+		if err := rows.Scan(&a, &b); err != nil {
+			return nil, fmt.Errorf("failed to scan dependency: %w", err)
+		}
+		if ra, rb := find(a), find(b); ra != rb {
+			parent[ra] = rb
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get dependencies: %w", err)
+	}
+
+	var groups [][]model.Item
+	index := make(map[string]int)
+	for _, item := range items {
+		root := find(item.ID)
+		i, ok := index[root]
+		if !ok {
+			i = len(groups)
+			index[root] = i
+			groups = append(groups, nil)
+		}
+		groups[i] = append(groups[i], item)
+	}
+	return groups, nil
+}
+
+// BlockedItem is a blocked item with what is holding it up.
+type BlockedItem struct {
+	model.Item
+	BlockedSince time.Time // last move to blocked, or UpdatedAt if unrecorded
+	WaitingOn    []string  // dependencies that aren't done
+}
+
+// BlockedItems returns unarchived blocked items with their unfinished
+// dependencies, blocked longest first.
+func (db *DB) BlockedItems(project string) ([]BlockedItem, error) {
+	status := model.StatusBlocked
+	items, err := db.ListItemsFiltered(ListFilter{Project: project, Status: &status})
+	if err != nil {
+		return nil, err
+	}
+
+	blocked := make([]BlockedItem, 0, len(items))
+	for _, item := range items {
+		b := BlockedItem{Item: item, BlockedSince: item.UpdatedAt}
+		history, err := db.GetStatusHistory(item.ID)
+		if err != nil {
+			return nil, err
+		}
+		for _, c := range slices.Backward(history) {
+			if c.NewStatus == model.StatusBlocked && !c.Undone {
+				b.BlockedSince = c.ChangedAt
+				break
+			}
+		}
+		if b.WaitingOn, err = db.unfinishedDeps(item.ID); err != nil {
+			return nil, err
+		}
+		blocked = append(blocked, b)
+	}
+
+	// Compare in Go: stored timestamps may carry different zone offsets
+	sort.SliceStable(blocked, func(i, j int) bool {
+		return blocked[i].BlockedSince.Before(blocked[j].BlockedSince)
+	})
+	return blocked, nil
+}
+
+// unfinishedDeps returns the ids itemID depends on that aren't done.
+func (db *DB) unfinishedDeps(itemID string) ([]string, error) {
+	rows, err := db.Query(`
+		SELECT d.depends_on FROM deps d
+		JOIN items i ON i.id = d.depends_on
+		WHERE d.item_id = ? AND i.status != 'done'
+		ORDER BY d.depends_on`, itemID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get dependencies: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan dependency: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
 // StatusReport contains aggregated project status.
 type StatusReport struct {
-	Project      string
-	Open         int
-	InProgress   int
-	Blocked      int
-	Done         int
-	Canceled     int
-	Ready        int
-	RecentDone   []model.Item // last 3 completed
-	InProgItems  []model.Item // current in-progress
-	BlockedItems []model.Item // blocked with reasons
-	ReadyItems   []model.Item // ready for work
+	Project           string
+	Open              int
+	InProgress        int
+	Blocked           int
+	Done              int
+	Canceled          int
+	Ready             int
+	EstimateMinutes   int          // sum of estimates
+	ActualMinutes     int          // sum of time logged
+	RemainingEstimate int          // estimates of open, in-progress and blocked items
+	CompletedEstimate int          // estimates of done items
+	RecentDone        []model.Item // last 3 completed
+	InProgItems       []model.Item // current in-progress
+	BlockedItems      []model.Item // blocked with reasons
+	ReadyItems        []model.Item // ready for work
+	Epics             []EpicStatus // open epics with child completion
+	WIPLimit          int          // project's in-progress limit; 0 means none
+	Stale             int          // in-progress items not updated within StaleAfter
+}
+
+// EpicStatus pairs an epic with the completion counts of its children.
+type EpicStatus struct {
+	Epic  model.Item
+	Done  int
+	Total int
+}
+
+// EpicProgress counts an epic's direct children and how many of them are done.
+// Children in any status other than done count toward total only.
+func (db *DB) EpicProgress(epicID string) (done, total int, err error) {
+	err = db.QueryRow(`
+		SELECT COUNT(*), COALESCE(SUM(CASE WHEN status = 'done' THEN 1 ELSE 0 END), 0)
+		FROM items WHERE parent_id = ?`, epicID).Scan(&total, &done)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to count children: %w", err)
+	}
+	return done, total, nil
 }
 
 // ProjectStatus returns an aggregated status report for a project.
@@ -169,6 +792,13 @@ func (db *DB) ProjectStatus(project string) (*StatusReport, error) {
 // ProjectStatusFiltered returns an aggregated status report with optional label filtering.
 func (db *DB) ProjectStatusFiltered(project string, labels []string) (*StatusReport, error) {
 	report := &StatusReport{Project: project}
+	if project != "" {
+		limit, err := db.WIPLimit(project)
+		if err != nil {
+			return nil, err
+		}
+		report.WIPLimit = limit
+	}
 
 	// Build label subquery for reuse
 	labelSubquery := ""
@@ -195,7 +825,8 @@ func (db *DB) ProjectStatusFiltered(project string, labels []string) (*StatusRep
 	}
 
 	// Count by status
-	query := `SELECT status, COUNT(*) FROM items WHERE 1=1`
+	query := `SELECT status, COUNT(*), COALESCE(SUM(estimate_minutes), 0), COALESCE(SUM(actual_minutes), 0)
+		FROM items WHERE 1=1`
 	args := []any{}
 	if project != "" {
 		query += ` AND project = ?`
@@ -215,19 +846,25 @@ func (db *DB) ProjectStatusFiltered(project string, labels []string) (*StatusRep
 
 	for rows.Next() {
 		var status string
-		var count int
-		if err := rows.Scan(&status, &count); err != nil {
+		var count, estimate, actual int
+		if err := rows.Scan(&status, &count, &estimate, &actual); err != nil {
 			return nil, fmt.Errorf("failed to scan status count: %w", err)
 		}
+		report.EstimateMinutes += estimate
+		report.ActualMinutes += actual
 		switch model.Status(status) {
 		case model.StatusOpen:
 			report.Open = count
+			report.RemainingEstimate += estimate
 		case model.StatusInProgress:
 			report.InProgress = count
+			report.RemainingEstimate += estimate
 		case model.StatusBlocked:
 			report.Blocked = count
+			report.RemainingEstimate += estimate
 		case model.StatusDone:
 			report.Done = count
+			report.CompletedEstimate = estimate
 		case model.StatusCanceled:
 			report.Canceled = count
 		}
@@ -247,6 +884,7 @@ func (db *DB) ProjectStatusFiltered(project string, labels []string) (*StatusRep
 	if err != nil {
 		return nil, err
 	}
+	report.Stale = len(staleItems(report.InProgItems, db.Now().Add(-StaleAfter)))
 
 	// Get blocked items
 	blockedStatus := model.StatusBlocked
@@ -257,7 +895,7 @@ func (db *DB) ProjectStatusFiltered(project string, labels []string) (*StatusRep
 
 	// Get recent done (last 3)
 	recentQuery := `
-		SELECT id, project, type, title, description, status, priority, parent_id, created_at, updated_at
+		SELECT ` + itemColumns + `
 		FROM items WHERE status = 'done'`
 	recentArgs := []any{}
 	if project != "" {
@@ -274,6 +912,22 @@ func (db *DB) ProjectStatusFiltered(project string, labels []string) (*StatusRep
 		return nil, err
 	}
 
+	// Get progress for epics that are still being worked on
+	epics, err := db.ListItemsFiltered(ListFilter{Project: project, Type: string(model.ItemTypeEpic), Labels: labels})
+	if err != nil {
+		return nil, err
+	}
+	for _, epic := range epics {
+		if epic.Status == model.StatusDone || epic.Status == model.StatusCanceled {
+			continue
+		}
+		done, total, err := db.EpicProgress(epic.ID)
+		if err != nil {
+			return nil, err
+		}
+		report.Epics = append(report.Epics, EpicStatus{Epic: epic, Done: done, Total: total})
+	}
+
 	return report, nil
 }
 
@@ -296,6 +950,44 @@ func (db *DB) ListProjects() ([]string, error) {
 	return projects, rows.Err()
 }
 
+// ProjectSummary is a project with its item counts by status.
+type ProjectSummary struct {
+	Name       string
+	Open       int
+	InProgress int
+	Blocked    int
+	Done       int
+}
+
+// ProjectSummaries returns every project with its open, in-progress, blocked
+// and done item counts, sorted by most open items first, then by name.
+func (db *DB) ProjectSummaries() ([]ProjectSummary, error) {
+	rows, err := db.Query(`
+		SELECT p.name,
+		       COALESCE(SUM(i.status = 'open'), 0),
+		       COALESCE(SUM(i.status = 'in_progress'), 0),
+		       COALESCE(SUM(i.status = 'blocked'), 0),
+		       COALESCE(SUM(i.status = 'done'), 0)
+		FROM projects p
+		LEFT JOIN items i ON i.project = p.name
+		GROUP BY p.name
+		ORDER BY 2 DESC, p.name`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query projects: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var summaries []ProjectSummary
+	for rows.Next() {
+		var s ProjectSummary
+		if err := rows.Scan(&s.Name, &s.Open, &s.InProgress, &s.Blocked, &s.Done); err != nil {
+			return nil, fmt.Errorf("failed to scan project: %w", err)
+		}
+		summaries = append(summaries, s)
+	}
+	return summaries, rows.Err()
+}
+
 // queryItems is a helper to scan item rows.
 func (db *DB) queryItems(query string, args ...any) ([]model.Item, error) {
 	rows, err := db.Query(query, args...)
@@ -306,18 +998,47 @@ func (db *DB) queryItems(query string, args ...any) ([]model.Item, error) {
 
 	var items []model.Item
 	for rows.Next() {
-		var item model.Item
-		var parentID sql.NullString
-		if err := rows.Scan(
-			&item.ID, &item.Project, &item.Type, &item.Title, &item.Description,
-			&item.Status, &item.Priority, &parentID, &item.CreatedAt, &item.UpdatedAt,
-		); err != nil {
+		item, err := scanItem(rows)
+		if err != nil {
 			return nil, fmt.Errorf("failed to scan item: %w", err)
 		}
-		if parentID.Valid {
-			item.ParentID = &parentID.String
-		}
 		items = append(items, item)
 	}
 	return items, rows.Err()
 }
+
+// AllItems streams every item (optionally scoped to a project) ordered by
+// creation time, without loading the full result set into memory.
+// Iteration stops at the first error, which is yielded with a zero item.
+func (db *DB) AllItems(project string) iter.Seq2[model.Item, error] {
+	return func(yield func(model.Item, error) bool) {
+		query := `SELECT ` + itemColumns + ` FROM items`
+		args := []any{}
+		if project != "" {
+			query += ` WHERE project = ?`
+			args = append(args, project)
+		}
+		query += ` ORDER BY created_at ASC, id ASC`
+
+		rows, err := db.Query(query, args...)
+		if err != nil {
+			yield(model.Item{}, fmt.Errorf("failed to query items: %w", err))
+			return
+		}
+		defer func() { _ = rows.Close() }()
+
+		for rows.Next() {
+			item, err := scanItem(rows)
+			if err != nil {
+				yield(model.Item{}, fmt.Errorf("failed to scan item: %w", err))
+				return
+			}
+			if !yield(item, nil) {
+				return
+			}
+		}
+		if err := rows.Err(); err != nil {
+			yield(model.Item{}, fmt.Errorf("failed to query items: %w", err))
+		}
+	}
+}
@@ -1,6 +1,10 @@
 package db
 
 import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
 	"testing"
 	"time"
 
@@ -128,6 +132,38 @@ func TestReadyItems(t *testing.T) {
 	}
 }
 
+func TestReadyItems_TransitiveDeps(t *testing.T) {
+	db := setupTestDB(t)
+
+	// a -> b -> c, where b is (prematurely) done but c is still open
+	a := createTestItemWithProject(t, db, "A", "test", model.StatusOpen, 2)
+	b := createTestItemWithProject(t, db, "B", "test", model.StatusDone, 2)
+	c := createTestItemWithProject(t, db, "C", "test", model.StatusOpen, 2)
+	if err := db.AddDep(a.ID, b.ID); err != nil {
+		t.Fatalf("failed to add dep: %v", err)
+	}
+	if err := db.AddDep(b.ID, c.ID); err != nil {
+		t.Fatalf("failed to add dep: %v", err)
+	}
+
+	ready, err := db.ReadyItems("test")
+	if err != nil {
+		t.Fatalf("failed to get ready: %v", err)
+	}
+	if len(ready) != 1 || ready[0].ID != c.ID {
+		t.Fatalf("expected only %s ready, got %v", c.ID, ready)
+	}
+
+	// Completing the deep dependency frees the top task
+	if err := db.UpdateStatus(c.ID, model.StatusDone); err != nil {
+		t.Fatalf("failed to update status: %v", err)
+	}
+	ready, _ = db.ReadyItems("test")
+	if len(ready) != 1 || ready[0].ID != a.ID {
+		t.Errorf("expected only %s ready, got %v", a.ID, ready)
+	}
+}
+
 func TestReadyItems_ProjectFilter(t *testing.T) {
 	db := setupTestDB(t)
 
@@ -145,6 +181,32 @@ func TestReadyItems_ProjectFilter(t *testing.T) {
 	}
 }
 
+func TestReadyItemsTop(t *testing.T) {
+	db := setupTestDB(t)
+
+	createTestItemWithProject(t, db, "Low", "proj1", model.StatusOpen, model.PriorityLow)
+	high := createTestItemWithProject(t, db, "High", "proj1", model.StatusOpen, model.PriorityHigh)
+	medium := createTestItemWithProject(t, db, "Medium", "proj1", model.StatusOpen, model.PriorityMedium)
+	createTestItemWithProject(t, db, "Other", "proj2", model.StatusOpen, model.PriorityHigh)
+
+	ready, err := db.ReadyItemsTop("proj1", nil, 2)
+	if err != nil {
+		t.Fatalf("ReadyItemsTop failed: %v", err)
+	}
+	if len(ready) != 2 || ready[0].ID != high.ID || ready[1].ID != medium.ID {
+		t.Fatalf("expected High then Medium, got %+v", ready)
+	}
+
+	ready, _ = db.ReadyItemsTop("proj1", nil, 0)
+	if len(ready) != 3 {
+		t.Errorf("expected all 3 ready items with no limit, got %d", len(ready))
+	}
+
+	if _, err := db.ReadyItemsTop("", nil, -1); err == nil {
+		t.Error("expected error for negative limit")
+	}
+}
+
 func TestProjectStatus(t *testing.T) {
 	db := setupTestDB(t)
 
@@ -247,6 +309,42 @@ func TestListItemsFiltered_Parent(t *testing.T) {
 	}
 }
 
+func TestListItemsFiltered_ParentWithStatus(t *testing.T) {
+	db := setupTestDB(t)
+
+	epic := createTestEpic(t, db, "Epic", "test")
+	open := createTestItemWithProject(t, db, "Open", "test", model.StatusOpen, 2)
+	done := createTestItemWithProject(t, db, "Done", "test", model.StatusDone, 2)
+	for _, id := range []string{open.ID, done.ID} {
+		if err := db.SetParent(id, epic.ID); err != nil {
+			t.Fatalf("failed to set parent: %v", err)
+		}
+	}
+
+	status := model.StatusOpen
+	items, err := db.ListItemsFiltered(ListFilter{Parent: epic.ID, Status: &status})
+	if err != nil {
+		t.Fatalf("failed to list: %v", err)
+	}
+	if len(items) != 1 || items[0].ID != open.ID {
+		t.Errorf("expected only the open child, got %v", items)
+	}
+}
+
+func TestListItemsFiltered_ParentNotEpic(t *testing.T) {
+	db := setupTestDB(t)
+	task := createTestItem(t, db, "Task")
+
+	_, err := db.ListItemsFiltered(ListFilter{Parent: task.ID})
+	if err == nil || !strings.Contains(err.Error(), "not an epic") {
+		t.Errorf("expected not-an-epic error, got %v", err)
+	}
+
+	if _, err := db.ListItemsFiltered(ListFilter{Parent: "ep-nope00"}); err == nil {
+		t.Error("expected error for nonexistent parent")
+	}
+}
+
 func TestListItemsFiltered_Type(t *testing.T) {
 	db := setupTestDB(t)
 
@@ -486,3 +584,972 @@ func TestListItemsFiltered_CombinedFilters(t *testing.T) {
 
 	_ = task3
 }
+
+func TestEpicProgress(t *testing.T) {
+	db := setupTestDB(t)
+
+	epic := createTestEpic(t, db, "Epic", "test")
+	statuses := []model.Status{model.StatusDone, model.StatusDone, model.StatusOpen, model.StatusCanceled}
+	for _, status := range statuses {
+		child := createTestItemWithProject(t, db, "Child", "test", status, 2)
+		if err := db.SetParent(child.ID, epic.ID); err != nil {
+			t.Fatalf("failed to set parent: %v", err)
+		}
+	}
+
+	done, total, err := db.EpicProgress(epic.ID)
+	if err != nil {
+		t.Fatalf("failed to get epic progress: %v", err)
+	}
+	if done != 2 {
+		t.Errorf("done = %d, want 2", done)
+	}
+	if total != 4 {
+		t.Errorf("total = %d, want 4", total)
+	}
+}
+
+func TestEpicProgress_NoChildren(t *testing.T) {
+	db := setupTestDB(t)
+
+	epic := createTestEpic(t, db, "Empty Epic", "test")
+
+	done, total, err := db.EpicProgress(epic.ID)
+	if err != nil {
+		t.Fatalf("failed to get epic progress: %v", err)
+	}
+	if done != 0 || total != 0 {
+		t.Errorf("progress = %d/%d, want 0/0", done, total)
+	}
+}
+
+func TestProjectStatus_Epics(t *testing.T) {
+	db := setupTestDB(t)
+
+	epic := createTestEpic(t, db, "Active Epic", "test")
+	closed := createTestEpic(t, db, "Closed Epic", "test")
+	if err := db.UpdateStatus(closed.ID, model.StatusDone); err != nil {
+		t.Fatalf("failed to close epic: %v", err)
+	}
+
+	child1 := createTestItemWithProject(t, db, "Child 1", "test", model.StatusDone, 2)
+	child2 := createTestItemWithProject(t, db, "Child 2", "test", model.StatusOpen, 2)
+	for _, child := range []*model.Item{child1, child2} {
+		if err := db.SetParent(child.ID, epic.ID); err != nil {
+			t.Fatalf("failed to set parent: %v", err)
+		}
+	}
+
+	report, err := db.ProjectStatus("test")
+	if err != nil {
+		t.Fatalf("failed to get status: %v", err)
+	}
+
+	if len(report.Epics) != 1 {
+		t.Fatalf("epics = %d, want 1 (done epics excluded)", len(report.Epics))
+	}
+	e := report.Epics[0]
+	if e.Epic.ID != epic.ID {
+		t.Errorf("epic = %s, want %s", e.Epic.ID, epic.ID)
+	}
+	if e.Done != 1 || e.Total != 2 {
+		t.Errorf("progress = %d/%d, want 1/2", e.Done, e.Total)
+	}
+}
+
+func TestSearchItems(t *testing.T) {
+	db := setupTestDB(t)
+
+	descMatch := createTestItemWithProject(t, db, "Session cleanup", "test", model.StatusOpen, 1)
+	if err := db.SetDescription(descMatch.ID, "Part of the Auth refactor"); err != nil {
+		t.Fatalf("failed to set description: %v", err)
+	}
+	titleMatch := createTestItemWithProject(t, db, "Auth refactor", "test", model.StatusOpen, 3)
+	createTestItemWithProject(t, db, "Unrelated", "test", model.StatusOpen, 1)
+	createTestItemWithProject(t, db, "auth refactor elsewhere", "other", model.StatusOpen, 1)
+
+	items, err := db.SearchItems("test", "AUTH refactor")
+	if err != nil {
+		t.Fatalf("failed to search: %v", err)
+	}
+	if len(items) != 2 {
+		t.Fatalf("expected 2 results, got %d", len(items))
+	}
+	// Title match ranks first despite lower priority
+	if items[0].ID != titleMatch.ID {
+		t.Errorf("first result = %s, want title match %s", items[0].ID, titleMatch.ID)
+	}
+	if items[1].ID != descMatch.ID {
+		t.Errorf("second result = %s, want description match %s", items[1].ID, descMatch.ID)
+	}
+
+	// Without a project, all projects are searched
+	items, _ = db.SearchItems("", "auth refactor")
+	if len(items) != 3 {
+		t.Errorf("expected 3 results across projects, got %d", len(items))
+	}
+}
+
+func TestSearchItems_LiteralWildcards(t *testing.T) {
+	db := setupTestDB(t)
+
+	createTestItemWithProject(t, db, "100% coverage", "test", model.StatusOpen, 2)
+	createTestItemWithProject(t, db, "100 tests", "test", model.StatusOpen, 2)
+
+	items, err := db.SearchItems("test", "100%")
+	if err != nil {
+		t.Fatalf("failed to search: %v", err)
+	}
+	if len(items) != 1 {
+		t.Errorf("expected %% to match literally, got %d results", len(items))
+	}
+}
+
+func TestOverdueItems(t *testing.T) {
+	db := setupTestDB(t)
+
+	now := time.Now()
+	due := func(title string, status model.Status, at time.Time) *model.Item {
+		t.Helper()
+		item := &model.Item{
+			ID:        model.GenerateID(model.ItemTypeTask),
+			Project:   "test",
+			Type:      model.ItemTypeTask,
+			Title:     title,
+			Status:    status,
+			Priority:  2,
+			DueAt:     &at,
+			CreatedAt: now,
+			UpdatedAt: now,
+		}
+		if err := db.CreateItem(item); err != nil {
+			t.Fatalf("failed to create item: %v", err)
+		}
+		return item
+	}
+
+	slightly := due("Slightly late", model.StatusOpen, now.Add(-time.Hour))
+	very := due("Very late", model.StatusInProgress, now.Add(-72*time.Hour))
+	due("Done late", model.StatusDone, now.Add(-48*time.Hour))
+	due("Future", model.StatusOpen, now.Add(24*time.Hour))
+	createTestItem(t, db, "No due date")
+
+	items, err := db.OverdueItems("test", now)
+	if err != nil {
+		t.Fatalf("failed to get overdue items: %v", err)
+	}
+	if len(items) != 2 {
+		t.Fatalf("expected 2 overdue items, got %d", len(items))
+	}
+	if items[0].ID != very.ID || items[1].ID != slightly.ID {
+		t.Errorf("order = [%s %s], want most overdue first [%s %s]", items[0].ID, items[1].ID, very.ID, slightly.ID)
+	}
+}
+
+func TestStaleInProgress(t *testing.T) {
+	db := setupTestDB(t)
+
+	now := time.Now()
+	touched := func(title string, status model.Status, at time.Time) *model.Item {
+		t.Helper()
+		item := &model.Item{
+			ID:        model.GenerateID(model.ItemTypeTask),
+			Project:   "test",
+			Type:      model.ItemTypeTask,
+			Title:     title,
+			Status:    status,
+			Priority:  2,
+			CreatedAt: at,
+			UpdatedAt: at,
+		}
+		if err := db.CreateItem(item); err != nil {
+			t.Fatalf("failed to create item: %v", err)
+		}
+		return item
+	}
+
+	week := touched("Abandoned", model.StatusInProgress, now.Add(-7*24*time.Hour))
+	days := touched("Forgotten", model.StatusInProgress, now.Add(-4*24*time.Hour))
+	touched("Active", model.StatusInProgress, now.Add(-time.Hour))
+	touched("Old but open", model.StatusOpen, now.Add(-30*24*time.Hour))
+
+	items, err := db.StaleInProgress("test", StaleAfter)
+	if err != nil {
+		t.Fatalf("StaleInProgress failed: %v", err)
+	}
+	if len(items) != 2 || items[0].ID != week.ID || items[1].ID != days.ID {
+		t.Fatalf("expected [Abandoned Forgotten], got %+v", items)
+	}
+
+	if items, _ := db.StaleInProgress("test", 5*24*time.Hour); len(items) != 1 {
+		t.Errorf("expected 1 item idle over 5 days, got %d", len(items))
+	}
+	if items, _ := db.StaleInProgress("other", StaleAfter); len(items) != 0 {
+		t.Errorf("expected no stale items in another project, got %d", len(items))
+	}
+
+	report, err := db.ProjectStatus("test")
+	if err != nil {
+		t.Fatalf("ProjectStatus failed: %v", err)
+	}
+	if report.Stale != 2 {
+		t.Errorf("report.Stale = %d, want 2", report.Stale)
+	}
+}
+
+func TestCreateItem_DueAtRoundTrip(t *testing.T) {
+	db := setupTestDB(t)
+
+	dueAt := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	item := &model.Item{
+		ID:      model.GenerateID(model.ItemTypeTask),
+		Project: "test",
+		Type:    model.ItemTypeTask,
+		Title:   "Due soon",
+		Status:  model.StatusOpen,
+		DueAt:   &dueAt,
+	}
+	if err := db.CreateItem(item); err != nil {
+		t.Fatalf("failed to create item: %v", err)
+	}
+
+	got, err := db.GetItem(item.ID)
+	if err != nil {
+		t.Fatalf("failed to get item: %v", err)
+	}
+	if got.DueAt == nil || !got.DueAt.Equal(dueAt) {
+		t.Errorf("due_at = %v, want %v", got.DueAt, dueAt)
+	}
+
+	// Items without a due date stay nil
+	other := createTestItem(t, db, "No due")
+	got, _ = db.GetItem(other.ID)
+	if got.DueAt != nil {
+		t.Errorf("expected nil due_at, got %v", got.DueAt)
+	}
+}
+
+func TestListItemsFiltered_Sort(t *testing.T) {
+	db := setupTestDB(t)
+
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	create := func(id string, status model.Status, priority int, created, updated time.Duration) {
+		t.Helper()
+		item := &model.Item{
+			ID:        id,
+			Project:   "test",
+			Type:      model.ItemTypeTask,
+			Title:     id,
+			Status:    status,
+			Priority:  priority,
+			CreatedAt: base.Add(created),
+			UpdatedAt: base.Add(updated),
+		}
+		if err := db.CreateItem(item); err != nil {
+			t.Fatalf("failed to create item: %v", err)
+		}
+	}
+	create("ts-a", model.StatusDone, 1, 1*time.Hour, 5*time.Hour)
+	create("ts-b", model.StatusOpen, 2, 2*time.Hour, 4*time.Hour)
+	create("ts-c", model.StatusInProgress, 2, 3*time.Hour, 6*time.Hour)
+	create("ts-d", model.StatusOpen, 1, 4*time.Hour, 1*time.Hour)
+
+	tests := []struct {
+		sort    string
+		reverse bool
+		want    []string
+	}{
+		{"", false, []string{"ts-d", "ts-a", "ts-c", "ts-b"}},
+		{"priority", true, []string{"ts-b", "ts-c", "ts-a", "ts-d"}},
+		{"created", false, []string{"ts-d", "ts-c", "ts-b", "ts-a"}},
+		{"created", true, []string{"ts-a", "ts-b", "ts-c", "ts-d"}},
+		{"updated", false, []string{"ts-c", "ts-a", "ts-b", "ts-d"}},
+		{"status", false, []string{"ts-c", "ts-d", "ts-b", "ts-a"}},
+	}
+
+	for _, tt := range tests {
+		items, err := db.ListItemsFiltered(ListFilter{Project: "test", Sort: tt.sort, Reverse: tt.reverse})
+		if err != nil {
+			t.Fatalf("sort %q: %v", tt.sort, err)
+		}
+		var got []string
+		for _, item := range items {
+			got = append(got, item.ID)
+		}
+		if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+			t.Errorf("sort %q reverse=%v: got %v, want %v", tt.sort, tt.reverse, got, tt.want)
+		}
+	}
+}
+
+func TestListItemsFiltered_InvalidSort(t *testing.T) {
+	db := setupTestDB(t)
+
+	_, err := db.ListItemsFiltered(ListFilter{Sort: "title; DROP TABLE items"})
+	if err == nil {
+		t.Fatal("expected error for unknown sort key")
+	}
+	if !strings.Contains(err.Error(), "invalid sort") {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestListItemsFiltered_Priority(t *testing.T) {
+	db := setupTestDB(t)
+
+	createTestItemWithProject(t, db, "High", "test", model.StatusOpen, 1)
+	createTestItemWithProject(t, db, "Medium", "test", model.StatusOpen, 2)
+	createTestItemWithProject(t, db, "Low", "test", model.StatusOpen, 3)
+	createTestItemWithProject(t, db, "High done", "test", model.StatusDone, 1)
+
+	status := model.StatusOpen
+	items, err := db.ListItemsFiltered(ListFilter{Status: &status, Priority: 1})
+	if err != nil {
+		t.Fatalf("failed to list: %v", err)
+	}
+	if len(items) != 1 || items[0].Title != "High" {
+		t.Errorf("expected only High, got %v", items)
+	}
+
+	items, err = db.ListItemsFiltered(ListFilter{MinPriority: 2})
+	if err != nil {
+		t.Fatalf("failed to list: %v", err)
+	}
+	if len(items) != 3 {
+		t.Errorf("expected 3 items at medium or higher, got %d", len(items))
+	}
+	for _, item := range items {
+		if item.Priority > 2 {
+			t.Errorf("unexpected %s priority item: %s", model.PriorityName(item.Priority), item.Title)
+		}
+	}
+
+	if _, err := db.ListItemsFiltered(ListFilter{Priority: 4}); err == nil {
+		t.Error("expected error for invalid priority")
+	}
+}
+
+func TestListItemsFiltered_DateRange(t *testing.T) {
+	db := setupTestDB(t)
+
+	day := func(d int) time.Time { return time.Date(2024, 5, d, 12, 0, 0, 0, time.UTC) }
+	var ids []string
+	for d := 1; d <= 4; d++ {
+		item := &model.Item{Project: "test", Type: model.ItemTypeTask, Title: fmt.Sprintf("Day %d", d),
+			Status: model.StatusOpen, CreatedAt: day(d)}
+		if err := db.CreateItem(item); err != nil {
+			t.Fatalf("failed to create item: %v", err)
+		}
+		ids = append(ids, item.ID)
+	}
+	// Touching the first item moves its updated_at, not its created_at
+	db.Clock = fixedClock{day(5)}
+	if err := db.UpdateStatus(ids[0], model.StatusInProgress); err != nil {
+		t.Fatalf("failed to start: %v", err)
+	}
+
+	titles := func(filter ListFilter) string {
+		t.Helper()
+		filter.Sort = "created"
+		filter.Reverse = true
+		items, err := db.ListItemsFiltered(filter)
+		if err != nil {
+			t.Fatalf("failed to list: %v", err)
+		}
+		var got []string
+		for _, item := range items {
+			got = append(got, item.Title)
+		}
+		return strings.Join(got, ",")
+	}
+
+	if got := titles(ListFilter{Since: day(2), Until: day(4)}); got != "Day 2,Day 3" {
+		t.Errorf("created in [day 2, day 4) = %s", got)
+	}
+	if got := titles(ListFilter{Since: day(5), DateField: "updated"}); got != "Day 1" {
+		t.Errorf("updated since day 5 = %s", got)
+	}
+	if got := titles(ListFilter{Since: day(2), Limit: 1, Offset: 1}); got != "Day 3" {
+		t.Errorf("paged = %s", got)
+	}
+	if n, err := db.CountItemsFiltered(ListFilter{Since: day(2), Limit: 1}); err != nil || n != 3 {
+		t.Errorf("count = %d, %v; want 3", n, err)
+	}
+
+	if _, err := db.ListItemsFiltered(ListFilter{Since: day(1), DateField: "due"}); err == nil {
+		t.Error("expected error for invalid date field")
+	}
+}
+
+func TestListItemsFiltered_ExcludeStatuses(t *testing.T) {
+	db := setupTestDB(t)
+
+	createTestItemWithProject(t, db, "Open", "test", model.StatusOpen, 2)
+	createTestItemWithProject(t, db, "Blocked", "test", model.StatusBlocked, 2)
+	createTestItemWithProject(t, db, "Done", "test", model.StatusDone, 2)
+	createTestItemWithProject(t, db, "Canceled", "test", model.StatusCanceled, 2)
+
+	tests := []struct {
+		exclude []model.Status
+		want    int
+	}{
+		{[]model.Status{model.StatusDone}, 3},
+		{[]model.Status{model.StatusDone, model.StatusCanceled}, 2},
+	}
+	for _, tt := range tests {
+		items, err := db.ListItemsFiltered(ListFilter{ExcludeStatuses: tt.exclude})
+		if err != nil {
+			t.Fatalf("failed to list: %v", err)
+		}
+		if len(items) != tt.want {
+			t.Errorf("excluding %v: got %d items, want %d", tt.exclude, len(items), tt.want)
+		}
+		for _, item := range items {
+			if slices.Contains(tt.exclude, item.Status) {
+				t.Errorf("excluding %v: got %s item %s", tt.exclude, item.Status, item.Title)
+			}
+		}
+	}
+
+	if _, err := db.ListItemsFiltered(ListFilter{ExcludeStatuses: []model.Status{"finished"}}); !errors.Is(err, ErrInvalidStatus) {
+		t.Errorf("expected ErrInvalidStatus, got %v", err)
+	}
+}
+
+func TestEmptyEpics(t *testing.T) {
+	db := setupTestDB(t)
+
+	empty := createTestEpic(t, db, "Scaffolding", "test")
+	full := createTestEpic(t, db, "Auth", "test")
+	createTestEpic(t, db, "Elsewhere", "other")
+	child := createTestItemWithProject(t, db, "Login", "test", model.StatusDone, 2)
+	if err := db.SetParent(child.ID, full.ID); err != nil {
+		t.Fatalf("failed to set parent: %v", err)
+	}
+
+	epics, err := db.EmptyEpics("test")
+	if err != nil {
+		t.Fatalf("EmptyEpics failed: %v", err)
+	}
+	if len(epics) != 1 || epics[0].ID != empty.ID {
+		t.Errorf("expected only %s, got %v", empty.ID, epics)
+	}
+
+	all, err := db.EmptyEpics("")
+	if err != nil {
+		t.Fatalf("EmptyEpics failed: %v", err)
+	}
+	if len(all) != 2 {
+		t.Errorf("expected 2 empty epics across projects, got %d", len(all))
+	}
+}
+
+func TestListItemsFiltered_LimitOffset(t *testing.T) {
+	db := setupTestDB(t)
+
+	for i := 1; i <= 5; i++ {
+		createTestItemWithProject(t, db, fmt.Sprintf("Task %d", i), "test", model.StatusOpen, 2)
+	}
+	all, err := db.ListItemsFiltered(ListFilter{})
+	if err != nil {
+		t.Fatalf("failed to list: %v", err)
+	}
+
+	items, err := db.ListItemsFiltered(ListFilter{Limit: 2, Offset: 1})
+	if err != nil {
+		t.Fatalf("failed to list: %v", err)
+	}
+	if len(items) != 2 || items[0].ID != all[1].ID || items[1].ID != all[2].ID {
+		t.Errorf("expected the second and third items, got %v", items)
+	}
+
+	// Offset without a limit returns the rest
+	items, err = db.ListItemsFiltered(ListFilter{Offset: 3})
+	if err != nil {
+		t.Fatalf("failed to list: %v", err)
+	}
+	if len(items) != 2 {
+		t.Errorf("expected 2 items, got %d", len(items))
+	}
+
+	total, err := db.CountItemsFiltered(ListFilter{Limit: 2, Offset: 1})
+	if err != nil {
+		t.Fatalf("failed to count: %v", err)
+	}
+	if total != 5 {
+		t.Errorf("expected count 5 ignoring limit, got %d", total)
+	}
+
+	if _, err := db.ListItemsFiltered(ListFilter{Limit: -1}); err == nil {
+		t.Error("expected error for negative limit")
+	}
+}
+
+func TestAllItems(t *testing.T) {
+	db := setupTestDB(t)
+
+	createTestItemWithProject(t, db, "Task 1", "proj1", model.StatusOpen, 2)
+	createTestItemWithProject(t, db, "Task 2", "proj1", model.StatusDone, 1)
+	createTestItemWithProject(t, db, "Task 3", "proj2", model.StatusOpen, 3)
+
+	count := 0
+	for item, err := range db.AllItems("proj1") {
+		if err != nil {
+			t.Fatalf("iteration error: %v", err)
+		}
+		if item.Project != "proj1" {
+			t.Errorf("unexpected project %q", item.Project)
+		}
+		count++
+	}
+	if count != 2 {
+		t.Errorf("expected 2 items in proj1, got %d", count)
+	}
+
+	count = 0
+	for _, err := range db.AllItems("") {
+		if err != nil {
+			t.Fatalf("iteration error: %v", err)
+		}
+		count++
+	}
+	if count != 3 {
+		t.Errorf("expected 3 items total, got %d", count)
+	}
+
+	// Breaking early must not error or leak the cursor
+	for range db.AllItems("") {
+		break
+	}
+	if _, err := db.ListItems("", nil); err != nil {
+		t.Errorf("query after early break failed: %v", err)
+	}
+}
+
+func TestSetArchived(t *testing.T) {
+	db := setupTestDB(t)
+
+	keep := createTestItemWithProject(t, db, "Keep", "test", model.StatusOpen, 2)
+	old := createTestItemWithProject(t, db, "Old", "test", model.StatusOpen, 2)
+
+	if err := db.SetArchived(old.ID, true); err != nil {
+		t.Fatalf("failed to archive: %v", err)
+	}
+
+	items, _ := db.ListItemsFiltered(ListFilter{Project: "test"})
+	if len(items) != 1 || items[0].ID != keep.ID {
+		t.Errorf("expected only unarchived item in list, got %v", items)
+	}
+	ready, _ := db.ReadyItems("test")
+	if len(ready) != 1 || ready[0].ID != keep.ID {
+		t.Errorf("expected only unarchived item in ready, got %v", ready)
+	}
+
+	items, _ = db.ListItemsFiltered(ListFilter{Project: "test", IncludeArchived: true})
+	if len(items) != 2 {
+		t.Errorf("expected 2 items with IncludeArchived, got %d", len(items))
+	}
+
+	got, _ := db.GetItem(old.ID)
+	if !got.Archived {
+		t.Error("expected item to be archived")
+	}
+
+	if err := db.SetArchived(old.ID, false); err != nil {
+		t.Fatalf("failed to unarchive: %v", err)
+	}
+	ready, _ = db.ReadyItems("test")
+	if len(ready) != 2 {
+		t.Errorf("expected 2 ready items after unarchive, got %d", len(ready))
+	}
+}
+
+func TestSetArchived_NotFound(t *testing.T) {
+	db := setupTestDB(t)
+
+	if err := db.SetArchived("ts-nonexistent", true); err == nil {
+		t.Error("expected error for nonexistent item")
+	}
+}
+
+func TestSetSnoozedUntil(t *testing.T) {
+	db := setupTestDB(t)
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	db.Clock = fixedClock{now}
+
+	keep := createTestItemWithProject(t, db, "Keep", "test", model.StatusOpen, 2)
+	later := createTestItemWithProject(t, db, "Later", "test", model.StatusOpen, 2)
+
+	// A zone far from UTC still compares correctly against the clock
+	tokyo := time.FixedZone("JST", 9*60*60)
+	until := now.Add(2 * time.Hour).In(tokyo)
+	if err := db.SetSnoozedUntil(later.ID, &until); err != nil {
+		t.Fatalf("failed to snooze: %v", err)
+	}
+
+	ready, _ := db.ReadyItems("test")
+	if len(ready) != 1 || ready[0].ID != keep.ID {
+		t.Errorf("expected snoozed item hidden from ready, got %v", ready)
+	}
+	items, _ := db.ListItemsFiltered(ListFilter{Project: "test"})
+	if len(items) != 1 || items[0].ID != keep.ID {
+		t.Errorf("expected snoozed item hidden from list, got %v", items)
+	}
+	items, _ = db.ListItemsFiltered(ListFilter{Project: "test", IncludeSnoozed: true})
+	if len(items) != 2 {
+		t.Errorf("expected 2 items with IncludeSnoozed, got %d", len(items))
+	}
+	got, _ := db.GetItem(later.ID)
+	if got.SnoozedUntil == nil || !got.SnoozedUntil.Equal(until) {
+		t.Errorf("SnoozedUntil = %v, want %v", got.SnoozedUntil, until)
+	}
+
+	// Once the time passes the item is back without any change to it
+	db.Clock = fixedClock{now.Add(3 * time.Hour)}
+	if ready, _ := db.ReadyItems("test"); len(ready) != 2 {
+		t.Errorf("expected 2 ready items after snooze ends, got %d", len(ready))
+	}
+
+	db.Clock = fixedClock{now}
+	if err := db.SetSnoozedUntil(later.ID, nil); err != nil {
+		t.Fatalf("failed to unsnooze: %v", err)
+	}
+	if ready, _ := db.ReadyItems("test"); len(ready) != 2 {
+		t.Errorf("expected 2 ready items after unsnooze, got %d", len(ready))
+	}
+	if err := db.SetSnoozedUntil("ts-nonexistent", nil); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestProjectStatus_Effort(t *testing.T) {
+	db := setupTestDB(t)
+
+	for i, est := range []int{120, 280} {
+		item := &model.Item{
+			ID:              model.GenerateID(model.ItemTypeTask),
+			Project:         "test",
+			Type:            model.ItemTypeTask,
+			Title:           "Task",
+			Status:          []model.Status{model.StatusOpen, model.StatusDone}[i],
+			EstimateMinutes: est,
+		}
+		if err := db.CreateItem(item); err != nil {
+			t.Fatalf("failed to create item: %v", err)
+		}
+		if err := db.AddTime(item.ID, est+60); err != nil {
+			t.Fatalf("failed to add time: %v", err)
+		}
+	}
+
+	report, err := db.ProjectStatus("test")
+	if err != nil {
+		t.Fatalf("failed to get status: %v", err)
+	}
+	if report.EstimateMinutes != 400 || report.ActualMinutes != 520 {
+		t.Errorf("effort = %d/%d, want 400/520", report.EstimateMinutes, report.ActualMinutes)
+	}
+	if report.RemainingEstimate != 120 || report.CompletedEstimate != 280 {
+		t.Errorf("burndown = %d remaining/%d completed, want 120/280", report.RemainingEstimate, report.CompletedEstimate)
+	}
+}
+
+func TestListChildren(t *testing.T) {
+	db := setupTestDB(t)
+
+	epic := createTestEpic(t, db, "Epic", "test")
+	other := createTestEpic(t, db, "Other epic", "test")
+	child := createTestItemWithProject(t, db, "Child", "test", model.StatusOpen, 2)
+	createTestItemWithProject(t, db, "Orphan", "test", model.StatusOpen, 2)
+	otherChild := createTestItemWithProject(t, db, "Other child", "test", model.StatusOpen, 2)
+	if err := db.SetParent(child.ID, epic.ID); err != nil {
+		t.Fatalf("failed to set parent: %v", err)
+	}
+	if err := db.SetParent(otherChild.ID, other.ID); err != nil {
+		t.Fatalf("failed to set parent: %v", err)
+	}
+
+	children, err := db.ListChildren(epic.ID)
+	if err != nil {
+		t.Fatalf("failed to list children: %v", err)
+	}
+	if len(children) != 1 || children[0].ID != child.ID {
+		t.Errorf("expected only %s, got %v", child.ID, children)
+	}
+}
+
+func TestListChildren_NotEpic(t *testing.T) {
+	db := setupTestDB(t)
+
+	task := createTestItemWithProject(t, db, "Task", "test", model.StatusOpen, 2)
+	_, err := db.ListChildren(task.ID)
+	if err == nil || !strings.Contains(err.Error(), "not an epic") {
+		t.Errorf("expected not-an-epic error, got %v", err)
+	}
+
+	if _, err := db.ListChildren("ep-missing"); err == nil {
+		t.Error("expected error for nonexistent epic")
+	}
+}
+
+func TestItemTree(t *testing.T) {
+	db := setupTestDB(t)
+
+	orphan := createTestItemWithProject(t, db, "Orphan", "test", model.StatusOpen, 1)
+	epic := createTestEpic(t, db, "Epic", "test")
+	child1 := createTestItemWithProject(t, db, "Child 1", "test", model.StatusDone, 2)
+	child2 := createTestItemWithProject(t, db, "Child 2", "test", model.StatusOpen, 2)
+	createTestItemWithProject(t, db, "Elsewhere", "other", model.StatusOpen, 2)
+	for _, c := range []*model.Item{child1, child2} {
+		if err := db.SetParent(c.ID, epic.ID); err != nil {
+			t.Fatalf("failed to set parent: %v", err)
+		}
+	}
+
+	roots, err := db.ItemTree("test")
+	if err != nil {
+		t.Fatalf("failed to build tree: %v", err)
+	}
+	if len(roots) != 2 {
+		t.Fatalf("expected 2 roots, got %d", len(roots))
+	}
+	if roots[0].Item.ID != epic.ID {
+		t.Errorf("first root = %s, want epic %s", roots[0].Item.ID, epic.ID)
+	}
+	if roots[1].Item.ID != orphan.ID {
+		t.Errorf("second root = %s, want orphan %s", roots[1].Item.ID, orphan.ID)
+	}
+	if len(roots[0].Children) != 2 {
+		t.Errorf("expected 2 children under epic, got %d", len(roots[0].Children))
+	}
+	if len(roots[1].Children) != 0 {
+		t.Errorf("orphan should have no children")
+	}
+}
+
+func TestCompletionStats(t *testing.T) {
+	db := setupTestDB(t)
+
+	// Wednesday; the current week starts Monday 2024-05-13
+	now := time.Date(2024, 5, 15, 12, 0, 0, 0, time.Local)
+	complete := func(title string, at time.Time) *model.Item {
+		t.Helper()
+		item := createTestItemWithProject(t, db, title, "stats", model.StatusOpen, model.PriorityMedium)
+		db.Clock = fixedClock{at}
+		if err := db.UpdateStatus(item.ID, model.StatusDone); err != nil {
+			t.Fatalf("failed to complete: %v", err)
+		}
+		return item
+	}
+	complete("This week", now.AddDate(0, 0, -1))
+	complete("Last week A", now.AddDate(0, 0, -7))
+	complete("Last week B", now.AddDate(0, 0, -8))
+	complete("Too old", now.AddDate(0, 0, -30))
+	reopened := complete("Reopened", now)
+
+	// Reopening clears done_at; a later edit must not move the completion
+	db.Clock = fixedClock{now}
+	if err := db.UpdateStatus(reopened.ID, model.StatusOpen); err != nil {
+		t.Fatalf("failed to reopen: %v", err)
+	}
+
+	weeks, err := db.CompletionStats("stats", now.AddDate(0, 0, -14))
+	if err != nil {
+		t.Fatalf("CompletionStats failed: %v", err)
+	}
+
+	want := []struct {
+		start string
+		count int
+	}{
+		{"2024-04-29", 0},
+		{"2024-05-06", 2},
+		{"2024-05-13", 1},
+	}
+	if len(weeks) != len(want) {
+		t.Fatalf("got %d weeks, want %d: %+v", len(weeks), len(want), weeks)
+	}
+	for i, w := range want {
+		if got := weeks[i].Start.Format("2006-01-02"); got != w.start || weeks[i].Count != w.count {
+			t.Errorf("week %d = %s/%d, want %s/%d", i, got, weeks[i].Count, w.start, w.count)
+		}
+	}
+}
+
+func TestUpdateStatus_DoneAt(t *testing.T) {
+	db := setupTestDB(t)
+	item := createTestItem(t, db, "Task")
+
+	doneAt := time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC)
+	db.Clock = fixedClock{doneAt}
+	if err := db.UpdateStatus(item.ID, model.StatusDone); err != nil {
+		t.Fatalf("failed to complete: %v", err)
+	}
+
+	// Re-marking done keeps the original completion time
+	db.Clock = fixedClock{doneAt.Add(time.Hour)}
+	if err := db.UpdateStatus(item.ID, model.StatusDone); err != nil {
+		t.Fatalf("failed to complete again: %v", err)
+	}
+	got, _ := db.GetItem(item.ID)
+	if got.DoneAt == nil || !got.DoneAt.Equal(doneAt) {
+		t.Errorf("done_at = %v, want %v", got.DoneAt, doneAt)
+	}
+
+	if err := db.UpdateStatus(item.ID, model.StatusOpen); err != nil {
+		t.Fatalf("failed to reopen: %v", err)
+	}
+	got, _ = db.GetItem(item.ID)
+	if got.DoneAt != nil {
+		t.Errorf("done_at = %v after reopen, want nil", got.DoneAt)
+	}
+}
+
+func TestUpdateStatus_StartedAt(t *testing.T) {
+	db := setupTestDB(t)
+	item := createTestItem(t, db, "Task")
+	never := createTestItem(t, db, "Never started")
+
+	startedAt := time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC)
+	db.Clock = fixedClock{startedAt}
+	if err := db.UpdateStatus(item.ID, model.StatusInProgress); err != nil {
+		t.Fatalf("failed to start: %v", err)
+	}
+
+	// Later moves, including a restart after reopening, keep the first start
+	for _, status := range []model.Status{model.StatusDone, model.StatusOpen, model.StatusInProgress, model.StatusDone} {
+		db.Clock = fixedClock{startedAt.Add(time.Hour)}
+		if err := db.UpdateStatus(item.ID, status); err != nil {
+			t.Fatalf("failed to set %s: %v", status, err)
+		}
+	}
+	got, _ := db.GetItem(item.ID)
+	if got.StartedAt == nil || !got.StartedAt.Equal(startedAt) {
+		t.Errorf("started_at = %v, want %v", got.StartedAt, startedAt)
+	}
+
+	if err := db.UpdateStatus(never.ID, model.StatusDone); err != nil {
+		t.Fatalf("failed to complete: %v", err)
+	}
+	got, _ = db.GetItem(never.ID)
+	if got.StartedAt != nil || got.DoneAt == nil {
+		t.Errorf("never-started task: started_at = %v, done_at = %v; want nil and set", got.StartedAt, got.DoneAt)
+	}
+}
+
+func TestReadyScored(t *testing.T) {
+	db := setupTestDB(t)
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	db.Clock = fixedClock{now}
+
+	create := func(title string, priority int, created time.Time) *model.Item {
+		t.Helper()
+		item := &model.Item{Project: "test", Type: model.ItemTypeTask, Title: title, Status: model.StatusOpen, Priority: priority, CreatedAt: created, UpdatedAt: created}
+		if err := db.CreateItem(item); err != nil {
+			t.Fatalf("failed to create %s: %v", title, err)
+		}
+		return item
+	}
+	old := create("Old low", 3, now.AddDate(0, 0, -60)) // age capped at 30 days
+	fresh := create("Fresh high", 1, now)
+	blocker := create("Blocker", 2, now)
+	waiting := create("Waiting", 1, now)
+	if err := db.AddDep(waiting.ID, blocker.ID); err != nil {
+		t.Fatalf("failed to add dep: %v", err)
+	}
+
+	scored, err := db.ReadyScored("test")
+	if err != nil {
+		t.Fatalf("ReadyScored failed: %v", err)
+	}
+	want := []struct {
+		id         string
+		score      float64
+		dependents int
+	}{
+		{fresh.ID, 30, 0},
+		{blocker.ID, 25, 1}, // ties keep priority order
+		{old.ID, 25, 0},
+	}
+	if len(scored) != len(want) {
+		t.Fatalf("got %d scored items, want %d", len(scored), len(want))
+	}
+	for i, w := range want {
+		if scored[i].ID != w.id || scored[i].Score != w.score || scored[i].Dependents != w.dependents {
+			t.Errorf("scored[%d] = %s %.1f (%d deps), want %s %.1f (%d deps)", i, scored[i].ID, scored[i].Score, scored[i].Dependents, w.id, w.score, w.dependents)
+		}
+	}
+}
+
+func TestIndependentReady(t *testing.T) {
+	db := setupTestDB(t)
+
+	schema := createTestItemWithProject(t, db, "Schema", "test", model.StatusDone, 2)
+	api := createTestItemWithProject(t, db, "API", "test", model.StatusOpen, 1)
+	ui := createTestItemWithProject(t, db, "UI", "test", model.StatusOpen, 3)
+	docs := createTestItemWithProject(t, db, "Docs", "test", model.StatusOpen, 2)
+	lint := createTestItemWithProject(t, db, "Lint", "test", model.StatusOpen, 2)
+	release := createTestItemWithProject(t, db, "Release", "test", model.StatusOpen, 1)
+	createTestItemWithProject(t, db, "Solo", "test", model.StatusOpen, 2)
+	createTestItemWithProject(t, db, "Elsewhere", "other", model.StatusOpen, 1)
+
+	for _, dep := range [][2]string{
+		{api.ID, schema.ID}, {ui.ID, schema.ID}, // shared prerequisite, already done
+		{release.ID, docs.ID}, {release.ID, lint.ID}, // common dependent
+	} {
+		if err := db.AddDep(dep[0], dep[1]); err != nil {
+			t.Fatalf("failed to add dep: %v", err)
+		}
+	}
+
+	groups, err := db.IndependentReady("test")
+	if err != nil {
+		t.Fatalf("IndependentReady failed: %v", err)
+	}
+	var got []string
+	for _, group := range groups {
+		var titles []string
+		for _, item := range group {
+			titles = append(titles, item.Title)
+		}
+		got = append(got, strings.Join(titles, ","))
+	}
+	// The done schema no longer ties API and UI together
+	want := []string{"API", "Docs,Lint", "Solo", "UI"}
+	if !slices.Equal(got, want) {
+		t.Errorf("groups = %q, want %q", got, want)
+	}
+}
+
+func TestBlockedItems(t *testing.T) {
+	db := setupTestDB(t)
+	start := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	dep := createTestItemWithProject(t, db, "Dependency", "test", model.StatusOpen, 2)
+	recent := createTestItemWithProject(t, db, "Recently blocked", "test", model.StatusOpen, 2)
+	old := createTestItemWithProject(t, db, "Long blocked", "test", model.StatusOpen, 2)
+	createTestItemWithProject(t, db, "Elsewhere", "other", model.StatusBlocked, 2)
+
+	db.Clock = fixedClock{start}
+	if err := db.BlockItems([]string{old.ID}, "waiting on vendor", false); err != nil {
+		t.Fatalf("failed to block: %v", err)
+	}
+	db.Clock = fixedClock{start.AddDate(0, 0, 3)}
+	if err := db.BlockItemsOn([]string{recent.ID}, dep.ID, "needs the dependency", false); err != nil {
+		t.Fatalf("failed to block on: %v", err)
+	}
+
+	blocked, err := db.BlockedItems("test")
+	if err != nil {
+		t.Fatalf("BlockedItems failed: %v", err)
+	}
+	if len(blocked) != 2 {
+		t.Fatalf("got %d blocked items, want 2", len(blocked))
+	}
+	if blocked[0].ID != old.ID || !blocked[0].BlockedSince.Equal(start) || blocked[0].BlockReason != "waiting on vendor" || len(blocked[0].WaitingOn) != 0 {
+		t.Errorf("first blocked = %+v", blocked[0])
+	}
+	if blocked[1].ID != recent.ID || !slices.Equal(blocked[1].WaitingOn, []string{dep.ID}) {
+		t.Errorf("second blocked = %+v", blocked[1])
+	}
+}
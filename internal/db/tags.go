@@ -0,0 +1,167 @@
+package db
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/baiirun/prog/internal/model"
+)
+
+// NormalizeTag lowercases and trims a tag so "Bug" and " bug" are the same tag.
+func NormalizeTag(tag string) string {
+	return strings.ToLower(strings.TrimSpace(tag))
+}
+
+// AddTag attaches a tag to an item.
+// Tags are normalized to lowercase; adding an existing tag is a no-op.
+func (db *DB) AddTag(itemID, tag string) error {
+	return db.AddTagBulk([]string{itemID}, tag)
+}
+
+// AddTagBulk attaches a tag to every item in ids in one transaction. If any
+// item doesn't exist, none are tagged. Items that already have the tag are
+// left as is.
+func (db *DB) AddTagBulk(ids []string, tag string) error {
+	tag = NormalizeTag(tag)
+	if tag == "" {
+		return fmt.Errorf("tag cannot be empty")
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	for _, id := range ids {
+		var count int
+		if err := tx.QueryRow(`SELECT COUNT(*) FROM items WHERE id = ?`, id).Scan(&count); err != nil {
+			return fmt.Errorf("failed to check item: %w", err)
+		}
+		if count == 0 {
+			return fmt.Errorf("item %w: %s (use 'prog list' to see available items)", ErrNotFound, id)
+		}
+		if _, err := tx.Exec(`INSERT OR IGNORE INTO tags (item_id, tag) VALUES (?, ?)`, id, tag); err != nil {
+			return fmt.Errorf("failed to add tag: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// RemoveTag detaches a tag from an item.
+func (db *DB) RemoveTag(itemID, tag string) error {
+	tag = NormalizeTag(tag)
+
+	result, err := db.Exec(`DELETE FROM tags WHERE item_id = ? AND tag = ?`, itemID, tag)
+	if err != nil {
+		return fmt.Errorf("failed to remove tag: %w", err)
+	}
+	rows, _ := result.RowsAffected()
+	if rows == 0 {
+		return fmt.Errorf("item does not have tag: %s", tag)
+	}
+	return nil
+}
+
+// RemoveTagBulk detaches a tag from every item in ids in one transaction and
+// returns the ids that had it. Items without the tag are skipped; if any
+// item doesn't exist, nothing is removed.
+func (db *DB) RemoveTagBulk(ids []string, tag string) ([]string, error) {
+	tag = NormalizeTag(tag)
+
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var removed []string
+	for _, id := range ids {
+		var count int
+		if err := tx.QueryRow(`SELECT COUNT(*) FROM items WHERE id = ?`, id).Scan(&count); err != nil {
+			return nil, fmt.Errorf("failed to check item: %w", err)
+		}
+		if count == 0 {
+			return nil, fmt.Errorf("item %w: %s (use 'prog list' to see available items)", ErrNotFound, id)
+		}
+		result, err := tx.Exec(`DELETE FROM tags WHERE item_id = ? AND tag = ?`, id, tag)
+		if err != nil {
+			return nil, fmt.Errorf("failed to remove tag: %w", err)
+		}
+		if rows, _ := result.RowsAffected(); rows > 0 {
+			removed = append(removed, id)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return removed, nil
+}
+
+// GetItemTags returns the tags attached to an item, sorted alphabetically.
+func (db *DB) GetItemTags(itemID string) ([]string, error) {
+	rows, err := db.Query(`SELECT tag FROM tags WHERE item_id = ? ORDER BY tag`, itemID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get item tags: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var tags []string
+	for rows.Next() {
+		var tag string
+		if err := rows.Scan(&tag); err != nil {
+			return nil, fmt.Errorf("failed to scan tag: %w", err)
+		}
+		tags = append(tags, tag)
+	}
+	return tags, rows.Err()
+}
+
+// PopulateItemTags fills in the Tags field for a slice of items in a single
+// query.
+func (db *DB) PopulateItemTags(items []model.Item) error {
+	if len(items) == 0 {
+		return nil
+	}
+
+	ids := make([]any, len(items))
+	for i, item := range items {
+		ids[i] = item.ID
+	}
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(items)), ", ")
+	rows, err := db.Query(`
+		SELECT item_id, tag FROM tags
+		WHERE item_id IN (`+placeholders+`)
+		ORDER BY item_id, tag`, ids...)
+	if err != nil {
+		return fmt.Errorf("failed to query item tags: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	tagMap := make(map[string][]string)
+	for rows.Next() {
+		var itemID, tag string
+		if err := rows.Scan(&itemID, &tag); err != nil {
+			return fmt.Errorf("failed to scan tag: %w", err)
+		}
+		tagMap[itemID] = append(tagMap[itemID], tag)
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to query item tags: %w", err)
+	}
+
+	for i := range items {
+		items[i].Tags = tagMap[items[i].ID]
+	}
+	return nil
+}
+
+// ListItemsByTag returns items carrying the given tag, optionally scoped to a project.
+func (db *DB) ListItemsByTag(project, tag string) ([]model.Item, error) {
+	return db.ListItemsFiltered(ListFilter{Project: project, Tag: tag})
+}
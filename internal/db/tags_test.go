@@ -0,0 +1,175 @@
+package db
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/baiirun/prog/internal/model"
+)
+
+func TestAddTag(t *testing.T) {
+	db := setupTestDB(t)
+
+	item := createTestItem(t, db, "Task")
+
+	if err := db.AddTag(item.ID, "Backend"); err != nil {
+		t.Fatalf("failed to add tag: %v", err)
+	}
+
+	tags, err := db.GetItemTags(item.ID)
+	if err != nil {
+		t.Fatalf("failed to get tags: %v", err)
+	}
+	if len(tags) != 1 || tags[0] != "backend" {
+		t.Errorf("tags = %v, want [backend]", tags)
+	}
+}
+
+func TestAddTag_Deduplicates(t *testing.T) {
+	db := setupTestDB(t)
+
+	item := createTestItem(t, db, "Task")
+
+	for _, tag := range []string{"bug", "BUG", " Bug "} {
+		if err := db.AddTag(item.ID, tag); err != nil {
+			t.Fatalf("failed to add tag %q: %v", tag, err)
+		}
+	}
+
+	tags, _ := db.GetItemTags(item.ID)
+	if len(tags) != 1 {
+		t.Errorf("expected 1 tag after duplicates, got %v", tags)
+	}
+}
+
+func TestAddTag_Empty(t *testing.T) {
+	db := setupTestDB(t)
+
+	item := createTestItem(t, db, "Task")
+
+	if err := db.AddTag(item.ID, "  "); err == nil {
+		t.Error("expected error for empty tag")
+	}
+}
+
+func TestAddTag_ItemNotFound(t *testing.T) {
+	db := setupTestDB(t)
+
+	if err := db.AddTag("nonexistent", "bug"); err == nil {
+		t.Error("expected error for nonexistent item")
+	}
+}
+
+func TestRemoveTag(t *testing.T) {
+	db := setupTestDB(t)
+
+	item := createTestItem(t, db, "Task")
+	if err := db.AddTag(item.ID, "bug"); err != nil {
+		t.Fatalf("failed to add tag: %v", err)
+	}
+
+	if err := db.RemoveTag(item.ID, "Bug"); err != nil {
+		t.Fatalf("failed to remove tag: %v", err)
+	}
+
+	tags, _ := db.GetItemTags(item.ID)
+	if len(tags) != 0 {
+		t.Errorf("expected no tags, got %v", tags)
+	}
+
+	if err := db.RemoveTag(item.ID, "bug"); err == nil {
+		t.Error("expected error removing tag the item doesn't have")
+	}
+}
+
+func TestAddTagBulk(t *testing.T) {
+	db := setupTestDB(t)
+
+	a := createTestItem(t, db, "A")
+	b := createTestItem(t, db, "B")
+	c := createTestItem(t, db, "C")
+	if err := db.AddTag(a.ID, "bug"); err != nil {
+		t.Fatalf("failed to add tag: %v", err)
+	}
+
+	// An unknown id rolls back the whole batch
+	if err := db.AddTagBulk([]string{b.ID, "ts-nope00"}, "bug"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+	if tags, _ := db.GetItemTags(b.ID); len(tags) != 0 {
+		t.Errorf("B tagged despite failed batch: %v", tags)
+	}
+
+	if err := db.AddTagBulk([]string{a.ID, b.ID, c.ID}, "Bug"); err != nil {
+		t.Fatalf("AddTagBulk failed: %v", err)
+	}
+	items, _ := db.ListItemsByTag("", "bug")
+	if len(items) != 3 {
+		t.Errorf("expected 3 tagged items, got %d", len(items))
+	}
+
+	removed, err := db.RemoveTagBulk([]string{a.ID, b.ID}, "bug")
+	if err != nil {
+		t.Fatalf("RemoveTagBulk failed: %v", err)
+	}
+	if len(removed) != 2 {
+		t.Errorf("removed = %v, want both", removed)
+	}
+	removed, err = db.RemoveTagBulk([]string{a.ID, c.ID}, "bug")
+	if err != nil {
+		t.Fatalf("RemoveTagBulk failed: %v", err)
+	}
+	if len(removed) != 1 || removed[0] != c.ID {
+		t.Errorf("removed = %v, want only %s", removed, c.ID)
+	}
+}
+
+func TestListItemsByTag(t *testing.T) {
+	db := setupTestDB(t)
+
+	a := createTestItemWithProject(t, db, "A", "alpha", model.StatusOpen, 2)
+	b := createTestItemWithProject(t, db, "B", "beta", model.StatusOpen, 2)
+	createTestItemWithProject(t, db, "C", "alpha", model.StatusOpen, 2)
+
+	for _, id := range []string{a.ID, b.ID} {
+		if err := db.AddTag(id, "backend"); err != nil {
+			t.Fatalf("failed to add tag: %v", err)
+		}
+	}
+
+	// Tags span projects
+	items, err := db.ListItemsByTag("", "backend")
+	if err != nil {
+		t.Fatalf("failed to list by tag: %v", err)
+	}
+	if len(items) != 2 {
+		t.Errorf("expected 2 items across projects, got %d", len(items))
+	}
+
+	// Project scoping still applies
+	items, err = db.ListItemsByTag("alpha", "BACKEND")
+	if err != nil {
+		t.Fatalf("failed to list by tag: %v", err)
+	}
+	if len(items) != 1 || items[0].ID != a.ID {
+		t.Errorf("expected only %s in alpha, got %v", a.ID, items)
+	}
+}
+
+func TestDeleteItem_RemovesTags(t *testing.T) {
+	db := setupTestDB(t)
+
+	item := createTestItem(t, db, "Task")
+	if err := db.AddTag(item.ID, "bug"); err != nil {
+		t.Fatalf("failed to add tag: %v", err)
+	}
+
+	if err := db.DeleteItem(item.ID); err != nil {
+		t.Fatalf("failed to delete item: %v", err)
+	}
+
+	tags, _ := db.GetItemTags(item.ID)
+	if len(tags) != 0 {
+		t.Errorf("expected tags to be deleted, got %v", tags)
+	}
+}
@@ -0,0 +1,128 @@
+package db
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Template is a named item structure that can be instantiated repeatedly.
+// Items are in the export format: a task, or an epic followed by its
+// children, keyed by the ids they were captured from.
+type Template struct {
+	Name      string
+	Items     []ImportItem
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// SaveTemplate captures the item with id (and, for an epic, its children
+// and the dependencies between them) as the template name, replacing any
+// existing template of that name. Statuses, logs and due dates are dropped
+// so applied items always start fresh.
+func (db *DB) SaveTemplate(name, id string) (*Template, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, fmt.Errorf("template name cannot be empty")
+	}
+
+	specs, err := db.ExportItem(id)
+	if err != nil {
+		return nil, err
+	}
+	for i := range specs {
+		specs[i].Status = ""
+		specs[i].Logs = nil
+		specs[i].DueAt = nil
+	}
+	body, err := json.Marshal(specs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode template: %w", err)
+	}
+
+	now := db.Now()
+	_, err = db.Exec(`
+		INSERT INTO templates (name, body, created_at, updated_at) VALUES (?, ?, ?, ?)
+		ON CONFLICT(name) DO UPDATE SET body = excluded.body, updated_at = excluded.updated_at`,
+		name, string(body), now, now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to save template: %w", err)
+	}
+	return db.GetTemplate(name)
+}
+
+// GetTemplate returns the template called name.
+func (db *DB) GetTemplate(name string) (*Template, error) {
+	row := db.QueryRow(`SELECT name, body, created_at, updated_at FROM templates WHERE name = ?`, name)
+	t, err := scanTemplate(row)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("template %w: %s (use 'prog template list' to see available templates)", ErrNotFound, name)
+	}
+	return t, err
+}
+
+// ListTemplates returns every template, sorted by name.
+func (db *DB) ListTemplates() ([]Template, error) {
+	rows, err := db.Query(`SELECT name, body, created_at, updated_at FROM templates ORDER BY name`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list templates: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var templates []Template
+	for rows.Next() {
+		t, err := scanTemplate(rows)
+		if err != nil {
+			return nil, err
+		}
+		templates = append(templates, *t)
+	}
+	return templates, rows.Err()
+}
+
+// DeleteTemplate removes the template called name. Items already created
+// from it are unaffected.
+func (db *DB) DeleteTemplate(name string) error {
+	result, err := db.Exec(`DELETE FROM templates WHERE name = ?`, name)
+	if err != nil {
+		return fmt.Errorf("failed to delete template: %w", err)
+	}
+	rows, _ := result.RowsAffected()
+	if rows == 0 {
+		return fmt.Errorf("template %w: %s", ErrNotFound, name)
+	}
+	return nil
+}
+
+// ApplyTemplate creates fresh open items in project from the template called
+// name, in one transaction. It returns the template and a map from each
+// template key to the id of the item created for it.
+func (db *DB) ApplyTemplate(name, project string) (*Template, map[string]string, error) {
+	t, err := db.GetTemplate(name)
+	if err != nil {
+		return nil, nil, err
+	}
+	ids, err := db.ImportItems(project, t.Items)
+	if err != nil {
+		return nil, nil, err
+	}
+	return t, ids, nil
+}
+
+// scanTemplate scans one templates row, decoding its body.
+func scanTemplate(row rowScanner) (*Template, error) {
+	var t Template
+	var body string
+	if err := row.Scan(&t.Name, &body, &t.CreatedAt, &t.UpdatedAt); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to scan template: %w", err)
+	}
+	if err := json.Unmarshal([]byte(body), &t.Items); err != nil {
+		return nil, fmt.Errorf("failed to decode template %s: %w", t.Name, err)
+	}
+	return &t, nil
+}
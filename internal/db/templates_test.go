@@ -0,0 +1,86 @@
+package db
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/baiirun/prog/internal/model"
+)
+
+func TestTemplates(t *testing.T) {
+	db := setupTestDB(t)
+
+	epic := createTestEpic(t, db, "Release", "src")
+	tag := createTestItemWithProject(t, db, "Tag version", "src", model.StatusDone, model.PriorityHigh)
+	publish := createTestItemWithProject(t, db, "Publish", "src", model.StatusOpen, 2)
+	for _, id := range []string{tag.ID, publish.ID} {
+		if err := db.SetParent(id, epic.ID); err != nil {
+			t.Fatalf("failed to set parent: %v", err)
+		}
+	}
+	if err := db.AddDep(publish.ID, tag.ID); err != nil {
+		t.Fatalf("failed to add dep: %v", err)
+	}
+	if err := db.AddLog(tag.ID, "tagged v1", ""); err != nil {
+		t.Fatalf("failed to add log: %v", err)
+	}
+
+	saved, err := db.SaveTemplate("release", epic.ID)
+	if err != nil {
+		t.Fatalf("SaveTemplate failed: %v", err)
+	}
+	if len(saved.Items) != 3 {
+		t.Fatalf("expected epic and 2 children, got %+v", saved.Items)
+	}
+
+	for _, project := range []string{"one", "two"} {
+		tmpl, ids, err := db.ApplyTemplate("release", project)
+		if err != nil {
+			t.Fatalf("ApplyTemplate failed: %v", err)
+		}
+		if len(ids) != len(tmpl.Items) {
+			t.Fatalf("created %d items, want %d", len(ids), len(tmpl.Items))
+		}
+
+		// Applied items start open and without logs, whatever the source's state
+		gotTag, _ := db.GetItem(ids[tag.ID])
+		if gotTag.Project != project || gotTag.Status != model.StatusOpen || gotTag.Priority != model.PriorityHigh {
+			t.Errorf("tag = %s/%s/%d, want %s/open/high", gotTag.Project, gotTag.Status, gotTag.Priority, project)
+		}
+		if gotTag.ParentID == nil || *gotTag.ParentID != ids[epic.ID] {
+			t.Errorf("tag parent = %v, want %s", gotTag.ParentID, ids[epic.ID])
+		}
+		if logs, _ := db.GetLogs(ids[tag.ID]); len(logs) != 0 {
+			t.Errorf("tag logs = %+v, want none", logs)
+		}
+		deps, _ := db.GetDeps(ids[publish.ID])
+		if len(deps) != 1 || deps[0] != ids[tag.ID] {
+			t.Errorf("publish deps = %v, want [%s]", deps, ids[tag.ID])
+		}
+	}
+
+	// Saving again under the same name replaces the template
+	if _, err := db.SaveTemplate("release", tag.ID); err != nil {
+		t.Fatalf("SaveTemplate (replace) failed: %v", err)
+	}
+	templates, err := db.ListTemplates()
+	if err != nil {
+		t.Fatalf("ListTemplates failed: %v", err)
+	}
+	if len(templates) != 1 || len(templates[0].Items) != 1 {
+		t.Errorf("templates = %+v, want one single-item template", templates)
+	}
+
+	if err := db.DeleteTemplate("release"); err != nil {
+		t.Fatalf("DeleteTemplate failed: %v", err)
+	}
+	if _, _, err := db.ApplyTemplate("release", "one"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("apply after delete = %v, want ErrNotFound", err)
+	}
+	if err := db.DeleteTemplate("release"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("second delete = %v, want ErrNotFound", err)
+	}
+	if _, err := db.SaveTemplate(" ", epic.ID); err == nil {
+		t.Error("expected error for empty template name")
+	}
+}
@@ -0,0 +1,171 @@
+// Package mcp serves prog's task operations to AI agents over the Model
+// Context Protocol: JSON-RPC 2.0 messages, one per line, on stdin/stdout.
+package mcp
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"runtime/debug"
+
+	"github.com/baiirun/prog/internal/db"
+)
+
+// ProtocolVersion is the MCP revision this server implements.
+const ProtocolVersion = "2024-11-05"
+
+// JSON-RPC error codes.
+const (
+	codeParseError     = -32700
+	codeInvalidRequest = -32600
+	codeMethodNotFound = -32601
+	codeInvalidParams  = -32602
+)
+
+// maxMessageSize bounds a single request line.
+const maxMessageSize = 4 * 1024 * 1024
+
+type request struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"` // absent for notifications
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+type response struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  any             `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// Server answers MCP requests using a prog database.
+type Server struct {
+	db *db.DB
+}
+
+// NewServer returns a server backed by database. The caller keeps ownership
+// of database and closes it after Serve returns.
+func NewServer(database *db.DB) *Server {
+	return &Server{db: database}
+}
+
+// Serve reads requests from in and writes responses to out until in is
+// exhausted. Notifications get no response. Tool failures are reported to the
+// client as tool results with isError set, not as protocol errors.
+func (s *Server) Serve(in io.Reader, out io.Writer) error {
+	scanner := bufio.NewScanner(in)
+	scanner.Buffer(make([]byte, 64*1024), maxMessageSize)
+	enc := json.NewEncoder(out)
+
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		resp := s.handle(line)
+		if resp == nil {
+			continue
+		}
+		if err := enc.Encode(resp); err != nil {
+			return fmt.Errorf("failed to write response: %w", err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read request: %w", err)
+	}
+	return nil
+}
+
+// handle processes one message, returning nil for notifications.
+func (s *Server) handle(line []byte) *response {
+	var req request
+	if err := json.Unmarshal(line, &req); err != nil {
+		return &response{JSONRPC: "2.0", ID: json.RawMessage("null"),
+			Error: &rpcError{Code: codeParseError, Message: "parse error: " + err.Error()}}
+	}
+	if req.ID == nil {
+		return nil
+	}
+
+	resp := &response{JSONRPC: "2.0", ID: req.ID}
+	if req.JSONRPC != "2.0" || req.Method == "" {
+		resp.Error = &rpcError{Code: codeInvalidRequest, Message: "invalid request"}
+		return resp
+	}
+
+	switch req.Method {
+	case "initialize":
+		resp.Result = map[string]any{
+			"protocolVersion": ProtocolVersion,
+			"capabilities":    map[string]any{"tools": map[string]any{}},
+			"serverInfo":      map[string]any{"name": "prog", "version": version()},
+		}
+	case "ping":
+		resp.Result = map[string]any{}
+	case "tools/list":
+		list := make([]map[string]any, len(tools))
+		for i, t := range tools {
+			list[i] = map[string]any{"name": t.name, "description": t.description, "inputSchema": t.schema}
+		}
+		resp.Result = map[string]any{"tools": list}
+	case "tools/call":
+		var params struct {
+			Name      string          `json:"name"`
+			Arguments json.RawMessage `json:"arguments"`
+		}
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			resp.Error = &rpcError{Code: codeInvalidParams, Message: "invalid params: " + err.Error()}
+			return resp
+		}
+		t := findTool(params.Name)
+		if t == nil {
+			resp.Error = &rpcError{Code: codeInvalidParams, Message: "unknown tool: " + params.Name}
+			return resp
+		}
+		resp.Result = s.callTool(t, params.Arguments)
+	default:
+		resp.Error = &rpcError{Code: codeMethodNotFound, Message: "method not found: " + req.Method}
+	}
+	return resp
+}
+
+// callTool runs t and wraps its JSON output (or error) as a tool result.
+func (s *Server) callTool(t *tool, args json.RawMessage) map[string]any {
+	if len(args) == 0 || string(args) == "null" {
+		args = json.RawMessage("{}")
+	}
+	result, err := t.call(s.db, args)
+	if err != nil {
+		return toolResult(err.Error(), true)
+	}
+	if t.mutates {
+		s.db.BackupQuiet()
+	}
+	b, err := json.Marshal(result)
+	if err != nil {
+		return toolResult("failed to encode result: "+err.Error(), true)
+	}
+	return toolResult(string(b), false)
+}
+
+func toolResult(text string, isError bool) map[string]any {
+	return map[string]any{
+		"content": []map[string]any{{"type": "text", "text": text}},
+		"isError": isError,
+	}
+}
+
+// version reports the module version the binary was built from.
+func version() string {
+	if info, ok := debug.ReadBuildInfo(); ok && info.Main.Version != "" {
+		return info.Main.Version
+	}
+	return "(devel)"
+}
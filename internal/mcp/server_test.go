@@ -0,0 +1,205 @@
+package mcp
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/baiirun/prog/internal/db"
+	"github.com/baiirun/prog/internal/model"
+)
+
+func setupTestServer(t *testing.T) (*Server, *db.DB) {
+	t.Helper()
+	t.Setenv("HOME", t.TempDir()) // in case a backup strays from the database
+	database, err := db.Open(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("failed to open db: %v", err)
+	}
+	if err := database.Init(); err != nil {
+		t.Fatalf("failed to init db: %v", err)
+	}
+	t.Cleanup(func() { _ = database.Close() })
+	return NewServer(database), database
+}
+
+// exchange sends each request line to the server and returns the decoded
+// responses in order.
+func exchange(t *testing.T, s *Server, requests ...string) []map[string]any {
+	t.Helper()
+	var out strings.Builder
+	if err := s.Serve(strings.NewReader(strings.Join(requests, "\n")), &out); err != nil {
+		t.Fatalf("Serve failed: %v", err)
+	}
+
+	var responses []map[string]any
+	scanner := bufio.NewScanner(strings.NewReader(out.String()))
+	for scanner.Scan() {
+		var resp map[string]any
+		if err := json.Unmarshal(scanner.Bytes(), &resp); err != nil {
+			t.Fatalf("invalid response %q: %v", scanner.Text(), err)
+		}
+		responses = append(responses, resp)
+	}
+	return responses
+}
+
+// callTool invokes one tool and returns its text content and error flag.
+func callTool(t *testing.T, s *Server, name string, args map[string]any) (string, bool) {
+	t.Helper()
+	req, err := json.Marshal(map[string]any{
+		"jsonrpc": "2.0", "id": 1, "method": "tools/call",
+		"params": map[string]any{"name": name, "arguments": args},
+	})
+	if err != nil {
+		t.Fatalf("failed to encode request: %v", err)
+	}
+	responses := exchange(t, s, string(req))
+	if len(responses) != 1 {
+		t.Fatalf("expected 1 response, got %d", len(responses))
+	}
+	result, ok := responses[0]["result"].(map[string]any)
+	if !ok {
+		t.Fatalf("tools/call %s returned no result: %v", name, responses[0])
+	}
+	content := result["content"].([]any)[0].(map[string]any)
+	return content["text"].(string), result["isError"].(bool)
+}
+
+func TestServe_Handshake(t *testing.T) {
+	s, _ := setupTestServer(t)
+
+	responses := exchange(t, s,
+		`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2024-11-05"}}`,
+		`{"jsonrpc":"2.0","method":"notifications/initialized"}`,
+		`{"jsonrpc":"2.0","id":2,"method":"tools/list"}`,
+		`{"jsonrpc":"2.0","id":3,"method":"bogus"}`,
+		`not json`,
+	)
+	if len(responses) != 4 {
+		t.Fatalf("expected 4 responses (no reply to the notification), got %d", len(responses))
+	}
+
+	init := responses[0]["result"].(map[string]any)
+	if init["protocolVersion"] != ProtocolVersion {
+		t.Errorf("protocolVersion = %v", init["protocolVersion"])
+	}
+
+	listed := responses[1]["result"].(map[string]any)["tools"].([]any)
+	var names []string
+	for _, tl := range listed {
+		names = append(names, tl.(map[string]any)["name"].(string))
+	}
+	want := "list_ready,create_task,start_task,complete_task,add_log"
+	if got := strings.Join(names, ","); got != want {
+		t.Errorf("tools = %s, want %s", got, want)
+	}
+
+	if code := responses[2]["error"].(map[string]any)["code"].(float64); code != codeMethodNotFound {
+		t.Errorf("unknown method code = %v, want %d", code, codeMethodNotFound)
+	}
+	if code := responses[3]["error"].(map[string]any)["code"].(float64); code != codeParseError {
+		t.Errorf("bad json code = %v, want %d", code, codeParseError)
+	}
+}
+
+func TestTools_TaskLifecycle(t *testing.T) {
+	s, database := setupTestServer(t)
+
+	text, isErr := callTool(t, s, "create_task", map[string]any{"title": "Write docs", "project": "mcp", "priority": "high"})
+	if isErr {
+		t.Fatalf("create_task failed: %s", text)
+	}
+	var created model.Item
+	if err := json.Unmarshal([]byte(text), &created); err != nil {
+		t.Fatalf("create_task returned %q: %v", text, err)
+	}
+	if created.Title != "Write docs" || created.Priority != model.PriorityHigh || created.Status != model.StatusOpen {
+		t.Errorf("unexpected created item: %+v", created)
+	}
+
+	text, _ = callTool(t, s, "list_ready", map[string]any{"project": "mcp"})
+	var ready []model.Item
+	if err := json.Unmarshal([]byte(text), &ready); err != nil || len(ready) != 1 {
+		t.Fatalf("list_ready = %s (%v)", text, err)
+	}
+
+	if text, isErr := callTool(t, s, "start_task", map[string]any{"id": created.ID}); isErr {
+		t.Fatalf("start_task failed: %s", text)
+	}
+	if text, isErr := callTool(t, s, "add_log", map[string]any{"id": created.ID, "message": "drafted"}); isErr {
+		t.Fatalf("add_log failed: %s", text)
+	}
+	if text, isErr := callTool(t, s, "complete_task", map[string]any{"id": created.ID, "note": "published"}); isErr {
+		t.Fatalf("complete_task failed: %s", text)
+	}
+
+	item, err := database.GetItem(created.ID)
+	if err != nil {
+		t.Fatalf("failed to get item: %v", err)
+	}
+	if item.Status != model.StatusDone {
+		t.Errorf("status = %s, want done", item.Status)
+	}
+	logs, _ := database.GetLogs(created.ID)
+	var messages []string
+	for _, l := range logs {
+		messages = append(messages, l.Message)
+	}
+	if got := strings.Join(messages, "|"); !strings.Contains(got, "drafted") || !strings.Contains(got, "Done: published") {
+		t.Errorf("logs = %v", messages)
+	}
+
+	// An empty ready list is [] rather than null
+	if text, _ := callTool(t, s, "list_ready", map[string]any{"project": "mcp"}); text != "[]" {
+		t.Errorf("empty list_ready = %s, want []", text)
+	}
+}
+
+func TestTools_Errors(t *testing.T) {
+	s, _ := setupTestServer(t)
+
+	tests := []struct {
+		tool string
+		args map[string]any
+		want string
+	}{
+		{"start_task", map[string]any{"id": "ts-nope00"}, "not found"},
+		{"create_task", map[string]any{"title": " "}, "title is required"},
+		{"create_task", map[string]any{"title": "x", "priority": "urgent"}, "invalid priority"},
+		{"add_log", map[string]any{"id": "ts-nope00", "mesage": "typo"}, "unknown field"},
+	}
+	for _, tt := range tests {
+		text, isErr := callTool(t, s, tt.tool, tt.args)
+		if !isErr || !strings.Contains(text, tt.want) {
+			t.Errorf("%s(%v) = %q (isError %v), want error containing %q", tt.tool, tt.args, text, isErr, tt.want)
+		}
+	}
+}
+
+func TestTools_BackupsStayBesideDatabase(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	path := filepath.Join(t.TempDir(), "scratch.db")
+	database, err := db.Open(path)
+	if err != nil {
+		t.Fatalf("failed to open db: %v", err)
+	}
+	if err := database.Init(); err != nil {
+		t.Fatalf("failed to init db: %v", err)
+	}
+	t.Cleanup(func() { _ = database.Close() })
+
+	if text, isErr := callTool(t, NewServer(database), "create_task", map[string]any{"title": "Scratch"}); isErr {
+		t.Fatalf("create_task failed: %s", text)
+	}
+	if backups, _ := db.ListBackups(path); len(backups) != 1 {
+		t.Errorf("backups beside the database = %+v, want 1", backups)
+	}
+	if _, err := os.Stat(filepath.Join(home, ".prog")); !os.IsNotExist(err) {
+		t.Errorf("mutation on a scratch database touched ~/.prog: %v", err)
+	}
+}
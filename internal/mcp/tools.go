@@ -0,0 +1,260 @@
+package mcp
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/baiirun/prog/internal/db"
+	"github.com/baiirun/prog/internal/model"
+)
+
+// tool is one operation exposed through tools/call. Mutating tools trigger a
+// backup after they succeed, like the equivalent CLI commands.
+type tool struct {
+	name        string
+	description string
+	schema      map[string]any
+	mutates     bool
+	call        func(database *db.DB, args json.RawMessage) (any, error)
+}
+
+var tools = []*tool{
+	{
+		name:        "list_ready",
+		description: "List open tasks whose dependencies are all done, highest priority first.",
+		schema: objectSchema(nil, map[string]any{
+			"project": stringProp("Only list tasks in this project"),
+		}),
+		call: listReady,
+	},
+	{
+		name:        "create_task",
+		description: "Create a task and return it.",
+		schema: objectSchema([]string{"title"}, map[string]any{
+			"title":       stringProp("Short description of the work"),
+			"project":     stringProp("Project scope"),
+			"priority":    stringProp("high, medium, or low (or 1-3); defaults to medium"),
+			"description": stringProp("Full context for whoever picks the task up"),
+			"parent":      stringProp("ID of the epic this task belongs to"),
+		}),
+		mutates: true,
+		call:    createTask,
+	},
+	{
+		name:        "start_task",
+		description: "Set a task to in_progress and return it.",
+		schema: objectSchema([]string{"id"}, map[string]any{
+			"id": stringProp("Task ID or unambiguous prefix"),
+		}),
+		mutates: true,
+		call:    startTask,
+	},
+	{
+		name:        "complete_task",
+		description: "Mark a task done, optionally logging how it was finished. Completing a recurring task creates its next occurrence.",
+		schema: objectSchema([]string{"id"}, map[string]any{
+			"id":   stringProp("Task ID or unambiguous prefix"),
+			"note": stringProp("How the work was finished, logged as \"Done: <note>\""),
+		}),
+		mutates: true,
+		call:    completeTask,
+	},
+	{
+		name:        "add_log",
+		description: "Add a timestamped progress entry to a task's log.",
+		schema: objectSchema([]string{"id", "message"}, map[string]any{
+			"id":      stringProp("Task ID or unambiguous prefix"),
+			"message": stringProp("What happened"),
+			"source":  stringProp("Who is logging; defaults to \"agent\""),
+		}),
+		mutates: true,
+		call:    addLog,
+	},
+}
+
+func findTool(name string) *tool {
+	for _, t := range tools {
+		if t.name == name {
+			return t
+		}
+	}
+	return nil
+}
+
+func objectSchema(required []string, props map[string]any) map[string]any {
+	schema := map[string]any{"type": "object", "properties": props}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+func stringProp(description string) map[string]any {
+	return map[string]any{"type": "string", "description": description}
+}
+
+// decodeArgs unmarshals tool arguments into v, rejecting unknown fields so
+// typos in argument names aren't silently ignored.
+func decodeArgs(args json.RawMessage, v any) error {
+	dec := json.NewDecoder(strings.NewReader(string(args)))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		return fmt.Errorf("invalid arguments: %w", err)
+	}
+	return nil
+}
+
+// loadItem returns the item with its labels populated.
+func loadItem(database *db.DB, id string) (*model.Item, error) {
+	item, err := database.GetItem(id)
+	if err != nil {
+		return nil, err
+	}
+	items := []model.Item{*item}
+	if err := database.PopulateItemLabels(items); err != nil {
+		return nil, err
+	}
+	return &items[0], nil
+}
+
+func listReady(database *db.DB, args json.RawMessage) (any, error) {
+	var a struct {
+		Project string `json:"project"`
+	}
+	if err := decodeArgs(args, &a); err != nil {
+		return nil, err
+	}
+	items, err := database.ReadyItems(a.Project)
+	if err != nil {
+		return nil, err
+	}
+	if err := database.PopulateItemLabels(items); err != nil {
+		return nil, err
+	}
+	if items == nil {
+		items = []model.Item{}
+	}
+	return items, nil
+}
+
+func createTask(database *db.DB, args json.RawMessage) (any, error) {
+	var a struct {
+		Title       string `json:"title"`
+		Project     string `json:"project"`
+		Priority    string `json:"priority"`
+		Description string `json:"description"`
+		Parent      string `json:"parent"`
+	}
+	if err := decodeArgs(args, &a); err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(a.Title) == "" {
+		return nil, fmt.Errorf("title is required")
+	}
+	priority := model.PriorityMedium
+	if a.Priority != "" {
+		p, err := model.ParsePriority(a.Priority)
+		if err != nil {
+			return nil, err
+		}
+		priority = p
+	}
+	if a.Parent != "" {
+		parent, err := database.ResolveID(a.Parent)
+		if err != nil {
+			return nil, err
+		}
+		a.Parent = parent
+	}
+
+	item := &model.Item{
+		Project:     a.Project,
+		Type:        model.ItemTypeTask,
+		Title:       a.Title,
+		Description: a.Description,
+		Status:      model.StatusOpen,
+		Priority:    priority,
+		PrioritySet: a.Priority != "",
+	}
+	if a.Parent != "" {
+		item.ParentID = &a.Parent
+	}
+	if err := database.CreateItem(item); err != nil {
+		return nil, err
+	}
+	return loadItem(database, item.ID)
+}
+
+func startTask(database *db.DB, args json.RawMessage) (any, error) {
+	var a struct {
+		ID string `json:"id"`
+	}
+	if err := decodeArgs(args, &a); err != nil {
+		return nil, err
+	}
+	id, err := database.ResolveID(a.ID)
+	if err != nil {
+		return nil, err
+	}
+	if err := database.UpdateStatuses([]string{id}, model.StatusInProgress, ""); err != nil {
+		return nil, err
+	}
+	return loadItem(database, id)
+}
+
+func completeTask(database *db.DB, args json.RawMessage) (any, error) {
+	var a struct {
+		ID   string `json:"id"`
+		Note string `json:"note"`
+	}
+	if err := decodeArgs(args, &a); err != nil {
+		return nil, err
+	}
+	id, err := database.ResolveID(a.ID)
+	if err != nil {
+		return nil, err
+	}
+	logMessage := ""
+	if note := strings.TrimSpace(a.Note); note != "" {
+		logMessage = "Done: " + note
+	}
+	next, err := database.CompleteItems([]string{id}, logMessage, false)
+	if err != nil {
+		return nil, err
+	}
+
+	item, err := loadItem(database, id)
+	if err != nil {
+		return nil, err
+	}
+	return struct {
+		Item           *model.Item `json:"item"`
+		NextOccurrence *model.Item `json:"next_occurrence,omitempty"`
+	}{item, next[id]}, nil
+}
+
+func addLog(database *db.DB, args json.RawMessage) (any, error) {
+	var a struct {
+		ID      string `json:"id"`
+		Message string `json:"message"`
+		Source  string `json:"source"`
+	}
+	if err := decodeArgs(args, &a); err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(a.Message) == "" {
+		return nil, fmt.Errorf("message is required")
+	}
+	if a.Source == "" {
+		a.Source = "agent"
+	}
+	id, err := database.ResolveID(a.ID)
+	if err != nil {
+		return nil, err
+	}
+	if err := database.AddLog(id, a.Message, a.Source); err != nil {
+		return nil, err
+	}
+	return map[string]string{"item_id": id, "message": a.Message, "source": a.Source}, nil
+}
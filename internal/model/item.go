@@ -3,7 +3,13 @@ package model
 
 import (
 	"crypto/rand"
+	"encoding/base32"
 	"encoding/hex"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
 )
 
@@ -13,13 +19,49 @@ import (
 //   - task: "ts-" (e.g., ts-a1b2c3)
 //   - epic: "ep-" (e.g., ep-a1b2c3)
 func GenerateID(itemType ItemType) string {
+	return IDFormat{}.Generate(itemType)
+}
+
+// IDRand is the source of randomness for every generated ID. Tests can
+// swap in a deterministic reader to get predictable IDs.
+var IDRand io.Reader = rand.Reader
+
+// randomBytes reads n bytes from IDRand.
+func randomBytes(n int) []byte {
+	b := make([]byte, n)
+	if _, err := io.ReadFull(IDRand, b); err != nil {
+		panic("reading random ID bytes failed: " + err.Error())
+	}
+	return b
+}
+
+// DefaultIDBytes is the number of random bytes in an ID when
+// IDFormat.Bytes is unset.
+const DefaultIDBytes = 3
+
+// IDFormat controls the random part of generated IDs.
+type IDFormat struct {
+	Bytes  int  // Random bytes per ID; 0 means DefaultIDBytes
+	Base32 bool // Encode as lowercase base32 instead of hex
+}
+
+// base32ID is unpadded base32 so IDs contain only letters and digits.
+var base32ID = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// Generate returns a new ID with the type prefix and f.Bytes random bytes,
+// hex or base32 encoded. Four bytes give 8 hex chars or 7 base32 chars.
+func (f IDFormat) Generate(itemType ItemType) string {
 	prefix := "ts-"
 	if itemType == ItemTypeEpic {
 		prefix = "ep-"
 	}
-	b := make([]byte, 3)
-	if _, err := rand.Read(b); err != nil {
-		panic("crypto/rand failed: " + err.Error())
+	n := f.Bytes
+	if n <= 0 {
+		n = DefaultIDBytes
+	}
+	b := randomBytes(n)
+	if f.Base32 {
+		return prefix + strings.ToLower(base32ID.EncodeToString(b))
 	}
 	return prefix + hex.EncodeToString(b)
 }
@@ -49,19 +91,117 @@ func (s Status) IsValid() bool {
 	return s == StatusOpen || s == StatusInProgress || s == StatusBlocked || s == StatusDone || s == StatusCanceled
 }
 
+// statusTransitions lists the statuses each status may move to directly.
+var statusTransitions = map[Status][]Status{
+	StatusOpen:       {StatusInProgress, StatusBlocked, StatusDone, StatusCanceled},
+	StatusInProgress: {StatusOpen, StatusBlocked, StatusDone, StatusCanceled},
+	StatusBlocked:    {StatusOpen, StatusInProgress, StatusCanceled},
+	StatusDone:       {StatusOpen, StatusInProgress, StatusCanceled},
+	StatusCanceled:   {StatusOpen},
+}
+
+// CanTransitionTo reports whether an item may move from s to next.
+// Staying in the same status is always allowed.
+func (s Status) CanTransitionTo(next Status) bool {
+	return s == next || slices.Contains(statusTransitions[s], next)
+}
+
+// Transitions returns the statuses s may move to directly.
+func (s Status) Transitions() []Status {
+	return slices.Clone(statusTransitions[s])
+}
+
+// Priority levels. Lower numbers are more urgent.
+const (
+	PriorityHigh   = 1
+	PriorityMedium = 2
+	PriorityLow    = 3
+)
+
+// ValidPriority reports whether p is one of the defined priority levels.
+func ValidPriority(p int) bool {
+	return p >= PriorityHigh && p <= PriorityLow
+}
+
+// PriorityName returns the word for a priority level ("high", "medium", "low"),
+// or the bare number if it is out of range.
+func PriorityName(p int) string {
+	switch p {
+	case PriorityHigh:
+		return "high"
+	case PriorityMedium:
+		return "medium"
+	case PriorityLow:
+		return "low"
+	}
+	return strconv.Itoa(p)
+}
+
+// Recurrences lists the valid values of Item.Recurrence, besides "" for a
+// one-off item.
+var Recurrences = []string{"daily", "weekly", "monthly"}
+
+// ValidRecurrence reports whether r is "" or one of Recurrences.
+func ValidRecurrence(r string) bool {
+	return r == "" || slices.Contains(Recurrences, r)
+}
+
+// NextOccurrence returns the due date one recurrence period after from.
+// It returns from unchanged for an invalid or empty recurrence.
+func NextOccurrence(recurrence string, from time.Time) time.Time {
+	switch recurrence {
+	case "daily":
+		return from.AddDate(0, 0, 1)
+	case "weekly":
+		return from.AddDate(0, 0, 7)
+	case "monthly":
+		return from.AddDate(0, 1, 0)
+	}
+	return from
+}
+
+// ParsePriority accepts a priority as a number (1-3) or a word (high, medium, low).
+func ParsePriority(s string) (int, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "high":
+		return PriorityHigh, nil
+	case "medium":
+		return PriorityMedium, nil
+	case "low":
+		return PriorityLow, nil
+	}
+	p, err := strconv.Atoi(strings.TrimSpace(s))
+	if err != nil || !ValidPriority(p) {
+		return 0, fmt.Errorf("invalid priority: %s (valid: 1/high, 2/medium, 3/low)", s)
+	}
+	return p, nil
+}
+
 // Item represents a task or epic in the system.
 type Item struct {
-	ID          string   // Unique identifier (ts-XXXXXX or ep-XXXXXX)
-	Project     string   // Project scope (e.g., "gaia", "myapp")
-	Type        ItemType // "task" or "epic"
-	Title       string   // Short description
-	Description string   // Full context, notes, handoff info
-	Status      Status   // Current state
-	Priority    int      // 1=high, 2=medium, 3=low
-	ParentID    *string  // Optional parent epic ID
-	Labels      []string // Attached label names (populated separately)
-	CreatedAt   time.Time
-	UpdatedAt   time.Time
+	ID              string     `json:"id"`                         // Unique identifier (ts-XXXXXX or ep-XXXXXX)
+	Project         string     `json:"project"`                    // Project scope (e.g., "gaia", "myapp")
+	Type            ItemType   `json:"type"`                       // "task" or "epic"
+	Title           string     `json:"title"`                      // Short description
+	Description     string     `json:"description,omitempty"`      // Full context, notes, handoff info
+	Status          Status     `json:"status"`                     // Current state
+	Priority        int        `json:"priority"`                   // 1=high, 2=medium, 3=low
+	PrioritySet     bool       `json:"priority_set,omitempty"`     // Priority was chosen explicitly, not defaulted or inherited from an epic
+	ParentID        *string    `json:"parent_id,omitempty"`        // Optional parent epic ID
+	Labels          []string   `json:"labels,omitempty"`           // Attached label names (populated separately)
+	Tags            []string   `json:"tags,omitempty"`             // Free-form lowercase tags (populated separately)
+	DueAt           *time.Time `json:"due_at,omitempty"`           // Optional deadline
+	Archived        bool       `json:"archived,omitempty"`         // Hidden from list and ready by default
+	Locked          bool       `json:"locked,omitempty"`           // Status and description changes need --force
+	SnoozedUntil    *time.Time `json:"snoozed_until,omitempty"`    // Hidden from ready and list until then
+	EstimateMinutes int        `json:"estimate_minutes,omitempty"` // Estimated effort; 0 means no estimate
+	ActualMinutes   int        `json:"actual_minutes,omitempty"`   // Time logged so far
+	StartedAt       *time.Time `json:"started_at,omitempty"`       // When the item first went in_progress; nil if it never started
+	DoneAt          *time.Time `json:"done_at,omitempty"`          // When the item was last marked done; nil unless done
+	BlockReason     string     `json:"block_reason,omitempty"`     // Why the item is blocked; empty unless blocked
+	Recurrence      string     `json:"recurrence,omitempty"`       // "daily", "weekly" or "monthly" to repeat on completion; empty for one-off items
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
 }
 
 // Log is a timestamped audit trail entry for an item.
@@ -69,6 +209,26 @@ type Log struct {
 	ID        int64
 	ItemID    string
 	Message   string
+	Source    string // Who wrote the entry (e.g. "claude", a username); may be empty
+	CreatedAt time.Time
+}
+
+// Note is a pinned key/value annotation on an item, such as "pr: 123".
+// Unlike logs, notes are overwritten in place rather than accumulating.
+type Note struct {
+	ItemID    string
+	Key       string
+	Value     string
+	UpdatedAt time.Time
+}
+
+// ChecklistItem is one step of an item's checklist: a small piece of work
+// that doesn't warrant its own task. Position is 1-based.
+type ChecklistItem struct {
+	ItemID    string
+	Position  int
+	Text      string
+	Done      bool
 	CreatedAt time.Time
 }
 
@@ -79,6 +239,33 @@ type Dep struct {
 	DependsOn string
 }
 
+// LinkKinds lists the valid values of Link.Kind.
+var LinkKinds = []string{"relates", "duplicates", "blocks"}
+
+// ValidLinkKind reports whether k is one of LinkKinds.
+func ValidLinkKind(k string) bool {
+	return slices.Contains(LinkKinds, k)
+}
+
+// Link is a loose relationship from FromID to ToID, such as "relates" or
+// "duplicates". Unlike a Dep, a link never holds an item back from ready.
+type Link struct {
+	FromID    string
+	ToID      string
+	Kind      string
+	CreatedAt time.Time
+}
+
+// StatusChange records one status transition of an item.
+type StatusChange struct {
+	ID        int64
+	ItemID    string
+	OldStatus Status
+	NewStatus Status
+	ChangedAt time.Time
+	Undone    bool // Reverted by undo
+}
+
 // Project represents a named project that groups related items.
 type Project struct {
 	Name        string
@@ -126,19 +313,13 @@ type Learning struct {
 
 // GenerateLearningID returns a new learning ID with lrn- prefix and 6 hex chars.
 func GenerateLearningID() string {
-	b := make([]byte, 3)
-	if _, err := rand.Read(b); err != nil {
-		panic("crypto/rand failed: " + err.Error())
-	}
+	b := randomBytes(3)
 	return "lrn-" + hex.EncodeToString(b)
 }
 
 // GenerateConceptID returns a new concept ID with con- prefix and 6 hex chars.
 func GenerateConceptID() string {
-	b := make([]byte, 3)
-	if _, err := rand.Read(b); err != nil {
-		panic("crypto/rand failed: " + err.Error())
-	}
+	b := randomBytes(3)
 	return "con-" + hex.EncodeToString(b)
 }
 
@@ -155,9 +336,6 @@ type Label struct {
 
 // GenerateLabelID returns a new label ID with lbl- prefix and 6 hex chars.
 func GenerateLabelID() string {
-	b := make([]byte, 3)
-	if _, err := rand.Read(b); err != nil {
-		panic("crypto/rand failed: " + err.Error())
-	}
+	b := randomBytes(3)
 	return "lbl-" + hex.EncodeToString(b)
 }
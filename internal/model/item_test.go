@@ -1,8 +1,10 @@
 package model
 
 import (
+	"bytes"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestGenerateID(t *testing.T) {
@@ -30,6 +32,34 @@ func TestGenerateID(t *testing.T) {
 	}
 }
 
+func TestIDFormat_Generate(t *testing.T) {
+	tests := []struct {
+		format  IDFormat
+		wantLen int
+		charset string
+	}{
+		{IDFormat{}, 6, "0123456789abcdef"},
+		{IDFormat{Bytes: 4}, 8, "0123456789abcdef"},
+		{IDFormat{Base32: true}, 5, "abcdefghijklmnopqrstuvwxyz234567"},
+		{IDFormat{Bytes: 5, Base32: true}, 8, "abcdefghijklmnopqrstuvwxyz234567"},
+	}
+
+	for _, tt := range tests {
+		id := tt.format.Generate(ItemTypeTask)
+		suffix, ok := strings.CutPrefix(id, "ts-")
+		if !ok {
+			t.Errorf("%+v: expected ts- prefix, got %q", tt.format, id)
+			continue
+		}
+		if len(suffix) != tt.wantLen {
+			t.Errorf("%+v: expected %d chars after prefix, got %q", tt.format, tt.wantLen, id)
+		}
+		if strings.Trim(suffix, tt.charset) != "" {
+			t.Errorf("%+v: unexpected characters in %q", tt.format, id)
+		}
+	}
+}
+
 func TestGenerateID_Uniqueness(t *testing.T) {
 	// With 3 random bytes (16^6 = 16M possible values) and 100 iterations,
 	// collision probability is ~0.03% (birthday paradox: n²/2N).
@@ -91,3 +121,135 @@ func TestStatus_IsValid(t *testing.T) {
 		})
 	}
 }
+
+func TestStatus_CanTransitionTo(t *testing.T) {
+	tests := []struct {
+		from, to Status
+		allowed  bool
+	}{
+		{StatusOpen, StatusInProgress, true},
+		{StatusInProgress, StatusDone, true},
+		{StatusInProgress, StatusBlocked, true},
+		{StatusBlocked, StatusInProgress, true},
+		{StatusDone, StatusOpen, true},
+		{StatusCanceled, StatusOpen, true},
+		{StatusDone, StatusDone, true},
+		{StatusDone, StatusBlocked, false},
+		{StatusBlocked, StatusDone, false},
+		{StatusCanceled, StatusInProgress, false},
+		{StatusCanceled, StatusDone, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.from)+"->"+string(tt.to), func(t *testing.T) {
+			if got := tt.from.CanTransitionTo(tt.to); got != tt.allowed {
+				t.Errorf("CanTransitionTo() = %v, want %v", got, tt.allowed)
+			}
+		})
+	}
+}
+
+func TestValidPriority(t *testing.T) {
+	tests := []struct {
+		priority int
+		valid    bool
+	}{
+		{0, false},
+		{1, true},
+		{2, true},
+		{3, true},
+		{4, false},
+		{-1, false},
+		{99, false},
+	}
+
+	for _, tt := range tests {
+		if got := ValidPriority(tt.priority); got != tt.valid {
+			t.Errorf("ValidPriority(%d) = %v, want %v", tt.priority, got, tt.valid)
+		}
+	}
+}
+
+func TestNextOccurrence(t *testing.T) {
+	from := time.Date(2024, 1, 31, 9, 0, 0, 0, time.UTC)
+	tests := []struct {
+		recurrence string
+		want       time.Time
+	}{
+		{"daily", time.Date(2024, 2, 1, 9, 0, 0, 0, time.UTC)},
+		{"weekly", time.Date(2024, 2, 7, 9, 0, 0, 0, time.UTC)},
+		{"monthly", time.Date(2024, 3, 2, 9, 0, 0, 0, time.UTC)},
+		{"", from},
+	}
+	for _, tt := range tests {
+		if got := NextOccurrence(tt.recurrence, from); !got.Equal(tt.want) {
+			t.Errorf("NextOccurrence(%q) = %v, want %v", tt.recurrence, got, tt.want)
+		}
+		if !ValidRecurrence(tt.recurrence) {
+			t.Errorf("ValidRecurrence(%q) = false, want true", tt.recurrence)
+		}
+	}
+	if ValidRecurrence("hourly") {
+		t.Error("ValidRecurrence(hourly) = true, want false")
+	}
+}
+
+func TestParsePriority(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    int
+		wantErr bool
+	}{
+		{"1", PriorityHigh, false},
+		{"2", PriorityMedium, false},
+		{"3", PriorityLow, false},
+		{"high", PriorityHigh, false},
+		{"Medium", PriorityMedium, false},
+		{"LOW", PriorityLow, false},
+		{"0", 0, true},
+		{"4", 0, true},
+		{"99", 0, true},
+		{"urgent", 0, true},
+		{"", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParsePriority(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParsePriority(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParsePriority(%q) = %d, want %d", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPriorityName(t *testing.T) {
+	if got := PriorityName(PriorityHigh); got != "high" {
+		t.Errorf("PriorityName(1) = %q, want high", got)
+	}
+	if got := PriorityName(PriorityLow); got != "low" {
+		t.Errorf("PriorityName(3) = %q, want low", got)
+	}
+	if got := PriorityName(7); got != "7" {
+		t.Errorf("PriorityName(7) = %q, want 7", got)
+	}
+}
+
+func TestIDRand(t *testing.T) {
+	orig := IDRand
+	t.Cleanup(func() { IDRand = orig })
+
+	IDRand = bytes.NewReader([]byte{0xa1, 0xb2, 0xc3, 0x00, 0x01, 0x02, 0xff, 0xee, 0xdd})
+	if got := GenerateID(ItemTypeTask); got != "ts-a1b2c3" {
+		t.Errorf("GenerateID = %q, want ts-a1b2c3", got)
+	}
+	if got := GenerateID(ItemTypeEpic); got != "ep-000102" {
+		t.Errorf("GenerateID = %q, want ep-000102", got)
+	}
+	if got := GenerateLabelID(); got != "lbl-ffeedd" {
+		t.Errorf("GenerateLabelID = %q, want lbl-ffeedd", got)
+	}
+}
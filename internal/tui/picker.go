@@ -0,0 +1,159 @@
+package tui
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/baiirun/prog/internal/model"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// pickerModel is a single-choice list of items, narrowed by typing after /.
+type pickerModel struct {
+	prompt    string
+	items     []model.Item
+	filtered  []model.Item // items matching search
+	search    string
+	searching bool // keys go to search rather than navigation
+	cursor    int
+	chosen    *model.Item
+}
+
+func newPicker(prompt string, items []model.Item) pickerModel {
+	return pickerModel{prompt: prompt, items: items, filtered: items}
+}
+
+// applySearch narrows the list to items whose id or title contains search,
+// ignoring case, keeping the cursor in range.
+func (m *pickerModel) applySearch() {
+	search := strings.ToLower(m.search)
+	m.filtered = nil
+	for _, item := range m.items {
+		if strings.Contains(strings.ToLower(item.ID), search) ||
+			strings.Contains(strings.ToLower(item.Title), search) {
+			m.filtered = append(m.filtered, item)
+		}
+	}
+	if m.cursor >= len(m.filtered) {
+		m.cursor = max(0, len(m.filtered)-1)
+	}
+}
+
+// Init implements tea.Model.
+func (m pickerModel) Init() tea.Cmd {
+	return nil
+}
+
+// Update implements tea.Model.
+func (m pickerModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	key, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+	if m.searching {
+		return m.updateSearch(key)
+	}
+	switch key.String() {
+	case "j", "down":
+		if m.cursor < len(m.filtered)-1 {
+			m.cursor++
+		}
+	case "k", "up":
+		if m.cursor > 0 {
+			m.cursor--
+		}
+	case "g", "home":
+		m.cursor = 0
+	case "G", "end":
+		m.cursor = max(0, len(m.filtered)-1)
+	case "/":
+		m.searching = true
+	case "enter":
+		if len(m.filtered) == 0 {
+			return m, nil
+		}
+		m.chosen = &m.filtered[m.cursor]
+		return m, tea.Quit
+	case "q", "esc", "ctrl+c":
+		return m, tea.Quit
+	}
+	return m, nil
+}
+
+// updateSearch edits the search text, filtering live. Enter keeps the
+// filter and returns to navigation; esc clears it.
+func (m pickerModel) updateSearch(key tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch key.String() {
+	case "ctrl+c":
+		return m, tea.Quit
+	case "enter":
+		m.searching = false
+	case "esc":
+		m.searching = false
+		m.search = ""
+		m.applySearch()
+	case "backspace":
+		if len(m.search) > 0 {
+			m.search = m.search[:len(m.search)-1]
+			m.applySearch()
+		}
+	default:
+		if len(key.String()) == 1 {
+			m.search += key.String()
+			m.applySearch()
+		}
+	}
+	return m, nil
+}
+
+// View implements tea.Model.
+func (m pickerModel) View() string {
+	if m.chosen != nil {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString(titleStyle.Render(m.prompt))
+	b.WriteString("\n\n")
+	for i, item := range m.filtered {
+		line := fmt.Sprintf("%s %s  %s", statusIcon(item.Status), item.ID, item.Title)
+		if item.Project != "" {
+			line += "  [" + item.Project + "]"
+		}
+		if i == m.cursor {
+			b.WriteString(selectedRowStyle.Render(line))
+		} else {
+			b.WriteString(line)
+		}
+		b.WriteString("\n")
+	}
+	if len(m.filtered) == 0 {
+		b.WriteString(helpStyle.Render("No matches"))
+		b.WriteString("\n")
+	}
+	b.WriteString("\n")
+	if m.searching || m.search != "" {
+		b.WriteString(inputStyle.Render("Search: " + m.search))
+		if m.searching {
+			b.WriteString(inputStyle.Render("█"))
+		}
+		b.WriteString("\n")
+	}
+	b.WriteString(helpStyle.Render("j/k:nav  /:search  enter:select  q:quit"))
+	b.WriteString("\n")
+	return b.String()
+}
+
+// Pick shows items under prompt, reading keys from in and drawing on out, and
+// returns the one selected. Typing after / narrows the list by id or title. It returns nil if the user quits without choosing.
+func Pick(prompt string, items []model.Item, in io.Reader, out io.Writer) (*model.Item, error) {
+	if len(items) == 0 {
+		return nil, nil
+	}
+	p := tea.NewProgram(newPicker(prompt, items), tea.WithInput(in), tea.WithOutput(out))
+	final, err := p.Run()
+	if err != nil {
+		return nil, err
+	}
+	return final.(pickerModel).chosen, nil
+}
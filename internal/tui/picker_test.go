@@ -0,0 +1,121 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/baiirun/prog/internal/model"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func testPickerItems() []model.Item {
+	return []model.Item{
+		{ID: "ts-aaa111", Title: "Write the parser", Status: model.StatusOpen},
+		{ID: "ts-bbb222", Title: "Document the parser", Status: model.StatusOpen},
+		{ID: "ts-ccc333", Title: "Release", Status: model.StatusOpen},
+	}
+}
+
+// press feeds keys to m one at a time, returning the final model and the
+// command from the last key. Single characters are typed as runes.
+func press(t *testing.T, m pickerModel, keys ...string) (pickerModel, tea.Cmd) {
+	t.Helper()
+	var cmd tea.Cmd
+	for _, k := range keys {
+		var msg tea.KeyMsg
+		switch k {
+		case "enter":
+			msg = tea.KeyMsg{Type: tea.KeyEnter}
+		case "esc":
+			msg = tea.KeyMsg{Type: tea.KeyEsc}
+		case "backspace":
+			msg = tea.KeyMsg{Type: tea.KeyBackspace}
+		case "down":
+			msg = tea.KeyMsg{Type: tea.KeyDown}
+		default:
+			msg = tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(k)}
+		}
+		var next tea.Model
+		next, cmd = m.Update(msg)
+		m = next.(pickerModel)
+	}
+	return m, cmd
+}
+
+func isQuit(cmd tea.Cmd) bool {
+	if cmd == nil {
+		return false
+	}
+	_, ok := cmd().(tea.QuitMsg)
+	return ok
+}
+
+func TestPicker_Select(t *testing.T) {
+	m, cmd := press(t, newPicker("Start which task?", testPickerItems()), "j", "down", "down", "k", "enter")
+	if !isQuit(cmd) {
+		t.Error("expected enter to quit")
+	}
+	if m.chosen == nil || m.chosen.ID != "ts-bbb222" {
+		t.Errorf("chosen = %+v, want ts-bbb222", m.chosen)
+	}
+	if view := m.View(); view != "" {
+		t.Errorf("view after choosing = %q, want empty", view)
+	}
+}
+
+func TestPicker_Cancel(t *testing.T) {
+	for _, key := range []string{"q", "esc"} {
+		m, cmd := press(t, newPicker("Start which task?", testPickerItems()), "j", key)
+		if !isQuit(cmd) {
+			t.Errorf("%s: expected quit", key)
+		}
+		if m.chosen != nil {
+			t.Errorf("%s: chosen = %+v, want nil", key, m.chosen)
+		}
+	}
+}
+
+func TestPicker_Filter(t *testing.T) {
+	m := newPicker("Start which task?", testPickerItems())
+	view := m.View()
+	for _, want := range []string{"Start which task?", "ts-aaa111", "ts-ccc333", "/:search"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("view missing %q:\n%s", want, view)
+		}
+	}
+
+	// Typing narrows by title, ignoring case
+	m, cmd := press(t, m, "/", "P", "a", "r", "s", "e", "r")
+	if isQuit(cmd) {
+		t.Fatal("typing in search should not quit")
+	}
+	if len(m.filtered) != 2 {
+		t.Fatalf("filtered = %+v, want the two parser items", m.filtered)
+	}
+	view = m.View()
+	if strings.Contains(view, "ts-ccc333") || !strings.Contains(view, "Search: Parser") {
+		t.Errorf("view not filtered:\n%s", view)
+	}
+
+	// Enter keeps the filter; selection picks from the filtered list
+	m, _ = press(t, m, "enter", "G", "enter")
+	if m.chosen == nil || m.chosen.ID != "ts-bbb222" {
+		t.Errorf("chosen = %+v, want ts-bbb222", m.chosen)
+	}
+
+	// Matching by id, and no matches leaves nothing to select
+	m, _ = press(t, newPicker("", testPickerItems()), "/", "c", "c", "c")
+	if len(m.filtered) != 1 || m.filtered[0].ID != "ts-ccc333" {
+		t.Errorf("filtered by id = %+v, want ts-ccc333", m.filtered)
+	}
+	m, _ = press(t, m, "x", "enter", "enter")
+	if m.chosen != nil || !strings.Contains(m.View(), "No matches") {
+		t.Errorf("chosen = %+v with no matches, want nil and a notice", m.chosen)
+	}
+
+	// Esc clears the search and restores every item
+	m, _ = press(t, m, "/", "esc")
+	if len(m.filtered) != 3 || m.search != "" {
+		t.Errorf("after esc filtered = %d items, search %q; want all, empty", len(m.filtered), m.search)
+	}
+}
@@ -398,7 +398,6 @@ func (m Model) submitInput() (tea.Model, tea.Cmd) {
 		return m, func() tea.Msg {
 			now := time.Now()
 			newItem := &model.Item{
-				ID:        model.GenerateID(model.ItemTypeTask),
 				Project:   project,
 				Type:      model.ItemTypeTask,
 				Title:     text,
@@ -426,10 +425,7 @@ func (m Model) submitInput() (tea.Model, tea.Cmd) {
 			return m, nil
 		}
 		return m, func() tea.Msg {
-			if err := m.db.UpdateStatus(item.ID, model.StatusBlocked); err != nil {
-				return actionMsg{err: err}
-			}
-			if err := m.db.AddLog(item.ID, "Blocked: "+text); err != nil {
+			if err := m.db.BlockItems([]string{item.ID}, text, false); err != nil {
 				return actionMsg{err: err}
 			}
 			return actionMsg{message: fmt.Sprintf("Blocked %s", item.ID)}
@@ -440,7 +436,7 @@ func (m Model) submitInput() (tea.Model, tea.Cmd) {
 			return m, nil
 		}
 		return m, func() tea.Msg {
-			if err := m.db.AddLog(item.ID, text); err != nil {
+			if err := m.db.AddLog(item.ID, text, ""); err != nil {
 				return actionMsg{err: err}
 			}
 			return actionMsg{message: fmt.Sprintf("Logged to %s", item.ID)}
@@ -452,7 +448,7 @@ func (m Model) submitInput() (tea.Model, tea.Cmd) {
 				return actionMsg{err: err}
 			}
 			if text != "" {
-				if err := m.db.AddLog(item.ID, "Canceled: "+text); err != nil {
+				if err := m.db.AddLog(item.ID, "Canceled: "+text, ""); err != nil {
 					return actionMsg{err: err}
 				}
 			}
@@ -917,7 +913,11 @@ func (m Model) detailView() string {
 		b.WriteString("\n" + detailLabelStyle.Render("Logs:") + "\n")
 		for _, log := range m.detailLogs {
 			ts := dimStyle.Render(log.CreatedAt.Format("2006-01-02 15:04"))
-			b.WriteString("  " + ts + " " + log.Message + "\n")
+			msg := log.Message
+			if log.Source != "" {
+				msg = "(" + log.Source + ") " + msg
+			}
+			b.WriteString("  " + ts + " " + msg + "\n")
 		}
 	}
 